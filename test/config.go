@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"test/handlers"
+	"test/handlers/middleware"
+)
+
+// reloadableFlags - флаги, новые значения которых применяются без перезапуска сервера
+var reloadableFlags = []string{"log-requests", "cors-origins", "cors-max-age", "rate-limit", "rate-burst"}
+
+// readSettings читает файл настроек -config с флагами из набора flags
+//
+// Файл - JSON-объект с именами флагов и их значениями: строками, числами или
+// логическими значениями, например
+//
+//	{"rate-limit": 5, "rate-burst": 10, "log-requests": false, "storage": "sqlite"}
+//
+// Returns:
+//
+//	map[string]string: значения флагов в строковом виде, как в командной строке
+//	error: ошибка чтения или разбора файла, неизвестный флаг или значение неверного вида
+func readSettings(flags *flag.FlagSet, path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var raw map[string]interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("файл настроек %s: %w", path, err)
+	}
+
+	settings := make(map[string]string, len(raw))
+	var errs []error
+	for name, value := range raw {
+		if name == "config" || flags.Lookup(name) == nil {
+			errs = append(errs, fmt.Errorf("неизвестная настройка %q", name))
+			continue
+		}
+		switch value := value.(type) {
+		case string:
+			settings[name] = value
+		case json.Number:
+			settings[name] = value.String()
+		case bool:
+			settings[name] = strconv.FormatBool(value)
+		default:
+			errs = append(errs, fmt.Errorf("настройка %s: ожидалась строка, число или логическое значение", name))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("файл настроек %s: %w", path, err)
+	}
+	return settings, nil
+}
+
+// runtimeConfig разбирает изменяемые на ходу настройки из значений флагов
+func runtimeConfig(values map[string]string) (middleware.RuntimeConfig, error) {
+	var config middleware.RuntimeConfig
+	var errs []error
+	parse := func(name string, parse func(string) error) {
+		if err := parse(values[name]); err != nil {
+			errs = append(errs, fmt.Errorf("неверное значение %s %q", name, values[name]))
+		}
+	}
+	parse("log-requests", func(s string) (err error) { config.LogRequests, err = strconv.ParseBool(s); return })
+	parse("cors-max-age", func(s string) (err error) { config.CORSMaxAge, err = strconv.Atoi(s); return })
+	parse("rate-limit", func(s string) (err error) { config.RateLimit, err = strconv.ParseFloat(s, 64); return })
+	parse("rate-burst", func(s string) (err error) { config.RateBurst, err = strconv.Atoi(s); return })
+	if origins := values["cors-origins"]; origins != "" {
+		config.CORSOrigins = strings.Split(origins, ",")
+	}
+	if err := errors.Join(errs...); err != nil {
+		return config, err
+	}
+	return config, config.Validate()
+}
+
+// configReloader перечитывает файл настроек по SIGHUP и POST /admin/config/reload
+//
+// Флаги, заданные в командной строке, важнее файла и при перезагрузке не меняются.
+// Переменные окружения читаются только при запуске: окружение работающего
+// процесса извне не меняется, поэтому перезагрузка перечитывает только файл.
+type configReloader struct {
+	mu       sync.Mutex
+	flags    *flag.FlagSet          // Флаги сервера, flag.CommandLine
+	path     string                 // Файл настроек
+	explicit map[string]bool        // Флаги, заданные в командной строке
+	base     map[string]string      // Значения флагов без файла: из командной строки, окружения или по умолчанию
+	applied  map[string]string      // Действующие значения флагов
+	runtime  *middleware.Reloadable // Получатель изменяемых на ходу настроек
+}
+
+// newConfigReloader читает файл настроек path и задает из него флаги набора flags,
+// не указанные в командной строке; вызывается после flags.Parse
+func newConfigReloader(flags *flag.FlagSet, path string) (*configReloader, error) {
+	c := &configReloader{
+		flags:    flags,
+		path:     path,
+		explicit: make(map[string]bool),
+		base:     make(map[string]string),
+	}
+	flags.Visit(func(f *flag.Flag) { c.explicit[f.Name] = true })
+	flags.VisitAll(func(f *flag.Flag) { c.base[f.Name] = f.Value.String() })
+
+	settings, err := readSettings(flags, path)
+	if err != nil {
+		return nil, err
+	}
+	c.applied = c.values(settings)
+	for name, value := range c.applied {
+		if value == c.base[name] {
+			continue
+		}
+		if err := flags.Set(name, value); err != nil {
+			return nil, fmt.Errorf("файл настроек %s: настройка %s: %w", path, name, err)
+		}
+	}
+	return c, nil
+}
+
+// values возвращает значения всех флагов с учетом файла settings
+func (c *configReloader) values(settings map[string]string) map[string]string {
+	values := make(map[string]string, len(c.base))
+	for name, value := range c.base {
+		values[name] = value
+		if fileValue, ok := settings[name]; ok && !c.explicit[name] {
+			values[name] = fileValue
+		}
+	}
+	return values
+}
+
+// reload перечитывает файл настроек и применяет изменяемые на ходу
+//
+// При любой ошибке не применяется ничего. Изменения остальных флагов
+// перечисляются в предупреждениях: они вступят в силу после перезапуска.
+// Итог записывается в stdout.
+func (c *configReloader) reload() (handlers.ConfigReload, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result, err := c.apply()
+	if err != nil {
+		fmt.Printf("Настройки не перезагружены: %v\n", err)
+		return result, err
+	}
+	if len(result.Changes) == 0 {
+		fmt.Println("Настройки перезагружены без изменений")
+	} else {
+		fmt.Printf("Настройки перезагружены: %s\n", strings.Join(result.Changes, "; "))
+	}
+	for _, warning := range result.Warnings {
+		fmt.Printf("Предупреждение: %s\n", warning)
+	}
+	return result, nil
+}
+
+// apply перечитывает файл настроек и передает изменяемые на ходу настройки в c.runtime
+func (c *configReloader) apply() (handlers.ConfigReload, error) {
+	settings, err := readSettings(c.flags, c.path)
+	if err != nil {
+		return handlers.ConfigReload{}, err
+	}
+	values := c.values(settings)
+	config, err := runtimeConfig(values)
+	if err != nil {
+		return handlers.ConfigReload{}, err
+	}
+
+	var result handlers.ConfigReload
+	for name, value := range values {
+		if value != c.applied[name] && !slices.Contains(reloadableFlags, name) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: изменение применится после перезапуска", name))
+		}
+	}
+	sort.Strings(result.Warnings)
+
+	if result.Changes, err = c.runtime.Reload(config); err != nil {
+		return handlers.ConfigReload{}, err
+	}
+	for _, name := range reloadableFlags {
+		c.applied[name] = values[name]
+	}
+	return result, nil
+}
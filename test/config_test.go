@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"test/handlers"
+	"test/handlers/middleware"
+	"test/storage"
+	"testing"
+	"time"
+)
+
+// newTestFlags возвращает набор флагов, как у сервера, разобранный из args
+func newTestFlags(t *testing.T, args ...string) *flag.FlagSet {
+	t.Helper()
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.String("storage", "memory", "")
+	flags.Int("max-tasks", 0, "")
+	flags.Bool("log-requests", true, "")
+	flags.String("cors-origins", "", "")
+	flags.Int("cors-max-age", 600, "")
+	flags.Float64("rate-limit", 0, "")
+	flags.Int("rate-burst", 20, "")
+	flags.String("config", "", "")
+	if err := flags.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return flags
+}
+
+// writeSettings записывает файл настроек path
+func writeSettings(t *testing.T, path, settings string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(settings), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestReloader создает configReloader для файла path и Reloadable с его настройками
+func newTestReloader(t *testing.T, flags *flag.FlagSet, path string) *configReloader {
+	t.Helper()
+	reloader, err := newConfigReloader(flags, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := runtimeConfig(reloader.applied)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloader.runtime, err = middleware.NewReloadable(t.Context(), io.Discard, config); err != nil {
+		t.Fatal(err)
+	}
+	return reloader
+}
+
+// TestConfigReloader проверяет чтение файла настроек и его перезагрузку через POST /admin/config/reload
+//
+// Проверяет:
+// - Файл задает флаги, не указанные в командной строке, флаг командной строки важнее файла
+// - Перезагрузка после правки файла применяет изменяемые на ходу настройки и перечисляет изменения
+// - Изменение остальных настроек дает предупреждение и не применяется
+// - Повторная перезагрузка без правки файла не дает изменений
+func TestConfigReloader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeSettings(t, path, `{"rate-limit": 2, "rate-burst": 50, "log-requests": false, "storage": "sqlite"}`)
+	flags := newTestFlags(t, "-rate-burst", "5")
+	reloader := newTestReloader(t, flags, path)
+
+	for name, expected := range map[string]string{"rate-limit": "2", "rate-burst": "5", "log-requests": "false", "storage": "sqlite", "max-tasks": "0"} {
+		if got := flags.Lookup(name).Value.String(); got != expected {
+			t.Errorf("Флаг %s: ожидалось %q, получено %q", name, expected, got)
+		}
+	}
+
+	mux := handlers.SetupHandlersWithConfig(storage.NewInMemoryStorage(), handlers.Config{ReloadConfig: reloader.reload})
+	reload := func() handlers.ConfigReload {
+		t.Helper()
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/admin/config/reload", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Ожидался код %d, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var result handlers.ConfigReload
+		if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	writeSettings(t, path, `{"rate-limit": 5, "rate-burst": 50, "log-requests": true, "cors-origins": "https://app.example.com", "storage": "bolt"}`)
+	result := reload()
+	if changes := fmt.Sprint(result.Changes); changes != "[log-requests: false -> true cors-origins:  -> https://app.example.com rate-limit: 2 -> 5]" {
+		t.Errorf("Неверный список изменений: %s", changes)
+	}
+	if warnings := fmt.Sprint(result.Warnings); warnings != "[storage: изменение применится после перезапуска]" {
+		t.Errorf("Неверные предупреждения: %s", warnings)
+	}
+	expected := middleware.RuntimeConfig{LogRequests: true, CORSOrigins: []string{"https://app.example.com"}, CORSMaxAge: 600, RateLimit: 5, RateBurst: 5}
+	if config := reloader.runtime.Config(); fmt.Sprint(config) != fmt.Sprint(expected) {
+		t.Errorf("Ожидались настройки %+v, получены %+v", expected, config)
+	}
+	if storageKind := flags.Lookup("storage").Value.String(); storageKind != "sqlite" {
+		t.Errorf("Настройка storage применена без перезапуска: %s", storageKind)
+	}
+
+	if result := reload(); len(result.Changes) != 0 {
+		t.Errorf("Ожидалась перезагрузка без изменений, получено %v", result.Changes)
+	}
+}
+
+// TestConfigReloaderRejected проверяет отклонение неверного файла настроек
+//
+// Проверяет:
+// - Неизвестная настройка, настройка config, значение неверного вида и неверный JSON отклоняются при запуске
+// - Неверный файл при перезагрузке отклоняется кодом 422, действующие настройки не меняются
+func TestConfigReloaderRejected(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	for _, settings := range []string{
+		`{"rate-limt": 5}`,
+		`{"config": "other.json"}`,
+		`{"cors-origins": ["https://app.example.com"]}`,
+		`{"rate-limit": 5`,
+	} {
+		writeSettings(t, path, settings)
+		if _, err := newConfigReloader(newTestFlags(t), path); err == nil {
+			t.Errorf("%s: ожидалась ошибка", settings)
+		}
+	}
+
+	writeSettings(t, path, `{"rate-limit": 2}`)
+	reloader := newTestReloader(t, newTestFlags(t), path)
+	mux := handlers.SetupHandlersWithConfig(storage.NewInMemoryStorage(), handlers.Config{ReloadConfig: reloader.reload})
+	for _, settings := range []string{`{"rate-limit": 5, "rate-limt": 5}`, `{"rate-limit": -1}`} {
+		writeSettings(t, path, settings)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/admin/config/reload", nil))
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: ожидался код %d, получен %d", settings, http.StatusUnprocessableEntity, w.Code)
+		}
+		if limit := reloader.runtime.Config().RateLimit; limit != 2 {
+			t.Errorf("%s: настройки изменены, rate-limit %v", settings, limit)
+		}
+	}
+}
+
+// TestReloadOnHangup проверяет перезагрузку настроек по SIGHUP
+//
+// Проверяет:
+// - После правки файла и SIGHUP процессу изменяемые на ходу настройки применяются
+func TestReloadOnHangup(t *testing.T) {
+	// Пока SIGHUP перехватывается, он не завершает процесс тестов, даже если
+	// reloadOnHangup еще не успел подписаться
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGHUP)
+	defer signal.Stop(guard)
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeSettings(t, path, `{"rate-limit": 2}`)
+	reloader := newTestReloader(t, newTestFlags(t), path)
+	go reloadOnHangup(t.Context(), reloader)
+
+	writeSettings(t, path, `{"rate-limit": 5}`)
+	deadline := time.Now().Add(5 * time.Second)
+	for reloader.runtime.Config().RateLimit != 5 {
+		if time.Now().After(deadline) {
+			t.Fatal("Настройки не перезагружены по SIGHUP")
+		}
+		if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"test/storage"
+)
+
+// CachesHandler возвращает состояние служебных кэшей хранилища
+// GET /admin/caches
+//
+// Ответ:
+//
+//	[
+//	  {
+//	    "name": "client_tokens",
+//	    "size": 12,
+//	    "capacity": 10000,
+//	    "hits": 3,
+//	    "misses": 15,
+//	    "flushes": 0
+//	  }
+//	]
+func CachesHandler(w http.ResponseWriter, r *http.Request, storage storage.CacheStorage) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(storage.Caches())
+}
+
+// FlushCacheHandler очищает служебный кэш по имени
+// POST /admin/caches/{name}/flush
+//
+// Возвращает состояние кэша после очистки в формате GET /admin/caches.
+// Неизвестное имя кэша отклоняется с кодом 404
+func FlushCacheHandler(w http.ResponseWriter, r *http.Request, storage storage.CacheStorage, name string) {
+	stats, err := storage.FlushCache(name)
+	if err != nil {
+		writeError(w, err.Error(), cacheErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// cacheErrorStatus определяет код ответа для ошибки очистки кэша
+func cacheErrorStatus(err error) int {
+	if errors.Is(err, storage.ErrCacheNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// CodeInvalidConfig - машинный код ошибки перезагрузки неверных настроек
+const CodeInvalidConfig = "invalid_config"
+
+// ConfigReload - результат перезагрузки настроек сервера, см. Config.ReloadConfig
+type ConfigReload struct {
+	Changes  []string `json:"changes"`            // Примененные изменения, например "rate-limit: 0 -> 5"
+	Warnings []string `json:"warnings,omitempty"` // Изменения, которые применятся только после перезапуска
+}
+
+// ReloadConfigHandler перечитывает настройки сервера и применяет изменяемые на ходу
+// POST /admin/config/reload
+//
+// Неверные настройки отклоняются целиком с кодом 422 и code invalid_config,
+// действующие настройки при этом не меняются.
+//
+// Ответ:
+//
+//	{
+//	  "changes": ["rate-limit: 0 -> 5"],
+//	  "warnings": ["storage: изменение применится после перезапуска"]
+//	}
+func ReloadConfigHandler(w http.ResponseWriter, r *http.Request, reload func() (ConfigReload, error)) {
+	result, err := reload()
+	if err != nil {
+		writeErrorCode(w, http.StatusUnprocessableEntity, CodeInvalidConfig, err.Error())
+		return
+	}
+	if result.Changes == nil {
+		result.Changes = []string{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// setupAdminHandlers регистрирует служебные маршруты /admin
+//
+// Если хранилище не имеет служебных кэшей (storage равно nil), маршруты /admin/caches
+// отвечают кодом 501; без reload кодом 501 отвечает POST /admin/config/reload.
+func setupAdminHandlers(mux *http.ServeMux, storage storage.CacheStorage, reload func() (ConfigReload, error)) {
+	mux.HandleFunc("/admin/config/reload", func(w http.ResponseWriter, r *http.Request) {
+		if !allowMethod(w, r, http.MethodPost) {
+			return
+		}
+		if reload == nil {
+			writeErrorCode(w, http.StatusNotImplemented, CodeNotSupported, "перезагрузка настроек не настроена")
+			return
+		}
+		ReloadConfigHandler(w, r, reload)
+	})
+
+	mux.HandleFunc("/admin/caches", func(w http.ResponseWriter, r *http.Request) {
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		if storage == nil {
+			writeNotSupported(w, "служебные кэши")
+			return
+		}
+		CachesHandler(w, r, storage)
+	})
+
+	// /admin/caches/{name}/flush
+	mux.HandleFunc("/admin/caches/", func(w http.ResponseWriter, r *http.Request) {
+		name, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/admin/caches/"), "/")
+		if name == "" || action != "flush" {
+			writeError(w, "Ресурс не найден", http.StatusNotFound)
+			return
+		}
+		if !allowMethod(w, r, http.MethodPost) {
+			return
+		}
+		if storage == nil {
+			writeNotSupported(w, "служебные кэши")
+			return
+		}
+		FlushCacheHandler(w, r, storage, name)
+	})
+}
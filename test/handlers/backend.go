@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"test/models"
+	"test/storage"
+)
+
+// CodeNotSupported - машинный код ошибки обращения к возможности, которую хранилище не поддерживает
+const CodeNotSupported = "not_supported"
+
+// backend - хранилище обработчиков и его необязательные возможности
+//
+// Возможности определяются один раз при настройке маршрутов. Поле со значением nil
+// означает, что хранилище возможность не поддерживает: она отмечается в GET /capabilities
+// как недоступная, а ее маршруты отвечают кодом 501, см. writeNotSupported.
+type backend struct {
+	storage.Storage
+	tokens     storage.TokenStorage
+	streaming  storage.StreamingStorage
+	patch      storage.PatchStorage
+	toggle     storage.ToggleStorage
+	protected  storage.ProtectedStorage
+	policy     storage.CompletionPolicyStorage
+	followUps  storage.FollowUpStorage
+	links      storage.LinkStorage
+	metadata   storage.MetadataStorage
+	tombstones storage.TombstoneStorage
+	caches     storage.CacheStorage
+	pages      storage.PaginatedStorage
+	batch      storage.BatchStorage
+	tokenBatch storage.TokenBatchStorage
+	priorities storage.PriorityStorage
+	tags       storage.TagStorage
+	softDelete storage.SoftDeleteStorage
+	overdue    storage.OverdueStorage
+	search     storage.SearchStorage
+	prefs      storage.PreferenceStorage
+	versioned  storage.VersionedStorage
+	pinger     storage.PingStorage
+	expiring   storage.ExpiringStorage
+}
+
+// newBackend определяет возможности хранилища
+func newBackend(s storage.Storage) backend {
+	b := backend{Storage: s}
+	b.tokens, _ = s.(storage.TokenStorage)
+	b.streaming, _ = s.(storage.StreamingStorage)
+	b.patch, _ = s.(storage.PatchStorage)
+	b.toggle, _ = s.(storage.ToggleStorage)
+	b.protected, _ = s.(storage.ProtectedStorage)
+	b.policy, _ = s.(storage.CompletionPolicyStorage)
+	b.followUps, _ = s.(storage.FollowUpStorage)
+	b.links, _ = s.(storage.LinkStorage)
+	b.metadata, _ = s.(storage.MetadataStorage)
+	b.tombstones, _ = s.(storage.TombstoneStorage)
+	b.caches, _ = s.(storage.CacheStorage)
+	b.pages, _ = s.(storage.PaginatedStorage)
+	b.batch, _ = s.(storage.BatchStorage)
+	b.tokenBatch, _ = s.(storage.TokenBatchStorage)
+	b.priorities, _ = s.(storage.PriorityStorage)
+	b.tags, _ = s.(storage.TagStorage)
+	b.softDelete, _ = s.(storage.SoftDeleteStorage)
+	b.overdue, _ = s.(storage.OverdueStorage)
+	b.search, _ = s.(storage.SearchStorage)
+	b.prefs, _ = s.(storage.PreferenceStorage)
+	b.versioned, _ = s.(storage.VersionedStorage)
+	b.pinger, _ = s.(storage.PingStorage)
+	b.expiring, _ = s.(storage.ExpiringStorage)
+	return b
+}
+
+// completedImmutable сообщает, запрещает ли хранилище изменение выполненных задач
+func (b backend) completedImmutable() bool {
+	return b.policy != nil && b.policy.CompletedImmutable()
+}
+
+// metadataLimit возвращает лимит размера метаданных, 0 - метаданные не поддерживаются
+func (b backend) metadataLimit() int {
+	if b.metadata == nil {
+		return 0
+	}
+	return b.metadata.MetadataLimit()
+}
+
+// listTasks возвращает источник списка задач хранилища
+//
+// Хранилище без потоковой выдачи (storage.StreamingStorage) отдает список целиком,
+// и источник обходит готовый срез.
+func listTasks(s storage.Storage) taskLister {
+	if streaming, ok := s.(storage.StreamingStorage); ok {
+		return streaming.ListTasksFunc
+	}
+	return func(ctx context.Context, fn func(*models.Task) error) error {
+		tasks, err := s.GetAllTasks(ctx)
+		if err != nil {
+			return err
+		}
+		return sliceLister(tasks)(ctx, fn)
+	}
+}
+
+// writeNotSupported отвечает кодом 501 на обращение к возможности, которую хранилище не поддерживает
+func writeNotSupported(w http.ResponseWriter, feature string) {
+	writeErrorCode(w, http.StatusNotImplemented, CodeNotSupported, "хранилище не поддерживает "+feature)
+}
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"test/models"
+	"test/schema"
+	"test/storage"
+	"time"
+)
+
+const (
+	// DefaultBulkCreateMaxItems - число задач в одном запросе POST /tasks/bulk по умолчанию
+	DefaultBulkCreateMaxItems = 100
+
+	// CodeInvalidItems - машинный код ошибки элементов тела POST /tasks/bulk, не прошедших валидацию
+	CodeInvalidItems = "invalid_items"
+)
+
+// itemError - ошибка поля задачи в элементе массива тела запроса с индексом элемента, начиная с 0
+type itemError struct {
+	Index int `json:"index"`
+	schema.FieldError
+}
+
+// BulkCreateHandler создает несколько задач одной операцией хранилища
+// POST /tasks/bulk
+//
+// Тело - JSON-массив объектов в формате запроса POST /tasks:
+//
+//	[
+//	  {"title": "Первая", "description": "Описание"},
+//	  {"title": "Вторая", "description": "Описание", "priority": "high"}
+//	]
+//
+// Задачи создаются все вместе одним вызовом storage.BatchStorage.CreateTasks
+// или не создается ни одна. Каждый элемент проверяется теми же правилами, что
+// и при POST /tasks, включая строгие правила Config.HardRules. Все ошибки всех
+// элементов возвращаются вместе с индексами элементов кодом 422
+//
+//	{
+//	  "error": "элемент 1: поле title обязательно",
+//	  "code": "invalid_items",
+//	  "errors": [{"index": 1, "field": "title", "rule": "required", "message": "поле title обязательно"}]
+//	}
+//
+// Ответ 201 - созданные задачи в порядке элементов, с предупреждениями мягких
+// правил в поле warnings каждой задачи.
+//
+// Элемент с client_token защищен от дублей, как POST /tasks: если задача с этим
+// токеном уже создавалась, на месте элемента возвращается исходная задача,
+// см. storage.TokenBatchStorage. Повтор запроса, все задачи которого уже созданы,
+// отвечает кодом 200 с исходными задачами.
+//
+// Args:
+//
+//	tokens: создание с client_token, nil - хранилище его не поддерживает
+//	preferences: настройки по умолчанию для элементов без priority и tags, nil - без них
+//	strict: отклонять неизвестные поля элементов, см. Config.StrictSchema
+//	maxItems: максимальное число задач в запросе
+func BulkCreateHandler(w http.ResponseWriter, r *http.Request, batch storage.BatchStorage, tokens storage.TokenBatchStorage, expiring storage.ExpiringStorage, preferences storage.PreferenceStorage, policy validationPolicy, strict bool, maxItems int) {
+	decoder := json.NewDecoder(r.Body)
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	var items []CreateTaskRequest
+	if err := decoder.Decode(&items); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(items) == 0 {
+		writeError(w, "Тело запроса не содержит ни одной задачи", http.StatusBadRequest)
+		return
+	}
+	if len(items) > maxItems {
+		writeError(w, fmt.Sprintf("Не больше %d задач в одном запросе", maxItems), http.StatusBadRequest)
+		return
+	}
+	defaults, err := taskDefaults(preferences)
+	if err != nil {
+		writeTaskError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	// Проверка всех элементов до создания первой задачи
+	var invalid []itemError
+	inputs := make([]storage.CreateTaskInput, len(items))
+	clientTokens := make([]string, len(items))
+	warnings := make([]schema.Errors, len(items))
+	for i, item := range items {
+		applyDefaults(defaults, &item.Priority, &item.Tags)
+		if item.expires() && expiring == nil {
+			writeNotSupported(w, "задачи с ограниченным временем жизни")
+			return
+		}
+		if item.ClientToken != "" && tokens == nil {
+			writeNotSupported(w, "клиентские токены")
+			return
+		}
+
+		errs := bulkItemErrors(item)
+		hard, soft := policy.check(softFields{Title: item.Title})
+		errs = append(errs, hard...)
+		for _, fe := range errs {
+			invalid = append(invalid, itemError{Index: i, FieldError: fe})
+		}
+		warnings[i] = soft
+		if len(errs) > 0 {
+			continue
+		}
+
+		// Ссылки и теги уже проверены bulkItemErrors, нормализация не вернет ошибку
+		links, _ := models.NormalizeLinks(item.Links)
+		item.Tags, _ = models.NormalizeTags(item.Tags)
+		inputs[i] = item.input(links)
+		clientTokens[i] = item.ClientToken
+	}
+	if len(invalid) > 0 {
+		writeItemErrors(w, invalid)
+		return
+	}
+
+	// Задачи без токенов создаются обычной пакетной операцией
+	var tasks []*models.Task
+	created := true
+	if strings.Join(clientTokens, "") == "" {
+		tasks, err = batch.CreateTasks(inputs)
+	} else {
+		var createdItems []bool
+		tasks, createdItems, err = tokens.CreateTasksWithTokens(clientTokens, inputs)
+		created = slices.Contains(createdItems, true)
+	}
+	if err != nil {
+		writeTaskError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]taskWithWarnings, len(tasks))
+	for i, task := range tasks {
+		response[i] = taskWithWarnings{Task: task, Warnings: warnings[i]}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// BulkDeleteRequest - тело запроса DELETE /tasks/bulk
+type BulkDeleteRequest struct {
+	IDs []int `json:"ids"`
+}
+
+// BulkDeleteResponse - ответ DELETE /tasks/bulk
+type BulkDeleteResponse struct {
+	Deleted  []int `json:"deleted"`   // ID удаленных задач
+	NotFound []int `json:"not_found"` // ID задач, которых нет, в том числе уже удаленных
+}
+
+// BulkDeleteHandler удаляет несколько задач одной операцией хранилища
+// DELETE /tasks/bulk
+//
+// Запрос:
+//
+//	{"ids": [1, 2, 3]}
+//
+// Задачи удаляются одним вызовом storage.BatchStorage.DeleteTasks так же, как
+// DELETE /tasks/{id}: мягко удаленную задачу можно восстановить. Отсутствующие
+// задачи удалению остальных не мешают, ответ 200 перечисляет, что произошло:
+//
+//	{"deleted": [1, 3], "not_found": [2]}
+//
+// Защищенные задачи удаляются только по одной: если среди ids есть защищенная,
+// не удаляется ни одна задача и запрос отклоняется с кодом 428. При confirm
+// запрос нужно подтвердить параметром ?confirm=true, иначе он отклоняется
+// с кодом 428 и кодом ошибки delete_confirmation_required:
+//
+//	{
+//	  "error": "удаление задач требует подтверждения: повторите запрос с параметром ?confirm=true",
+//	  "code": "delete_confirmation_required",
+//	  "status": 428
+//	}
+//
+// Значение параметра, кроме true и false, отклоняется с кодом 400 и кодом ошибки invalid_value.
+//
+// Args:
+//
+//	confirm: требовать подтверждения, см. Config.ConfirmDeletes
+//	maxItems: максимальное число ID в запросе
+func BulkDeleteHandler(w http.ResponseWriter, r *http.Request, batch storage.BatchStorage, confirm bool, maxItems int) {
+	confirmed := r.URL.Query().Get(ConfirmDeleteParam)
+	if confirmed != "" {
+		if invalid := schema.OneOf(ConfirmDeleteParam, confirmed, "true", "false"); invalid != nil {
+			writeInvalidValue(w, invalid)
+			return
+		}
+	}
+
+	var request BulkDeleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(request.IDs) == 0 {
+		writeError(w, "Поле ids не содержит ни одного ID", http.StatusBadRequest)
+		return
+	}
+	if len(request.IDs) > maxItems {
+		writeError(w, fmt.Sprintf("Не больше %d задач в одном запросе", maxItems), http.StatusBadRequest)
+		return
+	}
+	for _, id := range request.IDs {
+		if id <= 0 {
+			writeError(w, fmt.Sprintf("Неверный ID задачи %d: ожидалось положительное число", id), http.StatusBadRequest)
+			return
+		}
+	}
+	if confirm && confirmed != "true" {
+		writeErrorCode(w, http.StatusPreconditionRequired, CodeDeleteConfirmationRequired,
+			fmt.Sprintf("удаление задач требует подтверждения: повторите запрос с параметром ?%s=true", ConfirmDeleteParam))
+		return
+	}
+
+	deleted, missing, err := batch.DeleteTasks(request.IDs)
+	if errors.Is(err, storage.ErrTaskProtected) {
+		writeErrorCode(w, http.StatusPreconditionRequired, CodeDeleteConfirmationRequired,
+			err.Error()+": защищенные задачи удаляются по одной запросом DELETE /tasks/{id}")
+		return
+	}
+	if err != nil {
+		writeTaskError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := BulkDeleteResponse{Deleted: deleted, NotFound: missing}
+	if response.Deleted == nil {
+		response.Deleted = []int{}
+	}
+	if response.NotFound == nil {
+		response.NotFound = []int{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// bulkItemErrors проверяет элемент тела POST /tasks/bulk правилами POST /tasks
+//
+// Возвращаются все нарушения элемента; ссылки и теги, уже не прошедшие правила
+// схемы, повторно не проверяются.
+func bulkItemErrors(item CreateTaskRequest) schema.Errors {
+	var errs schema.Errors
+	if invalid, ok := schema.Validate(item).(schema.Errors); ok {
+		errs = append(errs, invalid...)
+	}
+	invalidField := func(name string) bool {
+		return slices.ContainsFunc(errs, func(fe schema.FieldError) bool { return strings.HasPrefix(fe.Field, name) })
+	}
+
+	if _, err := models.NormalizeLinks(item.Links); err != nil && !invalidField("links") {
+		errs = append(errs, schema.FieldError{Field: "links", Rule: "format", Message: err.Error()})
+	}
+	if _, err := models.NormalizeTags(item.Tags); err != nil && !invalidField("tags") {
+		errs = append(errs, schema.FieldError{Field: "tags", Rule: "format", Message: err.Error()})
+	}
+	if item.ExpiresAt != nil && item.ExpiresInSeconds != nil {
+		errs = append(errs, schema.FieldError{Field: "expires_at", Rule: "exclusive", Message: "поля expires_at и expires_in_seconds нельзя задавать вместе"})
+	}
+	if item.ExpiresAt != nil && !item.ExpiresAt.After(time.Now()) {
+		errs = append(errs, schema.FieldError{Field: "expires_at", Rule: "future", Message: "поле expires_at должно быть в будущем"})
+	}
+	return errs
+}
+
+// writeItemErrors отвечает кодом 422 с ошибками элементов тела запроса
+func writeItemErrors(w http.ResponseWriter, errs []itemError) {
+	messages := make([]string, len(errs))
+	for i, e := range errs {
+		messages[i] = fmt.Sprintf("элемент %d: %s", e.Index, e.Message)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(struct {
+		ErrorResponse
+		Errors []itemError `json:"errors"`
+	}{ErrorResponse{strings.Join(messages, "; "), CodeInvalidItems, http.StatusUnprocessableEntity}, errs})
+}
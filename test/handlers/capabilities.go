@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// capabilities - возможности развертывания, доступные клиентам через GET /capabilities
+//
+// Каждая возможность регистрируется в SetupHandlersWithConfig рядом с маршрутом,
+// который ее реализует, поэтому ответ следует из фактической настройки сервера,
+// а не из отдельно поддерживаемого списка. После настройки набор не меняется.
+type capabilities map[string]interface{}
+
+// register добавляет возможность с ее значением: флагом или числовым ограничением
+func (c capabilities) register(name string, value interface{}) {
+	if _, exists := c[name]; exists {
+		panic("handlers: возможность " + name + " зарегистрирована повторно")
+	}
+	c[name] = value
+}
+
+// ServeHTTP возвращает возможности развертывания
+// GET /capabilities
+//
+// Ответ:
+//
+//	{
+//	  "client_token": true,
+//	  "max_links": 10,
+//	  "strict_schema": false
+//	}
+func (c capabilities) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c)
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"test/storage"
+	"time"
+	"unicode"
+)
+
+const (
+	// CodeDuplicatesTooExpensive - машинный код отказа в поиске дубликатов из-за размера данных
+	CodeDuplicatesTooExpensive = "duplicates_too_expensive"
+
+	// MaxFuzzyDuplicateTasks - максимальное число задач для нечеткого поиска дубликатов
+	MaxFuzzyDuplicateTasks = 1000
+
+	// DuplicatesTimeout - время, за которое должен уложиться поиск дубликатов
+	DuplicatesTimeout = 2 * time.Second
+)
+
+// DuplicateGroup - группа задач с похожими названиями в ответе GET /tasks/duplicates
+type DuplicateGroup struct {
+	NormalizedTitle string  `json:"normalized_title"` // Нормализованное название задачи с наименьшим ID
+	IDs             []int   `json:"ids"`              // ID задач группы по возрастанию
+	Similarity      float64 `json:"similarity"`       // Наименьшее сходство пар, объединивших группу
+}
+
+// titleEntry - нормализованное название и задачи с ним
+type titleEntry struct {
+	title string
+	ids   []int
+}
+
+// errDuplicatesTimeout - поиск дубликатов не уложился в DuplicatesTimeout
+var errDuplicatesTimeout = errors.New("поиск дубликатов прерван по времени")
+
+// GetDuplicatesHandler возвращает группы задач с совпадающими или похожими названиями
+// GET /tasks/duplicates
+//
+// Названия сравниваются после нормализации: нижний регистр, ё как е, знаки
+// препинания убраны, пробелы схлопнуты. Без параметра threshold (или с threshold=1)
+// в группу попадают задачи с одинаковым нормализованным названием. С threshold
+// меньше 1 названия сравниваются нечетко: сходство - 1 минус расстояние
+// Левенштейна, деленное на длину более длинного названия, и задачи с похожими
+// попарно названиями объединяются в группу. Нечеткий поиск квадратичен, поэтому
+// выполняется не больше чем для MaxFuzzyDuplicateTasks задач; при большем числе
+// задач или если поиск не уложился в DuplicatesTimeout, возвращается 503 с
+// подсказкой использовать точный режим.
+//
+// Поиск идет по списку задач, полученному одним вызовом GetAllTasks, и видит
+// хранилище в один момент. Группы упорядочены по наименьшему ID и делятся на
+// страницы параметрами page и limit так же, как GET /tasks:
+//
+//	[
+//	  {
+//	    "normalized_title": "купить молоко",
+//	    "ids": [1, 4],
+//	    "similarity": 1
+//	  }
+//	]
+func GetDuplicatesHandler(w http.ResponseWriter, r *http.Request, storage storage.Storage) {
+	threshold := 1.0
+	if value := r.URL.Query().Get("threshold"); value != "" {
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || !(n > 0 && n <= 1) {
+			writeError(w, "Параметр threshold должен быть числом больше 0 и не больше 1", http.StatusBadRequest)
+			return
+		}
+		threshold = n
+	}
+	page, paged, message := requestPage(r)
+	if message != "" {
+		writeError(w, message, http.StatusBadRequest)
+		return
+	}
+
+	tasks, err := storage.GetAllTasks(r.Context())
+	if err != nil {
+		writeTaskError(w, err, http.StatusInternalServerError)
+		return
+	}
+	if threshold < 1 && len(tasks) > MaxFuzzyDuplicateTasks {
+		writeErrorCode(w, http.StatusServiceUnavailable, CodeDuplicatesTooExpensive, fmt.Sprintf(
+			"нечеткий поиск дубликатов выполняется не больше чем для %d задач, в хранилище %d; уберите threshold для точного сравнения названий",
+			MaxFuzzyDuplicateTasks, len(tasks)))
+		return
+	}
+
+	// Названия копируются сразу: дальше поиск не обращается к задачам хранилища
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	byTitle := make(map[string]*titleEntry)
+	var entries []*titleEntry
+	for _, task := range tasks {
+		title := normalizeTitle(task.Title)
+		entry, exists := byTitle[title]
+		if !exists {
+			entry = &titleEntry{title: title}
+			byTitle[title] = entry
+			entries = append(entries, entry)
+		}
+		entry.ids = append(entry.ids, task.ID)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), DuplicatesTimeout)
+	defer cancel()
+	groups, err := duplicateGroups(ctx, entries, threshold)
+	if err != nil {
+		writeErrorCode(w, http.StatusServiceUnavailable, CodeDuplicatesTooExpensive, fmt.Sprintf(
+			"поиск дубликатов не уложился в %s; уберите threshold для точного сравнения названий", DuplicatesTimeout))
+		return
+	}
+
+	if paged {
+		writePageHeaders(w, r, page, len(groups))
+		start := min(page.offset(), len(groups))
+		groups = groups[start : start+min(page.limit, len(groups)-start)]
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
+
+// duplicateGroups объединяет названия со сходством не меньше threshold в группы
+// из двух и более задач, упорядоченные по наименьшему ID
+//
+// Возвращает errDuplicatesTimeout, если ctx завершился до конца сравнения.
+func duplicateGroups(ctx context.Context, entries []*titleEntry, threshold float64) ([]DuplicateGroup, error) {
+	// Объединение непересекающихся множеств: parent[i] - родитель названия i
+	parent := make([]int, len(entries))
+	similarity := make([]float64, len(entries))
+	for i := range parent {
+		parent[i] = i
+		similarity[i] = 1
+	}
+	root := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+
+	if threshold < 1 {
+		titles := make([][]rune, len(entries))
+		for i, entry := range entries {
+			titles[i] = []rune(entry.title)
+		}
+		for i := range titles {
+			if ctx.Err() != nil {
+				return nil, errDuplicatesTimeout
+			}
+			for j := i + 1; j < len(titles); j++ {
+				score, ok := titleSimilarity(titles[i], titles[j], threshold)
+				if !ok {
+					continue
+				}
+				a, b := root(i), root(j)
+				low := min(score, similarity[a], similarity[b])
+				parent[b] = a
+				similarity[a] = low
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	for i := range entries {
+		members[root(i)] = append(members[root(i)], i)
+	}
+	var groups []DuplicateGroup
+	for r, indexes := range members {
+		var ids []int
+		for _, i := range indexes {
+			ids = append(ids, entries[i].ids...)
+		}
+		if len(ids) < 2 {
+			continue
+		}
+		sort.Ints(ids)
+		groups = append(groups, DuplicateGroup{IDs: ids, Similarity: similarity[r]})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].IDs[0] < groups[j].IDs[0] })
+
+	// Название группы - у задачи с наименьшим ID
+	titleOf := make(map[int]string)
+	for _, entry := range entries {
+		titleOf[entry.ids[0]] = entry.title
+	}
+	for i := range groups {
+		groups[i].NormalizedTitle = titleOf[groups[i].IDs[0]]
+	}
+	return groups, nil
+}
+
+// normalizeTitle приводит название к виду для сравнения: нижний регистр, ё как е,
+// без знаков препинания, слова через один пробел
+func normalizeTitle(title string) string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.ReplaceAll(strings.Join(words, " "), "ё", "е")
+}
+
+// titleSimilarity возвращает сходство названий a и b от 0 до 1 и признак того,
+// что оно не меньше threshold
+//
+// Сходство - 1 минус расстояние Левенштейна, деленное на длину более длинного
+// названия. Пары, которые не могут пройти порог из-за разницы длин, не сравниваются.
+func titleSimilarity(a, b []rune, threshold float64) (float64, bool) {
+	longest := max(len(a), len(b))
+	if longest == 0 {
+		return 1, true
+	}
+	// Небольшой запас, чтобы округление не отсекло пару точно на пороге
+	maxDistance := int((1-threshold)*float64(longest) + 1e-9)
+	if diff := len(a) - len(b); diff > maxDistance || -diff > maxDistance {
+		return 0, false
+	}
+
+	// Две строки матрицы расстояний вместо полной
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	score := 1 - float64(previous[len(b)])/float64(longest)
+	return score, score+1e-9 >= threshold
+}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"test/schema"
+	"test/storage"
+	"time"
+)
+
+const (
+	// CodeTaskCompletedImmutable - машинный код ошибки изменения выполненной задачи
+	CodeTaskCompletedImmutable = "task_completed_immutable"
+
+	// CodeTaskDeleted - машинный код ошибки обращения к недавно удаленной задаче
+	CodeTaskDeleted = "task_deleted"
+
+	// CodeDeleteConfirmationRequired - машинный код ошибки удаления без подтверждения
+	CodeDeleteConfirmationRequired = "delete_confirmation_required"
+
+	// CodeVersionConflict - машинный код ошибки обновления задачи, измененной другим клиентом
+	CodeVersionConflict = "version_conflict"
+
+	// CodeStorageFull - машинный код отказа в создании задачи в хранилище, достигшем лимита задач
+	CodeStorageFull = "storage_full"
+
+	// CodeStorageClosed - машинный код отказа в изменении закрытого хранилища при остановке сервера
+	CodeStorageClosed = "storage_closed"
+
+	// CodeRequestCanceled - машинный код ошибки запроса, прерванного отключением клиента
+	CodeRequestCanceled = "request_canceled"
+
+	// CodeDeadlineExceeded - машинный код ошибки запроса, не уложившегося в срок выполнения
+	CodeDeadlineExceeded = "deadline_exceeded"
+
+	// StatusClientClosedRequest - код ответа на запрос, клиент которого отключился
+	// до ответа, как у nginx; сам клиент его уже не получит, код виден в журнале
+	StatusClientClosedRequest = 499
+
+	// ConfirmDeleteHeader - заголовок подтверждения удаления, значением которого указывается ID задачи
+	ConfirmDeleteHeader = "X-Confirm-Delete"
+
+	// ConfirmDeleteParam - параметр подтверждения удаления нескольких задач, ?confirm=true
+	ConfirmDeleteParam = "confirm"
+)
+
+// ErrorResponse - JSON-тело ответа с ошибкой
+//
+//	{
+//	  "error": "задача не найдена: ID 1",
+//	  "status": 404
+//	}
+//
+// Все ошибки обработчиков имеют эту форму; ошибки, которые клиент должен различать,
+// дополнительно несут машинный код в поле code, а некоторые - подробности
+// в дополнительных полях (errors, deleted_at).
+type ErrorResponse struct {
+	Error  string `json:"error"`          // Текст ошибки для человека
+	Code   string `json:"code,omitempty"` // Машинный код ошибки, например invalid_value
+	Status int    `json:"status"`         // Код ответа HTTP
+}
+
+// writeError отправляет ошибку JSON-телом ErrorResponse без машинного кода
+func writeError(w http.ResponseWriter, message string, statusCode int) {
+	writeErrorCode(w, statusCode, "", message)
+}
+
+// writeErrorCode отправляет ошибку JSON-телом {"error": "...", "code": "...", "status": ...}
+func writeErrorCode(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: message, Code: code, Status: status})
+}
+
+// writeInvalidValue отвечает кодом 400 на значение перечисления вне списка допустимых
+//
+//	{
+//	  "error": "недопустимое значение \"name\" поля sort, допустимые значения: title",
+//	  "code": "invalid_value",
+//	  "errors": [{"field": "sort", "rule": "oneof", "code": "invalid_value", "message": "...", "allowed": ["title"]}]
+//	}
+func writeInvalidValue(w http.ResponseWriter, invalid *schema.FieldError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(struct {
+		ErrorResponse
+		Errors schema.Errors `json:"errors"`
+	}{ErrorResponse{invalid.Message, schema.CodeInvalidValue, http.StatusBadRequest}, schema.Errors{*invalid}})
+}
+
+// writeSchemaError отвечает кодом 400 на тело запроса, не прошедшее schema.Validate
+//
+// Значение перечисления вне списка допустимых возвращается JSON-телом с кодом
+// invalid_value, см. writeInvalidValue, остальные нарушения правил - без машинного
+// кода со списком ошибок полей:
+//
+//	{
+//	  "error": "длина поля title должна быть не меньше 1",
+//	  "status": 400,
+//	  "errors": [{"field": "title", "rule": "min", "message": "..."}]
+//	}
+//
+// Ошибка, не являющаяся schema.Errors, например неверный JSON, возвращается без списка.
+func writeSchemaError(w http.ResponseWriter, err error) {
+	var errs schema.Errors
+	if !errors.As(err, &errs) {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, fe := range errs {
+		if fe.Code == schema.CodeInvalidValue {
+			writeInvalidValue(w, &fe)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(struct {
+		ErrorResponse
+		Errors schema.Errors `json:"errors"`
+	}{ErrorResponse{errs.Error(), "", http.StatusBadRequest}, errs})
+}
+
+// writeTaskError сообщает об ошибке операции с задачей
+//
+// Код ответа и машинный код выбирает taskErrorResponse. Обращение к недавно
+// удаленной задаче и конфликт версий дополнительно несут подробности,
+// см. writeGone и writeVersionConflict.
+func writeTaskError(w http.ResponseWriter, err error, status int) {
+	var deleted *storage.TaskDeletedError
+	var conflict *storage.VersionConflictError
+	switch {
+	case errors.As(err, &deleted):
+		writeGone(w, deleted, time.UTC)
+	case errors.As(err, &conflict):
+		writeVersionConflict(w, conflict)
+	default:
+		status, code, message := taskErrorResponse(err, status)
+		writeErrorCode(w, status, code, message)
+	}
+}
+
+// taskErrorResponse сопоставляет ошибке операции с задачей код ответа, машинный код и текст
+//
+// Запрет изменения выполненной задачи возвращается кодом 409 с машинным кодом
+// task_completed_immutable, чтобы клиент мог отличить его от других конфликтов,
+// а обращение к недавно удаленной задаче - кодом 410. Отсутствие задачи, ссылки
+// или пространства метаданных возвращается кодом 404, заполненное хранилище -
+// 507, закрытое - 503, отмененный запрос и истекший срок - 499 и 504.
+// Остальные ошибки возвращаются без машинного кода с кодом status; его выбирает
+// вызывающий для ошибок, известных операции, а для непредвиденных ошибок
+// хранилища, например ввода-вывода, status - 500.
+//
+// Args:
+//
+//	err: ошибка операции
+//	status: код ответа на ошибки, не известные сопоставлению
+//
+// Returns:
+//
+//	int: код ответа
+//	string: машинный код ошибки или пустая строка
+//	string: текст ошибки для человека
+func taskErrorResponse(err error, status int) (int, string, string) {
+	var deleted *storage.TaskDeletedError
+	var conflict *storage.VersionConflictError
+	switch {
+	case errors.As(err, &deleted):
+		return http.StatusGone, CodeTaskDeleted, err.Error()
+	case errors.As(err, &conflict):
+		return http.StatusConflict, CodeVersionConflict, err.Error()
+	case errors.Is(err, storage.ErrTaskCompletedImmutable):
+		return http.StatusConflict, CodeTaskCompletedImmutable, err.Error()
+	case errors.Is(err, storage.ErrTaskAlreadyCompleted), errors.Is(err, storage.ErrTaskNotDeleted):
+		return http.StatusConflict, "", err.Error()
+	case errors.Is(err, storage.ErrInvalidPatch):
+		return http.StatusBadRequest, "", err.Error()
+	case errors.Is(err, storage.ErrTaskNotFound), errors.Is(err, storage.ErrLinkNotFound), errors.Is(err, storage.ErrMetadataNotFound):
+		return http.StatusNotFound, "", err.Error()
+	case errors.Is(err, storage.ErrStorageFull):
+		return http.StatusInsufficientStorage, CodeStorageFull, err.Error()
+	case errors.Is(err, storage.ErrStorageClosed):
+		return http.StatusServiceUnavailable, CodeStorageClosed, err.Error()
+	case errors.Is(err, context.Canceled):
+		return StatusClientClosedRequest, CodeRequestCanceled, "запрос отменен клиентом"
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, CodeDeadlineExceeded, "истек срок выполнения запроса"
+	default:
+		return status, "", err.Error()
+	}
+}
+
+// taskErrorStatus возвращает код ответа для ошибки операции с задачей из HTML-формы,
+// см. taskErrorResponse
+func taskErrorStatus(err error, status int) int {
+	status, _, _ = taskErrorResponse(err, status)
+	return status
+}
+
+// writeGone отвечает кодом 410 на обращение к недавно удаленной задаче, момент
+// удаления выводится в часовом поясе location
+//
+//	{
+//	  "error": "задача с ID 1 удалена 2024-01-01T12:00:00Z",
+//	  "code": "task_deleted",
+//	  "status": 410,
+//	  "deleted_at": "2024-01-01T12:00:00Z"
+//	}
+func writeGone(w http.ResponseWriter, deleted *storage.TaskDeletedError, location *time.Location) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusGone)
+	json.NewEncoder(w).Encode(struct {
+		ErrorResponse
+		DeletedAt string `json:"deleted_at"`
+	}{ErrorResponse{deleted.Error(), CodeTaskDeleted, http.StatusGone}, deleted.DeletedAt.In(location).Format(time.RFC3339)})
+}
+
+// writeVersionConflict отвечает кодом 409 на обновление устаревшей версии задачи
+//
+//	{
+//	  "error": "задача с ID 1 изменена другим клиентом, текущая версия 3",
+//	  "code": "version_conflict",
+//	  "status": 409,
+//	  "current_version": 3
+//	}
+func writeVersionConflict(w http.ResponseWriter, conflict *storage.VersionConflictError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(struct {
+		ErrorResponse
+		CurrentVersion int `json:"current_version"`
+	}{ErrorResponse{conflict.Error(), CodeVersionConflict, http.StatusConflict}, conflict.Version})
+}
+
+// writeDeleteError сообщает об ошибке удаления задачи id
+func writeDeleteError(w http.ResponseWriter, err error, id int) {
+	if errors.Is(err, storage.ErrTaskProtected) {
+		writeConfirmationRequired(w, id)
+		return
+	}
+	writeTaskError(w, err, http.StatusInternalServerError)
+}
+
+// writeConfirmationRequired отвечает кодом 428 с указанием, как подтвердить удаление задачи id
+func writeConfirmationRequired(w http.ResponseWriter, id int) {
+	writeErrorCode(w, http.StatusPreconditionRequired, CodeDeleteConfirmationRequired,
+		fmt.Sprintf("удаление задачи требует подтверждения: передайте заголовок %s: %d", ConfirmDeleteHeader, id))
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"test/models"
+	"test/schema"
+	"test/storage"
+)
+
+// FollowUpRequest - тело запроса POST /tasks/{id}/complete-with-followup
+type FollowUpRequest struct {
+	Title       string        `json:"title" validate:"required"`
+	Description string        `json:"description" validate:"required"`
+	Links       []models.Link `json:"links,omitempty" validate:"max=10"`
+	Protected   bool          `json:"protected,omitempty"`
+}
+
+// input преобразует запрос в поля задачи-продолжения
+func (req FollowUpRequest) input(links []models.Link) storage.CreateTaskInput {
+	return storage.CreateTaskInput{
+		Title:       req.Title,
+		Description: req.Description,
+		Links:       links,
+		Protected:   req.Protected,
+	}
+}
+
+// FollowUpResponse - ответ на POST /tasks/{id}/complete-with-followup
+type FollowUpResponse struct {
+	Completed *models.Task `json:"completed"`
+	FollowUp  *models.Task `json:"follow_up"`
+}
+
+// CompleteWithFollowUpHandler атомарно завершает задачу и создает ее продолжение
+// POST /tasks/{id}/complete-with-followup
+//
+// Запрос - поля новой задачи, как в POST /tasks:
+//
+//	{
+//	  "title": "Ревью X - раунд 2",
+//	  "description": "Проверить исправления"
+//	}
+//
+// Ответ с кодом 201 и адресом продолжения в заголовке Location:
+//
+//	{
+//	  "completed": {"id": 1, "title": "Ревью X", "description": "...", "completed": true},
+//	  "follow_up": {"id": 2, "title": "Ревью X - раунд 2", "description": "...", "completed": false, "follows_id": 1}
+//	}
+//
+// Если тело запроса неверно, исходная задача не меняется. Уже выполненная задача
+// отклоняется с кодом 409, чтобы повтор запроса не создал второе продолжение.
+func CompleteWithFollowUpHandler(w http.ResponseWriter, r *http.Request, storage storage.FollowUpStorage, id int) {
+	var followUp FollowUpRequest
+
+	// Декодирование JSON из тела запроса
+	err := json.NewDecoder(r.Body).Decode(&followUp)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := schema.Validate(followUp); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	links, err := models.NormalizeLinks(followUp.Links)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	completed, created, err := storage.CompleteWithFollowUp(id, followUp.input(links))
+	if err != nil {
+		writeTaskError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/tasks/"+strconv.Itoa(created.ID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(FollowUpResponse{Completed: completed, FollowUp: created})
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"test/schema"
+)
+
+// maxFormMemory ограничивает объем multipart-формы, хранимый в памяти
+const maxFormMemory = 1 << 20
+
+// isFormRequest сообщает, передано ли тело запроса HTML-формой
+// (application/x-www-form-urlencoded или multipart/form-data)
+func isFormRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data"
+}
+
+// formCompletedValues - значения поля completed формы, перечисляемые в ошибках
+var formCompletedValues = []string{"on", "true", "false"}
+
+// decodeTaskForm извлекает поля задачи из тела HTML-формы
+//
+// Поле completed обрабатывается по правилам checkbox: браузер передает
+// значение "on" для отмеченного флажка и не передает поле вовсе для снятого.
+//
+// Returns:
+//
+//	title, description: название и описание задачи
+//	completed: статус выполнения
+//	error: ошибка разбора формы
+func decodeTaskForm(r *http.Request) (title, description string, completed bool, err error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		err = r.ParseMultipartForm(maxFormMemory)
+	} else {
+		err = r.ParseForm()
+	}
+	if err != nil {
+		return "", "", false, err
+	}
+
+	title = r.PostForm.Get("title")
+	description = r.PostForm.Get("description")
+
+	switch value := r.PostForm.Get("completed"); value {
+	case "":
+		completed = false
+	case "on":
+		completed = true
+	default:
+		completed, err = strconv.ParseBool(value)
+		if err != nil {
+			return "", "", false, schema.OneOf("completed", value, formCompletedValues...)
+		}
+	}
+
+	return title, description, completed, nil
+}
+
+// wantsJSON сообщает, что клиент, отправивший форму, ожидает JSON вместо перенаправления
+func wantsJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+// returnURL возвращает адрес возврата, переданный формой в поле return_to
+//
+// Допускаются только относительные пути внутри сервиса, чтобы форма
+// не могла использоваться для перенаправления на сторонний сайт.
+// Если адрес не указан или небезопасен, возвращается fallback.
+func returnURL(r *http.Request, fallback string) string {
+	raw := r.PostForm.Get("return_to")
+	if raw == "" || !strings.HasPrefix(raw, "/") || strings.HasPrefix(raw, "//") || strings.Contains(raw, "\\") {
+		return fallback
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return fallback
+	}
+	return raw
+}
+
+// redirectWithFlash перенаправляет браузер с кодом 303, добавляя к адресу параметр error
+func redirectWithFlash(w http.ResponseWriter, r *http.Request, target, message string) {
+	u, err := url.Parse(target)
+	if err != nil {
+		u = &url.URL{Path: "/"}
+	}
+
+	query := u.Query()
+	query.Set("error", message)
+	u.RawQuery = query.Encode()
+
+	http.Redirect(w, r, u.String(), http.StatusSeeOther)
+}
+
+// writeFormError сообщает клиенту формы об ошибке: JSON-ответом, если он ожидает JSON,
+// иначе перенаправлением на адрес возврата с текстом ошибки в параметре error
+func writeFormError(w http.ResponseWriter, r *http.Request, message string, statusCode int) {
+	if wantsJSON(r) {
+		writeError(w, message, statusCode)
+		return
+	}
+	redirectWithFlash(w, r, returnURL(r, "/"), message)
+}
@@ -3,54 +3,369 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"test/models"
+	"test/schema"
 	"test/storage"
+	"time"
+
+	"golang.org/x/text/language"
 )
 
+// Config содержит настройки обработчиков
+type Config struct {
+	// StrictSchema включает проверку тел запросов по опубликованной JSON Schema:
+	// неизвестные поля и значения неверного типа отклоняются с кодом 400
+	StrictSchema bool
+
+	// ConfirmDeletes требует подтверждать удаление любой задачи заголовком
+	// X-Confirm-Delete с ее ID, а DELETE /tasks/bulk - параметром ?confirm=true;
+	// без подтверждения удаление отклоняется с кодом 428.
+	// Защищенные задачи (protected) требуют подтверждения независимо от этой настройки
+	ConfirmDeletes bool
+
+	// Collation - локаль сортировки ?sort=title, если клиент не указал ее сам
+	// параметром ?collate или заголовком Accept-Language. По умолчанию DefaultCollation
+	Collation string
+
+	// HardRules - мягкие правила валидации (см. SoftRuleNames), нарушение которых
+	// отклоняет запись с кодом 422 вместо предупреждения в ответе
+	HardRules []string
+
+	// QuickAddMaxLines - максимальное число задач в одном запросе POST /tasks/quick.
+	// По умолчанию DefaultQuickAddMaxLines
+	QuickAddMaxLines int
+
+	// BulkCreateMaxItems - максимальное число задач в одном запросе POST /tasks/bulk
+	// и DELETE /tasks/bulk.
+	// По умолчанию DefaultBulkCreateMaxItems
+	BulkCreateMaxItems int
+
+	// ReloadConfig перечитывает настройки сервера по POST /admin/config/reload.
+	// nil - перезагрузка не настроена, маршрут отвечает кодом 501
+	ReloadConfig func() (ConfigReload, error)
+}
+
+// Middleware - промежуточный обработчик, оборачивающий маршрутизатор, например middleware.Logging
+type Middleware = func(http.Handler) http.Handler
+
 // SetupHandlers настраивает маршрутизатор HTTP с обработчиками для работы с задачами
-func SetupHandlers(storage *storage.InMemoryStorage) *http.ServeMux {
+//
+// Маршрутизатор оборачивается middleware по порядку: первый получает запрос первым.
+func SetupHandlers(storage storage.Storage, middleware ...Middleware) http.Handler {
+	return SetupHandlersWithConfig(storage, Config{}, middleware...)
+}
+
+// SetupHandlersWithConfig настраивает маршрутизатор HTTP с заданными настройками обработчиков
+//
+// Маршруты возможностей, которые хранилище не поддерживает (см. интерфейсы пакета storage),
+// отвечают кодом 501, а сами возможности отмечаются в GET /capabilities как недоступные.
+// Ответы на GET выводят метки времени в часовом поясе ?tz или X-Timezone, см. withTimezone.
+// Маршрутизатор оборачивается middleware по порядку: первый получает запрос первым
+// и видит ответ целиком, включая ошибки параметра ?tz.
+// GET /healthz и GET /readyz отвечают в обход middleware, см. withProbes.
+func SetupHandlersWithConfig(storage storage.Storage, config Config, middleware ...Middleware) http.Handler {
 	mux := http.NewServeMux()
+	caps := capabilities{}
+	b := newBackend(storage)
+
+	if config.Collation == "" {
+		config.Collation = DefaultCollation
+	}
+	collation, _ := matchCollation(language.Make(config.Collation))
+	policy := newValidationPolicy(config.HardRules)
 
 	// Регистрация обработчиков для /tasks
+	caps.register("form_bodies", true)
+	caps.register("client_token", b.tokens != nil)
+	caps.register("task_expiry", b.expiring != nil)
+	caps.register("streaming_list", b.streaming != nil)
+	caps.register("sort_keys", sortKeys)
+	caps.register("sort_title_collation", collation.String())
+	caps.register("strict_schema", config.StrictSchema)
+	caps.register("completed_immutable", b.completedImmutable())
+	caps.register("text_plain", true)
+	caps.register("validation_rules", policy.modes())
+	caps.register("pagination", true)
+	caps.register("max_page_limit", MaxPageLimit)
+	caps.register("priorities", models.Priorities)
+	caps.register("max_tags", models.MaxTags)
+	caps.register("response_timezone", true)
 	mux.HandleFunc("/tasks", func(w http.ResponseWriter, r *http.Request) {
+		w, finish := textErrors(w, r)
+		defer finish()
+
+		if !allowMethod(w, r, http.MethodGet, http.MethodPost) {
+			return
+		}
 		switch r.Method {
 		case http.MethodPost:
-			CreateTaskHandler(w, r, storage)
+			if config.StrictSchema && !validateStrict(w, r, &CreateTaskRequest{}) {
+				return
+			}
+			CreateTaskHandler(w, r, storage, b.tokens, b.expiring, b.prefs, policy)
 		case http.MethodGet:
-			GetAllTasksHandler(w, r, storage)
-		default:
-			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			GetAllTasksHandler(w, r, storage, b.pages, b.priorities, b.tags, b.softDelete, collation)
+		}
+	})
+
+	// Регистрация обработчика быстрого добавления задач
+	if config.QuickAddMaxLines <= 0 {
+		config.QuickAddMaxLines = DefaultQuickAddMaxLines
+	}
+	caps.register("quick_add", b.batch != nil)
+	caps.register("quick_add_max_lines", config.QuickAddMaxLines)
+	mux.HandleFunc("/tasks/quick", func(w http.ResponseWriter, r *http.Request) {
+		w, finish := textErrors(w, r)
+		defer finish()
+
+		if !allowMethod(w, r, http.MethodPost) {
+			return
+		}
+		if b.batch == nil {
+			writeNotSupported(w, "создание нескольких задач одной операцией")
+			return
+		}
+		QuickAddHandler(w, r, b.batch, b.prefs, policy, config.QuickAddMaxLines)
+	})
+
+	// Регистрация обработчиков создания и удаления нескольких задач
+	if config.BulkCreateMaxItems <= 0 {
+		config.BulkCreateMaxItems = DefaultBulkCreateMaxItems
+	}
+	caps.register("bulk_create", b.batch != nil)
+	caps.register("bulk_create_max_items", config.BulkCreateMaxItems)
+	caps.register("bulk_delete", b.batch != nil)
+	mux.HandleFunc("/tasks/bulk", func(w http.ResponseWriter, r *http.Request) {
+		w, finish := textErrors(w, r)
+		defer finish()
+
+		if !allowMethod(w, r, http.MethodPost, http.MethodDelete) {
+			return
+		}
+		if b.batch == nil {
+			writeNotSupported(w, "создание и удаление нескольких задач одной операцией")
+			return
+		}
+		if r.Method == http.MethodDelete {
+			BulkDeleteHandler(w, r, b.batch, config.ConfirmDeletes, config.BulkCreateMaxItems)
+			return
+		}
+		BulkCreateHandler(w, r, b.batch, b.tokenBatch, b.expiring, b.prefs, policy, config.StrictSchema, config.BulkCreateMaxItems)
+	})
+
+	// Регистрация обработчика просроченных задач; до /tasks/, чтобы overdue не разбирался как ID
+	caps.register("overdue", b.overdue != nil)
+	mux.HandleFunc("/tasks/overdue", func(w http.ResponseWriter, r *http.Request) {
+		w, finish := textErrors(w, r)
+		defer finish()
+
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		if b.overdue == nil {
+			writeNotSupported(w, "просроченные задачи")
+			return
+		}
+		GetOverdueTasksHandler(w, r, b.overdue)
+	})
+
+	// Регистрация обработчиков настроек по умолчанию для новых задач
+	caps.register("preferences", b.prefs != nil)
+	mux.HandleFunc("/preferences", func(w http.ResponseWriter, r *http.Request) {
+		if !allowMethod(w, r, http.MethodGet, http.MethodPut) {
+			return
+		}
+		if b.prefs == nil {
+			writeNotSupported(w, "настройки по умолчанию")
+			return
+		}
+		if r.Method == http.MethodPut {
+			SetPreferencesHandler(w, r, b.prefs)
+			return
 		}
+		GetPreferencesHandler(w, r, b.prefs)
 	})
 
-	// Регистрация обработчиков для /tasks/{id}
+	// Регистрация обработчика поиска задач; до /tasks/, чтобы search не разбирался как ID
+	caps.register("search", b.search != nil)
+	caps.register("min_search_query_length", MinSearchQueryLength)
+	mux.HandleFunc("/tasks/search", func(w http.ResponseWriter, r *http.Request) {
+		w, finish := textErrors(w, r)
+		defer finish()
+
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		if b.search == nil {
+			writeNotSupported(w, "поиск задач")
+			return
+		}
+		SearchTasksHandler(w, r, b.search)
+	})
+
+	// Регистрация обработчика поиска дубликатов; до /tasks/, чтобы duplicates не разбирался как ID
+	caps.register("duplicates", true)
+	caps.register("max_fuzzy_duplicate_tasks", MaxFuzzyDuplicateTasks)
+	mux.HandleFunc("/tasks/duplicates", func(w http.ResponseWriter, r *http.Request) {
+		w, finish := textErrors(w, r)
+		defer finish()
+
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		GetDuplicatesHandler(w, r, storage)
+	})
+
+	// Регистрация обработчиков для /tasks/{id} и вложенных ресурсов задачи.
+	// Коды ответа на неверные пути описаны в таблице в routing.go
+	caps.register("delete_confirmation", config.ConfirmDeletes)
+	caps.register("tombstones", b.tombstones != nil)
+	caps.register("links", b.links != nil)
+	caps.register("max_links", models.MaxLinks)
+	caps.register("patch", b.patch != nil)
+	caps.register("toggle", b.toggle != nil)
+	caps.register("complete_with_followup", b.followUps != nil)
+	caps.register("metadata", b.metadata != nil)
+	caps.register("max_metadata_bytes", b.metadataLimit())
+	caps.register("soft_delete", b.softDelete != nil)
+	caps.register("optimistic_concurrency", b.versioned != nil)
 	mux.HandleFunc("/tasks/", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/tasks/" {
-			http.Error(w, "ID не указан", http.StatusBadRequest)
+		w, finish := textErrors(w, r)
+		defer finish()
+
+		path, status, message := parseTaskPath(r.URL.Path)
+		if status != 0 {
+			writeError(w, message, status)
 			return
 		}
+		id := path.ID
 
-		idStr := r.URL.Path[len("/tasks/"):]
-		id, err := strconv.Atoi(idStr)
-		if err != nil {
-			http.Error(w, "Неверный формат ID", http.StatusBadRequest)
+		switch {
+		case path.Resource == "":
+			if !allowMethod(w, r, http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete) {
+				return
+			}
+			switch r.Method {
+			case http.MethodGet:
+				GetTaskHandler(w, r, storage, b.metadata, id)
+			case http.MethodPut:
+				if config.StrictSchema && !validateStrict(w, r, &UpdateTaskRequest{}) {
+					return
+				}
+				UpdateTaskHandler(w, r, storage, b.versioned, id, policy)
+			case http.MethodPatch:
+				if config.StrictSchema && !validateStrict(w, r, &PatchTaskRequest{}) {
+					return
+				}
+				if b.patch == nil {
+					writeNotSupported(w, "частичное обновление задач")
+					return
+				}
+				PatchTaskHandler(w, r, b.patch, id, policy)
+			case http.MethodDelete:
+				DeleteTaskHandler(w, r, storage, b.protected, b.softDelete, id, config.ConfirmDeletes)
+			}
+		case path.Resource == "links" && b.links == nil:
+			writeNotSupported(w, "ссылки задач")
+		case path.Resource == "links" && !path.HasParam:
+			if !allowMethod(w, r, http.MethodPost) {
+				return
+			}
+			AddLinkHandler(w, r, b.links, id)
+		case path.Resource == "links":
+			index, ok := parseIndex(path.Param)
+			if !ok {
+				writeError(w, "Неверный формат индекса ссылки", http.StatusBadRequest)
+				return
+			}
+			if !allowMethod(w, r, http.MethodDelete) {
+				return
+			}
+			RemoveLinkHandler(w, r, b.links, id, index)
+		case path.Resource == "complete-with-followup" && b.followUps == nil:
+			writeNotSupported(w, "завершение задачи с продолжением")
+		case path.Resource == "complete-with-followup":
+			if !allowMethod(w, r, http.MethodPost) {
+				return
+			}
+			if config.StrictSchema && !validateStrict(w, r, &FollowUpRequest{}) {
+				return
+			}
+			CompleteWithFollowUpHandler(w, r, b.followUps, id)
+		case path.Resource == "metadata" && b.metadata == nil:
+			writeNotSupported(w, "метаданные задач")
+		case path.Resource == "metadata" && !path.HasParam:
+			if !allowMethod(w, r, http.MethodGet) {
+				return
+			}
+			GetMetadataHandler(w, r, b.metadata, id)
+		case path.Resource == "metadata":
+			if !models.ValidMetadataNamespace(path.Param) {
+				writeError(w, "Неверное имя пространства метаданных", http.StatusBadRequest)
+				return
+			}
+			if !allowMethod(w, r, http.MethodGet, http.MethodPut, http.MethodDelete) {
+				return
+			}
+			switch r.Method {
+			case http.MethodGet:
+				GetMetadataNamespaceHandler(w, r, b.metadata, id, path.Param)
+			case http.MethodPut:
+				PutMetadataHandler(w, r, b.metadata, id, path.Param)
+			case http.MethodDelete:
+				DeleteMetadataHandler(w, r, b.metadata, id, path.Param)
+			}
+		case path.Resource == "restore" && b.softDelete == nil:
+			writeNotSupported(w, "восстановление удаленных задач")
+		case path.Resource == "restore":
+			if !allowMethod(w, r, http.MethodPost) {
+				return
+			}
+			RestoreTaskHandler(w, r, storage, b.softDelete, id)
+		case path.Resource == "toggle" && b.toggle == nil:
+			writeNotSupported(w, "переключение выполнения задачи")
+		case path.Resource == "toggle":
+			if !allowMethod(w, r, http.MethodPut) {
+				return
+			}
+			ToggleTaskHandler(w, r, b.toggle, id)
+		}
+	})
+
+	// Регистрация служебных обработчиков
+	setupAdminHandlers(mux, b.caches, config.ReloadConfig)
+	caps.register("admin_caches", b.caches != nil)
+	caps.register("config_reload", config.ReloadConfig != nil)
+
+	// Регистрация обработчика JSON Schema
+	caps.register("json_schema", true)
+	mux.HandleFunc("/schemas/", func(w http.ResponseWriter, r *http.Request) {
+		if !allowMethod(w, r, http.MethodGet) {
 			return
 		}
+		SchemaHandler(w, r)
+	})
 
-		switch r.Method {
-		case http.MethodGet:
-			GetTaskHandler(w, r, storage, id)
-		case http.MethodPut:
-			UpdateTaskHandler(w, r, storage, id)
-		case http.MethodDelete:
-			DeleteTaskHandler(w, r, storage, id)
-		default:
-			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+	// Регистрация обработчика возможностей, последним после всех возможностей
+	mux.HandleFunc("/capabilities", func(w http.ResponseWriter, r *http.Request) {
+		if !allowMethod(w, r, http.MethodGet) {
+			return
 		}
+		caps.ServeHTTP(w, r)
 	})
 
-	return mux
+	// Часовой пояс ?tz передается обработчикам ответов на чтение в контексте запроса
+	var root http.Handler = withTimezone(mux, b.prefs)
+	for i := len(middleware) - 1; i >= 0; i-- {
+		root = middleware[i](root)
+	}
+
+	// Проверки состояния GET /healthz и GET /readyz не проходят через middleware
+	return withProbes(root, b.pinger)
 }
 
 // CreateTaskHandler создает новую задачу
@@ -60,51 +375,219 @@ func SetupHandlers(storage *storage.InMemoryStorage) *http.ServeMux {
 //
 //	{
 //	  "title": "Название задачи",
-//	  "description": "Описание задачи"
+//	  "description": "Описание задачи",
+//	  "links": [{"title": "Тикет", "url": "https://tracker.example/T-1"}],
+//	  "client_token": "необязательный токен повтора",
+//	  "priority": "high",
+//	  "due_date": "2024-01-05T18:00:00Z"
 //	}
 //
+// Поле priority необязательно, допустимые значения - models.Priorities; неизвестное
+// значение отклоняется с кодом 400 и кодом ошибки invalid_value, см. writeInvalidValue.
+// Необязательный срок due_date задается в RFC3339 с любым смещением и хранится в UTC.
+//
+// Необязательное время жизни задается моментом expires_at в будущем или числом
+// секунд expires_in_seconds от создания, но не обоими полями. Истекшая задача
+// отвечает кодом 404 и пропадает из списков, см. storage.ExpiringStorage;
+// хранилище без поддержки истечения отклоняет такой запрос кодом 501.
+//
+// Повторный запрос с тем же client_token возвращает исходную задачу
+// с кодом 200 вместо создания дубликата.
+//
+// Без полей priority и tags задача получает приоритет и теги по умолчанию,
+// см. SetPreferencesHandler; явные значения, в том числе "tags": [], сохраняются.
+//
+// Нарушения мягких правил (см. SoftRuleNames) не мешают созданию и перечисляются
+// в поле warnings ответа; правила из Config.HardRules отклоняют запрос с кодом 422.
+//
 // Ответ:
 //
 //	{
 //	  "id": 1,
 //	  "title": "Название задачи",
 //	  "description": "Описание задачи",
-//	  "completed": false
+//	  "completed": false,
+//	  "created_at": "2024-01-01T12:00:00Z",
+//	  "updated_at": "2024-01-01T12:00:00Z"
 //	}
-func CreateTaskHandler(w http.ResponseWriter, r *http.Request, storage *storage.InMemoryStorage) {
-	var taskData struct {
-		Title       string `json:"title"`
-		Description string `json:"description"`
+//
+// Поля id, created_at и updated_at задает сервер: их значения в запросе игнорируются.
+//
+// Также принимает HTML-форму (application/x-www-form-urlencoded или multipart/form-data)
+// с полями title, description и необязательным return_to, см. createTaskFromForm
+//
+// Args:
+//
+//	tokens: защита от дублей по client_token, nil - хранилище ее не поддерживает
+//	        и запрос с client_token отклоняется с кодом 501
+//	preferences: настройки по умолчанию для новых задач, nil - без них
+func CreateTaskHandler(w http.ResponseWriter, r *http.Request, storage storage.Storage, tokens storage.TokenStorage, expiring storage.ExpiringStorage, preferences storage.PreferenceStorage, policy validationPolicy) {
+	var taskData CreateTaskRequest
+
+	defaults, err := taskDefaults(preferences)
+	if err != nil {
+		writeTaskError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	// Тело запроса от HTML-формы обрабатывается отдельно от JSON
+	if isFormRequest(r) {
+		createTaskFromForm(w, r, storage, policy, defaults)
+		return
 	}
 
 	// Декодирование JSON из тела запроса
-	err := json.NewDecoder(r.Body).Decode(&taskData)
+	err = json.NewDecoder(r.Body).Decode(&taskData)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	applyDefaults(defaults, &taskData.Priority, &taskData.Tags)
 
-	// Валидация входных данных
-	if taskData.Title == "" || taskData.Description == "" {
-		http.Error(w, "Title и Description обязательны", http.StatusBadRequest)
+	// Валидация входных данных по правилам опубликованной схемы
+	if err := schema.Validate(taskData); err != nil {
+		writeSchemaError(w, err)
+		return
+	}
+
+	links, err := models.NormalizeLinks(taskData.Links)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if taskData.Tags, err = models.NormalizeTags(taskData.Tags); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Время жизни задачи задается одним из двух полей и только в будущем
+	if taskData.expires() {
+		if expiring == nil {
+			writeNotSupported(w, "задачи с ограниченным временем жизни")
+			return
+		}
+		if taskData.ExpiresAt != nil && taskData.ExpiresInSeconds != nil {
+			writeError(w, "Поля expires_at и expires_in_seconds нельзя задавать вместе", http.StatusBadRequest)
+			return
+		}
+		if taskData.ExpiresAt != nil && !taskData.ExpiresAt.After(time.Now()) {
+			writeError(w, "Поле expires_at должно быть в будущем", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Проверка мягкими правилами
+	violations, warnings := policy.check(softFields{Title: taskData.Title})
+	if len(violations) > 0 {
+		writeValidationFailed(w, violations)
+		return
+	}
+
+	input := taskData.input(links)
+
+	// Создание с клиентским токеном защищено от дублей при повторе запроса
+	if taskData.ClientToken != "" {
+		if tokens == nil {
+			writeNotSupported(w, "клиентские токены")
+			return
+		}
+		task, created, err := tokens.CreateTaskWithToken(taskData.ClientToken, input)
+		if err != nil {
+			writeTaskError(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		// Повтор возвращает исходную задачу с кодом 200
+		if created {
+			w.WriteHeader(http.StatusCreated)
+		}
+		json.NewEncoder(w).Encode(taskWithWarnings{Task: task, Warnings: warnings})
 		return
 	}
 
 	// Создание задачи в хранилище
-	task, err := storage.CreateTask(taskData.Title, taskData.Description)
+	task, err := storage.CreateTask(r.Context(), input)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeTaskError(w, err, http.StatusInternalServerError)
 		return
 	}
 
 	// Возврат созданной задачи с кодом 201
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(task)
+	json.NewEncoder(w).Encode(taskWithWarnings{Task: task, Warnings: warnings})
+}
+
+// createTaskFromForm создает задачу из полей HTML-формы
+//
+// Клиенту, ожидающему JSON (Accept: application/json), возвращается созданная задача
+// с кодом 201. Браузер перенаправляется с кодом 303 на адрес из поля return_to
+// или на адрес созданной задачи. Ошибки валидации передаются браузеру
+// в параметре error адреса возврата.
+func createTaskFromForm(w http.ResponseWriter, r *http.Request, storage storage.Storage, policy validationPolicy, defaults models.Preferences) {
+	title, description, _, err := decodeTaskForm(r)
+	if err != nil {
+		writeFormError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	request := CreateTaskRequest{Title: title, Description: description}
+	applyDefaults(defaults, &request.Priority, &request.Tags)
+
+	// Валидация входных данных по тем же правилам, что и для JSON
+	if err := schema.Validate(request); err != nil {
+		writeFormError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	violations, warnings := policy.check(softFields{Title: title})
+	if len(violations) > 0 {
+		writeFormError(w, r, violations.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	task, err := storage.CreateTask(r.Context(), request.input(nil))
+	if err != nil {
+		writeFormError(w, r, err.Error(), taskErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	location := "/tasks/" + strconv.Itoa(task.ID)
+	if wantsJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", location)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(taskWithWarnings{Task: task, Warnings: warnings})
+		return
+	}
+	http.Redirect(w, r, returnURL(r, location), http.StatusSeeOther)
 }
 
+// booleanValues - значения логических параметров запроса, перечисляемые в ошибках
+var booleanValues = []string{"true", "false"}
+
+// expandOptions - допустимые значения параметра expand задачи
+var expandOptions = []string{"metadata"}
+
 // GetAllTasksHandler возвращает список всех задач
 // GET /tasks
 //
+// Параметры запроса:
+//
+//	has_link: true - только задачи со ссылками, false - только задачи без ссылок
+//	priority: только задачи с этим приоритетом (low, medium, high, critical)
+//	tag: только задачи с этим тегом; при нескольких tag - со всеми указанными тегами,
+//	     не больше models.MaxTags значений
+//	include_deleted: true - включить мягко удаленные задачи (с полем deleted_at)
+//	sort: до MaxSortKeys ключей через запятую из title, priority, due_date, created_at
+//	      и id, "-" перед ключом - по убыванию (sort=-priority,due_date), см. sortedTasks
+//	collate: локаль сортировки (en, ru); без параметра берется из Accept-Language
+//	page: номер страницы, начиная с 1
+//	limit: размер страницы, по умолчанию DefaultPageLimit, не больше MaxPageLimit
+//
+// Без page и limit список отдается целиком. Страница отдается с заголовками
+// X-Total-Count (общее число задач с учетом фильтра) и Link, см. writePageHeaders.
+//
+// Клиенту, предпочитающему text/plain, задачи выводятся по одной на строку
+// ("[ ] 12 Купить молоко"), см. writeTaskTextStream.
+//
 // Ответ:
 // [
 //
@@ -112,34 +595,220 @@ func CreateTaskHandler(w http.ResponseWriter, r *http.Request, storage *storage.
 //	  "id": 1,
 //	  "title": "Задача 1",
 //	  "description": "Описание 1",
-//	  "completed": false
+//	  "completed": false,
+//	  "created_at": "2024-01-01T12:00:00Z",
+//	  "updated_at": "2024-01-01T12:00:00Z"
 //	}
 //
 // ]
-func GetAllTasksHandler(w http.ResponseWriter, r *http.Request, storage *storage.InMemoryStorage) {
-	tasks, err := storage.GetAllTasks()
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+//
+// Args:
+//
+//	pages: страницы хранилища, nil - страницы собираются обработчиком
+//	priorities: индекс задач по приоритету, nil - фильтр priority проверяет все задачи
+//	tags: индекс задач по тегам, nil - фильтр tag проверяет все задачи
+//	softDelete: удаленные задачи для include_deleted, nil - хранилище удаляет задачи
+//	            безвозвратно и запрос с include_deleted=true отклоняется с кодом 501
+func GetAllTasksHandler(w http.ResponseWriter, r *http.Request, storage storage.Storage, pages storage.PaginatedStorage, priorities storage.PriorityStorage, tags storage.TagStorage, softDelete storage.SoftDeleteStorage, collation language.Tag) {
+	var keep func(*models.Task) bool
+
+	page, paginated, message := requestPage(r)
+	if message != "" {
+		writeError(w, message, http.StatusBadRequest)
+		return
+	}
+
+	// Фильтрация по наличию ссылок
+	if value := r.URL.Query().Get("has_link"); value != "" {
+		hasLink, err := strconv.ParseBool(value)
+		if err != nil {
+			writeInvalidValue(w, schema.OneOf("has_link", value, booleanValues...))
+			return
+		}
+		keep = func(task *models.Task) bool {
+			return (len(task.Links) > 0) == hasLink
+		}
+	}
+
+	list := listTasks(storage)
+
+	// Удаленные задачи есть только в полном списке хранилища, без его страниц и индексов
+	if value := r.URL.Query().Get("include_deleted"); value != "" {
+		includeDeleted, err := strconv.ParseBool(value)
+		if err != nil {
+			writeInvalidValue(w, schema.OneOf("include_deleted", value, booleanValues...))
+			return
+		}
+		if includeDeleted {
+			if softDelete == nil {
+				writeNotSupported(w, "мягкое удаление задач")
+				return
+			}
+			tasks, err := softDelete.GetAllTasksWithDeleted()
+			if err != nil {
+				writeError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			list, pages, priorities, tags = sliceLister(tasks), nil, nil, nil
+		}
+	}
+
+	// Фильтрация по тегам: по индексу хранилища, если он есть
+	if wanted := r.URL.Query()["tag"]; len(wanted) > 0 {
+		if len(wanted) > models.MaxTags {
+			writeError(w, fmt.Sprintf("Параметр tag передан больше %d раз", models.MaxTags), http.StatusBadRequest)
+			return
+		}
+		for _, tag := range wanted {
+			if !models.ValidTag(tag) {
+				writeError(w, fmt.Sprintf("Неверный тег %q в параметре tag", tag), http.StatusBadRequest)
+				return
+			}
+		}
+		if tags != nil {
+			tasks, err := tags.TasksByTags(wanted)
+			if err != nil {
+				writeError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			// Индекс приоритетов дальше не нужен: приоритет проверяется по отобранным задачам
+			list, pages, priorities = sliceLister(tasks), nil, nil
+		} else {
+			previous := keep
+			keep = func(task *models.Task) bool {
+				return task.HasTags(wanted) && (previous == nil || previous(task))
+			}
+		}
+	}
+
+	// Фильтрация по приоритету: по индексу хранилища, если он есть
+	if priority := r.URL.Query().Get("priority"); priority != "" {
+		if invalid := schema.OneOf("priority", priority, models.Priorities...); invalid != nil {
+			writeInvalidValue(w, invalid)
+			return
+		}
+		if priorities != nil {
+			tasks, err := priorities.TasksByPriority(priority)
+			if err != nil {
+				writeError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			// Страницы хранилища не учитывают фильтр
+			list, pages = sliceLister(tasks), nil
+		} else {
+			hasLink := keep
+			keep = func(task *models.Task) bool {
+				return task.Priority == priority && (hasLink == nil || hasLink(task))
+			}
+		}
+	}
+
+	// Сортировка по ключам sort, см. sortedTasks
+	sortKey := r.URL.Query().Get("sort")
+	if sortKey != "" {
+		fields, invalid, message := parseSort(sortKey)
+		if invalid != nil {
+			writeInvalidValue(w, invalid)
+			return
+		}
+		if message != "" {
+			writeError(w, message, http.StatusBadRequest)
+			return
+		}
+		tag, invalid := requestCollation(r, collation)
+		if invalid != nil {
+			writeInvalidValue(w, invalid)
+			return
+		}
+		sorted, err := sortedTasks(r.Context(), list, keep, fields, tag)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		list, keep = sorted, nil
+	}
+
+	// Разбиение на страницы
+	if paginated {
+		tasks, total, err := pageTasks(r.Context(), pages, list, keep, sortKey != "", page)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writePageHeaders(w, r, page, total)
+		list, keep = sliceLister(tasks), nil
+	}
+
+	// Задачи пишутся в ответ по мере обхода источника, см. writeTaskStream
+	w.Header().Add("Vary", "Accept")
+	if wantsText(r) {
+		writeTaskTextStream(w, r, list, keep)
 		return
 	}
-	json.NewEncoder(w).Encode(tasks)
+	writeTaskStream(w, r, list, keep)
 }
 
 // GetTaskHandler возвращает задачу по ID
 // GET /tasks/{id}
 //
+// Параметры запроса:
+//
+//	expand: metadata - добавить в ответ метаданные интеграций
+//
+// Клиенту, предпочитающему text/plain, задача выводится текстовым блоком, см. writeTaskText.
+//
 // Ответ:
 //
 //	{
 //	  "id": 1,
 //	  "title": "Задача 1",
 //	  "description": "Описание 1",
-//	  "completed": false
+//	  "completed": false,
+//	  "created_at": "2024-01-01T12:00:00Z",
+//	  "updated_at": "2024-01-01T12:00:00Z"
 //	}
-func GetTaskHandler(w http.ResponseWriter, r *http.Request, storage *storage.InMemoryStorage, id int) {
-	task, err := storage.GetTask(id)
+//
+// Args:
+//
+//	metadata: метаданные задач для ?expand=metadata, nil - хранилище их не поддерживает
+func GetTaskHandler(w http.ResponseWriter, r *http.Request, storage storage.Storage, metadata storage.MetadataStorage, id int) {
+	var expandMetadata bool
+	if expand := r.URL.Query().Get("expand"); expand != "" {
+		if invalid := schema.OneOf("expand", expand, expandOptions...); invalid != nil {
+			writeInvalidValue(w, invalid)
+			return
+		}
+		expandMetadata = true
+	}
+
+	task, err := storage.GetTask(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeLocalTaskError(w, r, err)
+		return
+	}
+
+	var values map[string]json.RawMessage
+	if expandMetadata {
+		if metadata == nil {
+			writeNotSupported(w, "метаданные задач")
+			return
+		}
+		values, err = metadata.GetMetadata(id)
+		if err != nil {
+			writeTaskError(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Add("Vary", "Accept")
+	if wantsText(r) {
+		writeTaskText(w, task, values, responseLocation(r))
+		return
+	}
+	task = localTask(r, task)
+	w.Header().Set("Content-Type", "application/json")
+	if expandMetadata {
+		json.NewEncoder(w).Encode(taskWithMetadata{Task: task, Metadata: values})
 		return
 	}
 	json.NewEncoder(w).Encode(task)
@@ -153,48 +822,340 @@ func GetTaskHandler(w http.ResponseWriter, r *http.Request, storage *storage.InM
 //	{
 //	  "title": "Новое название",
 //	  "description": "Новое описание",
-//	  "completed": true
+//	  "completed": true,
+//	  "links": [{"title": "PR", "url": "https://git.example/pr/1"}]
 //	}
 //
+// Поле links необязательно: если оно не передано, ссылки задачи не меняются.
+// Так же необязательно поле priority: пустая строка снимает приоритет, неизвестное
+// значение отклоняется с кодом 400, как при создании.
+// Если выполненные задачи неизменяемы (storage.WithCompletedImmutable), правка
+// выполненной задачи, кроме возобновления, отклоняется с кодом 409 и кодом ошибки
+// task_completed_immutable. Мягкие правила проверяются так же, как при создании.
+//
 // Ответ:
 //
 //	{
 //	  "id": 1,
 //	  "title": "Новое название",
 //	  "description": "Новое описание",
-//	  "completed": true
+//	  "completed": true,
+//	  "created_at": "2024-01-01T12:00:00Z",
+//	  "updated_at": "2024-01-02T09:30:00Z"
 //	}
-func UpdateTaskHandler(w http.ResponseWriter, r *http.Request, storage *storage.InMemoryStorage, id int) {
-	var taskData struct {
-		Title       string `json:"title"`
-		Description string `json:"description"`
-		Completed   bool   `json:"completed"`
+//
+// Каждая успешная запись обновляет updated_at и увеличивает version; created_at,
+// updated_at из запроса игнорируются.
+//
+// Необязательное поле version включает оптимистичную блокировку: задача
+// обновляется, только если ее текущая версия равна переданной, иначе ответ 409
+// с кодом version_conflict и текущей версией в current_version. Запрос без
+// version или с version 0 (версии начинаются с 1, а 0 присылают клиенты,
+// отправляющие models.Task без версии) обновляет задачу безусловно. Если
+// хранилище не поддерживает условное обновление (versioned равен nil), запрос
+// с version получает 501.
+//
+// Также принимает HTML-форму с полями title, description, completed (checkbox)
+// и необязательным return_to, см. updateTaskFromForm
+func UpdateTaskHandler(w http.ResponseWriter, r *http.Request, storage storage.Storage, versioned storage.VersionedStorage, id int, policy validationPolicy) {
+	var taskData UpdateTaskRequest
+
+	// Тело запроса от HTML-формы обрабатывается отдельно от JSON
+	if isFormRequest(r) {
+		updateTaskFromForm(w, r, storage, id, policy)
+		return
 	}
 
 	// Декодирование JSON из тела запроса
 	err := json.NewDecoder(r.Body).Decode(&taskData)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Обновление задачи в хранилище
-	task, err := storage.UpdateTask(id, taskData.Title, taskData.Description, taskData.Completed)
+	if err := schema.Validate(taskData); err != nil {
+		writeSchemaError(w, err)
+		return
+	}
+
+	// Ссылки, не переданные в запросе, остаются без изменений
+	var links []models.Link
+	if taskData.Links != nil {
+		links, err = models.NormalizeLinks(taskData.Links)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Теги, не переданные в запросе, остаются без изменений
+	if taskData.Tags != nil {
+		if taskData.Tags, err = models.NormalizeTags(taskData.Tags); err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Проверка мягкими правилами
+	violations, warnings := policy.check(softFields{Title: taskData.Title})
+	if len(violations) > 0 {
+		writeValidationFailed(w, violations)
+		return
+	}
+
+	// Обновление задачи в хранилище, с проверкой версии, если клиент ее передал
+	var task *models.Task
+	switch {
+	case taskData.Version == nil || *taskData.Version == 0:
+		task, err = storage.UpdateTask(r.Context(), id, taskData.input(links))
+	case versioned == nil:
+		writeNotSupported(w, "условное обновление задач по версии")
+		return
+	default:
+		task, err = versioned.UpdateTaskIfVersion(id, *taskData.Version, taskData.input(links))
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeTaskError(w, err, http.StatusInternalServerError)
 		return
 	}
-	json.NewEncoder(w).Encode(task)
+	json.NewEncoder(w).Encode(taskWithWarnings{Task: task, Warnings: warnings})
+}
+
+// PatchTaskHandler частично обновляет задачу
+// PATCH /tasks/{id}
+//
+// Запрос - любое подмножество полей:
+//
+//	{
+//	  "completed": true
+//	}
+//
+// Поля, отсутствующие в теле, не меняются. null вместо значения поля
+// отклоняется с кодом 400, кроме due_date: для него null снимает срок.
+// Переданные поля проверяются правилами PatchTaskRequest, как тело PUT: пустой
+// title отклоняется с кодом 400 и списком ошибок полей, см. writeSchemaError,
+// а значение priority вне models.Priorities - с кодом 400 и кодом ошибки
+// invalid_value. Поля links и tags заменяют ссылки и теги целиком и проверяются
+// models.NormalizeLinks и models.NormalizeTags, как в PUT; пустой список их
+// удаляет. Неизвестные поля игнорируются, как и в PUT (в строгом режиме
+// отклоняются). Политика выполненных задач и мягкие правила валидации те же,
+// что у PUT.
+//
+// Ответ:
+//
+//	{
+//	  "id": 1,
+//	  "title": "Задача 1",
+//	  "description": "Описание 1",
+//	  "completed": true,
+//	  "created_at": "2024-01-01T12:00:00Z",
+//	  "updated_at": "2024-01-02T09:30:00Z"
+//	}
+func PatchTaskHandler(w http.ResponseWriter, r *http.Request, storage storage.PatchStorage, id int, policy validationPolicy) {
+	patch, err := decodePatch(r)
+	if err != nil {
+		writeSchemaError(w, err)
+		return
+	}
+
+	// Мягкие правила проверяют только переданные поля
+	title, _ := patch["title"].(string)
+	violations, warnings := policy.check(softFields{Title: title})
+	if len(violations) > 0 {
+		writeValidationFailed(w, violations)
+		return
+	}
+
+	task, err := storage.PatchTask(id, patch)
+	if err != nil {
+		writeTaskError(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(taskWithWarnings{Task: task, Warnings: warnings})
+}
+
+// decodePatch разбирает тело PATCH в значения полей для storage.PatchTask
+//
+// Тело разбирается как объект с сырыми значениями, чтобы отличить отсутствующее
+// поле от поля со значением по умолчанию (например, "completed": false).
+// Переданные поля проверяются schema.Validate по тегам PatchTaskRequest.
+func decodePatch(r *http.Request) (map[string]interface{}, error) {
+	var fields map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&fields); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		return nil, errors.New("тело запроса должно быть JSON-объектом")
+	}
+
+	var request PatchTaskRequest
+	for _, field := range []struct {
+		name  string
+		value interface{}
+	}{
+		{"title", &request.Title},
+		{"description", &request.Description},
+		{"completed", &request.Completed},
+		{"links", &request.Links},
+		{"tags", &request.Tags},
+		{"priority", &request.Priority},
+		{"due_date", &request.DueDate},
+	} {
+		raw, exists := fields[field.name]
+		if !exists {
+			continue
+		}
+		// null снимает срок выполнения, остальные поля обязаны иметь значение
+		if string(raw) == "null" && field.name != "due_date" {
+			return nil, fmt.Errorf("поле %s не может быть null", field.name)
+		}
+		if err := json.Unmarshal(raw, field.value); err != nil {
+			return nil, fmt.Errorf("неверное значение поля %s", field.name)
+		}
+	}
+	if err := schema.Validate(request); err != nil {
+		return nil, err
+	}
+
+	patch := make(map[string]interface{}, len(fields))
+	if request.Title != nil {
+		patch["title"] = *request.Title
+	}
+	if request.Description != nil {
+		patch["description"] = *request.Description
+	}
+	if request.Completed != nil {
+		patch["completed"] = *request.Completed
+	}
+	if request.Links != nil {
+		links, err := models.NormalizeLinks(request.Links)
+		if err != nil {
+			return nil, err
+		}
+		patch["links"] = links
+	}
+	if request.Tags != nil {
+		tags, err := models.NormalizeTags(request.Tags)
+		if err != nil {
+			return nil, err
+		}
+		patch["tags"] = tags
+	}
+	if request.Priority != nil {
+		patch["priority"] = *request.Priority
+	}
+	if _, exists := fields["due_date"]; exists {
+		var due time.Time
+		if request.DueDate != nil {
+			due = *request.DueDate
+		}
+		patch["due_date"] = due
+	}
+	return patch, nil
+}
+
+// updateTaskFromForm обновляет задачу из полей HTML-формы
+//
+// Отсутствие поля completed в форме означает снятый флажок, то есть completed=false.
+// Ответ формируется так же, как в createTaskFromForm.
+func updateTaskFromForm(w http.ResponseWriter, r *http.Request, storage storage.Storage, id int, policy validationPolicy) {
+	title, description, completed, err := decodeTaskForm(r)
+	if err != nil {
+		writeFormError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	violations, warnings := policy.check(softFields{Title: title})
+	if len(violations) > 0 {
+		writeFormError(w, r, violations.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	task, err := storage.UpdateTask(r.Context(), id, UpdateTaskRequest{Title: title, Description: description, Completed: completed}.input(nil))
+	if err != nil {
+		writeFormError(w, r, err.Error(), taskErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	if wantsJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(taskWithWarnings{Task: task, Warnings: warnings})
+		return
+	}
+	http.Redirect(w, r, returnURL(r, "/tasks/"+strconv.Itoa(task.ID)), http.StatusSeeOther)
 }
 
 // DeleteTaskHandler удаляет задачу по ID
 // DELETE /tasks/{id}
 //
-// Возвращает код 204 при успешном удалении
-func DeleteTaskHandler(w http.ResponseWriter, r *http.Request, storage *storage.InMemoryStorage, id int) {
-	err := storage.DeleteTask(id)
+// Удаление защищенной задачи, а при confirm - любой задачи, нужно подтвердить
+// заголовком X-Confirm-Delete со значением ID задачи. Без подтверждения запрос
+// отклоняется с кодом 428:
+//
+//	{
+//	  "error": "удаление задачи требует подтверждения: передайте заголовок X-Confirm-Delete: 1",
+//	  "code": "delete_confirmation_required"
+//	}
+//
+// Возвращает код 204 при успешном удалении. Хранилище с поддержкой мягкого
+// удаления (storage.SoftDeleteStorage) сохраняет задачу, и ее можно вернуть
+// запросом POST /tasks/{id}/restore, см. RestoreTaskHandler. Повторный DELETE
+// мягко удаленной задачи, как и DELETE /tasks/{id}?permanent=true, удаляет ее
+// окончательно; подтверждение для уже удаленной задачи не требуется.
+//
+// Args:
+//
+//	protected: удаление защищенных задач, nil - хранилище не поддерживает защиту,
+//	           и подтвержденное удаление выполняется обычным DeleteTask
+//	softDelete: окончательное удаление, nil - хранилище и так удаляет задачи
+//	            безвозвратно, и permanent ничего не меняет
+func DeleteTaskHandler(w http.ResponseWriter, r *http.Request, storage storage.Storage, protected storage.ProtectedStorage, softDelete storage.SoftDeleteStorage, id int, confirm bool) {
+	var permanent bool
+	if value := r.URL.Query().Get("permanent"); value != "" {
+		var err error
+		if permanent, err = strconv.ParseBool(value); err != nil {
+			writeInvalidValue(w, schema.OneOf("permanent", value, booleanValues...))
+			return
+		}
+	}
+
+	// Повторное удаление мягко удаленной задачи
+	if softDelete != nil {
+		purged, err := purgeDeleted(softDelete, id)
+		if err != nil {
+			writeDeleteError(w, err, id)
+			return
+		}
+		if purged {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	var err error
+	switch {
+	case r.Header.Get(ConfirmDeleteHeader) == strconv.Itoa(id):
+		if protected != nil {
+			err = protected.DeleteTaskConfirmed(id)
+		} else {
+			err = storage.DeleteTask(r.Context(), id)
+		}
+	case confirm:
+		// Несуществующая задача остается ошибкой 404, а не 428
+		if _, err := storage.GetTask(r.Context(), id); err != nil {
+			writeDeleteError(w, err, id)
+			return
+		}
+		writeConfirmationRequired(w, id)
+		return
+	default:
+		err = storage.DeleteTask(r.Context(), id)
+	}
+	if err == nil && permanent && softDelete != nil {
+		err = softDelete.PurgeTask(id)
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeDeleteError(w, err, id)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"test/storage"
+	"time"
+)
+
+// ReadyTimeout - время, за которое хранилище должно ответить на проверку GET /readyz
+const ReadyTimeout = 2 * time.Second
+
+// processStart - момент запуска процесса, от которого считается uptime_seconds
+var processStart = time.Now()
+
+// HealthResponse - ответ GET /healthz и GET /readyz
+type HealthResponse struct {
+	Status        string `json:"status"`                   // ok или unavailable
+	UptimeSeconds int64  `json:"uptime_seconds,omitempty"` // Время работы процесса, только в /healthz
+	Error         string `json:"error,omitempty"`          // Причина недоступности хранилища, только в /readyz
+}
+
+// HealthHandler сообщает, что процесс жив
+// GET /healthz
+//
+// Всегда отвечает кодом 200 и не обращается к хранилищу:
+//
+//	{
+//	  "status": "ok",
+//	  "uptime_seconds": 3600
+//	}
+func HealthHandler(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, HealthResponse{
+		Status:        "ok",
+		UptimeSeconds: int64(time.Since(processStart).Seconds()),
+	})
+}
+
+// ReadyHandler сообщает, готов ли сервер обслуживать запросы
+// GET /readyz
+//
+// Хранилище во внешней системе (storage.PingStorage) проверяется запросом к
+// серверу базы данных с таймаутом ReadyTimeout. Если сервер недоступен, ответ
+// 503 с причиной:
+//
+//	{
+//	  "status": "unavailable",
+//	  "error": "dial tcp 127.0.0.1:5432: connect: connection refused"
+//	}
+//
+// Args:
+//
+//	pinger: проверка хранилища, nil - хранилище в памяти или локальном файле доступно всегда
+func ReadyHandler(w http.ResponseWriter, r *http.Request, pinger storage.PingStorage) {
+	if pinger != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), ReadyTimeout)
+		defer cancel()
+		if err := pinger.Ping(ctx); err != nil {
+			writeHealth(w, http.StatusServiceUnavailable, HealthResponse{Status: "unavailable", Error: err.Error()})
+			return
+		}
+	}
+	writeHealth(w, http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+// writeHealth отправляет ответ проверки состояния
+func writeHealth(w http.ResponseWriter, status int, response HealthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// withProbes отвечает на GET /healthz и GET /readyz в обход next
+//
+// Проверки состояния не проходят через middleware (журнал, CORS, ограничение
+// частоты запросов и размера тела), чтобы балансировщик и Kubernetes получали
+// ответ, даже когда клиенты API его не получают.
+func withProbes(next http.Handler, pinger storage.PingStorage) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" && r.URL.Path != "/readyz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		if r.URL.Path == "/healthz" {
+			HealthHandler(w, r)
+		} else {
+			ReadyHandler(w, r, pinger)
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"test/models"
+	"test/storage"
+)
+
+// AddLinkHandler добавляет внешнюю ссылку к задаче
+// POST /tasks/{id}/links
+//
+// Запрос:
+//
+//	{
+//	  "title": "Тикет",
+//	  "url": "https://tracker.example/T-1"
+//	}
+//
+// Возвращает задачу с добавленной ссылкой и код 201.
+// Повторная ссылка отклоняется с кодом 409, превышение лимита ссылок - с кодом 400.
+func AddLinkHandler(w http.ResponseWriter, r *http.Request, storage storage.LinkStorage, id int) {
+	var link models.Link
+
+	// Декодирование JSON из тела запроса
+	err := json.NewDecoder(r.Body).Decode(&link)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	link, err = models.NormalizeLink(link)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	task, err := storage.AddLink(id, link)
+	if err != nil {
+		writeTaskError(w, err, linkErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(task)
+}
+
+// RemoveLinkHandler удаляет ссылку задачи по индексу
+// DELETE /tasks/{id}/links/{index}
+//
+// Индекс ссылки отсчитывается от 0 в порядке списка links задачи.
+// Возвращает код 204 при успешном удалении
+func RemoveLinkHandler(w http.ResponseWriter, r *http.Request, storage storage.LinkStorage, id, index int) {
+	_, err := storage.RemoveLink(id, index)
+	if err != nil {
+		writeTaskError(w, err, linkErrorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// linkErrorStatus определяет код ответа для ошибки операции со ссылками
+func linkErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrDuplicateLink):
+		return http.StatusConflict
+	case errors.Is(err, storage.ErrTooManyLinks):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
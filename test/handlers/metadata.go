@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"test/models"
+	"test/storage"
+)
+
+// taskWithMetadata - представление задачи с метаданными для GET /tasks/{id}?expand=metadata
+type taskWithMetadata struct {
+	*models.Task
+	Metadata map[string]json.RawMessage `json:"metadata"`
+}
+
+// GetMetadataHandler возвращает все метаданные задачи
+// GET /tasks/{id}/metadata
+//
+// Ответ:
+//
+//	{
+//	  "crm": {"deal_id": 42},
+//	  "slack": {"thread": "C01/1700000000.000100"}
+//	}
+func GetMetadataHandler(w http.ResponseWriter, r *http.Request, storage storage.MetadataStorage, id int) {
+	metadata, err := storage.GetMetadata(id)
+	if err != nil {
+		writeTaskError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(metadata)
+}
+
+// GetMetadataNamespaceHandler возвращает значение пространства метаданных задачи
+// GET /tasks/{id}/metadata/{namespace}
+//
+// Ответ - значение в том виде, в каком его сохранила интеграция
+func GetMetadataNamespaceHandler(w http.ResponseWriter, r *http.Request, storage storage.MetadataStorage, id int, namespace string) {
+	metadata, err := storage.GetMetadata(id)
+	if err != nil {
+		writeTaskError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	value, exists := metadata[namespace]
+	if !exists {
+		writeError(w, "Пространство метаданных не найдено", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(value)
+}
+
+// PutMetadataHandler заменяет значение пространства метаданных задачи
+// PUT /tasks/{id}/metadata/{namespace}
+//
+// Тело запроса - любой корректный JSON размером не больше storage.DefaultMetadataLimit
+// (настраивается storage.WithMetadataLimit). Содержимое не проверяется.
+// Превышение размера отклоняется с кодом 413. Возвращает код 204
+func PutMetadataHandler(w http.ResponseWriter, r *http.Request, storage storage.MetadataStorage, id int, namespace string) {
+	limit := storage.MetadataLimit()
+
+	// Чтение на байт больше лимита, чтобы отличить превышение от значения ровно на границе
+	value, err := io.ReadAll(io.LimitReader(r.Body, int64(limit)+1))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := storage.SetMetadata(id, namespace, value); err != nil {
+		writeTaskError(w, err, metadataErrorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteMetadataHandler удаляет пространство метаданных задачи
+// DELETE /tasks/{id}/metadata/{namespace}
+//
+// Возвращает код 204 при успешном удалении
+func DeleteMetadataHandler(w http.ResponseWriter, r *http.Request, storage storage.MetadataStorage, id int, namespace string) {
+	if err := storage.DeleteMetadata(id, namespace); err != nil {
+		writeTaskError(w, err, metadataErrorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// metadataErrorStatus определяет код ответа для ошибки операции с метаданными
+func metadataErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrMetadataInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, storage.ErrMetadataTooLarge):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
+}
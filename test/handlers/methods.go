@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// allowMethod проверяет, что маршрут поддерживает метод запроса
+//
+// Маршрут, поддерживающий methods, поддерживает и OPTIONS: на него отвечается
+// кодом 204 с заголовком Allow. На остальные методы отвечается кодом 405 с тем же
+// заголовком (RFC 7231, п. 6.5.5), например для /tasks:
+//
+//	Allow: GET, POST, OPTIONS
+//
+// Предварительные запросы CORS сюда не доходят, их обрабатывает middleware.NewCORSMiddleware.
+//
+// Args:
+//
+//	methods: методы маршрута в порядке вывода в Allow, без OPTIONS
+//
+// Returns:
+//
+//	bool: метод поддерживается и запрос нужно обработать; false - ответ уже отправлен
+func allowMethod(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	if slices.Contains(methods, r.Method) {
+		return true
+	}
+
+	w.Header().Set("Allow", strings.Join(append(methods, http.MethodOptions), ", "))
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return false
+	}
+	writeError(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+	return false
+}
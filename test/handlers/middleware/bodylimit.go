@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"test/handlers"
+)
+
+const (
+	// CodeBodyTooLarge - машинный код ошибки слишком большого тела запроса
+	CodeBodyTooLarge = "body_too_large"
+
+	// DefaultMaxBodyBytes - максимальный размер тела запроса по умолчанию, 1 МБ
+	DefaultMaxBodyBytes = 1 << 20
+)
+
+// BodyLimitOption настраивает NewBodyLimit
+type BodyLimitOption func(*bodyLimitSettings)
+
+// bodyLimitSettings - параметры NewBodyLimit, задаваемые опциями
+type bodyLimitSettings struct {
+	maxBytes int64 // Максимальный размер тела запроса в байтах
+}
+
+// WithMaxBodyBytes задает максимальный размер тела запроса в байтах
+func WithMaxBodyBytes(n int64) BodyLimitOption {
+	return func(s *bodyLimitSettings) {
+		s.maxBytes = n
+	}
+}
+
+// NewBodyLimit отклоняет запросы с телом больше WithMaxBodyBytes (по умолчанию
+// DefaultMaxBodyBytes) ответом 413
+//
+// Запрос с Content-Length больше лимита отклоняется сразу. Остальные тела
+// читаются через http.MaxBytesReader до обработчика: если тело оказалось больше
+// лимита, остаток тела в пределах еще одного лимита вычитывается, тело
+// закрывается, а обработчик не вызывается. Прочитанное тело, уложившееся в
+// лимит, передается обработчику без изменений.
+//
+// Args:
+//
+//	opts: опция WithMaxBodyBytes
+//
+// Returns:
+//
+//	func(http.Handler) http.Handler: промежуточный обработчик
+func NewBodyLimit(opts ...BodyLimitOption) func(http.Handler) http.Handler {
+	settings := bodyLimitSettings{maxBytes: DefaultMaxBodyBytes}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+	limit := settings.maxBytes
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if r.ContentLength > limit {
+				discardBody(r.Body, limit)
+				writeBodyTooLarge(w, limit)
+				return
+			}
+
+			data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					discardBody(r.Body, limit)
+					writeBodyTooLarge(w, limit)
+					return
+				}
+				r.Body.Close()
+				writeBodyError(w, http.StatusBadRequest, "", "не удалось прочитать тело запроса")
+				return
+			}
+			r.Body.Close()
+			r.Body = io.NopCloser(bytes.NewReader(data))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// discardBody вычитывает не больше limit байт тела и закрывает его
+//
+// Так клиент, отправляющий тело чуть больше лимита, успевает получить ответ 413
+// до закрытия соединения; тело произвольного размера целиком не читается.
+func discardBody(body io.ReadCloser, limit int64) {
+	io.CopyN(io.Discard, body, limit)
+	body.Close()
+}
+
+// writeBodyTooLarge отвечает 413 с лимитом размера тела
+func writeBodyTooLarge(w http.ResponseWriter, limit int64) {
+	writeBodyError(w, http.StatusRequestEntityTooLarge, CodeBodyTooLarge,
+		fmt.Sprintf("тело запроса больше %d байт", limit))
+}
+
+// writeBodyError отправляет ошибку чтения тела JSON-телом handlers.ErrorResponse
+func writeBodyError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(handlers.ErrorResponse{
+		Error:  message,
+		Code:   code,
+		Status: status,
+	})
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"test/handlers"
+)
+
+// CORSConfig содержит настройки NewCORSMiddleware
+type CORSConfig struct {
+	// AllowedOrigins - источники, которым разрешены запросы из браузера, например
+	// https://app.example.com. "*" разрешает любой источник
+	AllowedOrigins []string
+
+	// AllowedMethods - методы, разрешаемые в ответе на предварительный запрос.
+	// По умолчанию GET, HEAD, POST, PUT, PATCH и DELETE
+	AllowedMethods []string
+
+	// AllowedHeaders - заголовки запроса, разрешаемые в ответе на предварительный запрос.
+	// По умолчанию Content-Type, X-Confirm-Delete и X-Timezone
+	AllowedHeaders []string
+
+	// ExposedHeaders - заголовки ответа, доступные скрипту. По умолчанию заголовки
+	// API: X-Total-Count и Link страниц списка, Location созданной задачи и X-Timezone
+	ExposedHeaders []string
+
+	// MaxAge - время в секундах, на которое браузер может запомнить ответ на
+	// предварительный запрос; 0 - не указывать
+	MaxAge int
+}
+
+// NewCORSMiddleware разрешает запросы из браузера с источников config.AllowedOrigins
+//
+// Запрос без заголовка Origin передается дальше без изменений. Для разрешенного
+// источника ответ получает Access-Control-Allow-Origin с этим источником (или "*",
+// если разрешены любые) и Vary: Origin. Предварительный запрос (OPTIONS с
+// Access-Control-Request-Method) с разрешенного источника получает ответ 204
+// с разрешенными методами и заголовками и до обработчиков не доходит; с чужого
+// источника - 403. Остальные запросы с чужого источника обрабатываются как обычно,
+// но без заголовков CORS, поэтому браузер не отдаст ответ скрипту.
+//
+// Args:
+//
+//	config: настройки CORS
+//
+// Returns:
+//
+//	func(http.Handler) http.Handler: промежуточный обработчик
+func NewCORSMiddleware(config CORSConfig) func(http.Handler) http.Handler {
+	anyOrigin := slices.Contains(config.AllowedOrigins, "*")
+	methods := strings.Join(orDefault(config.AllowedMethods, []string{
+		http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+	}), ", ")
+	headers := strings.Join(orDefault(config.AllowedHeaders, []string{
+		"Content-Type", handlers.ConfirmDeleteHeader, handlers.TimezoneHeader,
+	}), ", ")
+	exposed := strings.Join(orDefault(config.ExposedHeaders, []string{
+		"X-Total-Count", "Link", "Location", handlers.TimezoneHeader,
+	}), ", ")
+
+	allowed := func(origin string) bool {
+		return anyOrigin || slices.Contains(config.AllowedOrigins, origin)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			w.Header().Add("Vary", "Origin")
+			if !allowed(origin) {
+				if preflight {
+					writeForbiddenOrigin(w, origin)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if anyOrigin {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+
+			if !preflight {
+				w.Header().Set("Access-Control-Expose-Headers", exposed)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Methods", methods)
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+			if config.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(config.MaxAge))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// orDefault возвращает values или fallback, если values пуст
+func orDefault(values, fallback []string) []string {
+	if len(values) == 0 {
+		return fallback
+	}
+	return values
+}
+
+// writeForbiddenOrigin отвечает 403 на предварительный запрос с неразрешенного источника
+func writeForbiddenOrigin(w http.ResponseWriter, origin string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(handlers.ErrorResponse{
+		Error:  "источник " + origin + " не разрешен",
+		Status: http.StatusForbidden,
+	})
+}
@@ -0,0 +1,94 @@
+// Package middleware содержит промежуточные обработчики для handlers.SetupHandlers
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// requestLogEntry - строка журнала запросов
+type requestLogEntry struct {
+	Time      time.Time `json:"time"`       // Момент начала обработки, UTC
+	Method    string    `json:"method"`     // Метод запроса
+	Path      string    `json:"path"`       // Путь запроса без параметров
+	Status    int       `json:"status"`     // Код ответа
+	LatencyMS float64   `json:"latency_ms"` // Время обработки в миллисекундах
+}
+
+// responseWriter запоминает код ответа, отправленный обработчиком
+type responseWriter struct {
+	http.ResponseWriter
+	status int // Код ответа; 0 - ответ еще не начат
+}
+
+// WriteHeader запоминает код и передает его клиенту
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write начинает ответ с кодом 200, если обработчик не задал код
+func (w *responseWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// Unwrap возвращает исходный ответ для http.ResponseController
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Logging записывает каждый запрос в out одной JSON-строкой
+//
+// Строка пишется после ответа и содержит метод, путь, код ответа и время
+// обработки, например:
+//
+//	{"time":"2024-01-01T12:00:00Z","method":"GET","path":"/tasks/1","status":200,"latency_ms":0.12}
+//
+// Параметры запроса не записываются: в них могут быть данные клиента. Записи
+// нескольких запросов не перемешиваются; ошибки записи в out игнорируются.
+//
+// Args:
+//
+//	out: получатель журнала, например os.Stdout
+//
+// Returns:
+//
+//	func(http.Handler) http.Handler: промежуточный обработчик
+func Logging(out io.Writer) func(http.Handler) http.Handler {
+	var mu sync.Mutex
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			recorder := &responseWriter{ResponseWriter: w}
+			next.ServeHTTP(recorder, r)
+
+			status := recorder.status
+			if status == 0 {
+				// Обработчик ничего не отправил, net/http ответит 200
+				status = http.StatusOK
+			}
+			line, err := json.Marshal(requestLogEntry{
+				Time:      start.UTC(),
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Status:    status,
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+			})
+			if err != nil {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			out.Write(append(line, '\n'))
+		})
+	}
+}
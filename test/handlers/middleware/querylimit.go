@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"test/handlers"
+)
+
+const (
+	// CodeQueryTooLong - машинный код ошибки слишком длинной строки запроса
+	CodeQueryTooLong = "query_too_long"
+
+	// CodeTooManyParameters - машинный код ошибки слишком большого числа параметров запроса
+	CodeTooManyParameters = "too_many_parameters"
+
+	// CodeTooManyValues - машинный код ошибки слишком большого числа значений одного параметра
+	CodeTooManyValues = "too_many_values"
+
+	// DefaultMaxQueryBytes - максимальная длина строки запроса по умолчанию, 8 КБ
+	DefaultMaxQueryBytes = 8 << 10
+
+	// DefaultMaxQueryParams - максимальное число разных параметров запроса по умолчанию
+	DefaultMaxQueryParams = 50
+
+	// DefaultMaxParamValues - максимальное число значений одного параметра по умолчанию
+	DefaultMaxParamValues = 100
+)
+
+// QueryLimitOption настраивает NewQueryLimit
+type QueryLimitOption func(*queryLimitSettings)
+
+// queryLimitSettings - параметры NewQueryLimit, задаваемые опциями
+type queryLimitSettings struct {
+	maxBytes  int // Максимальная длина строки запроса в байтах
+	maxParams int // Максимальное число разных параметров
+	maxValues int // Максимальное число значений одного параметра
+}
+
+// WithMaxQueryBytes задает максимальную длину строки запроса в байтах
+func WithMaxQueryBytes(n int) QueryLimitOption {
+	return func(s *queryLimitSettings) {
+		s.maxBytes = n
+	}
+}
+
+// WithMaxQueryParams задает максимальное число разных параметров запроса
+func WithMaxQueryParams(n int) QueryLimitOption {
+	return func(s *queryLimitSettings) {
+		s.maxParams = n
+	}
+}
+
+// WithMaxParamValues задает максимальное число значений одного повторяющегося параметра
+func WithMaxParamValues(n int) QueryLimitOption {
+	return func(s *queryLimitSettings) {
+		s.maxValues = n
+	}
+}
+
+// QueryLimitError - JSON-тело ответа на запрос, превысивший ограничение строки запроса
+//
+//	{
+//	  "error": "параметр tag передан больше 100 раз",
+//	  "code": "too_many_values",
+//	  "status": 400,
+//	  "limit": "max_param_values",
+//	  "max": 100,
+//	  "param": "tag"
+//	}
+type QueryLimitError struct {
+	handlers.ErrorResponse
+	Limit string `json:"limit"`           // Превышенное ограничение: max_query_bytes, max_query_params или max_param_values
+	Max   int    `json:"max"`             // Значение ограничения
+	Param string `json:"param,omitempty"` // Параметр со слишком большим числом значений
+}
+
+// NewQueryLimit отклоняет запросы со слишком длинной или слишком сложной строкой запроса
+//
+// Проверяются длина строки запроса (WithMaxQueryBytes, по умолчанию
+// DefaultMaxQueryBytes) - ответ 414, число разных параметров (WithMaxQueryParams,
+// по умолчанию DefaultMaxQueryParams) и число значений одного параметра
+// (WithMaxParamValues, по умолчанию DefaultMaxParamValues) - ответ 400.
+// Параметры считаются до их разбора обработчиком, а длина проверяется раньше
+// подсчета, поэтому запрос с десятками тысяч значений отклоняется, не будучи
+// разобран целиком. Ответ - QueryLimitError с именем превышенного ограничения.
+//
+// Args:
+//
+//	opts: опции WithMaxQueryBytes, WithMaxQueryParams и WithMaxParamValues
+//
+// Returns:
+//
+//	func(http.Handler) http.Handler: промежуточный обработчик
+func NewQueryLimit(opts ...QueryLimitOption) func(http.Handler) http.Handler {
+	settings := queryLimitSettings{
+		maxBytes:  DefaultMaxQueryBytes,
+		maxParams: DefaultMaxQueryParams,
+		maxValues: DefaultMaxParamValues,
+	}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.RawQuery
+			if len(query) > settings.maxBytes {
+				writeQueryLimit(w, http.StatusRequestURITooLong, CodeQueryTooLong, "max_query_bytes", settings.maxBytes, "",
+					fmt.Sprintf("строка запроса длиннее %d байт", settings.maxBytes))
+				return
+			}
+
+			counts := make(map[string]int)
+			for query != "" {
+				var pair string
+				pair, query, _ = strings.Cut(query, "&")
+				if pair == "" {
+					continue
+				}
+				name, _, _ := strings.Cut(pair, "=")
+				// Неверное экранирование не ошибка здесь: ее сообщит разбор обработчика
+				if unescaped, err := url.QueryUnescape(name); err == nil {
+					name = unescaped
+				}
+
+				counts[name]++
+				if len(counts) > settings.maxParams {
+					writeQueryLimit(w, http.StatusBadRequest, CodeTooManyParameters, "max_query_params", settings.maxParams, "",
+						fmt.Sprintf("строка запроса содержит больше %d параметров", settings.maxParams))
+					return
+				}
+				if counts[name] > settings.maxValues {
+					writeQueryLimit(w, http.StatusBadRequest, CodeTooManyValues, "max_param_values", settings.maxValues, name,
+						fmt.Sprintf("параметр %s передан больше %d раз", name, settings.maxValues))
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// writeQueryLimit отвечает ошибкой QueryLimitError
+func writeQueryLimit(w http.ResponseWriter, status int, code, limit string, max int, param, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(QueryLimitError{
+		ErrorResponse: handlers.ErrorResponse{Error: message, Code: code, Status: status},
+		Limit:         limit,
+		Max:           max,
+		Param:         param,
+	})
+}
@@ -0,0 +1,206 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"test/handlers"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// CodeRateLimited - машинный код ошибки превышения лимита запросов
+	CodeRateLimited = "rate_limited"
+
+	// DefaultRateLimitIdleTTL - время по умолчанию, после которого забывается
+	// лимит IP-адреса без запросов
+	DefaultRateLimitIdleTTL = 3 * time.Minute
+)
+
+// RateLimitOption настраивает NewRateLimiter
+type RateLimitOption func(*rateLimitSettings)
+
+// rateLimitSettings - параметры NewRateLimiter, задаваемые опциями
+type rateLimitSettings struct {
+	idleTTL time.Duration   // Время, после которого забывается лимит адреса без запросов
+	stop    <-chan struct{} // Закрытие останавливает очистку
+}
+
+// WithIdleTTL задает время, после которого забывается лимит IP-адреса без запросов
+//
+// Забытый адрес при следующем запросе снова получает полный запас burst.
+func WithIdleTTL(ttl time.Duration) RateLimitOption {
+	return func(s *rateLimitSettings) {
+		s.idleTTL = ttl
+	}
+}
+
+// WithStop останавливает фоновую очистку лимитов при закрытии stop
+//
+// Без этой опции очистка работает до завершения процесса.
+func WithStop(stop <-chan struct{}) RateLimitOption {
+	return func(s *rateLimitSettings) {
+		s.stop = stop
+	}
+}
+
+// clientLimiter - лимит одного IP-адреса
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen atomic.Int64 // Момент последнего запроса, Unix-время в наносекундах
+}
+
+// rateLimiter хранит лимиты IP-адресов
+//
+// Запрос с уже известного адреса берет блокировку только на чтение, а момент
+// последнего запроса обновляется атомарно, поэтому запросы с разных адресов не
+// ждут друг друга. Блокировку на запись берут только появление нового адреса и
+// очистка.
+type rateLimiter struct {
+	limit   rate.Limit
+	burst   int
+	clients map[string]*clientLimiter
+	mu      sync.RWMutex
+}
+
+// NewRateLimiter ограничивает частоту запросов с одного IP-адреса
+//
+// Каждый адрес получает свой маркерный бак: до burst запросов подряд, затем rps
+// запросов в секунду. Запрос сверх лимита получает ответ 429 с заголовком
+// Retry-After - числом секунд до появления маркера - и до обработчиков не доходит.
+// Адрес берется из RemoteAddr соединения; заголовки X-Forwarded-For не
+// учитываются, так как их задает клиент. Фоновая горутина раз в половину
+// WithIdleTTL забывает адреса, от которых не было запросов дольше WithIdleTTL.
+//
+// Args:
+//
+//	rps: число запросов в секунду с одного адреса
+//	burst: число запросов, которые можно отправить подряд
+//	opts: опции WithIdleTTL и WithStop
+//
+// Returns:
+//
+//	func(http.Handler) http.Handler: промежуточный обработчик
+func NewRateLimiter(rps float64, burst int, opts ...RateLimitOption) func(http.Handler) http.Handler {
+	settings := rateLimitSettings{idleTTL: DefaultRateLimitIdleTTL}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	limiter := newRateLimiter(rps, burst)
+	go limiter.sweepLoop(settings.idleTTL, settings.stop)
+	return limiter.middleware
+}
+
+// newRateLimiter создает лимиты адресов без фоновой очистки
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		limit:   rate.Limit(rps),
+		burst:   burst,
+		clients: make(map[string]*clientLimiter),
+	}
+}
+
+// middleware отклоняет запросы сверх лимита адреса, см. NewRateLimiter
+func (l *rateLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		reservation := l.client(clientIP(r), now).ReserveN(now, 1)
+		if !reservation.OK() {
+			writeRateLimited(w, 0)
+			return
+		}
+		if delay := reservation.DelayFrom(now); delay > 0 {
+			reservation.CancelAt(now)
+			writeRateLimited(w, delay)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setLimit меняет лимит всех адресов, сохраняя накопленные маркеры
+func (l *rateLimiter) setLimit(rps float64, burst int, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit, l.burst = rate.Limit(rps), burst
+	for _, client := range l.clients {
+		client.limiter.SetLimitAt(now, l.limit)
+		client.limiter.SetBurstAt(now, burst)
+	}
+}
+
+// client возвращает лимит адреса ip, создавая его при первом запросе
+func (l *rateLimiter) client(ip string, now time.Time) *rate.Limiter {
+	l.mu.RLock()
+	client, exists := l.clients[ip]
+	l.mu.RUnlock()
+
+	if !exists {
+		l.mu.Lock()
+		if client, exists = l.clients[ip]; !exists {
+			client = &clientLimiter{limiter: rate.NewLimiter(l.limit, l.burst)}
+			l.clients[ip] = client
+		}
+		l.mu.Unlock()
+	}
+	client.lastSeen.Store(now.UnixNano())
+	return client.limiter
+}
+
+// sweepLoop раз в ttl/2 забывает адреса без запросов дольше ttl, пока не закрыт stop
+func (l *rateLimiter) sweepLoop(ttl time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(max(ttl/2, time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			l.sweep(now.Add(-ttl))
+		}
+	}
+}
+
+// sweep забывает адреса, последний запрос с которых был раньше cutoff
+func (l *rateLimiter) sweep(cutoff time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for ip, client := range l.clients {
+		if client.lastSeen.Load() < cutoff.UnixNano() {
+			delete(l.clients, ip)
+		}
+	}
+}
+
+// clientIP возвращает IP-адрес клиента без порта
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// writeRateLimited отвечает 429 с заголовком Retry-After, округленным вверх до секунды
+//
+// Нулевой delay означает, что маркер не появится никогда (rps или burst равны 0);
+// клиенту все равно сообщается одна секунда, чтобы он не повторял запрос сразу.
+func writeRateLimited(w http.ResponseWriter, delay time.Duration) {
+	seconds := max(int(math.Ceil(delay.Seconds())), 1)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(handlers.ErrorResponse{
+		Error:  fmt.Sprintf("превышен лимит запросов, повторите через %d с", seconds),
+		Code:   CodeRateLimited,
+		Status: http.StatusTooManyRequests,
+	})
+}
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RuntimeConfig - настройки промежуточных обработчиков, которые можно менять без перезапуска
+type RuntimeConfig struct {
+	LogRequests bool     // Записывать каждый запрос в журнал, см. Logging
+	CORSOrigins []string // Источники CORS, пусто - CORS выключен, см. NewCORSMiddleware
+	CORSMaxAge  int      // Время в секундах, на которое браузер запоминает предварительный запрос
+	RateLimit   float64  // Запросов в секунду с одного адреса, 0 - без ограничения, см. NewRateLimiter
+	RateBurst   int      // Запросов подряд с одного адреса при RateLimit
+}
+
+// Validate проверяет настройки целиком
+//
+// Returns:
+//
+//	error: все найденные ошибки через errors.Join, nil - настройки верны
+func (c RuntimeConfig) Validate() error {
+	var errs []error
+	for _, origin := range c.CORSOrigins {
+		if strings.TrimSpace(origin) == "" {
+			errs = append(errs, errors.New("пустой источник в cors-origins"))
+			break
+		}
+	}
+	if c.CORSMaxAge < 0 {
+		errs = append(errs, fmt.Errorf("cors-max-age не может быть отрицательным: %d", c.CORSMaxAge))
+	}
+	if c.RateLimit < 0 {
+		errs = append(errs, fmt.Errorf("rate-limit не может быть отрицательным: %g", c.RateLimit))
+	}
+	if c.RateLimit > 0 && c.RateBurst < 1 {
+		errs = append(errs, fmt.Errorf("rate-burst должен быть не меньше 1 при rate-limit: %d", c.RateBurst))
+	}
+	return errors.Join(errs...)
+}
+
+// Diff описывает отличия настроек next от c, по строке на измененное поле
+//
+//	rate-limit: 0 -> 5
+func (c RuntimeConfig) Diff(next RuntimeConfig) []string {
+	var changes []string
+	add := func(name string, old, new interface{}) {
+		if fmt.Sprint(old) != fmt.Sprint(new) {
+			changes = append(changes, fmt.Sprintf("%s: %v -> %v", name, old, new))
+		}
+	}
+	add("log-requests", c.LogRequests, next.LogRequests)
+	add("cors-origins", strings.Join(c.CORSOrigins, ","), strings.Join(next.CORSOrigins, ","))
+	add("cors-max-age", c.CORSMaxAge, next.CORSMaxAge)
+	add("rate-limit", c.RateLimit, next.RateLimit)
+	add("rate-burst", c.RateBurst, next.RateBurst)
+	return changes
+}
+
+// Reloadable - журнал запросов, CORS и лимит частоты с заменяемыми на ходу настройками
+//
+// Настройки и построенная по ним цепочка обработчиков хранятся одним снимком,
+// который Reload заменяет атомарно. Запрос берет снимок один раз в начале,
+// поэтому выполняющиеся запросы дообрабатываются со старыми настройками,
+// а новые сразу получают новые. Лимиты адресов переживают замену: меняется
+// только их частота и запас, накопленные маркеры сохраняются.
+type Reloadable struct {
+	mu       sync.Mutex // Порядок замен снимка
+	current  atomic.Pointer[runtimeSnapshot]
+	next     http.Handler                    // Обработчик, оборачиваемый цепочкой
+	logging  func(http.Handler) http.Handler // Журнал запросов, общий для всех снимков
+	limiter  *rateLimiter                    // Лимиты адресов, общие для всех снимков
+	stop     <-chan struct{}                 // Закрытие останавливает очистку лимитов
+	sweeping sync.Once                       // Запуск очистки при первом включении лимита
+}
+
+// runtimeSnapshot - настройки и построенная по ним цепочка обработчиков
+type runtimeSnapshot struct {
+	config  RuntimeConfig
+	handler http.Handler
+}
+
+// NewReloadable создает промежуточный обработчик с настройками config
+//
+// Args:
+//
+//	ctx: завершение останавливает фоновую очистку лимитов
+//	out: получатель журнала запросов
+//	config: начальные настройки
+//
+// Returns:
+//
+//	*Reloadable: обработчик; в цепочку передается его метод Middleware
+//	error: ошибка Validate
+func NewReloadable(ctx context.Context, out io.Writer, config RuntimeConfig) (*Reloadable, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	r := &Reloadable{
+		logging: Logging(out),
+		limiter: newRateLimiter(config.RateLimit, config.RateBurst),
+		stop:    ctx.Done(),
+	}
+	r.current.Store(&runtimeSnapshot{config: config})
+	return r, nil
+}
+
+// Middleware оборачивает next цепочкой по текущим настройкам
+//
+// Reloadable оборачивает один обработчик: повторный вызов заменяет next.
+func (r *Reloadable) Middleware(next http.Handler) http.Handler {
+	r.mu.Lock()
+	r.next = next
+	r.current.Store(r.build(r.current.Load().config))
+	r.mu.Unlock()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.current.Load().handler.ServeHTTP(w, req)
+	})
+}
+
+// Config возвращает действующие настройки
+func (r *Reloadable) Config() RuntimeConfig {
+	return r.current.Load().config
+}
+
+// Reload заменяет настройки на config
+//
+// Неверные настройки отклоняются целиком, и действующие остаются без изменений.
+//
+// Returns:
+//
+//	[]string: изменения в формате RuntimeConfig.Diff
+//	error: ошибка Validate
+func (r *Reloadable) Reload(config RuntimeConfig) ([]string, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	changes := r.current.Load().config.Diff(config)
+	r.limiter.setLimit(config.RateLimit, config.RateBurst, time.Now())
+	r.current.Store(r.build(config))
+	return changes, nil
+}
+
+// build строит цепочку по настройкам config в порядке журнал, CORS, лимит частоты
+func (r *Reloadable) build(config RuntimeConfig) *runtimeSnapshot {
+	handler := r.next
+	if handler == nil {
+		return &runtimeSnapshot{config: config}
+	}
+	if config.RateLimit > 0 {
+		r.sweeping.Do(func() { go r.limiter.sweepLoop(DefaultRateLimitIdleTTL, r.stop) })
+		handler = r.limiter.middleware(handler)
+	}
+	if len(config.CORSOrigins) > 0 {
+		handler = NewCORSMiddleware(CORSConfig{AllowedOrigins: config.CORSOrigins, MaxAge: config.CORSMaxAge})(handler)
+	}
+	if config.LogRequests {
+		handler = r.logging(handler)
+	}
+	return &runtimeSnapshot{config: config, handler: handler}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+	"test/storage"
+)
+
+// GetOverdueTasksHandler возвращает невыполненные задачи, срок которых прошел
+// GET /tasks/overdue
+//
+// Задача без due_date никогда не считается просроченной. Ответ - массив задач
+// в порядке возрастания ID, как у GET /tasks; text/plain также поддерживается:
+//
+//	[
+//	  {
+//	    "id": 3,
+//	    "title": "Сдать отчет",
+//	    "description": "Квартальный отчет",
+//	    "completed": false,
+//	    "due_date": "2024-01-01T18:00:00Z",
+//	    "created_at": "2024-01-01T12:00:00Z",
+//	    "updated_at": "2024-01-01T12:00:00Z"
+//	  }
+//	]
+func GetOverdueTasksHandler(w http.ResponseWriter, r *http.Request, storage storage.OverdueStorage) {
+	tasks, err := storage.GetOverdueTasks()
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Vary", "Accept")
+	if wantsText(r) {
+		writeTaskTextStream(w, r, sliceLister(tasks), nil)
+		return
+	}
+	writeTaskStream(w, r, sliceLister(tasks), nil)
+}
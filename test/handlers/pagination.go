@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"test/models"
+	"test/storage"
+)
+
+// DefaultPageLimit - размер страницы списка задач, если параметр limit не задан
+const DefaultPageLimit = 20
+
+// MaxPageLimit - максимальный размер страницы списка задач
+const MaxPageLimit = 100
+
+// pageRequest - запрошенная страница списка задач
+type pageRequest struct {
+	page  int // Номер страницы, начиная с 1
+	limit int // Размер страницы
+}
+
+// offset возвращает число задач перед страницей
+func (p pageRequest) offset() int {
+	// Страница за пределами int заведомо за пределами списка
+	if p.page-1 > math.MaxInt/p.limit {
+		return math.MaxInt
+	}
+	return (p.page - 1) * p.limit
+}
+
+// requestPage разбирает параметры page и limit запроса
+//
+// Returns:
+//
+//	pageRequest: запрошенная страница
+//	bool: false, если ни page, ни limit не заданы и список отдается целиком
+//	string: сообщение об ошибке для ответа 400, пусто - параметры корректны
+func requestPage(r *http.Request) (pageRequest, bool, string) {
+	query := r.URL.Query()
+	page, limit := query.Get("page"), query.Get("limit")
+	if page == "" && limit == "" {
+		return pageRequest{}, false, ""
+	}
+
+	p := pageRequest{page: 1, limit: DefaultPageLimit}
+	if page != "" {
+		n, err := strconv.Atoi(page)
+		if err != nil || n < 1 {
+			return pageRequest{}, false, "Параметр page должен быть целым числом не меньше 1"
+		}
+		p.page = n
+	}
+	if limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return pageRequest{}, false, "Параметр limit должен быть целым числом не меньше 1"
+		}
+		if n > MaxPageLimit {
+			return pageRequest{}, false, fmt.Sprintf("Параметр limit не может быть больше %d", MaxPageLimit)
+		}
+		p.limit = n
+	}
+	return p, true, ""
+}
+
+// pageTasks возвращает задачи страницы и общее число задач списка
+//
+// Список без фильтра и сортировки делится на страницы хранилищем, если оно это
+// поддерживает (storage.PaginatedStorage). Иначе список собирается целиком:
+// несортированный упорядочивается по ID, чтобы страницы не пересекались.
+//
+// Args:
+//
+//	pages: страницы хранилища, nil - хранилище их не поддерживает
+//	list: источник задач
+//	keep: фильтр задач, nil - все задачи
+//	sorted: источник уже отсортирован
+func pageTasks(ctx context.Context, pages storage.PaginatedStorage, list taskLister, keep func(*models.Task) bool, sorted bool, p pageRequest) ([]*models.Task, int, error) {
+	if pages != nil && keep == nil && !sorted {
+		return pages.GetTasksPaginated(p.offset(), p.limit)
+	}
+
+	var tasks []*models.Task
+	err := list(ctx, func(task *models.Task) error {
+		if keep == nil || keep(task) {
+			tasks = append(tasks, task)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if !sorted {
+		sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	}
+
+	total := len(tasks)
+	start := min(p.offset(), total)
+	end := start + min(p.limit, total-start)
+	return tasks[start:end], total, nil
+}
+
+// writePageHeaders добавляет к ответу общее число задач и ссылки на соседние страницы
+//
+//	X-Total-Count: 45
+//	Link: </tasks?limit=20&page=1>; rel="first", </tasks?limit=20&page=1>; rel="prev",
+//	      </tasks?limit=20&page=3>; rel="next", </tasks?limit=20&page=3>; rel="last"
+//
+// Ссылки формата RFC 5988 сохраняют остальные параметры запроса.
+func writePageHeaders(w http.ResponseWriter, r *http.Request, p pageRequest, total int) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+
+	last := max((total+p.limit-1)/p.limit, 1)
+	link := func(page int, rel string) string {
+		query := r.URL.Query()
+		query.Set("page", strconv.Itoa(page))
+		query.Set("limit", strconv.Itoa(p.limit))
+		return fmt.Sprintf("<%s?%s>; rel=%q", r.URL.Path, query.Encode(), rel)
+	}
+
+	links := []string{link(1, "first")}
+	if p.page > 1 {
+		links = append(links, link(min(p.page-1, last), "prev"))
+	}
+	if p.page < last {
+		links = append(links, link(p.page+1, "next"))
+	}
+	links = append(links, link(last, "last"))
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"test/models"
+	"test/schema"
+	"test/storage"
+)
+
+// PreferencesRequest - тело запроса PUT /preferences
+//
+// Поля проверяются теми же правилами, что и поля задачи в POST /tasks.
+type PreferencesRequest struct {
+	DefaultPriority string   `json:"default_priority,omitempty" validate:"priority"`
+	DefaultTags     []string `json:"default_tags,omitempty" validate:"max=20"`
+	Timezone        string   `json:"timezone,omitempty"`
+}
+
+// GetPreferencesHandler возвращает настройки по умолчанию для новых задач
+// GET /preferences
+//
+//	{
+//	  "default_priority": "high",
+//	  "default_tags": ["работа"],
+//	  "timezone": "Asia/Bangkok"
+//	}
+//
+// Незаданные настройки в ответе отсутствуют.
+func GetPreferencesHandler(w http.ResponseWriter, r *http.Request, preferences storage.PreferenceStorage) {
+	current, err := preferences.GetPreferences()
+	if err != nil {
+		writeTaskError(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(current)
+}
+
+// SetPreferencesHandler заменяет настройки по умолчанию целиком
+// PUT /preferences
+//
+// Тело - PreferencesRequest, незаданное поле сбрасывает настройку. Приоритет
+// и теги по умолчанию подставляются в POST /tasks, POST /tasks/bulk,
+// POST /tasks/quick и HTML-форму создания, если запрос их не задает; часовой
+// пояс применяется к ответам на чтение без ?tz и X-Timezone. Неизвестные поля
+// отклоняются кодом 400. Ответ 200 - сохраненные настройки, как у GET /preferences.
+func SetPreferencesHandler(w http.ResponseWriter, r *http.Request, preferences storage.PreferenceStorage) {
+	var request PreferencesRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := schema.Validate(request); err != nil {
+		writeSchemaError(w, err)
+		return
+	}
+
+	tags, err := models.NormalizeTags(request.DefaultTags)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if request.Timezone != "" {
+		if _, err := loadTimezone(request.Timezone); err != nil {
+			writeErrorCode(w, http.StatusBadRequest, CodeInvalidTimezone, err.Error())
+			return
+		}
+	}
+
+	updated := models.Preferences{DefaultPriority: request.DefaultPriority, Timezone: request.Timezone}
+	if len(tags) > 0 {
+		updated.DefaultTags = tags
+	}
+	if err := preferences.SetPreferences(updated); err != nil {
+		writeTaskError(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// taskDefaults возвращает настройки по умолчанию для новых задач; без PreferenceStorage - пустые
+func taskDefaults(preferences storage.PreferenceStorage) (models.Preferences, error) {
+	if preferences == nil {
+		return models.Preferences{}, nil
+	}
+	return preferences.GetPreferences()
+}
+
+// applyDefaults подставляет приоритет и теги по умолчанию, если они не заданы
+//
+// Пустой, но заданный список тегов остается пустым: nil означает, что поля не было в запросе.
+func applyDefaults(defaults models.Preferences, priority *string, tags *[]string) {
+	if *priority == "" {
+		*priority = defaults.DefaultPriority
+	}
+	if *tags == nil {
+		*tags = slices.Clone(defaults.DefaultTags)
+	}
+}
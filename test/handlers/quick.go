@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"test/models"
+	"test/schema"
+	"test/storage"
+)
+
+const (
+	// DefaultQuickAddMaxLines - число задач в одном запросе POST /tasks/quick по умолчанию
+	DefaultQuickAddMaxLines = 100
+
+	// CodeInvalidLines - машинный код ошибки строк тела POST /tasks/quick, не прошедших валидацию
+	CodeInvalidLines = "invalid_lines"
+)
+
+// quickTaskLine - поля задачи одной строки тела POST /tasks/quick
+//
+// Описание у таких задач пустое, поэтому проверяются только название и число тегов.
+type quickTaskLine struct {
+	Title string   `json:"title" validate:"required"`
+	Tags  []string `json:"tags,omitempty" validate:"max=20"`
+}
+
+// lineError - ошибка поля задачи в строке тела запроса с номером строки, начиная с 1
+type lineError struct {
+	Line int `json:"line"`
+	schema.FieldError
+}
+
+// QuickAddHandler создает задачи из строк текста, по одной на строку
+// POST /tasks/quick
+//
+// Тело text/plain, каждая непустая строка - название задачи, см. models.ParseQuickLine:
+//
+//	Купить молоко #дом
+//	! Позвонить в банк #срочно
+//
+// Задачи создаются все вместе или ни одной. Строки проверяются теми же правилами,
+// что и при POST /tasks, кроме обязательного описания. Ошибки всех строк
+// возвращаются вместе с номерами строк: кодом 400 и code invalid_lines, если
+// название пустое, кодом 422 и code validation_failed при нарушении строгих правил
+//
+//	{
+//	  "error": "строка 2: поле title обязательно",
+//	  "code": "invalid_lines",
+//	  "errors": [{"line": 2, "field": "title", "rule": "required", "message": "поле title обязательно"}]
+//	}
+//
+// Строка с "!" создает задачу с приоритетом high, метки #тег становятся тегами задачи.
+//
+// Ответ 201 - созданные задачи в порядке строк:
+// [
+//
+//	{
+//	  "id": 1,
+//	  "title": "Купить молоко",
+//	  "description": "",
+//	  "completed": false,
+//	  "created_at": "2024-01-01T12:00:00Z",
+//	  "updated_at": "2024-01-01T12:00:00Z",
+//	  "tags": ["дом"]
+//	}
+//
+// ]
+//
+// Args:
+//
+//	maxLines: максимальное число задач в запросе
+func QuickAddHandler(w http.ResponseWriter, r *http.Request, batch storage.BatchStorage, preferences storage.PreferenceStorage, policy validationPolicy, maxLines int) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "text/plain" {
+		writeError(w, "Тело запроса должно иметь тип text/plain", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// Пустые строки и строки из пробелов пропускаются, но учитываются в номерах
+	type quickLine struct {
+		number int
+		models.QuickLine
+	}
+	var lines []quickLine
+	scanner := bufio.NewScanner(r.Body)
+	for number := 1; scanner.Scan(); number++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		if len(lines) == maxLines {
+			writeError(w, fmt.Sprintf("Не больше %d задач в одном запросе", maxLines), http.StatusBadRequest)
+			return
+		}
+		lines = append(lines, quickLine{number: number, QuickLine: models.ParseQuickLine(scanner.Text())})
+	}
+	if err := scanner.Err(); err != nil {
+		writeError(w, "Не удалось прочитать тело запроса: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(lines) == 0 {
+		writeError(w, "Тело запроса не содержит ни одной задачи", http.StatusBadRequest)
+		return
+	}
+	defaults, err := taskDefaults(preferences)
+	if err != nil {
+		writeTaskError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	// Проверка всех строк до создания первой задачи
+	var invalid, violations []lineError
+	inputs := make([]storage.CreateTaskInput, len(lines))
+	warnings := make([]schema.Errors, len(lines))
+	for i, line := range lines {
+		if errs, ok := schema.Validate(quickTaskLine{Title: line.Title, Tags: line.Tags}).(schema.Errors); ok {
+			for _, fe := range errs {
+				invalid = append(invalid, lineError{Line: line.number, FieldError: fe})
+			}
+			continue
+		}
+
+		hard, soft := policy.check(softFields{Title: line.Title})
+		for _, fe := range hard {
+			violations = append(violations, lineError{Line: line.number, FieldError: fe})
+		}
+		warnings[i] = soft
+		inputs[i] = storage.CreateTaskInput{Title: line.Title, Tags: line.Tags}
+		if line.HighPriority {
+			inputs[i].Priority = models.PriorityHigh
+		}
+		applyDefaults(defaults, &inputs[i].Priority, &inputs[i].Tags)
+	}
+	if len(invalid) > 0 {
+		writeLineErrors(w, http.StatusBadRequest, CodeInvalidLines, append(invalid, violations...))
+		return
+	}
+	if len(violations) > 0 {
+		writeLineErrors(w, http.StatusUnprocessableEntity, CodeValidationFailed, violations)
+		return
+	}
+
+	tasks, err := batch.CreateTasks(inputs)
+	if err != nil {
+		writeTaskError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]taskWithWarnings, len(tasks))
+	for i, task := range tasks {
+		response[i] = taskWithWarnings{Task: task, Warnings: warnings[i]}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeLineErrors отвечает ошибками строк тела запроса
+func writeLineErrors(w http.ResponseWriter, status int, code string, errs []lineError) {
+	messages := make([]string, len(errs))
+	for i, e := range errs {
+		messages[i] = fmt.Sprintf("строка %d: %s", e.Line, e.Message)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		ErrorResponse
+		Errors []lineError `json:"errors"`
+	}{ErrorResponse{strings.Join(messages, "; "), code, status}, errs})
+}
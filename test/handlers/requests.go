@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"test/models"
+	"test/storage"
+	"time"
+)
+
+// CreateTaskRequest - тело запроса POST /tasks
+//
+// Теги validate задают правила проверки и одновременно публикуемую JSON Schema.
+type CreateTaskRequest struct {
+	Title       string        `json:"title" validate:"required"`
+	Description string        `json:"description" validate:"required"`
+	Links       []models.Link `json:"links,omitempty" validate:"max=10"`
+	Tags        []string      `json:"tags,omitempty" validate:"max=20"`
+	ClientToken string        `json:"client_token,omitempty" validate:"max=128"`
+	Protected   bool          `json:"protected,omitempty"`
+	Priority    string        `json:"priority,omitempty" validate:"priority"`
+	DueDate     *time.Time    `json:"due_date,omitempty"`
+
+	// Время жизни задачи: момент истечения или число секунд от создания, не оба сразу
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+	ExpiresInSeconds *int       `json:"expires_in_seconds,omitempty" validate:"min=1"`
+}
+
+// expires проверяет, что клиент задал время жизни задачи
+func (req CreateTaskRequest) expires() bool {
+	return req.ExpiresAt != nil || req.ExpiresInSeconds != nil
+}
+
+// input преобразует запрос в поля создаваемой задачи
+func (req CreateTaskRequest) input(links []models.Link) storage.CreateTaskInput {
+	return storage.CreateTaskInput{
+		Title:       req.Title,
+		Description: req.Description,
+		Links:       links,
+		Tags:        req.Tags,
+		Protected:   req.Protected,
+		Priority:    req.Priority,
+		DueDate:     req.DueDate,
+		ExpiresAt:   req.ExpiresAt,
+		ExpiresIn:   expiresIn(req.ExpiresInSeconds),
+	}
+}
+
+// expiresIn преобразует expires_in_seconds во время жизни, nil - бессрочно
+func expiresIn(seconds *int) time.Duration {
+	if seconds == nil {
+		return 0
+	}
+	return time.Duration(*seconds) * time.Second
+}
+
+// UpdateTaskRequest - тело запроса PUT /tasks/{id}
+type UpdateTaskRequest struct {
+	Title       string        `json:"title"`
+	Description string        `json:"description"`
+	Completed   bool          `json:"completed"`
+	Links       []models.Link `json:"links,omitempty" validate:"max=10"`
+	Tags        []string      `json:"tags,omitempty" validate:"max=20"` // nil - теги без изменений
+	Protected   *bool         `json:"protected,omitempty"`
+	Priority    *string       `json:"priority,omitempty" validate:"priority"`
+	DueDate     *time.Time    `json:"due_date,omitempty"`
+	Version     *int          `json:"version,omitempty"` // Ожидаемая версия задачи, nil или 0 - без проверки
+}
+
+// PatchTaskRequest - тело запроса PATCH /tasks/{id}
+//
+// Все поля необязательны; описывает публикуемую схему и правила проверки переданных
+// полей, разбор тела выполняет decodePatch.
+type PatchTaskRequest struct {
+	Title       *string       `json:"title,omitempty" validate:"min=1"`
+	Description *string       `json:"description,omitempty"`
+	Completed   *bool         `json:"completed,omitempty"`
+	Links       []models.Link `json:"links,omitempty" validate:"max=10"` // [] удаляет все ссылки
+	Tags        []string      `json:"tags,omitempty" validate:"max=20"`  // [] удаляет все теги
+	Priority    *string       `json:"priority,omitempty" validate:"priority"`
+	DueDate     *time.Time    `json:"due_date,omitempty"` // null снимает срок
+}
+
+// input преобразует запрос в новые значения полей задачи
+func (req UpdateTaskRequest) input(links []models.Link) storage.UpdateTaskInput {
+	return storage.UpdateTaskInput{
+		Title:       req.Title,
+		Description: req.Description,
+		Completed:   req.Completed,
+		Links:       links,
+		Tags:        req.Tags,
+		Protected:   req.Protected,
+		Priority:    req.Priority,
+		DueDate:     req.DueDate,
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"test/storage"
+)
+
+// RestoreTaskHandler восстанавливает мягко удаленную задачу
+// POST /tasks/{id}/restore
+//
+// DELETE /tasks/{id} не стирает задачу, а отмечает ее удаленной: она пропадает
+// из списка и отвечает кодом 410, пока хранилище помнит удаление, затем 404.
+// Восстановить задачу можно в любой момент, в том числе после этого, но не
+// после окончательного удаления (см. DeleteTaskHandler). Задача, которая не удалена, отклоняется с кодом 409.
+//
+// Ответ - восстановленная задача:
+//
+//	{
+//	  "id": 1,
+//	  "title": "Задача 1",
+//	  "description": "Описание 1",
+//	  "completed": false,
+//	  "created_at": "2024-01-01T12:00:00Z",
+//	  "updated_at": "2024-01-02T09:30:00Z"
+//	}
+func RestoreTaskHandler(w http.ResponseWriter, r *http.Request, storage storage.Storage, softDelete storage.SoftDeleteStorage, id int) {
+	if err := softDelete.RestoreTask(id); err != nil {
+		writeTaskError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	task, err := storage.GetTask(r.Context(), id)
+	if err != nil {
+		writeTaskError(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
+
+// purgeDeleted окончательно удаляет задачу id, если она мягко удалена
+//
+// Returns:
+//
+//	bool: задача была мягко удалена и теперь стерта
+//	error: ошибка поиска задачи; неудаленная задача ошибкой не считается
+func purgeDeleted(softDelete storage.SoftDeleteStorage, id int) (bool, error) {
+	err := softDelete.PurgeTask(id)
+	if errors.Is(err, storage.ErrTaskNotDeleted) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Канонические коды ответа маршрутов /tasks/{id}...
+//
+// Проверки выполняются сверху вниз, ответ определяет первая сработавшая строка.
+// Все маршруты задачи, включая вложенные ресурсы, разбирают путь через parseTaskPath,
+// поэтому таблица едина для всего семейства. Регрессионный тест: tests/routing_test.go.
+// Пути /tasks/quick и /tasks/bulk - отдельные маршруты списка задач, а не задачи с ID "quick" и "bulk".
+// Ответы 204 на OPTIONS и 405 несут заголовок Allow с методами маршрута, см. allowMethod.
+//
+//	| Проверка                                   | Пример                          | Код |
+//	|--------------------------------------------|---------------------------------|-----|
+//	| ID не указан                               | /tasks/                         | 400 |
+//	| ID не положительное десятичное число       | /tasks/abc, /tasks/0, /tasks/01 | 400 |
+//	| ID не помещается в int                     | /tasks/99999999999999999999     | 400 |
+//	| неизвестный вложенный ресурс или           | /tasks/1/, /tasks/1/foo,        | 404 |
+//	| лишние сегменты пути                       | /tasks/1/links/0/x              |     |
+//	| неверный параметр вложенного ресурса       | /tasks/1/links/abc              | 400 |
+//	| OPTIONS                                    | OPTIONS /tasks/1                | 204 |
+//	| метод не поддерживается маршрутом          | POST /tasks/1                   | 405 |
+//	| неверное тело запроса                      | PUT /tasks/99 с телом "{"       | 400 |
+//	| задача недавно удалена                     | GET /tasks/1 после DELETE       | 410 |
+//	| задача или элемент ресурса не найдены      | GET /tasks/99                   | 404 |
+//	| успех                                      | GET /tasks/1                    | 2xx |
+
+// taskResources - вложенные ресурсы задачи и допустимость параметра после имени ресурса
+var taskResources = map[string]bool{
+	"links":                  true,  // /tasks/{id}/links и /tasks/{id}/links/{index}
+	"complete-with-followup": false, // /tasks/{id}/complete-with-followup
+	"metadata":               true,  // /tasks/{id}/metadata и /tasks/{id}/metadata/{namespace}
+	"restore":                false, // /tasks/{id}/restore
+	"toggle":                 false, // /tasks/{id}/toggle
+}
+
+// taskPath - разобранный путь /tasks/{id}[/{resource}[/{param}]]
+type taskPath struct {
+	ID       int    // ID задачи
+	Resource string // Вложенный ресурс, пустой для самой задачи
+	Param    string // Параметр вложенного ресурса, например индекс ссылки
+	HasParam bool   // Параметр указан
+}
+
+// parseTaskPath разбирает путь запроса к задаче и ее вложенным ресурсам
+//
+// Returns:
+//
+//	taskPath: разобранный путь
+//	int: код ошибки по таблице выше или 0, если путь корректен
+//	string: сообщение об ошибке
+func parseTaskPath(path string) (taskPath, int, string) {
+	rest := strings.TrimPrefix(path, "/tasks/")
+	if rest == "" {
+		return taskPath{}, http.StatusBadRequest, "ID не указан"
+	}
+
+	segments := strings.Split(rest, "/")
+	id, ok := parsePositiveInt(segments[0])
+	if !ok {
+		return taskPath{}, http.StatusBadRequest, "Неверный формат ID"
+	}
+
+	p := taskPath{ID: id}
+	switch len(segments) {
+	case 1:
+		return p, 0, ""
+	case 2, 3:
+		p.Resource = segments[1]
+		allowsParam, known := taskResources[p.Resource]
+		if !known || (len(segments) == 3 && !allowsParam) {
+			return taskPath{}, http.StatusNotFound, "Ресурс не найден"
+		}
+		if len(segments) == 3 {
+			p.Param, p.HasParam = segments[2], true
+		}
+		return p, 0, ""
+	default:
+		return taskPath{}, http.StatusNotFound, "Ресурс не найден"
+	}
+}
+
+// parsePositiveInt разбирает ID в канонической записи: десятичное число без знака,
+// пробелов и ведущих нулей, больше нуля и в пределах int
+func parsePositiveInt(s string) (int, bool) {
+	if s == "" || s[0] < '1' || s[0] > '9' {
+		return 0, false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// parseIndex разбирает индекс элемента вложенного ресурса: 0 или положительное число
+func parseIndex(s string) (int, bool) {
+	if s == "0" {
+		return 0, true
+	}
+	return parsePositiveInt(s)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"test/models"
+	"test/schema"
+)
+
+// schemaDefinition связывает публикуемую схему с типом Go, из которого она строится
+type schemaDefinition struct {
+	title string      // Название схемы
+	value interface{} // Значение типа, описываемого схемой
+}
+
+// schemas - публикуемые схемы по имени файла в /schemas/
+var schemas = map[string]schemaDefinition{
+	"task.json":                {"Task", models.Task{}},
+	"create-task-request.json": {"CreateTaskRequest", CreateTaskRequest{}},
+	"update-task-request.json": {"UpdateTaskRequest", UpdateTaskRequest{}},
+	"follow-up-request.json":   {"FollowUpRequest", FollowUpRequest{}},
+	"patch-task-request.json":  {"PatchTaskRequest", PatchTaskRequest{}},
+}
+
+// SchemaHandler возвращает JSON Schema задачи или тела запроса
+// GET /schemas/{name}.json
+//
+// Доступные схемы: task.json, create-task-request.json, update-task-request.json,
+// follow-up-request.json, patch-task-request.json
+func SchemaHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Path[len("/schemas/"):]
+	definition, exists := schemas[name]
+	if !exists {
+		writeError(w, "Схема не найдена", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/schema+json")
+	json.NewEncoder(w).Encode(schema.Generate(r.URL.Path, definition.title, definition.value))
+}
+
+// validateStrict проверяет JSON-тело запроса по опубликованной схеме target
+//
+// В строгом режиме отклоняются неизвестные поля и значения неверного типа,
+// которые обычный разбор JSON молча пропускает. Тело запроса восстанавливается,
+// чтобы обработчик мог прочитать его повторно. Тела HTML-форм не проверяются.
+//
+// Returns:
+//
+//	bool: true, если запрос можно передать обработчику
+func validateStrict(w http.ResponseWriter, r *http.Request, target interface{}) bool {
+	if isFormRequest(r) {
+		return true
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	if err := schema.Validate(target); err != nil {
+		writeSchemaError(w, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"test/storage"
+	"unicode/utf8"
+)
+
+// MinSearchQueryLength - минимальная длина параметра q запроса GET /tasks/search в символах
+const MinSearchQueryLength = 2
+
+// SearchTasksHandler ищет задачи по подстроке в названии или описании
+// GET /tasks/search?q=отчет
+//
+// Регистр не учитывается, пробелы по краям q отбрасываются. Запрос короче
+// MinSearchQueryLength символов отклоняется кодом 400. Ответ - массив задач
+// в порядке возрастания ID, как у GET /tasks; text/plain также поддерживается.
+func SearchTasksHandler(w http.ResponseWriter, r *http.Request, storage storage.SearchStorage) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if utf8.RuneCountInString(query) < MinSearchQueryLength {
+		writeError(w, fmt.Sprintf("Параметр q должен содержать не меньше %d символов", MinSearchQueryLength), http.StatusBadRequest)
+		return
+	}
+
+	tasks, err := storage.SearchTasks(query)
+	if err != nil {
+		writeTaskError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Vary", "Accept")
+	if wantsText(r) {
+		writeTaskTextStream(w, r, sliceLister(tasks), nil)
+		return
+	}
+	writeTaskStream(w, r, sliceLister(tasks), nil)
+}
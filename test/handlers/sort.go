@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"test/models"
+	"test/schema"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+const (
+	// DefaultCollation - локаль сортировки по названию, если клиент ее не указал
+	DefaultCollation = "en"
+
+	// MaxSortKeys - максимальное число ключей в параметре sort
+	MaxSortKeys = 3
+)
+
+// collationLanguages - локали, поддерживаемые сортировкой по названию
+var collationLanguages = []language.Tag{language.English, language.Russian}
+
+// sortKeys - допустимые ключи параметра sort списка задач
+var sortKeys = []string{"title", "priority", "due_date", "created_at", "id"}
+
+// sortField - ключ сортировки и ее направление
+type sortField struct {
+	key        string
+	descending bool
+}
+
+// parseSort разбирает параметр sort: до MaxSortKeys ключей через запятую,
+// "-" перед ключом - сортировка по убыванию
+//
+// Returns:
+//
+//	[]sortField: ключи в порядке приоритета
+//	*schema.FieldError: ошибка, если ключ не из sortKeys
+//	string: сообщение об ошибке для лишних, пустых и повторяющихся ключей
+func parseSort(value string) ([]sortField, *schema.FieldError, string) {
+	keys := strings.Split(value, ",")
+	if len(keys) > MaxSortKeys {
+		return nil, nil, fmt.Sprintf("Параметр sort содержит больше %d ключей", MaxSortKeys)
+	}
+	fields := make([]sortField, 0, len(keys))
+	seen := make([]string, 0, len(keys))
+	for _, key := range keys {
+		field := sortField{key: strings.TrimPrefix(key, "-")}
+		field.descending = field.key != key
+		if invalid := schema.OneOf("sort", field.key, sortKeys...); invalid != nil {
+			return nil, invalid, ""
+		}
+		if slices.Contains(seen, field.key) {
+			return nil, nil, fmt.Sprintf("Ключ %s указан в параметре sort дважды", field.key)
+		}
+		seen = append(seen, field.key)
+		fields = append(fields, field)
+	}
+	return fields, nil, ""
+}
+
+// CollationNames возвращает локали, поддерживаемые сортировкой по названию
+func CollationNames() []string {
+	names := make([]string, len(collationLanguages))
+	for i, tag := range collationLanguages {
+		names[i] = tag.String()
+	}
+	return names
+}
+
+// collationMatcher подбирает поддерживаемую локаль по запросу клиента
+var collationMatcher = language.NewMatcher(collationLanguages)
+
+// matchCollation возвращает поддерживаемую локаль, ближайшую к тегу, и признак совпадения
+func matchCollation(tags ...language.Tag) (language.Tag, bool) {
+	_, index, confidence := collationMatcher.Match(tags...)
+	return collationLanguages[index], confidence != language.No
+}
+
+// requestCollation определяет локаль сортировки запроса
+//
+// Порядок выбора: параметр ?collate, заголовок Accept-Language, локаль по умолчанию.
+// Неизвестная локаль в ?collate - ошибка клиента, а неподходящий Accept-Language
+// просто не учитывается.
+//
+// Returns:
+//
+//	language.Tag: локаль сортировки
+//	*schema.FieldError: ошибка, если ?collate содержит неподдерживаемую локаль
+func requestCollation(r *http.Request, fallback language.Tag) (language.Tag, *schema.FieldError) {
+	if value := r.URL.Query().Get("collate"); value != "" {
+		if tag, err := language.Parse(value); err == nil {
+			if tag, ok := matchCollation(tag); ok {
+				return tag, nil
+			}
+		}
+		return language.Und, schema.OneOf("collate", value, CollationNames()...)
+	}
+
+	if header := r.Header.Get("Accept-Language"); header != "" {
+		tags, _, err := language.ParseAcceptLanguage(header)
+		if err == nil && len(tags) > 0 {
+			if tag, ok := matchCollation(tags...); ok {
+				return tag, nil
+			}
+		}
+	}
+	return fallback, nil
+}
+
+// sortedTasks собирает задачи источника и сортирует их по ключам fields
+//
+// Ключи сравниваются по очереди, следующий - только при равенстве предыдущих:
+//
+//	title: по названию с учетом локали tag, без учета регистра; числа в
+//	       названиях сравниваются по значению ("2 дела" раньше "10 дел")
+//	priority: по срочности, от low до critical; задачи без приоритета последние
+//	due_date: по сроку; задачи без срока последние
+//	created_at: по моменту создания
+//	id: по ID
+//
+// Задачи без приоритета или срока остаются последними и при сортировке по
+// убыванию. Задачи, равные по всем ключам, упорядочиваются по возрастанию ID,
+// поэтому порядок полный и страницы не перемешиваются между запросами.
+// Для сортировки список приходится собрать целиком, поэтому результат возвращается
+// источником поверх готового среза.
+func sortedTasks(ctx context.Context, list taskLister, keep func(*models.Task) bool, fields []sortField, tag language.Tag) (taskLister, error) {
+	var tasks []*models.Task
+	err := list(ctx, func(task *models.Task) error {
+		if keep == nil || keep(task) {
+			tasks = append(tasks, task)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	collator := collate.New(tag, collate.IgnoreCase, collate.Numeric)
+	sort.Slice(tasks, func(i, j int) bool {
+		for _, field := range fields {
+			order, absent := compareTasks(tasks[i], tasks[j], field.key, collator)
+			if order == 0 {
+				continue
+			}
+			if field.descending && !absent {
+				order = -order
+			}
+			return order < 0
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+
+	return sliceLister(tasks), nil
+}
+
+// compareTasks сравнивает задачи a и b по ключу key
+//
+// Returns:
+//
+//	int: отрицательное, если a раньше b по возрастанию, 0 при равенстве
+//	bool: true, если у одной из задач нет значения ключа; такая задача
+//	      всегда последняя, и направление сортировки не меняет результат
+func compareTasks(a, b *models.Task, key string, collator *collate.Collator) (int, bool) {
+	switch key {
+	case "title":
+		return collator.CompareString(a.Title, b.Title), false
+	case "priority":
+		if a.Priority == "" || b.Priority == "" {
+			return absentLast(a.Priority == "", b.Priority == ""), true
+		}
+		return cmp.Compare(slices.Index(models.Priorities, a.Priority), slices.Index(models.Priorities, b.Priority)), false
+	case "due_date":
+		if a.DueDate == nil || b.DueDate == nil {
+			return absentLast(a.DueDate == nil, b.DueDate == nil), true
+		}
+		return a.DueDate.Compare(*b.DueDate), false
+	case "created_at":
+		return a.CreatedAt.Compare(b.CreatedAt), false
+	default:
+		return cmp.Compare(a.ID, b.ID), false
+	}
+}
+
+// absentLast сравнивает задачи, у одной или обеих из которых нет значения ключа:
+// задача без значения идет после задачи со значением
+func absentLast(aAbsent, bAbsent bool) int {
+	switch {
+	case aAbsent == bAbsent:
+		return 0
+	case aAbsent:
+		return 1
+	default:
+		return -1
+	}
+}
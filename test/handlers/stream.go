@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"test/models"
+)
+
+// taskLister - источник задач, передающий их по одной
+type taskLister func(ctx context.Context, fn func(*models.Task) error) error
+
+// sliceLister возвращает источник, обходящий готовый срез задач
+func sliceLister(tasks []*models.Task) taskLister {
+	return func(ctx context.Context, fn func(*models.Task) error) error {
+		for _, task := range tasks {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(task); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// writeTaskStream записывает задачи в ответ JSON-массивом по мере их поступления
+//
+// Массив не собирается в памяти целиком: открывающая скобка, элементы через запятую
+// и закрывающая скобка пишутся по отдельности. Пока не записан первый элемент,
+// ошибка источника возвращается обычным ответом 500. После начала ответа код
+// изменить уже нельзя, поэтому ответ обрывается без закрывающей скобки,
+// и клиент получает заведомо некорректный JSON вместо неполного списка.
+// Метки времени задач выводятся в часовом поясе ответа, см. localTask.
+//
+// Args:
+//
+//	list: источник задач
+//	keep: фильтр задач, nil - все задачи
+func writeTaskStream(w http.ResponseWriter, r *http.Request, list taskLister, keep func(*models.Task) bool) {
+	started := false
+	err := list(r.Context(), func(task *models.Task) error {
+		if keep != nil && !keep(task) {
+			return nil
+		}
+
+		data, err := json.Marshal(localTask(r, task))
+		if err != nil {
+			return err
+		}
+
+		separator := ","
+		if !started {
+			w.Header().Set("Content-Type", "application/json")
+			separator = "["
+			started = true
+		}
+		if _, err := w.Write([]byte(separator)); err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	})
+
+	if err != nil {
+		if !started {
+			writeTaskError(w, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if !started {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[]\n"))
+		return
+	}
+	w.Write([]byte("]\n"))
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"test/models"
+	"time"
+	"unicode"
+)
+
+// textContentType - тип содержимого текстовых ответов
+const textContentType = "text/plain; charset=utf-8"
+
+// textLabelWidth - ширина колонки названий полей в текстовом представлении задачи
+const textLabelWidth = len("detected_links: ")
+
+// wantsText сообщает, что клиент предпочитает text/plain ответу JSON
+//
+// Учитываются веса q заголовка Accept: text/plain выбирается, только если его вес
+// строго больше веса application/json. При равных весах, в том числе для */*,
+// ответ остается JSON.
+func wantsText(r *http.Request) bool {
+	header := r.Header.Get("Accept")
+	if header == "" {
+		return false
+	}
+
+	textQ, jsonQ := acceptQuality(header, "text", "plain"), acceptQuality(header, "application", "json")
+	return textQ > 0 && textQ > jsonQ
+}
+
+// acceptQuality возвращает вес типа содержимого в заголовке Accept
+//
+// Из подходящих диапазонов берется самый точный: тип/подтип, затем тип/*, затем */*.
+func acceptQuality(header, mainType, subType string) float64 {
+	quality, precision := 0.0, -1
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		var level int
+		switch mediaType {
+		case mainType + "/" + subType:
+			level = 2
+		case mainType + "/*":
+			level = 1
+		case "*/*":
+			level = 0
+		default:
+			continue
+		}
+		if level < precision {
+			continue
+		}
+
+		q := 1.0
+		if value, exists := params["q"]; exists {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		quality, precision = q, level
+	}
+	return quality
+}
+
+// textErrorWriter приводит ошибки ответа к одной текстовой строке с кодом
+//
+// Тело ответа с кодом 400 и выше накапливается вместо отправки и после завершения
+// обработчика заменяется строкой вида "404 Not Found: задача не найдена: ID 1".
+// Из JSON-ошибок берется поле error. Успешные ответы передаются без изменений.
+type textErrorWriter struct {
+	http.ResponseWriter
+	status int          // Код ошибки, 0 - ответ не является ошибкой
+	body   bytes.Buffer // Исходное тело ошибки
+}
+
+// WriteHeader запоминает код ошибки или передает код успешного ответа
+func (w *textErrorWriter) WriteHeader(status int) {
+	if status >= http.StatusBadRequest {
+		w.status = status
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write накапливает тело ошибки или передает тело успешного ответа
+func (w *textErrorWriter) Write(data []byte) (int, error) {
+	if w.status != 0 {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// finish отправляет накопленную ошибку одной строкой
+func (w *textErrorWriter) finish() {
+	if w.status == 0 {
+		return
+	}
+
+	message := strings.TrimSpace(w.body.String())
+	var body struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(w.body.Bytes(), &body) == nil && body.Error != "" {
+		message = body.Error
+	}
+
+	header := w.ResponseWriter.Header()
+	header.Del("Content-Length")
+	header.Set("Content-Type", textContentType)
+	header.Set("X-Content-Type-Options", "nosniff")
+	w.ResponseWriter.WriteHeader(w.status)
+	fmt.Fprintf(w.ResponseWriter, "%d %s: %s\n", w.status, http.StatusText(w.status), textLine(message))
+}
+
+// textErrors подменяет ответ GET-запроса, для которого клиент предпочитает text/plain,
+// так, чтобы ошибки отправлялись одной строкой, см. textErrorWriter
+//
+// Returns:
+//
+//	http.ResponseWriter: ответ для обработчика
+//	func(): завершение ответа, вызывается после обработчика
+func textErrors(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, func()) {
+	if r.Method != http.MethodGet || !wantsText(r) {
+		return w, func() {}
+	}
+
+	writer := &textErrorWriter{ResponseWriter: w}
+	return writer, writer.finish
+}
+
+// textLine заменяет управляющие символы пробелами, чтобы значение занимало одну строку
+func textLine(value string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, value)
+}
+
+// writeTaskLine записывает задачу строкой "[ ] 12 Купить молоко"; [x] - выполненная задача
+func writeTaskLine(w io.Writer, task *models.Task) error {
+	status := "[ ]"
+	if task.Completed {
+		status = "[x]"
+	}
+	_, err := fmt.Fprintf(w, "%s %d %s\n", status, task.ID, textLine(task.Title))
+	return err
+}
+
+// writeTaskTextStream записывает задачи в ответ text/plain по одной на строку
+//
+// Как и writeTaskStream, пишет задачи по мере поступления; ошибка источника
+// до первой строки возвращается кодом 500, после - обрывает ответ.
+func writeTaskTextStream(w http.ResponseWriter, r *http.Request, list taskLister, keep func(*models.Task) bool) {
+	started := false
+	err := list(r.Context(), func(task *models.Task) error {
+		if keep != nil && !keep(task) {
+			return nil
+		}
+		if !started {
+			w.Header().Set("Content-Type", textContentType)
+			started = true
+		}
+		return writeTaskLine(w, task)
+	})
+
+	if err != nil {
+		if !started {
+			writeTaskError(w, err, http.StatusInternalServerError)
+		}
+		return
+	}
+	if !started {
+		w.Header().Set("Content-Type", textContentType)
+	}
+}
+
+// writeTaskText записывает задачу в ответ text/plain небольшим блоком
+//
+//	[x] 12 Выпустить релиз
+//	description:    Собрать сборку
+//	                и опубликовать
+//	links:          https://git.example/pr/1 (PR)
+//	tags:           release, backend
+//	follows_id:     11
+//	version:        3
+//	created_at:     2024-01-01T12:00:00Z
+//	updated_at:     2024-01-02T09:30:00Z
+//
+// Выводятся те же поля, что и в JSON-ответе. Первая строка совпадает со строкой
+// списка. Пустые поля не выводятся, значения из нескольких строк продолжаются
+// с отступом колонки значений. Метки времени, срок и момент истечения выводятся
+// в RFC3339 в часовом поясе location, см. withTimezone.
+func writeTaskText(w http.ResponseWriter, task *models.Task, metadata map[string]json.RawMessage, location *time.Location) {
+	var out bytes.Buffer
+	writeTaskLine(&out, task)
+
+	field := func(label string, lines ...string) {
+		for i, line := range lines {
+			if i == 0 {
+				label += ":"
+			} else {
+				label = ""
+			}
+			fmt.Fprintf(&out, "%-*s%s\n", textLabelWidth, label, textLine(line))
+		}
+	}
+	timestamp := func(t time.Time) string {
+		return t.In(location).Format(time.RFC3339)
+	}
+
+	if task.Description != "" {
+		field("description", strings.Split(strings.ReplaceAll(task.Description, "\r\n", "\n"), "\n")...)
+	}
+	if len(task.Links) > 0 {
+		links := make([]string, len(task.Links))
+		for i, link := range task.Links {
+			links[i] = link.URL
+			if link.Title != "" {
+				links[i] += " (" + link.Title + ")"
+			}
+		}
+		field("links", links...)
+	}
+	if len(task.Tags) > 0 {
+		field("tags", strings.Join(task.Tags, ", "))
+	}
+	if task.Protected {
+		field("protected", "yes")
+	}
+	if task.FollowsID != 0 {
+		field("follows_id", strconv.Itoa(task.FollowsID))
+	}
+	if task.Priority != "" {
+		field("priority", task.Priority)
+	}
+	if task.DueDate != nil {
+		field("due_date", timestamp(*task.DueDate))
+	}
+	if task.ExpiresAt != nil {
+		field("expires_at", timestamp(*task.ExpiresAt))
+	}
+	if len(task.Mentions) > 0 {
+		field("mentions", strings.Join(task.Mentions, ", "))
+	}
+	if len(task.DetectedLinks) > 0 {
+		field("detected_links", task.DetectedLinks...)
+	}
+	field("version", strconv.Itoa(task.Version))
+	field("created_at", timestamp(task.CreatedAt))
+	field("updated_at", timestamp(task.UpdatedAt))
+	if len(metadata) > 0 {
+		namespaces := make([]string, 0, len(metadata))
+		for namespace := range metadata {
+			namespaces = append(namespaces, namespace)
+		}
+		sort.Strings(namespaces)
+
+		values := make([]string, len(namespaces))
+		for i, namespace := range namespaces {
+			var compact bytes.Buffer
+			json.Compact(&compact, metadata[namespace])
+			values[i] = namespace + " " + compact.String()
+		}
+		field("metadata", values...)
+	}
+
+	w.Header().Set("Content-Type", textContentType)
+	w.Write(out.Bytes())
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"test/models"
+	"test/storage"
+	"time"
+
+	// Встроенная база часовых поясов: в минимальных контейнерах нет /usr/share/zoneinfo
+	_ "time/tzdata"
+)
+
+const (
+	// TimezoneHeader - заголовок часового пояса меток времени в ответе, если не задан ?tz
+	TimezoneHeader = "X-Timezone"
+
+	// CodeInvalidTimezone - машинный код ошибки неизвестного часового пояса
+	CodeInvalidTimezone = "invalid_timezone"
+)
+
+// timezoneKey - ключ часового пояса ответа в контексте запроса, см. withTimezone
+type timezoneKey struct{}
+
+// loadTimezone возвращает часовой пояс по имени из базы IANA, например Asia/Bangkok
+//
+// Local не принимается: ответ не должен зависеть от настроек сервера.
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "Local" {
+		return nil, fmt.Errorf("неизвестный часовой пояс %q", name)
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("неизвестный часовой пояс %q", name)
+	}
+	return location, nil
+}
+
+// responseLocation возвращает часовой пояс меток времени ответа, выбранный withTimezone
+//
+// Returns:
+//
+//	*time.Location: часовой пояс клиента, UTC - если он не задан
+func responseLocation(r *http.Request) *time.Location {
+	if location, ok := r.Context().Value(timezoneKey{}).(*time.Location); ok {
+		return location
+	}
+	return time.UTC
+}
+
+// localTask возвращает задачу с метками времени, сроком и моментом истечения в часовом поясе ответа
+//
+// Переводятся только поля времени models.Task, поэтому текст задачи и метаданные
+// интеграций выводятся как есть, даже если содержат похожие на метки времени значения.
+// Задача хранилища не меняется: при заданном поясе возвращается ее копия.
+func localTask(r *http.Request, task *models.Task) *models.Task {
+	location := responseLocation(r)
+	if location == time.UTC {
+		return task
+	}
+
+	local := *task
+	local.CreatedAt = task.CreatedAt.In(location)
+	local.UpdatedAt = task.UpdatedAt.In(location)
+	local.DueDate = inLocation(task.DueDate, location)
+	local.DeletedAt = inLocation(task.DeletedAt, location)
+	local.ExpiresAt = inLocation(task.ExpiresAt, location)
+	return &local
+}
+
+// inLocation возвращает момент t в часовом поясе location, nil - для nil
+func inLocation(t *time.Time, location *time.Location) *time.Time {
+	if t == nil {
+		return nil
+	}
+	local := t.In(location)
+	return &local
+}
+
+// writeLocalTaskError сообщает об ошибке чтения задачи, как writeTaskError, но
+// момент удаления в ответе 410 выводит в часовом поясе ответа, как и метки времени задачи
+func writeLocalTaskError(w http.ResponseWriter, r *http.Request, err error) {
+	var deleted *storage.TaskDeletedError
+	if errors.As(err, &deleted) {
+		writeGone(w, deleted, responseLocation(r))
+		return
+	}
+	writeTaskError(w, err, http.StatusInternalServerError)
+}
+
+// withTimezone выводит метки времени ответов на чтение в часовом поясе клиента
+//
+// Часовой пояс задается параметром ?tz или заголовком X-Timezone, по умолчанию -
+// настройкой timezone из preferences (см. SetPreferencesHandler), без нее UTC.
+// Метки времени остаются в формате RFC3339, меняется только смещение, например
+// 2024-01-01T19:00:00+07:00 для tz=Asia/Bangkok. Хранилище по-прежнему хранит UTC,
+// запросы на запись параметр не учитывают. Неизвестный пояс отклоняется с кодом 400.
+//
+// Сам перевод выполняют обработчики при построении ответа, см. localTask:
+// пояс передается им в контексте запроса.
+func withTimezone(next http.Handler, preferences storage.PreferenceStorage) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		name := r.URL.Query().Get("tz")
+		if name == "" {
+			name = r.Header.Get(TimezoneHeader)
+		}
+		if name == "" && preferences != nil {
+			if defaults, err := preferences.GetPreferences(); err == nil {
+				name = defaults.Timezone
+			}
+		}
+		if name == "" || name == "UTC" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		location, err := loadTimezone(name)
+		if err != nil {
+			writeErrorCode(w, http.StatusBadRequest, CodeInvalidTimezone, err.Error())
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), timezoneKey{}, location)))
+	})
+}
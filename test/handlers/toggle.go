@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"test/storage"
+)
+
+// ToggleTaskHandler инвертирует отметку о выполнении задачи
+// PUT /tasks/{id}/toggle
+//
+// Тело запроса не нужно: хранилище само читает текущее значение completed и
+// записывает противоположное одной операцией, см. storage.ToggleStorage.
+// Параллельные запросы не теряют переключений. Неизменяемость выполненных
+// задач переключению не мешает: снятие отметки переоткрывает задачу.
+//
+// Ответ - обновленная задача:
+//
+//	{
+//	  "id": 1,
+//	  "title": "Задача 1",
+//	  "description": "Описание 1",
+//	  "completed": true,
+//	  "created_at": "2024-01-01T12:00:00Z",
+//	  "updated_at": "2024-01-02T09:30:00Z"
+//	}
+func ToggleTaskHandler(w http.ResponseWriter, r *http.Request, toggle storage.ToggleStorage, id int) {
+	task, err := toggle.ToggleTask(id)
+	if err != nil {
+		writeTaskError(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"test/models"
+	"test/schema"
+	"unicode/utf8"
+)
+
+const (
+	// RuleTitleLength - правило мягкого ограничения длины названия задачи
+	RuleTitleLength = "title_length"
+
+	// SoftTitleLength - длина названия в символах, сверх которой выдается предупреждение
+	SoftTitleLength = 120
+
+	// CodeValidationFailed - машинный код ошибки нарушения правила, настроенного как строгое
+	CodeValidationFailed = "validation_failed"
+)
+
+// softFields - поля записываемой задачи, проверяемые мягкими правилами
+type softFields struct {
+	Title string
+}
+
+// softRule - правило валидации, которое по умолчанию не мешает сохранению задачи
+type softRule struct {
+	name  string
+	check func(fields softFields) *schema.FieldError
+}
+
+// softRules - мягкие правила в порядке проверки
+var softRules = []softRule{
+	{name: RuleTitleLength, check: func(fields softFields) *schema.FieldError {
+		if utf8.RuneCountInString(fields.Title) <= SoftTitleLength {
+			return nil
+		}
+		return &schema.FieldError{
+			Field:   "title",
+			Rule:    RuleTitleLength,
+			Message: fmt.Sprintf("название длиннее %d символов", SoftTitleLength),
+		}
+	}},
+}
+
+// SoftRuleNames возвращает имена мягких правил, которые можно сделать строгими через Config.HardRules
+func SoftRuleNames() []string {
+	names := make([]string, len(softRules))
+	for i, rule := range softRules {
+		names[i] = rule.name
+	}
+	return names
+}
+
+// validationPolicy определяет, какие мягкие правила развертывания строгие
+type validationPolicy map[string]bool
+
+// newValidationPolicy строит политику из имен строгих правил
+//
+// Неизвестное имя правила - ошибка настройки, поэтому вызывает панику.
+func newValidationPolicy(hardRules []string) validationPolicy {
+	policy := make(validationPolicy, len(softRules))
+	for _, rule := range softRules {
+		policy[rule.name] = false
+	}
+	for _, name := range hardRules {
+		if _, exists := policy[name]; !exists {
+			panic("handlers: неизвестное правило валидации " + name)
+		}
+		policy[name] = true
+	}
+	return policy
+}
+
+// modes возвращает режим каждого правила для GET /capabilities: warning или error
+func (p validationPolicy) modes() map[string]string {
+	modes := make(map[string]string, len(p))
+	for name, hard := range p {
+		modes[name] = "warning"
+		if hard {
+			modes[name] = "error"
+		}
+	}
+	return modes
+}
+
+// check проверяет поля мягкими правилами
+//
+// Returns:
+//
+//	errors: нарушения строгих правил, запись должна быть отклонена
+//	warnings: нарушения мягких правил, запись выполняется
+func (p validationPolicy) check(fields softFields) (errors, warnings schema.Errors) {
+	for _, rule := range softRules {
+		violation := rule.check(fields)
+		if violation == nil {
+			continue
+		}
+		if p[rule.name] {
+			errors = append(errors, *violation)
+		} else {
+			warnings = append(warnings, *violation)
+		}
+	}
+	return errors, warnings
+}
+
+// taskWithWarnings - ответ успешной записи задачи с предупреждениями мягких правил
+type taskWithWarnings struct {
+	*models.Task
+	Warnings schema.Errors `json:"warnings,omitempty"`
+}
+
+// writeValidationFailed отвечает кодом 422 на нарушение строгих правил
+//
+//	{
+//	  "error": "название длиннее 120 символов",
+//	  "code": "validation_failed",
+//	  "errors": [{"field": "title", "rule": "title_length", "message": "..."}]
+//	}
+func writeValidationFailed(w http.ResponseWriter, errs schema.Errors) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(struct {
+		ErrorResponse
+		Errors schema.Errors `json:"errors"`
+	}{ErrorResponse{errs.Error(), CodeValidationFailed, http.StatusUnprocessableEntity}, errs})
+}
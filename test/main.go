@@ -1,21 +1,353 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
 	"test/handlers"
+	"test/handlers/middleware"
 	"test/storage"
+	"time"
 )
 
 func main() {
+	strictSchema := flag.Bool("strict-schema", false, "проверять тела запросов по опубликованной JSON Schema")
+	confirmDeletes := flag.Bool("confirm-deletes", false, "требовать подтверждение удаления задач: заголовок X-Confirm-Delete или ?confirm=true для /tasks/bulk")
+	collation := flag.String("collation", handlers.DefaultCollation, "локаль сортировки по названию по умолчанию: "+strings.Join(handlers.CollationNames(), ", "))
+	completedImmutable := flag.Bool("completed-immutable", false, "запретить изменение выполненных задач, кроме возобновления")
+	hardRules := flag.String("hard-rules", "", "мягкие правила валидации через запятую, нарушение которых отклоняет запись: "+strings.Join(handlers.SoftRuleNames(), ", "))
+	quickAddMaxLines := flag.Int("quick-add-max-lines", handlers.DefaultQuickAddMaxLines, "максимальное число задач в одном запросе POST /tasks/quick")
+	bulkCreateMaxItems := flag.Int("bulk-create-max-items", handlers.DefaultBulkCreateMaxItems, "максимальное число задач в одном запросе POST и DELETE /tasks/bulk")
+	storageKind := flag.String("storage", envOr("STORAGE", "memory"), "хранилище задач: memory, file, journal, sqlite, postgres, redis, mongo или bolt (переменная STORAGE)")
+	databaseURL := flag.String("database-url", os.Getenv("DATABASE_URL"), "путь к JSON-файлу, файлу SQLite или bbolt, строка подключения PostgreSQL или адрес Redis или MongoDB (переменная DATABASE_URL)")
+	maxTasks := flag.Int("max-tasks", 0, "максимальное число задач хранилища memory; 0 - без лимита")
+	evictCompleted := flag.Bool("evict-completed", false, "при -max-tasks удалять самую старую выполненную задачу вместо отказа в создании новой")
+	snapshotPath := flag.String("snapshot", os.Getenv("SNAPSHOT_PATH"), "файл снимка хранилища memory: загружается при запуске и записывается при остановке (переменная SNAPSHOT_PATH)")
+	logRequests := flag.Bool("log-requests", true, "записывать каждый запрос JSON-строкой в stdout")
+	corsOrigins := flag.String("cors-origins", os.Getenv("CORS_ORIGINS"), "источники через запятую, которым разрешены запросы из браузера, * - любые; пусто - CORS выключен (переменная CORS_ORIGINS)")
+	corsMaxAge := flag.Int("cors-max-age", 600, "время в секундах, на которое браузер запоминает ответ на предварительный запрос CORS")
+	rateLimit := flag.Float64("rate-limit", 0, "число запросов в секунду с одного IP-адреса, сверх которого отвечать 429; 0 - без ограничения")
+	rateBurst := flag.Int("rate-burst", 20, "число запросов, которые один IP-адрес может отправить подряд при -rate-limit")
+	maxBodyBytes := flag.Int64("max-body-bytes", middleware.DefaultMaxBodyBytes, "максимальный размер тела запроса в байтах, сверх которого отвечать 413")
+	maxQueryBytes := flag.Int("max-query-bytes", middleware.DefaultMaxQueryBytes, "максимальная длина строки запроса в байтах, сверх которой отвечать 414")
+	maxQueryParams := flag.Int("max-query-params", middleware.DefaultMaxQueryParams, "максимальное число разных параметров строки запроса")
+	maxParamValues := flag.Int("max-param-values", middleware.DefaultMaxParamValues, "максимальное число значений одного параметра строки запроса")
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "JSON-файл настроек с именами флагов, перечитывается по SIGHUP и POST /admin/config/reload; флаги командной строки важнее (переменная CONFIG_FILE)")
+	flag.Parse()
+
+	// Файл настроек задает флаги, не указанные в командной строке
+	var reloader *configReloader
+	if *configPath != "" {
+		var err error
+		if reloader, err = newConfigReloader(flag.CommandLine, *configPath); err != nil {
+			fmt.Printf("Ошибка чтения настроек: %v\n", err)
+			return
+		}
+	}
+
+	var rules []string
+	if *hardRules != "" {
+		rules = strings.Split(*hardRules, ",")
+		for _, rule := range rules {
+			if !slices.Contains(handlers.SoftRuleNames(), rule) {
+				fmt.Printf("Неизвестное правило валидации: %s\n", rule)
+				return
+			}
+		}
+	}
+
+	var opts []storage.Option
+	if *completedImmutable {
+		opts = append(opts, storage.WithCompletedImmutable())
+	}
+	if *maxTasks < 0 {
+		fmt.Println("Лимит задач не может быть отрицательным")
+		return
+	}
+	if *maxTasks != 0 && *storageKind != "memory" {
+		fmt.Println("Лимит задач поддерживается только хранилищем memory")
+		return
+	}
+	eviction := storage.RejectNew
+	if *evictCompleted {
+		eviction = storage.EvictOldestCompleted
+	}
+
 	// Инициализация хранилища и обработчиков
-	taskStorage := storage.NewInMemoryStorage()
-	mux := handlers.SetupHandlers(taskStorage)
+	taskStorage, err := openStorage(*storageKind, *databaseURL, *maxTasks, eviction, opts)
+	if err != nil {
+		fmt.Printf("Ошибка открытия хранилища: %v\n", err)
+		return
+	}
+	// Хранилище закрывается последним: после остановки сервера и записи снимка
+	defer func() {
+		if err := taskStorage.Close(); err != nil {
+			fmt.Printf("Ошибка закрытия хранилища: %v\n", err)
+		}
+	}()
 
-	fmt.Println("Сервер запущен на порту 8080")
-	err := http.ListenAndServe(":8080", mux)
+	var snapshotStorage *storage.InMemoryStorage
+	if *snapshotPath != "" {
+		memory, ok := taskStorage.(*storage.InMemoryStorage)
+		if !ok {
+			fmt.Println("Снимок поддерживается только хранилищем memory")
+			return
+		}
+		if err := loadSnapshot(memory, *snapshotPath); err != nil {
+			fmt.Printf("Ошибка загрузки снимка: %v\n", err)
+			return
+		}
+		snapshotStorage = memory
+	}
+
+	// SIGTERM и Ctrl+C останавливают сервер после завершения текущих запросов
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	// Фоновое удаление истекших задач до остановки сервера
+	if expiring, ok := taskStorage.(storage.ExpiringStorage); ok {
+		expiring.Start(ctx)
+		defer expiring.Stop()
+	}
+
+	// Журнал запросов, CORS и лимит частоты меняются без перезапуска, см. configReloader
+	runtimeSettings := middleware.RuntimeConfig{
+		LogRequests: *logRequests,
+		CORSMaxAge:  *corsMaxAge,
+		RateLimit:   *rateLimit,
+		RateBurst:   *rateBurst,
+	}
+	if *corsOrigins != "" {
+		runtimeSettings.CORSOrigins = strings.Split(*corsOrigins, ",")
+	}
+	runtime, err := middleware.NewReloadable(ctx, os.Stdout, runtimeSettings)
 	if err != nil {
+		fmt.Printf("Неверные настройки: %v\n", err)
+		return
+	}
+	config := handlers.Config{
+		StrictSchema:       *strictSchema,
+		ConfirmDeletes:     *confirmDeletes,
+		Collation:          *collation,
+		HardRules:          rules,
+		QuickAddMaxLines:   *quickAddMaxLines,
+		BulkCreateMaxItems: *bulkCreateMaxItems,
+	}
+	if reloader != nil {
+		reloader.runtime = runtime
+		config.ReloadConfig = reloader.reload
+		go reloadOnHangup(ctx, reloader)
+	}
+
+	mux := handlers.SetupHandlersWithConfig(taskStorage, config, requestMiddleware(runtime, *maxBodyBytes,
+		middleware.WithMaxQueryBytes(*maxQueryBytes), middleware.WithMaxQueryParams(*maxQueryParams), middleware.WithMaxParamValues(*maxParamValues))...)
+
+	server := &http.Server{Addr: ":8080", Handler: mux}
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Println("Сервер запущен на порту 8080")
+	err = server.ListenAndServe()
+	if !errors.Is(err, http.ErrServerClosed) {
 		fmt.Printf("Ошибка запуска сервера: %v\n", err)
 		return
 	}
+	<-stopped
+
+	if snapshotStorage != nil {
+		if err := saveSnapshot(snapshotStorage, *snapshotPath); err != nil {
+			fmt.Printf("Ошибка записи снимка: %v\n", err)
+			return
+		}
+		fmt.Printf("Снимок записан в %s\n", *snapshotPath)
+	}
+}
+
+// requestMiddleware возвращает промежуточные обработчики сервера
+//
+// Первым идет runtime: журнал запросов, CORS и лимит частоты, в этом порядке, чтобы
+// в журнал попадали и предварительные запросы CORS и отказы по лимиту, а
+// предварительные запросы браузера не расходовали лимит клиента. Размер тела
+// проверяется последним, чтобы не читать тела запросов сверх лимита частоты,
+// а перед ним - строка запроса с ограничениями queryLimits.
+func requestMiddleware(runtime *middleware.Reloadable, maxBodyBytes int64, queryLimits ...middleware.QueryLimitOption) []handlers.Middleware {
+	return []handlers.Middleware{
+		runtime.Middleware,
+		middleware.NewQueryLimit(queryLimits...),
+		middleware.NewBodyLimit(middleware.WithMaxBodyBytes(maxBodyBytes)),
+	}
+}
+
+// reloadOnHangup перечитывает настройки по каждому SIGHUP до завершения ctx
+//
+// Итог перезагрузки записывает reloader.reload.
+func reloadOnHangup(ctx context.Context, reloader *configReloader) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			reloader.reload()
+		}
+	}
+}
+
+// loadSnapshot загружает снимок хранилища из файла, если он есть
+func loadSnapshot(s *storage.InMemoryStorage, path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return s.LoadSnapshot(f)
+}
+
+// saveSnapshot записывает снимок хранилища во временный файл и заменяет им path,
+// чтобы сбой во время записи не испортил прежний снимок
+func saveSnapshot(s *storage.InMemoryStorage, path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := s.SaveSnapshot(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// openStorage создает хранилище задач выбранного вида
+//
+// Args:
+//
+//	kind: memory, file, journal, sqlite, postgres, redis, mongo или bolt
+//	databaseURL: путь к JSON-файлу (по умолчанию tasks.json), файлу журнала (по умолчанию tasks.journal)
+//	или файлу SQLite (по умолчанию tasks.db),
+//	строка подключения PostgreSQL, адрес Redis (по умолчанию redis://localhost:6379/0),
+//	адрес MongoDB с именем базы данных в пути (по умолчанию mongodb://localhost:27017/tasks)
+//	или путь к файлу bbolt (по умолчанию tasks.bolt)
+//	maxTasks: лимит задач хранилища memory, 0 - без лимита
+//	eviction: поведение хранилища memory при достижении лимита
+//	opts: опции хранилища
+//
+// Returns:
+//
+//	storage.Storage: хранилище задач, закрываемое Close при остановке сервера
+//	error: ошибка открытия хранилища
+func openStorage(kind, databaseURL string, maxTasks int, eviction storage.EvictionPolicy, opts []storage.Option) (storage.Storage, error) {
+	switch kind {
+	case "memory":
+		return storage.NewInMemoryStorageWithLimit(maxTasks, eviction, opts...), nil
+	case "file":
+		if databaseURL == "" {
+			databaseURL = "tasks.json"
+		}
+		s, err := storage.NewFileStorage(databaseURL, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case "journal":
+		if databaseURL == "" {
+			databaseURL = "tasks.journal"
+		}
+		s, err := storage.NewJournaledStorage(databaseURL, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case "sqlite":
+		if databaseURL == "" {
+			databaseURL = "tasks.db"
+		}
+		s, err := storage.NewSQLiteStorage(databaseURL, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case "postgres":
+		if databaseURL == "" {
+			return nil, fmt.Errorf("для хранилища postgres нужна строка подключения DATABASE_URL")
+		}
+		s, err := storage.NewPostgresStorage(databaseURL, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case "redis":
+		if databaseURL == "" {
+			databaseURL = "redis://localhost:6379/0"
+		}
+		s, err := storage.NewRedisStorage(databaseURL, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case "mongo":
+		if databaseURL == "" {
+			databaseURL = "mongodb://localhost:27017/tasks"
+		}
+		s, err := storage.NewMongoStorage(databaseURL, mongoDatabase(databaseURL), "tasks", opts...)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case "bolt":
+		if databaseURL == "" {
+			databaseURL = "tasks.bolt"
+		}
+		s, err := storage.NewBoltStorage(databaseURL, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("неизвестное хранилище %q", kind)
+	}
+}
+
+// mongoDatabase возвращает имя базы данных из пути адреса MongoDB, по умолчанию tasks
+func mongoDatabase(uri string) string {
+	if u, err := url.Parse(uri); err == nil && strings.Trim(u.Path, "/") != "" {
+		return strings.Trim(u.Path, "/")
+	}
+	return "tasks"
+}
+
+// envOr возвращает значение переменной окружения или fallback, если она не задана
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
 }
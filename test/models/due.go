@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Overdue проверяет, что задача не выполнена, а ее срок прошел к моменту now
+//
+// Задача без срока (DueDate == nil) никогда не просрочена.
+func (t *Task) Overdue(now time.Time) bool {
+	return !t.Completed && t.DueDate != nil && t.DueDate.Before(now)
+}
+
+// Expired проверяет, что момент истечения задачи наступил к моменту now
+//
+// Задача без момента истечения (ExpiresAt == nil) никогда не истекает.
+func (t *Task) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxLinks - максимальное число ссылок у задачи
+	MaxLinks = 10
+
+	// MaxLinkURLLength - максимальная длина URL ссылки
+	MaxLinkURLLength = 2048
+
+	// MaxLinkTitleLength - максимальная длина названия ссылки
+	MaxLinkTitleLength = 200
+)
+
+// Link - внешняя ссылка задачи на тикет, PR или документ
+type Link struct {
+	Title string `json:"title,omitempty" validate:"max=200"`
+	URL   string `json:"url" validate:"required,max=2048"`
+}
+
+// NormalizeLink проверяет ссылку и приводит ее URL к каноническому виду
+//
+// Допускаются только абсолютные адреса http и https с указанным хостом,
+// в том числе с национальными доменами (IDN). Управляющие и пробельные символы
+// внутри адреса запрещены, чтобы нельзя было протащить схему вида "java\tscript:".
+//
+// Returns:
+//
+//	Link: ссылка со схемой и хостом в нижнем регистре
+//	error: ошибка проверки ссылки
+func NormalizeLink(link Link) (Link, error) {
+	raw := strings.TrimSpace(link.URL)
+	if raw == "" {
+		return Link{}, errors.New("URL ссылки обязателен")
+	}
+	if len(raw) > MaxLinkURLLength {
+		return Link{}, fmt.Errorf("URL ссылки длиннее %d символов", MaxLinkURLLength)
+	}
+	if utf8.RuneCountInString(link.Title) > MaxLinkTitleLength {
+		return Link{}, fmt.Errorf("название ссылки длиннее %d символов", MaxLinkTitleLength)
+	}
+	if !utf8.ValidString(raw) || strings.IndexFunc(raw, func(r rune) bool {
+		return unicode.IsControl(r) || unicode.IsSpace(r)
+	}) >= 0 {
+		return Link{}, fmt.Errorf("URL ссылки %q содержит недопустимые символы", raw)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return Link{}, fmt.Errorf("неверный URL ссылки %q", raw)
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return Link{}, fmt.Errorf("URL ссылки %q должен использовать схему http или https", raw)
+	}
+	if u.Host == "" || u.Hostname() == "" {
+		return Link{}, fmt.Errorf("в URL ссылки %q не указан хост", raw)
+	}
+	if u.User != nil {
+		return Link{}, fmt.Errorf("URL ссылки %q не должен содержать учетные данные", raw)
+	}
+
+	// Схема и хост приводятся к нижнему регистру в исходной записи адреса:
+	// URL.String экранировал бы национальные домены
+	rest := raw[len(u.Scheme)+len("://"):]
+	hostEnd := strings.IndexAny(rest, "/?#")
+	if hostEnd < 0 {
+		hostEnd = len(rest)
+	}
+	normalized := scheme + "://" + strings.ToLower(rest[:hostEnd]) + rest[hostEnd:]
+
+	return Link{Title: strings.TrimSpace(link.Title), URL: normalized}, nil
+}
+
+// NormalizeLinks проверяет список ссылок задачи
+//
+// Кроме проверки каждой ссылки, ограничивает их число MaxLinks
+// и отклоняет повторяющиеся URL.
+//
+// Returns:
+//
+//	[]Link: нормализованные ссылки
+//	error: ошибка проверки списка
+func NormalizeLinks(links []Link) ([]Link, error) {
+	if len(links) > MaxLinks {
+		return nil, fmt.Errorf("у задачи может быть не больше %d ссылок", MaxLinks)
+	}
+
+	normalized := make([]Link, 0, len(links))
+	seen := make(map[string]bool, len(links))
+	for _, link := range links {
+		link, err := NormalizeLink(link)
+		if err != nil {
+			return nil, err
+		}
+		if seen[link.URL] {
+			return nil, fmt.Errorf("ссылка %q указана повторно", link.URL)
+		}
+		seen[link.URL] = true
+		normalized = append(normalized, link)
+	}
+	return normalized, nil
+}
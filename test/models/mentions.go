@@ -0,0 +1,106 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// MaxExtractLength - число байт описания, в которых ищутся упоминания и ссылки
+	MaxExtractLength = 64 * 1024
+
+	// MaxExtracted - максимальное число упоминаний и ссылок, извлекаемых из описания
+	MaxExtracted = 100
+)
+
+var (
+	// mentionPattern находит @имя, перед которым нет буквы, цифры или части адреса,
+	// чтобы не принимать за упоминание e-mail и пути вида /@user.
+	// Имя может содержать буквы любого алфавита, цифры, "_", "." и "-", но не заканчиваться на "." или "-"
+	mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@./])@([\p{L}\p{N}_](?:[\p{L}\p{N}_.-]{0,62}[\p{L}\p{N}_])?)`)
+
+	// urlPattern находит кандидатов в адреса http и https до пробела или кавычки
+	urlPattern = regexp.MustCompile("(?i)https?://[^\\s<>\"'`]+")
+)
+
+// ExtractMentions возвращает имена пользователей, упомянутых в тексте как @имя
+//
+// Имена возвращаются без "@" в порядке первого появления, без повторов.
+// Просматриваются первые MaxExtractLength байт текста, возвращается не больше
+// MaxExtracted имен; поиск регулярным выражением линеен по длине текста.
+func ExtractMentions(text string) []string {
+	matches := mentionPattern.FindAllStringSubmatch(truncateForExtract(text), -1)
+
+	var mentions []string
+	seen := make(map[string]bool)
+	for _, match := range matches {
+		name := match[1]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		mentions = append(mentions, name)
+		if len(mentions) == MaxExtracted {
+			break
+		}
+	}
+	return mentions
+}
+
+// ExtractLinks возвращает адреса http и https, встречающиеся в тексте
+//
+// Знаки препинания в конце адреса и непарные закрывающие скобки отбрасываются,
+// поэтому ссылки markdown вида [текст](https://example.com) и адреса в конце
+// предложения извлекаются без лишних символов. Адреса проходят NormalizeLink,
+// недопустимые пропускаются. Ограничения объема те же, что у ExtractMentions.
+func ExtractLinks(text string) []string {
+	matches := urlPattern.FindAllString(truncateForExtract(text), -1)
+
+	var links []string
+	seen := make(map[string]bool)
+	for _, candidate := range matches {
+		link, err := NormalizeLink(Link{URL: trimURL(candidate)})
+		if err != nil || seen[link.URL] {
+			continue
+		}
+		seen[link.URL] = true
+		links = append(links, link.URL)
+		if len(links) == MaxExtracted {
+			break
+		}
+	}
+	return links
+}
+
+// trimURL отбрасывает знаки препинания и непарные скобки в конце адреса
+func trimURL(candidate string) string {
+	openParen := strings.Count(candidate, "(") - strings.Count(candidate, ")")
+	openSquare := strings.Count(candidate, "[") - strings.Count(candidate, "]")
+
+	for candidate != "" {
+		last := candidate[len(candidate)-1]
+		switch {
+		case strings.IndexByte(".,;:!?*_~", last) >= 0:
+		case last == ')' && openParen < 0:
+			openParen++
+		case last == ']' && openSquare < 0:
+			openSquare++
+		default:
+			return candidate
+		}
+		candidate = candidate[:len(candidate)-1]
+	}
+	return candidate
+}
+
+// truncateForExtract обрезает текст до MaxExtractLength байт, не разрывая символ UTF-8
+func truncateForExtract(text string) string {
+	if len(text) <= MaxExtractLength {
+		return text
+	}
+	end := MaxExtractLength
+	for end > 0 && text[end]&0xC0 == 0x80 {
+		end--
+	}
+	return text[:end]
+}
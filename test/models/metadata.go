@@ -0,0 +1,14 @@
+package models
+
+import "regexp"
+
+// metadataNamespacePattern - допустимое имя пространства метаданных интеграции
+var metadataNamespacePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,63}$`)
+
+// ValidMetadataNamespace проверяет имя пространства метаданных
+//
+// Имя начинается со строчной латинской буквы и содержит до 64 строчных латинских букв,
+// цифр, "_" и "-", например "crm" или "slack-bot".
+func ValidMetadataNamespace(namespace string) bool {
+	return metadataNamespacePattern.MatchString(namespace)
+}
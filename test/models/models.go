@@ -1,8 +1,49 @@
 package models
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type Task struct {
 	ID          int    `json:"id"`
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	Completed   bool   `json:"completed"`
+	Links       []Link `json:"links,omitempty"`
+	Protected   bool   `json:"protected,omitempty"`
+	FollowsID   int    `json:"follows_id,omitempty"`
+	Priority    string `json:"priority,omitempty"` // Одно из Priorities, пусто - не задан
+
+	// Теги задачи без повторов в порядке добавления, см. NormalizeTags
+	Tags []string `json:"tags,omitempty"`
+
+	// Срок выполнения задачи в UTC, nil - без срока, см. Overdue
+	DueDate *time.Time `json:"due_date,omitempty"`
+
+	// Номер версии задачи: 1 при создании, увеличивается хранилищем при каждом
+	// изменении полей задачи (обновление, ссылки, выполнение с продолжением), но
+	// не при удалении, восстановлении и изменении метаданных, см. storage.VersionedStorage
+	Version int `json:"version"`
+
+	// Моменты создания и последнего изменения задачи в UTC. Задаются хранилищем,
+	// значения из тел запросов не принимаются
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Момент мягкого удаления задачи, nil - задача не удалена. Удаленная задача
+	// хранится до восстановления, но не видна операциям с задачами
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	// Момент истечения задачи в UTC, nil - задача не истекает. Истекшая задача
+	// не видна операциям с задачами и удаляется хранилищем, см. Expired
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// Данные интеграций по пространствам имен, см. ValidMetadataNamespace.
+	// Не входят в обычное представление задачи и выдаются только по ?expand=metadata
+	Metadata map[string]json.RawMessage `json:"-"`
+
+	// Поля, вычисляемые из описания при каждом его изменении, см. ExtractMentions и ExtractLinks
+	Mentions      []string `json:"mentions,omitempty"`
+	DetectedLinks []string `json:"detected_links,omitempty"`
 }
@@ -0,0 +1,13 @@
+package models
+
+// Preferences - настройки по умолчанию для новых задач
+//
+// DefaultPriority и DefaultTags подставляются при создании задачи, в которой
+// приоритет или теги не заданы; явные значения запроса, в том числе пустой
+// список тегов, не заменяются. Timezone - часовой пояс меток времени ответов
+// на чтение, если запрос не задает его сам.
+type Preferences struct {
+	DefaultPriority string   `json:"default_priority,omitempty"` // Приоритет новых задач без приоритета
+	DefaultTags     []string `json:"default_tags,omitempty"`     // Теги новых задач без тегов
+	Timezone        string   `json:"timezone,omitempty"`         // Часовой пояс ответов по умолчанию, пусто - UTC
+}
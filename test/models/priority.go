@@ -0,0 +1,14 @@
+package models
+
+// Допустимые значения приоритета задачи
+const (
+	PriorityLow      = "low"
+	PriorityMedium   = "medium"
+	PriorityHigh     = "high"
+	PriorityCritical = "critical"
+)
+
+// Priorities - допустимые значения приоритета в порядке возрастания срочности
+//
+// Пустой приоритет означает, что он не задан.
+var Priorities = []string{PriorityLow, PriorityMedium, PriorityHigh, PriorityCritical}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// QuickLine - задача, записанная одной строкой для быстрого добавления
+type QuickLine struct {
+	Title        string   // Название без служебных меток
+	Tags         []string // Теги из меток #тег в конце строки, без "#", в порядке записи
+	HighPriority bool     // Строка начиналась с "!"
+}
+
+// ParseQuickLine разбирает строку быстрого добавления задачи
+//
+// Строка вида "! Позвонить в банк #дом #срочно" дает название "Позвонить в банк",
+// высокий приоритет и теги "дом" и "срочно". Теги распознаются только в конце
+// строки, поэтому "#" в середине названия ("Исправить #12 в отчете") остается
+// его частью. Тег проверяется ValidTag; повторы отбрасываются.
+func ParseQuickLine(line string) QuickLine {
+	var parsed QuickLine
+	rest := strings.TrimSpace(line)
+	if strings.HasPrefix(rest, "!") {
+		parsed.HighPriority = true
+		rest = strings.TrimSpace(rest[1:])
+	}
+
+	// Метки снимаются с конца строки по одной, пока последнее слово - тег
+	var tags []string
+	for rest != "" {
+		cut := strings.LastIndexFunc(rest, unicode.IsSpace) + 1
+		tag, ok := quickTag(rest[cut:])
+		if !ok {
+			break
+		}
+		tags = append(tags, tag)
+		rest = strings.TrimRightFunc(rest[:cut], unicode.IsSpace)
+	}
+
+	seen := make(map[string]bool, len(tags))
+	for i := len(tags) - 1; i >= 0; i-- {
+		if !seen[tags[i]] {
+			seen[tags[i]] = true
+			parsed.Tags = append(parsed.Tags, tags[i])
+		}
+	}
+	parsed.Title = rest
+	return parsed
+}
+
+// quickTag возвращает имя тега из слова "#тег"; false - слово не является тегом
+func quickTag(word string) (string, bool) {
+	name, ok := strings.CutPrefix(word, "#")
+	if !ok || !ValidTag(name) {
+		return "", false
+	}
+	return name, true
+}
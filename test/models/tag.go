@@ -0,0 +1,68 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxTags - максимальное число тегов у задачи
+	MaxTags = 20
+
+	// MaxTagLength - максимальная длина тега
+	MaxTagLength = 50
+)
+
+// ValidTag проверяет имя тега: непустая строка из букв, цифр, "_" и "-"
+// не длиннее MaxTagLength символов
+func ValidTag(name string) bool {
+	if name == "" || utf8.RuneCountInString(name) > MaxTagLength {
+		return false
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeTags проверяет теги задачи и убирает повторы
+//
+// Пробелы по краям тегов отбрасываются, теги сравниваются с учетом регистра.
+// Повторы отбрасываются, порядок первого появления сохраняется, после этого
+// тегов должно быть не больше MaxTags.
+//
+// Returns:
+//
+//	[]string: теги без повторов, пустой срез для пустого списка
+//	error: ошибка проверки тега или числа тегов
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if !ValidTag(tag) {
+			return nil, fmt.Errorf("неверный тег %q: допускаются буквы, цифры, \"_\" и \"-\", не больше %d символов", tag, MaxTagLength)
+		}
+		if !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > MaxTags {
+		return nil, fmt.Errorf("у задачи может быть не больше %d тегов", MaxTags)
+	}
+	return normalized, nil
+}
+
+// HasTags проверяет, что у задачи есть все теги tags
+func (t *Task) HasTags(tags []string) bool {
+	for _, tag := range tags {
+		if !slices.Contains(t.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,340 @@
+// Package schema формирует JSON Schema и проверяет значения по тегам validate структур Go
+//
+// Теги validate являются единственным источником правил: по ним строится
+// публикуемая схема и выполняется проверка входящих данных, поэтому схема
+// и фактическая валидация не могут разойтись.
+//
+// Поддерживаемые правила:
+//
+//	required: поле обязательно; строка, срез и указатель также не должны быть пустыми
+//	min=N:    минимальная длина строки или среза, минимальное значение числа
+//	max=N:    максимальная длина строки или среза, максимальное значение числа
+//	oneof=A B: строка должна быть одним из перечисленных через пробел значений, см. OneOf
+//	priority: то же, что oneof со значениями models.Priorities
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"test/models"
+	"time"
+	"unicode/utf8"
+)
+
+// Draft - версия спецификации JSON Schema публикуемых схем
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// CodeInvalidValue - машинный код ошибки значения, не входящего в список допустимых
+const CodeInvalidValue = "invalid_value"
+
+// FieldError описывает нарушение правила валидации поля
+type FieldError struct {
+	Field   string   `json:"field"`             // Имя поля в JSON или параметра запроса
+	Rule    string   `json:"rule"`              // Нарушенное правило: required, min, max, oneof
+	Code    string   `json:"code,omitempty"`    // Машинный код ошибки, см. CodeInvalidValue
+	Message string   `json:"message"`           // Описание ошибки
+	Allowed []string `json:"allowed,omitempty"` // Допустимые значения для правила oneof
+}
+
+func (e FieldError) Error() string {
+	return e.Message
+}
+
+// Errors - список ошибок валидации
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// OneOf проверяет, что значение перечисляемого поля входит в список допустимых
+//
+// Единый формат ошибки для всех перечислений - полей тел запросов и параметров:
+//
+//	{"field": "sort", "rule": "oneof", "code": "invalid_value", "message": "...", "allowed": ["title"]}
+//
+// Список allowed должен браться из того же места, где определены значения
+// перечисления, чтобы новое значение сразу попадало и в проверку, и в ошибку.
+//
+// Returns:
+//
+//	*FieldError: nil, если значение допустимо
+func OneOf(field, value string, allowed ...string) *FieldError {
+	for _, candidate := range allowed {
+		if value == candidate {
+			return nil
+		}
+	}
+	return &FieldError{
+		Field:   field,
+		Rule:    "oneof",
+		Code:    CodeInvalidValue,
+		Message: fmt.Sprintf("недопустимое значение %q поля %s, допустимые значения: %s", value, field, strings.Join(allowed, ", ")),
+		Allowed: append([]string(nil), allowed...),
+	}
+}
+
+// fieldRules - правила валидации одного поля структуры
+type fieldRules struct {
+	name     string   // Имя поля в JSON
+	index    int      // Индекс поля в структуре
+	required bool     // Поле обязательно
+	min, max *int     // Границы длины или значения
+	oneOf    []string // Допустимые значения строки
+}
+
+// parseFields извлекает правила валидации полей структуры из тегов json и validate
+func parseFields(t reflect.Type) []fieldRules {
+	fields := make([]fieldRules, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name := sf.Name
+		if tag, ok := sf.Tag.Lookup("json"); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+
+		rules := fieldRules{name: name, index: i}
+		for _, rule := range strings.Split(sf.Tag.Get("validate"), ",") {
+			key, value, _ := strings.Cut(rule, "=")
+			switch key {
+			case "required":
+				rules.required = true
+			case "min", "max":
+				n, err := strconv.Atoi(value)
+				if err != nil {
+					panic(fmt.Sprintf("schema: неверное правило %q поля %s.%s", rule, t.Name(), sf.Name))
+				}
+				if key == "min" {
+					rules.min = &n
+				} else {
+					rules.max = &n
+				}
+			case "oneof":
+				rules.oneOf = strings.Fields(value)
+				if len(rules.oneOf) == 0 {
+					panic(fmt.Sprintf("schema: пустое правило %q поля %s.%s", rule, t.Name(), sf.Name))
+				}
+			case "priority":
+				// Список берется из models, чтобы новый приоритет сразу попадал в проверку и схему
+				rules.oneOf = slices.Clone(models.Priorities)
+			case "":
+			default:
+				panic(fmt.Sprintf("schema: неизвестное правило %q поля %s.%s", rule, t.Name(), sf.Name))
+			}
+		}
+		fields = append(fields, rules)
+	}
+	return fields
+}
+
+// Generate строит JSON Schema для структуры v
+//
+// Args:
+//
+//	id: идентификатор схемы ($id), обычно адрес, по которому она публикуется
+//	title: название схемы
+//	v: значение или указатель на значение структуры
+//
+// Returns:
+//
+//	map[string]interface{}: схема, готовая к сериализации в JSON
+func Generate(id, title string, v interface{}) map[string]interface{} {
+	s := typeSchema(reflect.TypeOf(v))
+	s["$schema"] = Draft
+	s["$id"] = id
+	s["title"] = title
+	return s
+}
+
+// typeSchema строит схему для типа Go
+func typeSchema(t reflect.Type) map[string]interface{} {
+	if t.Kind() == reflect.Pointer {
+		s := typeSchema(t.Elem())
+		s["type"] = []interface{}{s["type"], "null"}
+		return s
+	}
+
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema строит схему объекта по полям структуры
+func structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := make([]string, 0)
+
+	for _, rules := range parseFields(t) {
+		fieldType := t.Field(rules.index).Type
+		prop := typeSchema(fieldType)
+
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		minKey, maxKey := boundKeys(fieldType)
+		if rules.required && fieldType.Kind() == reflect.String && rules.min == nil {
+			prop["minLength"] = 1
+		}
+		if rules.required && fieldType.Kind() == reflect.Slice && rules.min == nil {
+			prop["minItems"] = 1
+		}
+		if rules.min != nil && minKey != "" {
+			prop[minKey] = *rules.min
+		}
+		if rules.max != nil && maxKey != "" {
+			prop[maxKey] = *rules.max
+		}
+		if rules.oneOf != nil {
+			prop["enum"] = rules.oneOf
+		}
+
+		properties[rules.name] = prop
+		if rules.required {
+			required = append(required, rules.name)
+		}
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// boundKeys возвращает ключевые слова JSON Schema для правил min и max типа
+func boundKeys(t reflect.Type) (minKey, maxKey string) {
+	switch t.Kind() {
+	case reflect.String:
+		return "minLength", "maxLength"
+	case reflect.Slice, reflect.Array:
+		return "minItems", "maxItems"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "minimum", "maximum"
+	default:
+		return "", ""
+	}
+}
+
+// Validate проверяет значение структуры v по тегам validate
+//
+// Returns:
+//
+//	error: nil, если значение корректно, иначе Errors со списком нарушений
+func Validate(v interface{}) error {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	errs := validateStruct("", value)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateStruct проверяет поля структуры и вложенных структур
+//
+// Имена полей вложенных значений дополняются префиксом, например links[0].url.
+func validateStruct(prefix string, value reflect.Value) Errors {
+	var errs Errors
+	for _, rules := range parseFields(value.Type()) {
+		rules.name = prefix + rules.name
+		field := value.Field(rules.index)
+		errs = append(errs, validateField(rules, field)...)
+
+		// Элементы срезов структур проверяются по их собственным тегам
+		if field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Struct {
+			for i := 0; i < field.Len(); i++ {
+				errs = append(errs, validateStruct(fmt.Sprintf("%s[%d].", rules.name, i), field.Index(i))...)
+			}
+		}
+	}
+	return errs
+}
+
+// validateField проверяет одно поле по его правилам
+func validateField(rules fieldRules, value reflect.Value) Errors {
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			if rules.required {
+				return Errors{{Field: rules.name, Rule: "required", Message: fmt.Sprintf("поле %s обязательно", rules.name)}}
+			}
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	// Пустая необязательная строка означает отсутствие значения
+	if rules.oneOf != nil && value.Kind() == reflect.String && value.Len() > 0 {
+		if err := OneOf(rules.name, value.String(), rules.oneOf...); err != nil {
+			return Errors{*err}
+		}
+	}
+
+	var size float64
+	var unit string
+	switch value.Kind() {
+	case reflect.String:
+		size, unit = float64(utf8.RuneCountInString(value.String())), "длина поля %s должна"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		size, unit = float64(value.Len()), "число элементов поля %s должно"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size, unit = float64(value.Int()), "значение поля %s должно"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		size, unit = float64(value.Uint()), "значение поля %s должно"
+	case reflect.Float32, reflect.Float64:
+		size, unit = value.Float(), "значение поля %s должно"
+	default:
+		return nil
+	}
+
+	// Для чисел required означает только присутствие поля в схеме
+	isNumber := strings.HasPrefix(unit, "значение")
+	if rules.required && !isNumber && size == 0 {
+		return Errors{{Field: rules.name, Rule: "required", Message: fmt.Sprintf("поле %s обязательно", rules.name)}}
+	}
+	if rules.min != nil && size < float64(*rules.min) {
+		return Errors{{Field: rules.name, Rule: "min", Message: fmt.Sprintf(unit+" быть не меньше %d", rules.name, *rules.min)}}
+	}
+	if rules.max != nil && size > float64(*rules.max) {
+		return Errors{{Field: rules.name, Rule: "max", Message: fmt.Sprintf(unit+" быть не больше %d", rules.name, *rules.max)}}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"fmt"
+	"test/models"
+)
+
+// CreateTasks создает несколько задач одной операцией
+//
+// Задачи получают последовательные ID в порядке inputs и одинаковый момент создания;
+// другие записи не вклиниваются между ними.
+//
+// Args:
+//
+//	inputs: поля новых задач
+//
+// Returns:
+//
+//	[]*models.Task: созданные задачи в порядке inputs
+//	error: ошибка при создании задач, ErrStorageFull, если места нет для всех задач
+func (s *InMemoryStorage) CreateTasks(inputs []CreateTaskInput) ([]*models.Task, error) {
+	tasks, _, err := s.CreateTasksWithTokens(make([]string, len(inputs)), inputs)
+	return tasks, err
+}
+
+// CreateTasksWithTokens создает несколько задач одной операцией, как CreateTasks,
+// с защитой отдельных задач клиентскими токенами, как CreateTaskWithToken
+//
+// Задача, токен которой уже создал еще существующую задачу, заново не создается:
+// на ее месте возвращается исходная задача. Повтор токена внутри одного вызова
+// возвращает задачу, созданную первым элементом с этим токеном. Новые задачи
+// по-прежнему получают последовательные ID и создаются все или ни одна.
+//
+// Args:
+//
+//	tokens: клиентские токены в порядке inputs, пустая строка - задача без токена
+//	inputs: поля новых задач
+//
+// Returns:
+//
+//	[]*models.Task: созданные или ранее созданные задачи в порядке inputs
+//	[]bool: true для задач, созданных этим вызовом
+//	error: ошибка при создании задач, ErrStorageFull, если места нет для всех новых задач
+func (s *InMemoryStorage) CreateTasksWithTokens(tokens []string, inputs []CreateTaskInput) ([]*models.Task, []bool, error) {
+	// Блокировка на запись на все создание, чтобы задачи появились вместе
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.writable(); err != nil {
+		return nil, nil, err
+	}
+
+	// Сначала определяется, какие задачи создавать: хранилище не меняется, пока
+	// не зарезервировано место для всех новых задач
+	now := s.now()
+	tasks := make([]*models.Task, len(inputs))
+	created := make([]bool, len(inputs))
+	replayed := make(map[int]*models.Task)
+	first := make(map[string]int)
+	for i, token := range tokens {
+		if token != "" {
+			if id, exists := s.tokens.lookup(token, now); exists {
+				if task, err := s.find(id); err == nil {
+					tasks[i], replayed[id] = task, task
+					continue
+				}
+			}
+			if _, repeated := first[token]; repeated {
+				continue
+			}
+			first[token] = i
+		}
+		created[i] = true
+	}
+	if err := s.reserve(countTrue(created), replayed); err != nil {
+		return nil, nil, err
+	}
+
+	for i, input := range inputs {
+		if !created[i] {
+			continue
+		}
+		tasks[i] = newTask(input, now)
+		tasks[i].ID = s.allocateID()
+		s.tasks[tasks[i].ID] = tasks[i]
+		s.indexPriority(tasks[i])
+		s.indexTags(tasks[i])
+		if tokens[i] != "" {
+			s.tokens.remember(tokens[i], tasks[i].ID, now)
+		}
+	}
+	for i, token := range tokens {
+		if tasks[i] == nil {
+			tasks[i] = tasks[first[token]]
+		}
+	}
+	return tasks, created, nil
+}
+
+// countTrue возвращает число значений true в values
+func countTrue(values []bool) int {
+	n := 0
+	for _, value := range values {
+		if value {
+			n++
+		}
+	}
+	return n
+}
+
+// DeleteTasks мягко удаляет несколько задач одной операцией
+//
+// Все задачи удаляются под одной блокировкой на запись, как DeleteTask каждая.
+// Несуществующие, удаленные и истекшие задачи попадают в missing и удалению
+// остальных не мешают; повторный ID учитывается один раз. Защищенная задача
+// удаляется только по одной с подтверждением, поэтому при ней не удаляется ни
+// одна задача.
+//
+// Args:
+//
+//	ids: ID задач для удаления
+//
+// Returns:
+//
+//	deleted: ID удаленных задач в порядке ids
+//	missing: ID не найденных задач в порядке ids
+//	error: ErrTaskProtected с ID защищенной задачи
+func (s *InMemoryStorage) DeleteTasks(ids []int) (deleted []int, missing []int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.writable(); err != nil {
+		return nil, nil, err
+	}
+
+	var tasks []*models.Task
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		task, err := s.findCopy(id)
+		if err != nil {
+			missing = append(missing, id)
+			continue
+		}
+		if task.Protected {
+			return nil, nil, fmt.Errorf("задача %d: %w", id, ErrTaskProtected)
+		}
+		tasks = append(tasks, task)
+	}
+
+	now := s.now()
+	deletedAt := now.UTC()
+	for _, task := range tasks {
+		task.DeletedAt = &deletedAt
+		s.store(task)
+		s.tombstones.add(task.ID, now)
+		deleted = append(deleted, task.ID)
+	}
+	return deleted, missing, nil
+}
@@ -0,0 +1,305 @@
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"test/models"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Корзины и ключи хранилища bbolt
+var (
+	boltTasks      = []byte("tasks")         // Задачи по ID
+	boltMeta       = []byte("meta")          // Служебные значения хранилища
+	boltTombstones = []byte("tombstones")    // Моменты удаления задач по ID
+	boltTokens     = []byte("client_tokens") // Записи токенов создания по токену
+	boltLastID     = []byte("last_id")       // Последний выданный ID в корзине meta
+)
+
+// boltOpenTimeout - время ожидания блокировки файла, занятого другим процессом
+const boltOpenTimeout = 5 * time.Second
+
+// boltKey кодирует ID ключом корзины: big-endian сохраняет порядок ID при обходе
+func boltKey(id int) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(id))
+}
+
+// boltID восстанавливает ID из ключа boltKey
+func boltID(key []byte) (int, error) {
+	if len(key) != 8 {
+		return 0, fmt.Errorf("поврежденный ключ задачи %x", key)
+	}
+	return int(binary.BigEndian.Uint64(key)), nil
+}
+
+// boltToken - сохраняемая запись токена создания
+type boltToken struct {
+	TaskID    int       `json:"task_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BoltStorage реализует хранилище задач во встроенной базе данных bbolt
+//
+// Поддерживает те же возможности, что и InMemoryStorage, и принимает те же опции.
+// Задача хранится JSON-документом в корзине tasks под ключом ID в big-endian,
+// последний выданный ID - в корзине meta, поэтому ID не выдаются повторно
+// и после перезапуска. Файл открывает только один процесс: bbolt блокирует его.
+type BoltStorage struct {
+	*recordStorage
+	db *bolt.DB
+}
+
+// NewBoltStorage открывает базу данных bbolt и создает корзины, если их нет
+//
+// Args:
+//
+//	path: путь к файлу базы данных, создается при отсутствии
+//	opts: опции хранилища
+//
+// Returns:
+//
+//	*BoltStorage: хранилище задач
+//	error: ошибка открытия базы данных или создания корзин
+func NewBoltStorage(path string, opts ...Option) (*BoltStorage, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("открытие базы данных bbolt: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltTasks, boltMeta, boltTombstones, boltTokens} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("создание корзин bbolt: %w", err)
+	}
+	return &BoltStorage{recordStorage: newRecordStorage(boltBackend{db: db}, opts), db: db}, nil
+}
+
+// Close закрывает базу данных, см. Storage.Close
+func (s *BoltStorage) Close() error {
+	return s.shutdown(s.db.Close)
+}
+
+// boltBackend выполняет операции с записями в транзакциях bbolt
+type boltBackend struct {
+	db *bolt.DB
+}
+
+// view выполняет fn в транзакции только для чтения
+func (b boltBackend) view(ctx context.Context, fn func(tx recordTx) error) error {
+	return b.db.View(func(tx *bolt.Tx) error {
+		return fn(boltTx{ctx: ctx, tx: tx})
+	})
+}
+
+// update выполняет fn в транзакции на запись, откатывая ее при ошибке
+//
+// bbolt выполняет транзакции на запись по одной, поэтому проверка и изменение
+// задачи не пересекаются с другими записями.
+func (b boltBackend) update(ctx context.Context, fn func(tx recordTx) error) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return fn(boltTx{ctx: ctx, tx: tx})
+	})
+}
+
+// boltTx реализует recordTx в транзакции bbolt
+type boltTx struct {
+	ctx context.Context
+	tx  *bolt.Tx
+}
+
+func (t boltTx) task(id int) (*models.Task, bool, error) {
+	data := t.tx.Bucket(boltTasks).Get(boltKey(id))
+	if data == nil {
+		return nil, false, nil
+	}
+	task, err := decodeTask(data)
+	if err != nil {
+		return nil, false, err
+	}
+	task.ID = id
+	return task, true, nil
+}
+
+func (t boltTx) eachTask(fn func(*models.Task) error) error {
+	cursor := t.tx.Bucket(boltTasks).Cursor()
+	for key, data := cursor.First(); key != nil; key, data = cursor.Next() {
+		if err := t.ctx.Err(); err != nil {
+			return err
+		}
+		id, err := boltID(key)
+		if err != nil {
+			return err
+		}
+		task, err := decodeTask(data)
+		if err != nil {
+			return err
+		}
+		task.ID = id
+		if err := fn(task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t boltTx) insertTask(task *models.Task) error {
+	lastID, err := t.lastID()
+	if err != nil {
+		return err
+	}
+	task.ID = lastID + 1
+	if err := t.setLastID(task.ID); err != nil {
+		return err
+	}
+	return t.updateTask(task)
+}
+
+func (t boltTx) updateTask(task *models.Task) error {
+	data, err := encodeTask(task)
+	if err != nil {
+		return err
+	}
+	return t.tx.Bucket(boltTasks).Put(boltKey(task.ID), data)
+}
+
+func (t boltTx) deleteTask(id int) error {
+	return t.tx.Bucket(boltTasks).Delete(boltKey(id))
+}
+
+func (t boltTx) tombstone(id int) (time.Time, bool, error) {
+	data := t.tx.Bucket(boltTombstones).Get(boltKey(id))
+	if data == nil {
+		return time.Time{}, false, nil
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(data))), true, nil
+}
+
+func (t boltTx) putTombstone(id int, deletedAt time.Time) error {
+	return t.tx.Bucket(boltTombstones).Put(boltKey(id), binary.BigEndian.AppendUint64(nil, uint64(deletedAt.UnixNano())))
+}
+
+func (t boltTx) pruneTombstones(before time.Time, keep int) error {
+	type entry struct {
+		key       []byte
+		deletedAt time.Time
+	}
+	var entries []entry
+	bucket := t.tx.Bucket(boltTombstones)
+	err := bucket.ForEach(func(key, data []byte) error {
+		deletedAt := time.Unix(0, int64(binary.BigEndian.Uint64(data)))
+		entries = append(entries, entry{key: append([]byte(nil), key...), deletedAt: deletedAt})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].deletedAt.Before(entries[j].deletedAt) })
+	extra := len(entries) - max(keep, 0)
+	for i, e := range entries {
+		if i >= extra && e.deletedAt.After(before) {
+			break
+		}
+		if err := bucket.Delete(e.key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t boltTx) clientToken(token string) (int, time.Time, bool, error) {
+	data := t.tx.Bucket(boltTokens).Get([]byte(token))
+	if data == nil {
+		return 0, time.Time{}, false, nil
+	}
+	var record boltToken
+	if err := json.Unmarshal(data, &record); err != nil {
+		return 0, time.Time{}, false, fmt.Errorf("поврежденная запись токена: %w", err)
+	}
+	return record.TaskID, record.CreatedAt, true, nil
+}
+
+func (t boltTx) putClientToken(token string, id int, createdAt time.Time) error {
+	data, err := json.Marshal(boltToken{TaskID: id, CreatedAt: createdAt})
+	if err != nil {
+		return err
+	}
+	return t.tx.Bucket(boltTokens).Put([]byte(token), data)
+}
+
+func (t boltTx) deleteClientToken(token string) error {
+	return t.tx.Bucket(boltTokens).Delete([]byte(token))
+}
+
+func (t boltTx) pruneClientTokens(before time.Time, keep int) error {
+	type entry struct {
+		token     []byte
+		createdAt time.Time
+	}
+	var entries []entry
+	bucket := t.tx.Bucket(boltTokens)
+	err := bucket.ForEach(func(token, data []byte) error {
+		var record boltToken
+		if err := json.Unmarshal(data, &record); err != nil {
+			return fmt.Errorf("поврежденная запись токена: %w", err)
+		}
+		entries = append(entries, entry{token: append([]byte(nil), token...), createdAt: record.CreatedAt})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].createdAt.Before(entries[j].createdAt) })
+	extra := len(entries) - max(keep, 0)
+	for i, e := range entries {
+		if i >= extra && e.createdAt.After(before) {
+			break
+		}
+		if err := bucket.Delete(e.token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t boltTx) countClientTokens() (int, error) {
+	count := 0
+	cursor := t.tx.Bucket(boltTokens).Cursor()
+	for key, _ := cursor.First(); key != nil; key, _ = cursor.Next() {
+		count++
+	}
+	return count, nil
+}
+
+func (t boltTx) clearClientTokens() error {
+	if err := t.tx.DeleteBucket(boltTokens); err != nil {
+		return err
+	}
+	_, err := t.tx.CreateBucket(boltTokens)
+	return err
+}
+
+func (t boltTx) lastID() (int, error) {
+	data := t.tx.Bucket(boltMeta).Get(boltLastID)
+	if data == nil {
+		return 0, nil
+	}
+	return int(binary.BigEndian.Uint64(data)), nil
+}
+
+func (t boltTx) setLastID(id int) error {
+	return t.tx.Bucket(boltMeta).Put(boltLastID, boltKey(id))
+}
@@ -0,0 +1,53 @@
+package storage
+
+// ClientTokenCache - имя кэша клиентских токенов создания задач
+const ClientTokenCache = "client_tokens"
+
+// CacheStats описывает состояние служебного кэша хранилища
+type CacheStats struct {
+	Name     string `json:"name"`     // Имя кэша
+	Size     int    `json:"size"`     // Текущее число записей
+	Capacity int    `json:"capacity"` // Максимальное число записей
+	Hits     uint64 `json:"hits"`     // Число найденных записей
+	Misses   uint64 `json:"misses"`   // Число промахов
+	Flushes  uint64 `json:"flushes"`  // Число очисток
+}
+
+// Caches возвращает состояние служебных кэшей хранилища
+//
+// Returns:
+//
+//	[]CacheStats: состояние каждого кэша
+func (s *InMemoryStorage) Caches() []CacheStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return []CacheStats{s.tokens.stats()}
+}
+
+// FlushCache очищает служебный кэш по имени
+//
+// Очистка выполняется под блокировкой на запись, поэтому операции, уже проверившие
+// или сохранившие запись кэша, завершаются с ней, а следующие видят пустой кэш.
+// Счетчики обращений при очистке не сбрасываются.
+//
+// Args:
+//
+//	name: имя кэша
+//
+// Returns:
+//
+//	CacheStats: состояние кэша после очистки
+//	error: ErrCacheNotFound для неизвестного имени
+func (s *InMemoryStorage) FlushCache(name string) (CacheStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch name {
+	case ClientTokenCache:
+		s.tokens.flush()
+		return s.tokens.stats(), nil
+	default:
+		return CacheStats{}, ErrCacheNotFound
+	}
+}
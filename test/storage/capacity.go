@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"sort"
+	"test/models"
+)
+
+// EvictionPolicy определяет поведение хранилища с лимитом задач при его достижении
+type EvictionPolicy int
+
+const (
+	// RejectNew - создание задачи сверх лимита возвращает ErrStorageFull
+	RejectNew EvictionPolicy = iota
+
+	// EvictOldestCompleted - для новой задачи удаляется выполненная задача с наименьшим ID;
+	// если выполненных задач не хватает, создание возвращает ErrStorageFull
+	EvictOldestCompleted
+)
+
+// NewInMemoryStorageWithLimit создает хранилище задач в памяти, хранящее не больше maxTasks задач
+//
+// Лимит учитывает все хранимые задачи, в том числе мягко удаленные. Проверка
+// лимита и вытеснение выполняются под той же блокировкой на запись, что и
+// создание, поэтому конкурентные запросы не могут превысить лимит. Вытесненная
+// задача удаляется без записи об удалении, как будто ее не было.
+//
+// Args:
+//
+//	maxTasks: максимальное число задач, 0 - без лимита
+//	policy: поведение при достижении лимита
+//	opts: опции хранилища
+//
+// Returns:
+//
+//	*InMemoryStorage: хранилище с лимитом задач
+func NewInMemoryStorageWithLimit(maxTasks int, policy EvictionPolicy, opts ...Option) *InMemoryStorage {
+	s := NewInMemoryStorage(opts...)
+	s.maxTasks = maxTasks
+	s.eviction = policy
+	return s
+}
+
+// reserve освобождает место для n новых задач, вызывается под блокировкой на запись
+//
+// При нехватке места ничего не удаляется: либо места хватает на все n задач,
+// либо возвращается ErrStorageFull. Задачи из keep не вытесняются.
+func (s *InMemoryStorage) reserve(n int, keep map[int]*models.Task) error {
+	excess := len(s.tasks) + n - s.maxTasks
+	if s.maxTasks == 0 || excess <= 0 {
+		return nil
+	}
+	if s.eviction != EvictOldestCompleted {
+		return ErrStorageFull
+	}
+
+	var completed []*models.Task
+	for _, task := range s.tasks {
+		if _, kept := keep[task.ID]; task.Completed && !kept {
+			completed = append(completed, task)
+		}
+	}
+	if len(completed) < excess {
+		return ErrStorageFull
+	}
+	sort.Slice(completed, func(i, j int) bool { return completed[i].ID < completed[j].ID })
+	for _, task := range completed[:excess] {
+		delete(s.tasks, task.ID)
+		s.removeFromPriority(task.ID, task.Priority)
+		s.removeFromTags(task.ID, task.Tags)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"sync"
+)
+
+// Close закрывает хранилище: останавливает фоновое удаление истекших задач,
+// после чего изменения задач возвращают ErrStorageClosed
+//
+// Задачи остаются в памяти и доступны для чтения, например для SaveSnapshot.
+// Повторный вызов ничего не делает.
+//
+// Returns:
+//
+//	error: всегда nil
+func (s *InMemoryStorage) Close() error {
+	s.Stop()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+// writable возвращает ErrStorageClosed после Close, вызывается под блокировкой на запись
+func (s *InMemoryStorage) writable() error {
+	if s.closed {
+		return ErrStorageClosed
+	}
+	return nil
+}
+
+// Close закрывает хранилище, см. InMemoryStorage.Close
+func (s *ShardedStorage) Close() error {
+	s.closed.Store(true)
+	return nil
+}
+
+// closableBackend - recordBackend, отклоняющий операции после закрытия хранилища
+//
+// Операции выполняются под блокировкой на чтение, а закрытие - под блокировкой
+// на запись, поэтому close дожидается выполняющихся операций и освобождает
+// ресурсы, только когда ими уже никто не пользуется.
+type closableBackend struct {
+	recordBackend
+	mu     sync.RWMutex
+	closed bool
+}
+
+// view выполняет fn только для чтения, если хранилище не закрыто и ctx не отменен
+func (b *closableBackend) view(ctx context.Context, fn func(tx recordTx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return b.run(func() error { return b.recordBackend.view(ctx, fn) })
+}
+
+// update выполняет fn в транзакции на запись, если хранилище не закрыто и ctx не отменен
+func (b *closableBackend) update(ctx context.Context, fn func(tx recordTx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return b.run(func() error { return b.recordBackend.update(ctx, fn) })
+}
+
+// run выполняет op или возвращает ErrStorageClosed, если хранилище закрыто
+func (b *closableBackend) run(op func() error) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return ErrStorageClosed
+	}
+	return op()
+}
+
+// close отмечает хранилище закрытым и один раз вызывает release
+func (b *closableBackend) close(release func() error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	return release()
+}
+
+// shutdown закрывает хранилище и освобождает его ресурсы функцией release,
+// см. Storage.Close
+func (s *recordStorage) shutdown(release func() error) error {
+	return s.backend.close(release)
+}
@@ -0,0 +1,66 @@
+package storage
+
+// NextID возвращает ID, который получит следующая созданная задача
+//
+// Returns:
+//
+//	int: следующий ID
+//	error: всегда nil
+func (s *InMemoryStorage) NextID() (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.nextFreeID(), nil
+}
+
+// SetLastID задает последний выданный ID, см. CounterStorage
+//
+// Args:
+//
+//	id: последний выданный ID, не меньше текущего
+//
+// Returns:
+//
+//	error: ErrLastIDDecrease, если id меньше текущего значения
+func (s *InMemoryStorage) SetLastID(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.writable(); err != nil {
+		return err
+	}
+
+	if id < s.lastID {
+		return ErrLastIDDecrease
+	}
+	s.lastID = id
+	return nil
+}
+
+// reserveID отмечает ID задачи, заданный не счетчиком, как выданный, вызывается
+// под блокировкой на запись
+//
+// Счетчик поднимается до max(счетчик, explicit), и созданные после этого задачи
+// explicit не получат. Через reserveID должен проходить любой путь, добавляющий
+// задачу с уже известным ID; сейчас это только LoadSnapshot.
+func (s *InMemoryStorage) reserveID(explicit int) {
+	s.lastID = max(s.lastID, explicit)
+}
+
+// allocateID выдает новый ID, вызывается под блокировкой на запись
+//
+// ID, уже занятые задачами в хранилище, пропускаются на случай, если счетчик
+// отстал от задач: повторная выдача ID перезаписала бы существующую задачу.
+func (s *InMemoryStorage) allocateID() int {
+	s.lastID = s.nextFreeID()
+	return s.lastID
+}
+
+// nextFreeID возвращает первый свободный ID после счетчика, вызывается под блокировкой
+func (s *InMemoryStorage) nextFreeID() int {
+	id := s.lastID + 1
+	for {
+		if _, exists := s.tasks[id]; !exists {
+			return id
+		}
+		id++
+	}
+}
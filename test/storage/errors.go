@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrTaskNotFound возвращается при обращении к несуществующей задаче, обернутой с ее ID,
+	// см. taskNotFound; недавно удаленная задача возвращает *TaskDeletedError
+	ErrTaskNotFound = errors.New("задача не найдена")
+
+	// ErrTooManyLinks возвращается при попытке превысить models.MaxLinks ссылок у задачи
+	ErrTooManyLinks = errors.New("превышено максимальное число ссылок задачи")
+
+	// ErrDuplicateLink возвращается при добавлении ссылки, уже имеющейся у задачи
+	ErrDuplicateLink = errors.New("ссылка уже добавлена к задаче")
+
+	// ErrLinkNotFound возвращается при обращении к несуществующему индексу ссылки
+	ErrLinkNotFound = errors.New("ссылка не найдена")
+
+	// ErrCacheNotFound возвращается при обращении к неизвестному служебному кэшу
+	ErrCacheNotFound = errors.New("кэш не найден")
+
+	// ErrTaskCompletedImmutable возвращается при изменении выполненной задачи,
+	// если хранилище создано с WithCompletedImmutable
+	ErrTaskCompletedImmutable = errors.New("выполненную задачу можно только возобновить")
+
+	// ErrTaskAlreadyCompleted возвращается при попытке завершить уже выполненную задачу
+	ErrTaskAlreadyCompleted = errors.New("задача уже выполнена")
+
+	// ErrMetadataInvalid возвращается для неверного имени пространства метаданных или некорректного JSON
+	ErrMetadataInvalid = errors.New("неверное пространство метаданных или значение не является JSON")
+
+	// ErrMetadataTooLarge возвращается, если значение метаданных превышает допустимый размер
+	ErrMetadataTooLarge = errors.New("значение метаданных превышает допустимый размер")
+
+	// ErrMetadataNotFound возвращается при обращении к отсутствующему пространству метаданных
+	ErrMetadataNotFound = errors.New("пространство метаданных не найдено")
+
+	// ErrLastIDDecrease возвращается при попытке задать последний выданный ID меньше текущего
+	ErrLastIDDecrease = errors.New("последний выданный ID нельзя уменьшить")
+
+	// ErrInvalidPatch возвращается для неизвестного поля или значения неверного типа в PatchTask
+	ErrInvalidPatch = errors.New("неверное поле частичного обновления")
+
+	// ErrTaskProtected возвращается при удалении защищенной задачи без подтверждения
+	ErrTaskProtected = errors.New("задача защищена от удаления, требуется подтверждение")
+
+	// ErrTaskNotDeleted возвращается при восстановлении задачи, которая не удалена
+	ErrTaskNotDeleted = errors.New("задача не удалена")
+
+	// ErrStorageFull возвращается при создании задачи в хранилище, достигшем лимита задач
+	ErrStorageFull = errors.New("хранилище заполнено: достигнут лимит задач")
+
+	// ErrStorageClosed возвращается при изменении задач в хранилище после Close
+	ErrStorageClosed = errors.New("хранилище закрыто")
+)
+
+// taskNotFound возвращает ErrTaskNotFound с ID задачи
+func taskNotFound(id int) error {
+	return fmt.Errorf("%w: ID %d", ErrTaskNotFound, id)
+}
+
+// TaskDeletedError возвращается при обращении к недавно удаленной задаче
+//
+// Хранилище помнит удаления в течение DefaultTombstoneTTL (см. WithTombstoneTTL),
+// после чего задача считается просто не найденной. Мягко удаленную задачу можно
+// восстановить и позже, см. SoftDeleteStorage. ID удаленных задач повторно не выдаются.
+type TaskDeletedError struct {
+	ID        int       // ID удаленной задачи
+	DeletedAt time.Time // Момент удаления
+}
+
+func (e *TaskDeletedError) Error() string {
+	return fmt.Sprintf("задача с ID %d удалена %s", e.ID, e.DeletedAt.UTC().Format(time.RFC3339))
+}
+
+// VersionConflictError возвращается при обновлении задачи, версия которой
+// отличается от версии, которую видел клиент, см. VersionedStorage
+type VersionConflictError struct {
+	ID      int // ID задачи
+	Version int // Текущая версия задачи
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("задача с ID %d изменена другим клиентом, текущая версия %d", e.ID, e.Version)
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"test/models"
+	"time"
+)
+
+// Start запускает фоновое удаление истекших задач
+//
+// Каждые WithExpiryInterval (по умолчанию DefaultExpiryInterval) вызывается
+// PurgeExpired. Удаление работает до Stop или отмены ctx; повторный Start
+// до Stop ничего не делает. Истекшие задачи не видны и без фонового удаления,
+// оно только освобождает память.
+//
+// Args:
+//
+//	ctx: контекст, при отмене которого удаление останавливается
+func (s *InMemoryStorage) Start(ctx context.Context) {
+	s.expiryMu.Lock()
+	defer s.expiryMu.Unlock()
+	if s.stopExpiry != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	s.stopExpiry = func() {
+		cancel()
+		<-done
+	}
+	go func() {
+		defer close(done)
+		interval := s.expiryInterval
+		if interval <= 0 {
+			interval = DefaultExpiryInterval
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.PurgeExpired()
+			}
+		}
+	}()
+}
+
+// Stop останавливает фоновое удаление истекших задач и дожидается его завершения
+//
+// Stop без Start и повторный Stop ничего не делают. После Stop удаление можно
+// снова запустить Start.
+func (s *InMemoryStorage) Stop() {
+	s.expiryMu.Lock()
+	stop := s.stopExpiry
+	s.stopExpiry = nil
+	s.expiryMu.Unlock()
+
+	if stop != nil {
+		stop()
+	}
+}
+
+// PurgeExpired окончательно удаляет истекшие задачи, в том числе мягко удаленные
+//
+// Запись об удалении для истекших задач не создается: обращение к ним
+// возвращает ErrTaskNotFound, а не *TaskDeletedError.
+//
+// Returns:
+//
+//	int: число удаленных задач
+//	error: ошибка при удалении задач
+func (s *InMemoryStorage) PurgeExpired() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.writable(); err != nil {
+		return 0, err
+	}
+
+	now := s.now()
+	purged := 0
+	for id, task := range s.tasks {
+		if task.Expired(now) {
+			s.removeFromPriority(id, task.Priority)
+			s.removeFromTags(id, task.Tags)
+			delete(s.tasks, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// visible проверяет, что задача не удалена и не истекла к моменту now
+func visible(task *models.Task, now time.Time) bool {
+	return task.DeletedAt == nil && !task.Expired(now)
+}
+
+// expiresAt возвращает момент истечения новой задачи в UTC, созданной в момент now
+func expiresAt(input CreateTaskInput, now time.Time) *time.Time {
+	switch {
+	case input.ExpiresAt != nil:
+		utc := input.ExpiresAt.UTC()
+		return &utc
+	case input.ExpiresIn > 0:
+		utc := now.Add(input.ExpiresIn).UTC()
+		return &utc
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,344 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"test/models"
+	"time"
+)
+
+// fileState - содержимое файла хранилища
+//
+// Задачи хранятся в том же виде, что и в остальных хранилищах (см. encodeTask),
+// поэтому метаданные интеграций возвращаются байт в байт.
+type fileState struct {
+	LastID     int                       `json:"last_id"`
+	Tasks      map[int]json.RawMessage   `json:"tasks"`
+	Tombstones map[int]time.Time         `json:"tombstones"`
+	Tokens     map[string]fileTokenEntry `json:"client_tokens"`
+}
+
+// fileTokenEntry - сохраняемая запись токена создания
+type fileTokenEntry struct {
+	TaskID    int       `json:"task_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// newFileState возвращает пустое состояние
+func newFileState() *fileState {
+	return &fileState{
+		Tasks:      map[int]json.RawMessage{},
+		Tombstones: map[int]time.Time{},
+		Tokens:     map[string]fileTokenEntry{},
+	}
+}
+
+// clone возвращает копию состояния для транзакции на запись
+//
+// Сериализованные задачи не изменяются на месте, а заменяются целиком,
+// поэтому копируются только карты.
+func (s *fileState) clone() *fileState {
+	return &fileState{
+		LastID:     s.LastID,
+		Tasks:      maps.Clone(s.Tasks),
+		Tombstones: maps.Clone(s.Tombstones),
+		Tokens:     maps.Clone(s.Tokens),
+	}
+}
+
+// FileStorage реализует хранилище задач в памяти с сохранением в JSON-файл
+//
+// Поддерживает те же возможности, что и InMemoryStorage, и принимает те же опции.
+// Файл перезаписывается после каждого изменения до ответа клиенту: новое состояние
+// пишется во временный файл рядом и заменяет прежний переименованием, поэтому
+// сбой во время записи оставляет прежний файл целым. Последний выданный ID
+// сохраняется вместе с задачами, и после перезапуска нумерация продолжается.
+// Файл должен использоваться одним процессом.
+type FileStorage struct {
+	*recordStorage
+}
+
+// NewFileStorage загружает хранилище из файла
+//
+// Отсутствующий файл означает пустое хранилище. Пустой или поврежденный файл
+// тоже дает пустое хранилище с записью в журнал; поврежденный файл перед этим
+// переименовывается в <path>.corrupt-<время>, чтобы первая запись не уничтожила
+// данные, которые еще можно восстановить вручную.
+//
+// Args:
+//
+//	path: путь к JSON-файлу, создается при первом изменении
+//	opts: опции хранилища
+//
+// Returns:
+//
+//	*FileStorage: хранилище задач
+//	error: ошибка чтения файла или переименования поврежденного файла
+func NewFileStorage(path string, opts ...Option) (*FileStorage, error) {
+	state, err := loadFileState(path)
+	if err != nil {
+		return nil, err
+	}
+	backend := &fileBackend{path: path, state: state}
+	return &FileStorage{recordStorage: newRecordStorage(backend, opts)}, nil
+}
+
+// Close закрывает хранилище, см. Storage.Close
+//
+// Файл перезаписывается после каждого изменения, поэтому Close ничего не
+// дописывает, а только дожидается выполняющихся записей.
+func (s *FileStorage) Close() error {
+	return s.shutdown(func() error { return nil })
+}
+
+// loadFileState читает состояние из файла, см. NewFileStorage
+func loadFileState(path string) (*fileState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return newFileState(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("чтение файла хранилища: %w", err)
+	}
+	if len(data) == 0 {
+		log.Printf("файл хранилища %s пуст, хранилище начато заново", path)
+		return newFileState(), nil
+	}
+
+	state := newFileState()
+	if err := json.Unmarshal(data, state); err != nil {
+		backup := fmt.Sprintf("%s.corrupt-%d", path, time.Now().UnixNano())
+		if err := os.Rename(path, backup); err != nil {
+			return nil, fmt.Errorf("перенос поврежденного файла хранилища: %w", err)
+		}
+		log.Printf("файл хранилища %s поврежден (%v), он сохранен как %s, хранилище начато заново", path, err, backup)
+		return newFileState(), nil
+	}
+	return state, nil
+}
+
+// fileBackend хранит состояние в памяти и сохраняет его в файл после каждой записи
+type fileBackend struct {
+	path    string
+	writeMu sync.Mutex   // Выполняет транзакции на запись по одной
+	mu      sync.RWMutex // Защищает подмену state
+	state   *fileState   // Сохраненное состояние; не изменяется после публикации
+}
+
+// view выполняет fn над последним сохраненным состоянием
+//
+// Состояние не изменяется после публикации, поэтому чтение не ждет записи файла.
+func (b *fileBackend) view(ctx context.Context, fn func(tx recordTx) error) error {
+	b.mu.RLock()
+	state := b.state
+	b.mu.RUnlock()
+	return fn(fileTx{ctx: ctx, state: state})
+}
+
+// update выполняет fn над копией состояния и публикует ее, только если fn
+// вернула nil и копия записана в файл
+func (b *fileBackend) update(ctx context.Context, fn func(tx recordTx) error) error {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	b.mu.RLock()
+	state := b.state.clone()
+	b.mu.RUnlock()
+
+	if err := fn(fileTx{ctx: ctx, state: state}); err != nil {
+		return err
+	}
+	if err := b.save(state); err != nil {
+		return fmt.Errorf("запись файла хранилища: %w", err)
+	}
+
+	b.mu.Lock()
+	b.state = state
+	b.mu.Unlock()
+	return nil
+}
+
+// save атомарно заменяет файл хранилища состоянием state
+func (b *fileBackend) save(state *fileState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(b.path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(b.path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), b.path); err != nil {
+		return err
+	}
+
+	// Синхронизация каталога сохраняет само переименование; не везде поддерживается
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// fileTx реализует recordTx над состоянием в памяти
+type fileTx struct {
+	ctx   context.Context
+	state *fileState
+}
+
+func (t fileTx) task(id int) (*models.Task, bool, error) {
+	data, exists := t.state.Tasks[id]
+	if !exists {
+		return nil, false, nil
+	}
+	task, err := decodeTask(data)
+	if err != nil {
+		return nil, false, err
+	}
+	task.ID = id
+	return task, true, nil
+}
+
+func (t fileTx) eachTask(fn func(*models.Task) error) error {
+	ids := make([]int, 0, len(t.state.Tasks))
+	for id := range t.state.Tasks {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		if err := t.ctx.Err(); err != nil {
+			return err
+		}
+		task, _, err := t.task(id)
+		if err != nil {
+			return err
+		}
+		if err := fn(task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t fileTx) insertTask(task *models.Task) error {
+	t.state.LastID++
+	task.ID = t.state.LastID
+	return t.updateTask(task)
+}
+
+func (t fileTx) updateTask(task *models.Task) error {
+	data, err := encodeTask(task)
+	if err != nil {
+		return err
+	}
+	t.state.Tasks[task.ID] = data
+	return nil
+}
+
+func (t fileTx) deleteTask(id int) error {
+	delete(t.state.Tasks, id)
+	return nil
+}
+
+func (t fileTx) tombstone(id int) (time.Time, bool, error) {
+	deletedAt, exists := t.state.Tombstones[id]
+	return deletedAt, exists, nil
+}
+
+func (t fileTx) putTombstone(id int, deletedAt time.Time) error {
+	t.state.Tombstones[id] = deletedAt
+	return nil
+}
+
+func (t fileTx) pruneTombstones(before time.Time, keep int) error {
+	ids := make([]int, 0, len(t.state.Tombstones))
+	for id := range t.state.Tombstones {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return t.state.Tombstones[ids[i]].Before(t.state.Tombstones[ids[j]]) })
+
+	extra := len(ids) - max(keep, 0)
+	for i, id := range ids {
+		if i >= extra && t.state.Tombstones[id].After(before) {
+			break
+		}
+		delete(t.state.Tombstones, id)
+	}
+	return nil
+}
+
+func (t fileTx) clientToken(token string) (int, time.Time, bool, error) {
+	entry, exists := t.state.Tokens[token]
+	return entry.TaskID, entry.CreatedAt, exists, nil
+}
+
+func (t fileTx) putClientToken(token string, id int, createdAt time.Time) error {
+	t.state.Tokens[token] = fileTokenEntry{TaskID: id, CreatedAt: createdAt}
+	return nil
+}
+
+func (t fileTx) deleteClientToken(token string) error {
+	delete(t.state.Tokens, token)
+	return nil
+}
+
+func (t fileTx) pruneClientTokens(before time.Time, keep int) error {
+	tokens := make([]string, 0, len(t.state.Tokens))
+	for token := range t.state.Tokens {
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return t.state.Tokens[tokens[i]].CreatedAt.Before(t.state.Tokens[tokens[j]].CreatedAt)
+	})
+
+	extra := len(tokens) - max(keep, 0)
+	for i, token := range tokens {
+		if i >= extra && t.state.Tokens[token].CreatedAt.After(before) {
+			break
+		}
+		delete(t.state.Tokens, token)
+	}
+	return nil
+}
+
+func (t fileTx) countClientTokens() (int, error) {
+	return len(t.state.Tokens), nil
+}
+
+func (t fileTx) clearClientTokens() error {
+	clear(t.state.Tokens)
+	return nil
+}
+
+func (t fileTx) lastID() (int, error) {
+	return t.state.LastID, nil
+}
+
+func (t fileTx) setLastID(id int) error {
+	t.state.LastID = id
+	return nil
+}
@@ -0,0 +1,442 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"test/models"
+	"time"
+)
+
+// Storage - хранилище задач, с которым работают обработчики HTTP
+//
+// Интерфейс содержит только основные операции с задачами, поэтому его легко
+// реализовать поверх базы данных или подменить в тестах. Дополнительные
+// возможности (клиентские токены, ссылки, метаданные и т.д.) описаны отдельными
+// интерфейсами ниже: обработчики проверяют их наличие у хранилища и сообщают
+// о недоступных возможностях через GET /capabilities.
+//
+// Основные операции принимают контекст запроса: отмененный контекст или
+// истекший срок прерывает операцию с ошибкой ctx.Err(), а хранилища поверх баз
+// данных передают его в запросы к базе.
+type Storage interface {
+	CreateTask(ctx context.Context, input CreateTaskInput) (*models.Task, error)
+	GetAllTasks(ctx context.Context) ([]*models.Task, error)
+	GetTask(ctx context.Context, id int) (*models.Task, error)
+	UpdateTask(ctx context.Context, id int, input UpdateTaskInput) (*models.Task, error)
+	DeleteTask(ctx context.Context, id int) error
+
+	// Close освобождает ресурсы хранилища; после него изменения задач возвращают
+	// ErrStorageClosed, а у хранилищ с файлом или базой данных - и чтение.
+	// Повторный вызов ничего не делает и возвращает nil
+	Close() error
+}
+
+// TaskStorage - прежнее название Storage
+//
+// Deprecated: используйте Storage.
+type TaskStorage = Storage
+
+// TokenStorage - хранилище с защитой создания задач клиентским токеном
+type TokenStorage interface {
+	CreateTaskWithToken(token string, input CreateTaskInput) (*models.Task, bool, error)
+}
+
+// StreamingStorage - хранилище, передающее список задач по одной без сбора в срез
+type StreamingStorage interface {
+	ListTasksFunc(ctx context.Context, fn func(*models.Task) error) error
+}
+
+// PatchStorage - хранилище с частичным обновлением задач
+type PatchStorage interface {
+	PatchTask(id int, patch map[string]interface{}) (*models.Task, error)
+}
+
+// ToggleStorage - хранилище, инвертирующее отметку о выполнении задачи одной операцией
+type ToggleStorage interface {
+	ToggleTask(id int) (*models.Task, error)
+}
+
+// ProtectedStorage - хранилище с защитой задач от удаления без подтверждения
+type ProtectedStorage interface {
+	DeleteTaskConfirmed(id int) error
+}
+
+// CompletionPolicyStorage - хранилище, сообщающее политику изменения выполненных задач
+type CompletionPolicyStorage interface {
+	CompletedImmutable() bool
+}
+
+// FollowUpStorage - хранилище с завершением задачи и созданием продолжения одной операцией
+type FollowUpStorage interface {
+	CompleteWithFollowUp(id int, input CreateTaskInput) (*models.Task, *models.Task, error)
+}
+
+// LinkStorage - хранилище с изменением отдельных ссылок задачи
+type LinkStorage interface {
+	AddLink(id int, link models.Link) (*models.Task, error)
+	RemoveLink(id, index int) (*models.Task, error)
+}
+
+// MetadataStorage - хранилище метаданных интеграций задач
+type MetadataStorage interface {
+	MetadataLimit() int
+	GetMetadata(id int) (map[string]json.RawMessage, error)
+	SetMetadata(id int, namespace string, value json.RawMessage) error
+	DeleteMetadata(id int, namespace string) error
+}
+
+// TombstoneStorage - хранилище, отличающее недавно удаленные задачи от несуществующих
+// (*TaskDeletedError) в течение TombstoneTTL
+type TombstoneStorage interface {
+	TombstoneTTL() time.Duration
+}
+
+// CacheStorage - хранилище со служебными кэшами, доступными через /admin/caches
+type CacheStorage interface {
+	Caches() []CacheStats
+	FlushCache(name string) (CacheStats, error)
+}
+
+// BatchStorage - хранилище, создающее и удаляющее несколько задач одной атомарной операцией
+type BatchStorage interface {
+	CreateTasks(inputs []CreateTaskInput) ([]*models.Task, error)
+	DeleteTasks(ids []int) (deleted []int, missing []int, err error)
+}
+
+// TokenBatchStorage - хранилище, создающее несколько задач одной атомарной операцией
+// с защитой отдельных задач клиентскими токенами, см. InMemoryStorage.CreateTasksWithTokens
+type TokenBatchStorage interface {
+	CreateTasksWithTokens(tokens []string, inputs []CreateTaskInput) ([]*models.Task, []bool, error)
+}
+
+// PaginatedStorage - хранилище, отдающее список задач страницами вместе с общим числом задач
+type PaginatedStorage interface {
+	GetTasksPaginated(offset, limit int) ([]*models.Task, int, error)
+}
+
+// SoftDeleteStorage - хранилище, которое удаляет задачи мягко и может их восстановить
+//
+// PurgeTask окончательно удаляет мягко удаленную задачу: восстановить ее уже
+// нельзя, а для неудаленной задачи возвращается ErrTaskNotDeleted.
+type SoftDeleteStorage interface {
+	RestoreTask(id int) error
+	PurgeTask(id int) error
+	GetAllTasksWithDeleted() ([]*models.Task, error)
+}
+
+// PriorityStorage - хранилище, отбирающее задачи по приоритету
+type PriorityStorage interface {
+	TasksByPriority(priority string) ([]*models.Task, error)
+}
+
+// TagStorage - хранилище, отбирающее задачи, у которых есть все заданные теги
+type TagStorage interface {
+	TasksByTags(tags []string) ([]*models.Task, error)
+}
+
+// OverdueStorage - хранилище, отбирающее просроченные задачи
+type OverdueStorage interface {
+	GetOverdueTasks() ([]*models.Task, error)
+}
+
+// SearchStorage - хранилище, ищущее задачи по подстроке в названии или описании
+type SearchStorage interface {
+	SearchTasks(query string) ([]*models.Task, error)
+}
+
+// PreferenceStorage - хранилище настроек по умолчанию для новых задач
+type PreferenceStorage interface {
+	GetPreferences() (models.Preferences, error)
+	SetPreferences(preferences models.Preferences) error
+}
+
+// CounterStorage - хранилище, позволяющее узнать и поднять счетчик ID задач
+//
+// SetLastID нужен, когда задачи с ID до id уже выданы вне хранилища (например,
+// перенесены в архив): новые задачи получат ID больше id. Уменьшить счетчик
+// нельзя, задание текущего значения ничего не меняет.
+type CounterStorage interface {
+	NextID() (int, error)
+	SetLastID(id int) error
+}
+
+// VersionedStorage - хранилище с условным обновлением задачи по ее версии
+//
+// UpdateTaskIfVersion возвращает *VersionConflictError, если версия задачи
+// (models.Task.Version) не равна version, и ошибку поиска, если задачи нет.
+type VersionedStorage interface {
+	UpdateTaskIfVersion(id, version int, input UpdateTaskInput) (*models.Task, error)
+}
+
+// ExpiringStorage - хранилище, удаляющее задачи по истечении models.Task.ExpiresAt
+//
+// Истекшая задача сразу перестает быть видна, как несуществующая. Start запускает
+// фоновое удаление истекших задач (PurgeExpired), Stop останавливает его.
+// Хранилищам без этого интерфейса обработчики не передают ExpiresAt и ExpiresIn
+// в CreateTaskInput, отклоняя такие запросы кодом 501.
+type ExpiringStorage interface {
+	Start(ctx context.Context)
+	Stop()
+	PurgeExpired() (int, error)
+}
+
+// TaskTx - операции с задачами внутри транзакции, см. TransactionalStorage
+//
+// Операции видят изменения, уже сделанные в той же транзакции. DeleteTask
+// удаляет мягко и не удаляет защищенную задачу (ErrTaskProtected).
+type TaskTx interface {
+	CreateTask(input CreateTaskInput) (*models.Task, error)
+	GetTask(id int) (*models.Task, error)
+	UpdateTask(id int, input UpdateTaskInput) (*models.Task, error)
+	DeleteTask(id int) error
+}
+
+// TransactionalStorage - хранилище, выполняющее несколько операций с задачами атомарно
+//
+// RunInTransaction применяет изменения, сделанные fn через tx, только если fn
+// вернула nil; иначе хранилище остается прежним. Другие запросы не видят
+// промежуточного состояния транзакции.
+type TransactionalStorage interface {
+	RunInTransaction(fn func(tx TaskTx) error) error
+}
+
+// PingStorage - хранилище во внешней системе, доступность которой можно проверить
+//
+// Ping возвращает ошибку, если сервер базы данных недоступен. Хранилища в памяти
+// процесса и в локальных файлах интерфейс не реализуют: они доступны всегда.
+type PingStorage interface {
+	Ping(ctx context.Context) error
+}
+
+// InMemoryStorage поддерживает все возможности хранилища
+var (
+	_ Storage                 = (*InMemoryStorage)(nil)
+	_ TokenStorage            = (*InMemoryStorage)(nil)
+	_ StreamingStorage        = (*InMemoryStorage)(nil)
+	_ PatchStorage            = (*InMemoryStorage)(nil)
+	_ ToggleStorage           = (*InMemoryStorage)(nil)
+	_ ProtectedStorage        = (*InMemoryStorage)(nil)
+	_ CompletionPolicyStorage = (*InMemoryStorage)(nil)
+	_ FollowUpStorage         = (*InMemoryStorage)(nil)
+	_ LinkStorage             = (*InMemoryStorage)(nil)
+	_ MetadataStorage         = (*InMemoryStorage)(nil)
+	_ TombstoneStorage        = (*InMemoryStorage)(nil)
+	_ CacheStorage            = (*InMemoryStorage)(nil)
+	_ PaginatedStorage        = (*InMemoryStorage)(nil)
+	_ BatchStorage            = (*InMemoryStorage)(nil)
+	_ TokenBatchStorage       = (*InMemoryStorage)(nil)
+	_ PriorityStorage         = (*InMemoryStorage)(nil)
+	_ TransactionalStorage    = (*InMemoryStorage)(nil)
+	_ TagStorage              = (*InMemoryStorage)(nil)
+	_ SoftDeleteStorage       = (*InMemoryStorage)(nil)
+	_ OverdueStorage          = (*InMemoryStorage)(nil)
+	_ SearchStorage           = (*InMemoryStorage)(nil)
+	_ CounterStorage          = (*InMemoryStorage)(nil)
+	_ VersionedStorage        = (*InMemoryStorage)(nil)
+	_ ExpiringStorage         = (*InMemoryStorage)(nil)
+	_ PreferenceStorage       = (*InMemoryStorage)(nil)
+)
+
+// SQLiteStorage поддерживает все возможности хранилища
+var (
+	_ Storage                 = (*SQLiteStorage)(nil)
+	_ TokenStorage            = (*SQLiteStorage)(nil)
+	_ StreamingStorage        = (*SQLiteStorage)(nil)
+	_ PatchStorage            = (*SQLiteStorage)(nil)
+	_ ToggleStorage           = (*SQLiteStorage)(nil)
+	_ ProtectedStorage        = (*SQLiteStorage)(nil)
+	_ CompletionPolicyStorage = (*SQLiteStorage)(nil)
+	_ FollowUpStorage         = (*SQLiteStorage)(nil)
+	_ LinkStorage             = (*SQLiteStorage)(nil)
+	_ MetadataStorage         = (*SQLiteStorage)(nil)
+	_ TombstoneStorage        = (*SQLiteStorage)(nil)
+	_ CacheStorage            = (*SQLiteStorage)(nil)
+	_ PaginatedStorage        = (*SQLiteStorage)(nil)
+	_ BatchStorage            = (*SQLiteStorage)(nil)
+	_ TokenBatchStorage       = (*SQLiteStorage)(nil)
+	_ PriorityStorage         = (*SQLiteStorage)(nil)
+	_ TransactionalStorage    = (*SQLiteStorage)(nil)
+	_ TagStorage              = (*SQLiteStorage)(nil)
+	_ SoftDeleteStorage       = (*SQLiteStorage)(nil)
+	_ OverdueStorage          = (*SQLiteStorage)(nil)
+	_ SearchStorage           = (*SQLiteStorage)(nil)
+	_ CounterStorage          = (*SQLiteStorage)(nil)
+	_ VersionedStorage        = (*SQLiteStorage)(nil)
+	_ PingStorage             = (*SQLiteStorage)(nil)
+)
+
+// PostgresStorage поддерживает все возможности хранилища
+var (
+	_ Storage                 = (*PostgresStorage)(nil)
+	_ TokenStorage            = (*PostgresStorage)(nil)
+	_ StreamingStorage        = (*PostgresStorage)(nil)
+	_ PatchStorage            = (*PostgresStorage)(nil)
+	_ ToggleStorage           = (*PostgresStorage)(nil)
+	_ ProtectedStorage        = (*PostgresStorage)(nil)
+	_ CompletionPolicyStorage = (*PostgresStorage)(nil)
+	_ FollowUpStorage         = (*PostgresStorage)(nil)
+	_ LinkStorage             = (*PostgresStorage)(nil)
+	_ MetadataStorage         = (*PostgresStorage)(nil)
+	_ TombstoneStorage        = (*PostgresStorage)(nil)
+	_ CacheStorage            = (*PostgresStorage)(nil)
+	_ PaginatedStorage        = (*PostgresStorage)(nil)
+	_ BatchStorage            = (*PostgresStorage)(nil)
+	_ TokenBatchStorage       = (*PostgresStorage)(nil)
+	_ PriorityStorage         = (*PostgresStorage)(nil)
+	_ TransactionalStorage    = (*PostgresStorage)(nil)
+	_ TagStorage              = (*PostgresStorage)(nil)
+	_ SoftDeleteStorage       = (*PostgresStorage)(nil)
+	_ OverdueStorage          = (*PostgresStorage)(nil)
+	_ SearchStorage           = (*PostgresStorage)(nil)
+	_ CounterStorage          = (*PostgresStorage)(nil)
+	_ VersionedStorage        = (*PostgresStorage)(nil)
+	_ PingStorage             = (*PostgresStorage)(nil)
+)
+
+// RedisStorage поддерживает все возможности хранилища
+var (
+	_ Storage                 = (*RedisStorage)(nil)
+	_ TokenStorage            = (*RedisStorage)(nil)
+	_ StreamingStorage        = (*RedisStorage)(nil)
+	_ PatchStorage            = (*RedisStorage)(nil)
+	_ ToggleStorage           = (*RedisStorage)(nil)
+	_ ProtectedStorage        = (*RedisStorage)(nil)
+	_ CompletionPolicyStorage = (*RedisStorage)(nil)
+	_ FollowUpStorage         = (*RedisStorage)(nil)
+	_ LinkStorage             = (*RedisStorage)(nil)
+	_ MetadataStorage         = (*RedisStorage)(nil)
+	_ TombstoneStorage        = (*RedisStorage)(nil)
+	_ CacheStorage            = (*RedisStorage)(nil)
+	_ PaginatedStorage        = (*RedisStorage)(nil)
+	_ BatchStorage            = (*RedisStorage)(nil)
+	_ TokenBatchStorage       = (*RedisStorage)(nil)
+	_ PriorityStorage         = (*RedisStorage)(nil)
+	_ TransactionalStorage    = (*RedisStorage)(nil)
+	_ TagStorage              = (*RedisStorage)(nil)
+	_ SoftDeleteStorage       = (*RedisStorage)(nil)
+	_ OverdueStorage          = (*RedisStorage)(nil)
+	_ SearchStorage           = (*RedisStorage)(nil)
+	_ CounterStorage          = (*RedisStorage)(nil)
+	_ VersionedStorage        = (*RedisStorage)(nil)
+	_ PingStorage             = (*RedisStorage)(nil)
+)
+
+// BoltStorage поддерживает все возможности хранилища
+var (
+	_ Storage                 = (*BoltStorage)(nil)
+	_ TokenStorage            = (*BoltStorage)(nil)
+	_ StreamingStorage        = (*BoltStorage)(nil)
+	_ PatchStorage            = (*BoltStorage)(nil)
+	_ ToggleStorage           = (*BoltStorage)(nil)
+	_ ProtectedStorage        = (*BoltStorage)(nil)
+	_ CompletionPolicyStorage = (*BoltStorage)(nil)
+	_ FollowUpStorage         = (*BoltStorage)(nil)
+	_ LinkStorage             = (*BoltStorage)(nil)
+	_ MetadataStorage         = (*BoltStorage)(nil)
+	_ TombstoneStorage        = (*BoltStorage)(nil)
+	_ CacheStorage            = (*BoltStorage)(nil)
+	_ PaginatedStorage        = (*BoltStorage)(nil)
+	_ BatchStorage            = (*BoltStorage)(nil)
+	_ TokenBatchStorage       = (*BoltStorage)(nil)
+	_ PriorityStorage         = (*BoltStorage)(nil)
+	_ TransactionalStorage    = (*BoltStorage)(nil)
+	_ TagStorage              = (*BoltStorage)(nil)
+	_ SoftDeleteStorage       = (*BoltStorage)(nil)
+	_ OverdueStorage          = (*BoltStorage)(nil)
+	_ SearchStorage           = (*BoltStorage)(nil)
+	_ CounterStorage          = (*BoltStorage)(nil)
+	_ VersionedStorage        = (*BoltStorage)(nil)
+)
+
+// MongoStorage поддерживает все возможности хранилища
+var (
+	_ Storage                 = (*MongoStorage)(nil)
+	_ TokenStorage            = (*MongoStorage)(nil)
+	_ StreamingStorage        = (*MongoStorage)(nil)
+	_ PatchStorage            = (*MongoStorage)(nil)
+	_ ToggleStorage           = (*MongoStorage)(nil)
+	_ ProtectedStorage        = (*MongoStorage)(nil)
+	_ CompletionPolicyStorage = (*MongoStorage)(nil)
+	_ FollowUpStorage         = (*MongoStorage)(nil)
+	_ LinkStorage             = (*MongoStorage)(nil)
+	_ MetadataStorage         = (*MongoStorage)(nil)
+	_ TombstoneStorage        = (*MongoStorage)(nil)
+	_ CacheStorage            = (*MongoStorage)(nil)
+	_ PaginatedStorage        = (*MongoStorage)(nil)
+	_ BatchStorage            = (*MongoStorage)(nil)
+	_ TokenBatchStorage       = (*MongoStorage)(nil)
+	_ PriorityStorage         = (*MongoStorage)(nil)
+	_ TransactionalStorage    = (*MongoStorage)(nil)
+	_ TagStorage              = (*MongoStorage)(nil)
+	_ SoftDeleteStorage       = (*MongoStorage)(nil)
+	_ OverdueStorage          = (*MongoStorage)(nil)
+	_ SearchStorage           = (*MongoStorage)(nil)
+	_ CounterStorage          = (*MongoStorage)(nil)
+	_ VersionedStorage        = (*MongoStorage)(nil)
+	_ PingStorage             = (*MongoStorage)(nil)
+)
+
+// FileStorage поддерживает все возможности хранилища
+var (
+	_ Storage                 = (*FileStorage)(nil)
+	_ TokenStorage            = (*FileStorage)(nil)
+	_ StreamingStorage        = (*FileStorage)(nil)
+	_ PatchStorage            = (*FileStorage)(nil)
+	_ ToggleStorage           = (*FileStorage)(nil)
+	_ ProtectedStorage        = (*FileStorage)(nil)
+	_ CompletionPolicyStorage = (*FileStorage)(nil)
+	_ FollowUpStorage         = (*FileStorage)(nil)
+	_ LinkStorage             = (*FileStorage)(nil)
+	_ MetadataStorage         = (*FileStorage)(nil)
+	_ TombstoneStorage        = (*FileStorage)(nil)
+	_ CacheStorage            = (*FileStorage)(nil)
+	_ PaginatedStorage        = (*FileStorage)(nil)
+	_ BatchStorage            = (*FileStorage)(nil)
+	_ TokenBatchStorage       = (*FileStorage)(nil)
+	_ PriorityStorage         = (*FileStorage)(nil)
+	_ TransactionalStorage    = (*FileStorage)(nil)
+	_ TagStorage              = (*FileStorage)(nil)
+	_ SoftDeleteStorage       = (*FileStorage)(nil)
+	_ OverdueStorage          = (*FileStorage)(nil)
+	_ SearchStorage           = (*FileStorage)(nil)
+	_ CounterStorage          = (*FileStorage)(nil)
+	_ VersionedStorage        = (*FileStorage)(nil)
+)
+
+// JournaledStorage поддерживает все возможности хранилища
+var (
+	_ Storage                 = (*JournaledStorage)(nil)
+	_ TokenStorage            = (*JournaledStorage)(nil)
+	_ StreamingStorage        = (*JournaledStorage)(nil)
+	_ PatchStorage            = (*JournaledStorage)(nil)
+	_ ToggleStorage           = (*JournaledStorage)(nil)
+	_ ProtectedStorage        = (*JournaledStorage)(nil)
+	_ CompletionPolicyStorage = (*JournaledStorage)(nil)
+	_ FollowUpStorage         = (*JournaledStorage)(nil)
+	_ LinkStorage             = (*JournaledStorage)(nil)
+	_ MetadataStorage         = (*JournaledStorage)(nil)
+	_ TombstoneStorage        = (*JournaledStorage)(nil)
+	_ CacheStorage            = (*JournaledStorage)(nil)
+	_ PaginatedStorage        = (*JournaledStorage)(nil)
+	_ BatchStorage            = (*JournaledStorage)(nil)
+	_ TokenBatchStorage       = (*JournaledStorage)(nil)
+	_ PriorityStorage         = (*JournaledStorage)(nil)
+	_ TransactionalStorage    = (*JournaledStorage)(nil)
+	_ TagStorage              = (*JournaledStorage)(nil)
+	_ SoftDeleteStorage       = (*JournaledStorage)(nil)
+	_ OverdueStorage          = (*JournaledStorage)(nil)
+	_ SearchStorage           = (*JournaledStorage)(nil)
+	_ CounterStorage          = (*JournaledStorage)(nil)
+	_ VersionedStorage        = (*JournaledStorage)(nil)
+)
+
+// ShardedStorage поддерживает основные операции, переключение выполнения, защиту
+// от удаления, счетчик ID и условное обновление по версии
+var (
+	_ Storage                 = (*ShardedStorage)(nil)
+	_ ToggleStorage           = (*ShardedStorage)(nil)
+	_ ProtectedStorage        = (*ShardedStorage)(nil)
+	_ CompletionPolicyStorage = (*ShardedStorage)(nil)
+	_ CounterStorage          = (*ShardedStorage)(nil)
+	_ VersionedStorage        = (*ShardedStorage)(nil)
+)
@@ -0,0 +1,332 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// journalEntry - строка журнала: изменения одной транзакции на запись
+//
+// Значение null в картах означает удаление записи. Первая строка журнала после
+// Compact содержит все состояние целиком.
+type journalEntry struct {
+	LastID     int                        `json:"last_id,omitempty"`
+	Tasks      map[int]json.RawMessage    `json:"tasks,omitempty"`
+	Tombstones map[int]*time.Time         `json:"tombstones,omitempty"`
+	Tokens     map[string]*fileTokenEntry `json:"client_tokens,omitempty"`
+}
+
+// empty проверяет, что транзакция ничего не изменила
+func (e *journalEntry) empty() bool {
+	return e.LastID == 0 && len(e.Tasks) == 0 && len(e.Tombstones) == 0 && len(e.Tokens) == 0
+}
+
+// diffState возвращает изменения, переводящие состояние from в to
+func diffState(from, to *fileState) *journalEntry {
+	entry := &journalEntry{
+		Tasks:      map[int]json.RawMessage{},
+		Tombstones: map[int]*time.Time{},
+		Tokens:     map[string]*fileTokenEntry{},
+	}
+	if to.LastID != from.LastID {
+		entry.LastID = to.LastID
+	}
+
+	for id, data := range to.Tasks {
+		if previous, exists := from.Tasks[id]; !exists || !bytes.Equal(previous, data) {
+			entry.Tasks[id] = data
+		}
+	}
+	for id := range from.Tasks {
+		if _, exists := to.Tasks[id]; !exists {
+			entry.Tasks[id] = nil
+		}
+	}
+
+	for id, deletedAt := range to.Tombstones {
+		if previous, exists := from.Tombstones[id]; !exists || !previous.Equal(deletedAt) {
+			entry.Tombstones[id] = &deletedAt
+		}
+	}
+	for id := range from.Tombstones {
+		if _, exists := to.Tombstones[id]; !exists {
+			entry.Tombstones[id] = nil
+		}
+	}
+
+	for token, record := range to.Tokens {
+		if previous, exists := from.Tokens[token]; !exists || previous.TaskID != record.TaskID || !previous.CreatedAt.Equal(record.CreatedAt) {
+			entry.Tokens[token] = &record
+		}
+	}
+	for token := range from.Tokens {
+		if _, exists := to.Tokens[token]; !exists {
+			entry.Tokens[token] = nil
+		}
+	}
+	return entry
+}
+
+// apply применяет строку журнала к состоянию
+func (s *fileState) apply(entry *journalEntry) {
+	s.LastID = max(s.LastID, entry.LastID)
+	for id, data := range entry.Tasks {
+		if data == nil {
+			delete(s.Tasks, id)
+		} else {
+			s.Tasks[id] = data
+		}
+	}
+	for id, deletedAt := range entry.Tombstones {
+		if deletedAt == nil {
+			delete(s.Tombstones, id)
+		} else {
+			s.Tombstones[id] = *deletedAt
+		}
+	}
+	for token, record := range entry.Tokens {
+		if record == nil {
+			delete(s.Tokens, token)
+		} else {
+			s.Tokens[token] = *record
+		}
+	}
+}
+
+// JournaledStorage реализует хранилище задач в памяти с журналом изменений в файле
+//
+// Поддерживает те же возможности, что и InMemoryStorage, и принимает те же опции.
+// Каждая транзакция на запись дописывает в журнал одну JSON-строку со своими
+// изменениями и дожидается ее записи на диск до ответа клиенту. При загрузке
+// строки применяются по порядку, поэтому состояние, включая последний выданный ID,
+// восстанавливается однозначно. Журнал растет с каждым изменением, Compact
+// заменяет его одной строкой с текущим состоянием. Файл должен использоваться
+// одним процессом.
+type JournaledStorage struct {
+	*recordStorage
+	backend *journalBackend
+}
+
+// NewJournaledStorage восстанавливает хранилище из журнала и открывает его для дозаписи
+//
+// Незавершенная последняя строка (сбой во время записи) отбрасывается с записью
+// в журнал сервера и обрезается, чтобы следующая строка не склеилась с ней.
+// Поврежденная строка в середине журнала считается ошибкой: пропустить ее
+// значило бы молча потерять изменения.
+//
+// Args:
+//
+//	logPath: путь к файлу журнала, создается при отсутствии
+//	opts: опции хранилища
+//
+// Returns:
+//
+//	*JournaledStorage: хранилище задач
+//	error: ошибка чтения журнала или поврежденная строка
+func NewJournaledStorage(logPath string, opts ...Option) (*JournaledStorage, error) {
+	state, size, err := replayJournal(logPath)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(logPath, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("открытие журнала: %w", err)
+	}
+	if err := file.Truncate(size); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("обрезка журнала: %w", err)
+	}
+	if _, err := file.Seek(size, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("открытие журнала: %w", err)
+	}
+
+	backend := &journalBackend{path: logPath, file: file, state: state}
+	return &JournaledStorage{recordStorage: newRecordStorage(backend, opts), backend: backend}, nil
+}
+
+// replayJournal восстанавливает состояние из журнала
+//
+// Returns:
+//
+//	*fileState: восстановленное состояние
+//	int64: длина целых строк журнала, остаток отбрасывается
+//	error: ошибка чтения или поврежденная строка в середине журнала
+func replayJournal(path string) (*fileState, int64, error) {
+	state := newFileState()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("чтение журнала: %w", err)
+	}
+
+	var offset int64
+	for line := 1; len(data) > 0; line++ {
+		end := bytes.IndexByte(data, '\n')
+		if end < 0 {
+			log.Printf("журнал %s: незавершенная строка %d отброшена", path, line)
+			break
+		}
+
+		var entry journalEntry
+		if err := json.Unmarshal(data[:end], &entry); err != nil {
+			return nil, 0, fmt.Errorf("журнал %s: поврежденная строка %d: %w", path, line, err)
+		}
+		state.apply(&entry)
+		offset += int64(end + 1)
+		data = data[end+1:]
+	}
+	return state, offset, nil
+}
+
+// Compact заменяет журнал одной строкой с текущим состоянием
+//
+// Остаются только последние версии задач (включая мягко удаленные, которые еще
+// можно восстановить), действующие записи об удалении и токены создания. Журнал
+// заменяется переименованием временного файла, поэтому сбой во время Compact
+// оставляет прежний журнал целым.
+//
+// Returns:
+//
+//	error: ошибка записи журнала
+func (s *JournaledStorage) Compact() error {
+	return s.recordStorage.backend.run(s.backend.compact)
+}
+
+// Close закрывает файл журнала, см. Storage.Close
+//
+// Каждое изменение записывается в журнал сразу, поэтому Close ничего не
+// дописывает, а только дожидается выполняющихся операций и закрывает файл.
+func (s *JournaledStorage) Close() error {
+	return s.shutdown(func() error {
+		s.backend.writeMu.Lock()
+		defer s.backend.writeMu.Unlock()
+		return s.backend.file.Close()
+	})
+}
+
+// journalBackend хранит состояние в памяти и дописывает изменения в журнал
+type journalBackend struct {
+	path    string
+	writeMu sync.Mutex   // Выполняет транзакции на запись и Compact по одной
+	file    *os.File     // Журнал, открытый для дозаписи
+	mu      sync.RWMutex // Защищает подмену state
+	state   *fileState   // Записанное в журнал состояние; не изменяется после публикации
+}
+
+// view выполняет fn над последним записанным состоянием
+func (b *journalBackend) view(ctx context.Context, fn func(tx recordTx) error) error {
+	b.mu.RLock()
+	state := b.state
+	b.mu.RUnlock()
+	return fn(fileTx{ctx: ctx, state: state})
+}
+
+// update выполняет fn над копией состояния и публикует ее, только если fn
+// вернула nil и строка с изменениями записана на диск
+func (b *journalBackend) update(ctx context.Context, fn func(tx recordTx) error) error {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	b.mu.RLock()
+	previous := b.state
+	b.mu.RUnlock()
+
+	state := previous.clone()
+	if err := fn(fileTx{ctx: ctx, state: state}); err != nil {
+		return err
+	}
+	if entry := diffState(previous, state); !entry.empty() {
+		if err := b.append(entry); err != nil {
+			return fmt.Errorf("запись журнала: %w", err)
+		}
+	}
+
+	b.mu.Lock()
+	b.state = state
+	b.mu.Unlock()
+	return nil
+}
+
+// append дописывает строку в журнал и дожидается ее записи на диск
+//
+// Если запись не удалась, журнал обрезается до прежней длины: неподтвержденная
+// строка не должна ни примениться при загрузке, ни оказаться перед следующей.
+func (b *journalBackend) append(entry *journalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	offset, err := b.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	_, err = b.file.Write(line)
+	if err == nil {
+		err = b.file.Sync()
+	}
+	if err != nil {
+		b.file.Truncate(offset)
+		b.file.Seek(offset, io.SeekStart)
+		return err
+	}
+	return nil
+}
+
+// compact записывает текущее состояние новым журналом из одной строки, см. JournaledStorage.Compact
+func (b *journalBackend) compact() error {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	b.mu.RLock()
+	state := b.state
+	b.mu.RUnlock()
+
+	line, err := json.Marshal(diffState(newFileState(), state))
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	dir := filepath.Dir(b.path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(b.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("сжатие журнала: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(line); err != nil {
+		tmp.Close()
+		return fmt.Errorf("сжатие журнала: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("сжатие журнала: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), b.path); err != nil {
+		tmp.Close()
+		return fmt.Errorf("сжатие журнала: %w", err)
+	}
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+
+	// Дозапись продолжается в новый файл; прежний дескриптор указывает на замененный
+	b.file.Close()
+	b.file = tmp
+	return nil
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"encoding/json"
+	"test/models"
+)
+
+// MetadataLimit возвращает максимальный размер значения пространства метаданных
+func (s *InMemoryStorage) MetadataLimit() int {
+	return s.metadataLimit
+}
+
+// GetMetadata возвращает копию всех метаданных задачи
+//
+// Args:
+//
+//	id: ID задачи
+//
+// Returns:
+//
+//	map[string]json.RawMessage: метаданные по пространствам имен, пустые, если их нет
+//	error: ошибка при поиске задачи
+func (s *InMemoryStorage) GetMetadata(id int) (map[string]json.RawMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	task, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+	return copyMetadata(task), nil
+}
+
+// SetMetadata заменяет значение пространства метаданных задачи
+//
+// Метаданные принадлежат интеграциям, а не пользователю, поэтому их можно менять
+// и у выполненной задачи при WithCompletedImmutable.
+//
+// Args:
+//
+//	id: ID задачи
+//	namespace: пространство имен, прошедшее models.ValidMetadataNamespace
+//	value: корректный JSON размером не больше MetadataLimit
+//
+// Returns:
+//
+//	error: ошибка при поиске задачи, ErrMetadataInvalid или ErrMetadataTooLarge
+func (s *InMemoryStorage) SetMetadata(id int, namespace string, value json.RawMessage) error {
+	if err := checkMetadata(namespace, value, s.metadataLimit); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.writable(); err != nil {
+		return err
+	}
+
+	task, err := s.findCopy(id)
+	if err != nil {
+		return err
+	}
+	setMetadata(task, namespace, value)
+	s.store(task)
+	return nil
+}
+
+// DeleteMetadata удаляет пространство метаданных задачи
+//
+// Args:
+//
+//	id: ID задачи
+//	namespace: пространство имен
+//
+// Returns:
+//
+//	error: ошибка при поиске задачи или ErrMetadataNotFound
+func (s *InMemoryStorage) DeleteMetadata(id int, namespace string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.writable(); err != nil {
+		return err
+	}
+
+	task, err := s.findCopy(id)
+	if err != nil {
+		return err
+	}
+	if err := deleteMetadata(task, namespace); err != nil {
+		return err
+	}
+	s.store(task)
+	return nil
+}
+
+// checkMetadata проверяет имя пространства и значение метаданных до записи
+func checkMetadata(namespace string, value json.RawMessage, limit int) error {
+	if !models.ValidMetadataNamespace(namespace) || !json.Valid(value) {
+		return ErrMetadataInvalid
+	}
+	if len(value) > limit {
+		return ErrMetadataTooLarge
+	}
+	return nil
+}
+
+// copyMetadata возвращает копию метаданных задачи
+func copyMetadata(task *models.Task) map[string]json.RawMessage {
+	metadata := make(map[string]json.RawMessage, len(task.Metadata))
+	for namespace, value := range task.Metadata {
+		metadata[namespace] = append(json.RawMessage(nil), value...)
+	}
+	return metadata
+}
+
+// setMetadata заменяет значение пространства метаданных задачи копией value
+func setMetadata(task *models.Task, namespace string, value json.RawMessage) {
+	if task.Metadata == nil {
+		task.Metadata = make(map[string]json.RawMessage)
+	}
+	task.Metadata[namespace] = append(json.RawMessage(nil), value...)
+}
+
+// deleteMetadata удаляет пространство метаданных задачи
+func deleteMetadata(task *models.Task, namespace string) error {
+	if _, exists := task.Metadata[namespace]; !exists {
+		return ErrMetadataNotFound
+	}
+
+	delete(task.Metadata, namespace)
+	if len(task.Metadata) == 0 {
+		task.Metadata = nil
+	}
+	return nil
+}
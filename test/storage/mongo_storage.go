@@ -0,0 +1,358 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"test/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Коллекции и поля хранилища MongoDB
+const (
+	mongoCounters       = "counters"       // Счетчики ID по имени коллекции задач
+	mongoTombstonesSfx  = "_tombstones"    // Суффикс коллекции моментов удаления задач
+	mongoTokensSfx      = "_client_tokens" // Суффикс коллекции токенов создания
+	mongoDeletedAtField = "deleted_at"     // Момент удаления в наносекундах Unix
+	mongoCreatedAtField = "created_at"     // Момент создания токена в наносекундах Unix
+)
+
+// mongoTimeout ограничивает время одной операции хранилища, включая повторы транзакции
+const mongoTimeout = 10 * time.Second
+
+// mongoTask - документ задачи
+//
+// Задача хранится JSON-строкой, а не вложенным документом, чтобы метаданные
+// интеграций возвращались байт в байт, как в остальных хранилищах.
+type mongoTask struct {
+	ID   int64  `bson:"_id"`
+	Task string `bson:"task"`
+}
+
+// mongoCounter - документ счетчика ID
+type mongoCounter struct {
+	Seq int64 `bson:"seq"`
+}
+
+// mongoTombstone - документ записи об удалении
+type mongoTombstone struct {
+	DeletedAt int64 `bson:"deleted_at"`
+}
+
+// mongoToken - документ токена создания
+type mongoToken struct {
+	TaskID    int64 `bson:"task_id"`
+	CreatedAt int64 `bson:"created_at"`
+}
+
+// MongoStorage реализует хранилище задач в MongoDB
+//
+// Поддерживает те же возможности, что и InMemoryStorage, и принимает те же опции.
+// Задача хранится документом с ID в поле _id, ID выдает findAndModify счетчика
+// в коллекции counters, поэтому несколько экземпляров сервера с одной базой
+// данных не получат одинаковых ID. Записи выполняются многодокументными
+// транзакциями, которые MongoDB поддерживает только в наборе реплик или
+// через mongos: для разработки достаточно набора реплик из одного узла.
+type MongoStorage struct {
+	*recordStorage
+	client *mongo.Client
+}
+
+// NewMongoStorage подключается к MongoDB и создает недостающие индексы
+//
+// Args:
+//
+//	uri: адрес сервера, например mongodb://localhost:27017/?replicaSet=rs0
+//	db: имя базы данных
+//	collection: имя коллекции задач; записи об удалении и токены создания
+//	хранятся в коллекциях с суффиксами _tombstones и _client_tokens
+//	opts: опции хранилища
+//
+// Returns:
+//
+//	*MongoStorage: хранилище задач
+//	error: ошибка разбора адреса, подключения или создания индексов
+func NewMongoStorage(uri, db, collection string, opts ...Option) (*MongoStorage, error) {
+	client, err := mongo.Connect(options.Client().ApplyURI(uri))
+	if err != nil {
+		return nil, fmt.Errorf("адрес MongoDB: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(ctx)
+		return nil, fmt.Errorf("подключение к MongoDB: %w", err)
+	}
+
+	database := client.Database(db)
+	backend := mongoBackend{
+		client:     client,
+		name:       collection,
+		tasks:      database.Collection(collection),
+		counters:   database.Collection(mongoCounters),
+		tombstones: database.Collection(collection + mongoTombstonesSfx),
+		tokens:     database.Collection(collection + mongoTokensSfx),
+	}
+	if err := backend.createIndexes(ctx); err != nil {
+		client.Disconnect(ctx)
+		return nil, fmt.Errorf("создание индексов MongoDB: %w", err)
+	}
+	return &MongoStorage{recordStorage: newRecordStorage(backend, opts), client: client}, nil
+}
+
+// Close закрывает подключения к MongoDB, см. Storage.Close
+func (s *MongoStorage) Close() error {
+	return s.shutdown(func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+		defer cancel()
+		return s.client.Disconnect(ctx)
+	})
+}
+
+// Ping проверяет подключение к серверу MongoDB
+func (s *MongoStorage) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx, nil)
+}
+
+// mongoBackend выполняет операции с записями в MongoDB
+type mongoBackend struct {
+	client     *mongo.Client
+	name       string // Имя коллекции задач, ключ ее счетчика ID
+	tasks      *mongo.Collection
+	counters   *mongo.Collection
+	tombstones *mongo.Collection
+	tokens     *mongo.Collection
+}
+
+// createIndexes создает индексы, по которым вытесняются самые давние записи
+func (b mongoBackend) createIndexes(ctx context.Context) error {
+	indexes := []struct {
+		collection *mongo.Collection
+		field      string
+	}{{b.tombstones, mongoDeletedAtField}, {b.tokens, mongoCreatedAtField}}
+	for _, index := range indexes {
+		if _, err := index.collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: index.field, Value: 1}}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// view выполняет fn отдельными запросами: каждое чтение видит последние записи
+func (b mongoBackend) view(ctx context.Context, fn func(tx recordTx) error) error {
+	ctx, cancel := context.WithTimeout(ctx, mongoTimeout)
+	defer cancel()
+	return fn(mongoTx{ctx: ctx, b: b})
+}
+
+// update выполняет fn в транзакции MongoDB
+//
+// Транзакция, прерванная конфликтом записи с другой транзакцией, выполняется
+// заново, пока не истечет mongoTimeout.
+func (b mongoBackend) update(ctx context.Context, fn func(tx recordTx) error) error {
+	ctx, cancel := context.WithTimeout(ctx, mongoTimeout)
+	defer cancel()
+
+	session, err := b.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		return nil, fn(mongoTx{ctx: ctx, b: b})
+	})
+	return err
+}
+
+// mongoTx реализует recordTx запросами MongoDB; ctx несет сессию транзакции на запись
+type mongoTx struct {
+	ctx context.Context
+	b   mongoBackend
+}
+
+// byID возвращает фильтр документа по _id
+func byID(id any) bson.D {
+	return bson.D{{Key: "_id", Value: id}}
+}
+
+// upsert заменяет документ с заданным _id или создает его
+func (t mongoTx) upsert(collection *mongo.Collection, id, document any) error {
+	_, err := collection.ReplaceOne(t.ctx, byID(id), document, options.Replace().SetUpsert(true))
+	return err
+}
+
+// prune удаляет документы, у которых field не позже before, и самые давние документы сверх keep
+func (t mongoTx) prune(collection *mongo.Collection, field string, before time.Time, keep int) error {
+	filter := bson.D{{Key: field, Value: bson.D{{Key: "$lte", Value: before.UnixNano()}}}}
+	if _, err := collection.DeleteMany(t.ctx, filter); err != nil {
+		return err
+	}
+
+	find := options.Find().
+		SetSort(bson.D{{Key: field, Value: -1}}).
+		SetSkip(int64(max(keep, 0))).
+		SetProjection(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := collection.Find(t.ctx, bson.D{}, find)
+	if err != nil {
+		return err
+	}
+	var extra []struct {
+		ID any `bson:"_id"`
+	}
+	if err := cursor.All(t.ctx, &extra); err != nil {
+		return err
+	}
+	if len(extra) == 0 {
+		return nil
+	}
+	ids := make(bson.A, len(extra))
+	for i, document := range extra {
+		ids[i] = document.ID
+	}
+	_, err = collection.DeleteMany(t.ctx, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}}})
+	return err
+}
+
+// scanMongoTask восстанавливает задачу из документа
+func scanMongoTask(document mongoTask) (*models.Task, error) {
+	task, err := decodeTask([]byte(document.Task))
+	if err != nil {
+		return nil, err
+	}
+	task.ID = int(document.ID)
+	return task, nil
+}
+
+func (t mongoTx) task(id int) (*models.Task, bool, error) {
+	var document mongoTask
+	err := t.b.tasks.FindOne(t.ctx, byID(int64(id))).Decode(&document)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	task, err := scanMongoTask(document)
+	return task, err == nil, err
+}
+
+func (t mongoTx) eachTask(fn func(*models.Task) error) error {
+	cursor, err := t.b.tasks.Find(t.ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(t.ctx)
+
+	for cursor.Next(t.ctx) {
+		var document mongoTask
+		if err := cursor.Decode(&document); err != nil {
+			return fmt.Errorf("поврежденная запись задачи: %w", err)
+		}
+		task, err := scanMongoTask(document)
+		if err != nil {
+			return err
+		}
+		if err := fn(task); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func (t mongoTx) insertTask(task *models.Task) error {
+	var counter mongoCounter
+	update := bson.D{{Key: "$inc", Value: bson.D{{Key: "seq", Value: int64(1)}}}}
+	after := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	if err := t.b.counters.FindOneAndUpdate(t.ctx, byID(t.b.name), update, after).Decode(&counter); err != nil {
+		return err
+	}
+	task.ID = int(counter.Seq)
+	return t.updateTask(task)
+}
+
+func (t mongoTx) updateTask(task *models.Task) error {
+	data, err := encodeTask(task)
+	if err != nil {
+		return err
+	}
+	return t.upsert(t.b.tasks, int64(task.ID), mongoTask{ID: int64(task.ID), Task: string(data)})
+}
+
+func (t mongoTx) deleteTask(id int) error {
+	_, err := t.b.tasks.DeleteOne(t.ctx, byID(int64(id)))
+	return err
+}
+
+func (t mongoTx) tombstone(id int) (time.Time, bool, error) {
+	var document mongoTombstone
+	err := t.b.tombstones.FindOne(t.ctx, byID(int64(id))).Decode(&document)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return time.Unix(0, document.DeletedAt), true, nil
+}
+
+func (t mongoTx) putTombstone(id int, deletedAt time.Time) error {
+	return t.upsert(t.b.tombstones, int64(id), mongoTombstone{DeletedAt: deletedAt.UnixNano()})
+}
+
+func (t mongoTx) pruneTombstones(before time.Time, keep int) error {
+	return t.prune(t.b.tombstones, mongoDeletedAtField, before, keep)
+}
+
+func (t mongoTx) clientToken(token string) (int, time.Time, bool, error) {
+	var document mongoToken
+	err := t.b.tokens.FindOne(t.ctx, byID(token)).Decode(&document)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, time.Time{}, false, nil
+	}
+	if err != nil {
+		return 0, time.Time{}, false, err
+	}
+	return int(document.TaskID), time.Unix(0, document.CreatedAt), true, nil
+}
+
+func (t mongoTx) putClientToken(token string, id int, createdAt time.Time) error {
+	return t.upsert(t.b.tokens, token, mongoToken{TaskID: int64(id), CreatedAt: createdAt.UnixNano()})
+}
+
+func (t mongoTx) deleteClientToken(token string) error {
+	_, err := t.b.tokens.DeleteOne(t.ctx, byID(token))
+	return err
+}
+
+func (t mongoTx) pruneClientTokens(before time.Time, keep int) error {
+	return t.prune(t.b.tokens, mongoCreatedAtField, before, keep)
+}
+
+func (t mongoTx) countClientTokens() (int, error) {
+	count, err := t.b.tokens.CountDocuments(t.ctx, bson.D{})
+	return int(count), err
+}
+
+func (t mongoTx) clearClientTokens() error {
+	_, err := t.b.tokens.DeleteMany(t.ctx, bson.D{})
+	return err
+}
+
+func (t mongoTx) lastID() (int, error) {
+	var counter mongoCounter
+	err := t.b.counters.FindOne(t.ctx, byID(t.b.name)).Decode(&counter)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	return int(counter.Seq), err
+}
+
+func (t mongoTx) setLastID(id int) error {
+	return t.upsert(t.b.counters, t.b.name, bson.D{{Key: "seq", Value: int64(id)}})
+}
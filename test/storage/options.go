@@ -0,0 +1,114 @@
+package storage
+
+import "time"
+
+const (
+	// DefaultClientTokenTTL - время, в течение которого повторное создание с тем же токеном возвращает исходную задачу
+	DefaultClientTokenTTL = 24 * time.Hour
+
+	// DefaultClientTokenCapacity - максимальное число запоминаемых клиентских токенов
+	DefaultClientTokenCapacity = 10000
+
+	// DefaultTombstoneTTL - время, в течение которого обращение к удаленной задаче возвращает TaskDeletedError
+	DefaultTombstoneTTL = 24 * time.Hour
+
+	// DefaultTombstoneCapacity - максимальное число запоминаемых удалений
+	DefaultTombstoneCapacity = 10000
+
+	// DefaultMetadataLimit - максимальный размер значения одного пространства метаданных в байтах
+	DefaultMetadataLimit = 4 * 1024
+
+	// DefaultExpiryInterval - период фонового удаления истекших задач, см. ExpiringStorage
+	DefaultExpiryInterval = time.Minute
+)
+
+// settings - параметры хранилища, задаваемые опциями
+//
+// Общие для всех реализаций хранилища, поэтому одни и те же опции
+// подходят и для InMemoryStorage, и для хранилищ в базах данных.
+type settings struct {
+	now                func() time.Time // Источник текущего времени
+	tokenTTL           time.Duration    // Время жизни клиентских токенов
+	tokenCapacity      int              // Максимальное число клиентских токенов
+	tombstoneTTL       time.Duration    // Время, в течение которого помнится удаление
+	tombstoneCapacity  int              // Максимальное число запоминаемых удалений
+	completedImmutable bool             // Выполненные задачи можно только возобновить
+	metadataLimit      int              // Максимальный размер пространства метаданных
+	expiryInterval     time.Duration    // Период фонового удаления истекших задач
+}
+
+// newSettings возвращает параметры по умолчанию с примененными опциями
+func newSettings(opts []Option) settings {
+	cfg := settings{
+		now:               time.Now,
+		tokenTTL:          DefaultClientTokenTTL,
+		tokenCapacity:     DefaultClientTokenCapacity,
+		tombstoneTTL:      DefaultTombstoneTTL,
+		tombstoneCapacity: DefaultTombstoneCapacity,
+		metadataLimit:     DefaultMetadataLimit,
+		expiryInterval:    DefaultExpiryInterval,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// Option настраивает хранилище при создании
+type Option func(*settings)
+
+// WithClock задает источник текущего времени (используется в тестах)
+func WithClock(now func() time.Time) Option {
+	return func(s *settings) {
+		s.now = now
+	}
+}
+
+// WithClientTokenTTL задает время жизни клиентских токенов создания
+func WithClientTokenTTL(ttl time.Duration) Option {
+	return func(s *settings) {
+		s.tokenTTL = ttl
+	}
+}
+
+// WithClientTokenCapacity задает максимальное число запоминаемых клиентских токенов
+func WithClientTokenCapacity(capacity int) Option {
+	return func(s *settings) {
+		s.tokenCapacity = capacity
+	}
+}
+
+// WithTombstoneTTL задает время, в течение которого хранилище помнит удаленные задачи
+func WithTombstoneTTL(ttl time.Duration) Option {
+	return func(s *settings) {
+		s.tombstoneTTL = ttl
+	}
+}
+
+// WithTombstoneCapacity задает максимальное число запоминаемых удалений
+func WithTombstoneCapacity(capacity int) Option {
+	return func(s *settings) {
+		s.tombstoneCapacity = capacity
+	}
+}
+
+// WithCompletedImmutable запрещает изменять выполненные задачи, кроме их возобновления
+func WithCompletedImmutable() Option {
+	return func(s *settings) {
+		s.completedImmutable = true
+	}
+}
+
+// WithMetadataLimit задает максимальный размер значения пространства метаданных в байтах
+func WithMetadataLimit(limit int) Option {
+	return func(s *settings) {
+		s.metadataLimit = limit
+	}
+}
+
+// WithExpiryInterval задает период фонового удаления истекших задач после Start
+func WithExpiryInterval(interval time.Duration) Option {
+	return func(s *settings) {
+		s.expiryInterval = interval
+	}
+}
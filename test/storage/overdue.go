@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"sort"
+	"test/models"
+	"time"
+)
+
+// GetOverdueTasks возвращает невыполненные задачи, срок которых прошел, в порядке возрастания ID
+//
+// Срок сравнивается с текущим моментом часов хранилища (см. WithClock).
+//
+// Returns:
+//
+//	[]*models.Task: просроченные задачи
+//	error: ошибка при получении задач
+func (s *InMemoryStorage) GetOverdueTasks() ([]*models.Task, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.now()
+	tasks := []*models.Task{}
+	for _, task := range s.tasks {
+		if visible(task, now) && task.Overdue(now) {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks, nil
+}
+
+// utcDueDate возвращает копию срока в UTC; nil и нулевое время означают отсутствие срока
+func utcDueDate(due *time.Time) *time.Time {
+	if due == nil || due.IsZero() {
+		return nil
+	}
+	utc := due.UTC()
+	return &utc
+}
+
+// sameDueDate проверяет, что сроки совпадают, в том числе оба отсутствуют
+func sameDueDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"sort"
+	"test/models"
+)
+
+// GetTasksPaginated возвращает страницу списка задач в порядке возрастания ID
+//
+// Args:
+//
+//	offset: число пропускаемых задач от начала списка
+//	limit: максимальное число задач на странице
+//
+// Returns:
+//
+//	[]*models.Task: задачи страницы, пустой срез за пределами списка
+//	int: общее число задач
+//	error: ошибка при получении задач
+func (s *InMemoryStorage) GetTasksPaginated(offset, limit int) ([]*models.Task, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.now()
+	ids := make([]int, 0, len(s.tasks))
+	for id, task := range s.tasks {
+		if visible(task, now) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	start, end := pageBounds(len(ids), offset, limit)
+	tasks := make([]*models.Task, 0, end-start)
+	for _, id := range ids[start:end] {
+		tasks = append(tasks, s.tasks[id])
+	}
+	return tasks, len(ids), nil
+}
+
+// pageBounds возвращает границы страницы [start, end) в списке из total элементов
+func pageBounds(total, offset, limit int) (int, int) {
+	start := min(max(offset, 0), total)
+	end := start + min(max(limit, 0), total-start)
+	return start, end
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"slices"
+	"test/models"
+)
+
+// GetPreferences возвращает копию настроек по умолчанию для новых задач
+//
+// Returns:
+//
+//	models.Preferences: настройки, пустые, если SetPreferences не вызывался
+//	error: всегда nil
+func (s *InMemoryStorage) GetPreferences() (models.Preferences, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	preferences := s.preferences
+	preferences.DefaultTags = slices.Clone(preferences.DefaultTags)
+	return preferences, nil
+}
+
+// SetPreferences заменяет настройки по умолчанию целиком
+//
+// Настройки живут только в памяти процесса и в снимок не входят. Значения
+// проверяет вызывающий код, хранилище их не разбирает.
+//
+// Args:
+//
+//	preferences: новые настройки
+//
+// Returns:
+//
+//	error: ErrStorageClosed после Close
+func (s *InMemoryStorage) SetPreferences(preferences models.Preferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.writable(); err != nil {
+		return err
+	}
+
+	preferences.DefaultTags = slices.Clone(preferences.DefaultTags)
+	s.preferences = preferences
+	return nil
+}
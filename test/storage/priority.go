@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"sort"
+	"test/models"
+)
+
+// TasksByPriority возвращает задачи с приоритетом priority в порядке возрастания ID
+//
+// Задачи берутся из индекса по приоритету, без обхода всех задач.
+//
+// Args:
+//
+//	priority: приоритет из models.Priorities
+//
+// Returns:
+//
+//	[]*models.Task: задачи с этим приоритетом
+//	error: ошибка при получении задач
+func (s *InMemoryStorage) TasksByPriority(priority string) ([]*models.Task, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.now()
+	bucket := s.byPriority[priority]
+	tasks := make([]*models.Task, 0, len(bucket))
+	for _, task := range bucket {
+		if !task.Expired(now) {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks, nil
+}
+
+// indexPriority добавляет задачу в индекс ее приоритета, вызывается под блокировкой на запись
+func (s *InMemoryStorage) indexPriority(task *models.Task) {
+	if task.Priority == "" {
+		return
+	}
+	bucket := s.byPriority[task.Priority]
+	if bucket == nil {
+		bucket = make(map[int]*models.Task)
+		s.byPriority[task.Priority] = bucket
+	}
+	bucket[task.ID] = task
+}
+
+// removeFromPriority удаляет задачу id из индекса приоритета priority
+func (s *InMemoryStorage) removeFromPriority(id int, priority string) {
+	bucket := s.byPriority[priority]
+	delete(bucket, id)
+	if len(bucket) == 0 {
+		delete(s.byPriority, priority)
+	}
+}
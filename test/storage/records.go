@@ -0,0 +1,721 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"test/models"
+	"time"
+)
+
+// recordTx - операции с записями хранилища внутри одной транзакции
+//
+// Реализуется каждым хранилищем во внешней системе (база данных, файл, сервер):
+// ему достаточно уметь хранить задачи, записи об удалении и клиентские токены,
+// а правила работы с задачами выполняет recordStorage.
+type recordTx interface {
+	// task возвращает задачу по ID вместе с метаданными; false - задачи нет
+	task(id int) (*models.Task, bool, error)
+
+	// eachTask передает задачи функции fn в порядке возрастания ID
+	eachTask(fn func(*models.Task) error) error
+
+	// insertTask сохраняет новую задачу, назначая ей ID, который никогда не выдавался ранее
+	insertTask(task *models.Task) error
+
+	// updateTask заменяет сохраненную задачу с тем же ID
+	updateTask(task *models.Task) error
+
+	// deleteTask удаляет сохраненную задачу; записи об удалении не меняются
+	deleteTask(id int) error
+
+	// tombstone возвращает момент удаления задачи; false - записи нет
+	tombstone(id int) (time.Time, bool, error)
+
+	// putTombstone запоминает удаление задачи
+	putTombstone(id int, deletedAt time.Time) error
+
+	// pruneTombstones удаляет записи об удалении, сделанные раньше before,
+	// и самые давние записи сверх keep
+	pruneTombstones(before time.Time, keep int) error
+
+	// clientToken возвращает ID задачи, созданной с токеном, и момент создания; false - токена нет
+	clientToken(token string) (int, time.Time, bool, error)
+
+	// putClientToken запоминает токен создания задачи id
+	putClientToken(token string, id int, createdAt time.Time) error
+
+	// deleteClientToken забывает токен создания
+	deleteClientToken(token string) error
+
+	// pruneClientTokens удаляет токены, созданные раньше before, и самые давние токены сверх keep
+	pruneClientTokens(before time.Time, keep int) error
+
+	// countClientTokens возвращает число сохраненных токенов создания
+	countClientTokens() (int, error)
+
+	// clearClientTokens удаляет все токены создания
+	clearClientTokens() error
+
+	// lastID возвращает последний выданный ID, 0 - ID еще не выдавались
+	lastID() (int, error)
+
+	// setLastID задает последний выданный ID; вызывается только с ID больше текущего
+	setLastID(id int) error
+}
+
+// recordBackend - транзакционный доступ к записям хранилища
+type recordBackend interface {
+	// view выполняет fn только для чтения
+	view(ctx context.Context, fn func(tx recordTx) error) error
+
+	// update выполняет fn в транзакции на запись: изменения сохраняются,
+	// только если fn вернула nil
+	update(ctx context.Context, fn func(tx recordTx) error) error
+}
+
+// recordStorage реализует возможности хранилища поверх recordBackend
+//
+// Правила те же, что у InMemoryStorage: проверка и изменение задачи выполняются
+// в одной транзакции, удаление мягкое - задача сохраняется с отметкой DeletedAt,
+// удаленные задачи возвращают *TaskDeletedError в течение времени жизни записи
+// об удалении, ID не используются повторно. При переполнении
+// токенов создания вытесняются самые давние, а не давно не использованные;
+// счетчики обращений к ним ведутся в памяти процесса.
+type recordStorage struct {
+	backend *closableBackend
+	cfg     settings
+
+	tokenHits    atomic.Uint64 // Число найденных токенов
+	tokenMisses  atomic.Uint64 // Число неизвестных или истекших токенов
+	tokenFlushes atomic.Uint64 // Число очисток токенов
+}
+
+// newRecordStorage создает хранилище поверх backend с заданными опциями
+func newRecordStorage(backend recordBackend, opts []Option) *recordStorage {
+	return &recordStorage{backend: &closableBackend{recordBackend: backend}, cfg: newSettings(opts)}
+}
+
+// encodeTask сериализует задачу вместе с метаданными, которые не входят в ее JSON-представление
+func encodeTask(task *models.Task) ([]byte, error) {
+	return json.Marshal(taskRecord{Task: task, Metadata: task.Metadata})
+}
+
+// decodeTask восстанавливает задачу, сериализованную encodeTask
+func decodeTask(data []byte) (*models.Task, error) {
+	record := taskRecord{Task: &models.Task{}}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("поврежденная запись задачи: %w", err)
+	}
+	record.Task.Metadata = record.Metadata
+	return record.Task, nil
+}
+
+// taskRecord - сохраняемое представление задачи
+type taskRecord struct {
+	*models.Task
+	Metadata map[string]json.RawMessage `json:"metadata,omitempty"`
+}
+
+// CreateTask создает новую задачу; ctx прерывает транзакцию базы данных
+func (s *recordStorage) CreateTask(ctx context.Context, input CreateTaskInput) (*models.Task, error) {
+	task := newTask(input, s.cfg.now())
+	err := s.backend.update(ctx, func(tx recordTx) error {
+		return tx.insertTask(task)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// CreateTasks создает несколько задач в одной транзакции, см. InMemoryStorage.CreateTasks
+func (s *recordStorage) CreateTasks(inputs []CreateTaskInput) ([]*models.Task, error) {
+	var tasks []*models.Task
+	err := s.backend.update(context.Background(), func(tx recordTx) error {
+		now := s.cfg.now()
+		tasks = make([]*models.Task, len(inputs))
+		for i, input := range inputs {
+			tasks[i] = newTask(input, now)
+			if err := tx.insertTask(tasks[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// DeleteTasks мягко удаляет несколько задач в одной транзакции, см. InMemoryStorage.DeleteTasks
+func (s *recordStorage) DeleteTasks(ids []int) (deleted []int, missing []int, err error) {
+	err = s.backend.update(context.Background(), func(tx recordTx) error {
+		deleted, missing = nil, nil
+		seen := make(map[int]bool, len(ids))
+		for _, id := range ids {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			task, exists, err := tx.task(id)
+			if err != nil {
+				return err
+			}
+			if !exists || task.DeletedAt != nil {
+				missing = append(missing, id)
+				continue
+			}
+			if task.Protected {
+				return fmt.Errorf("задача %d: %w", id, ErrTaskProtected)
+			}
+			if err := s.softDelete(tx, id, false); err != nil {
+				return err
+			}
+			deleted = append(deleted, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return deleted, missing, nil
+}
+
+// CreateTaskWithToken создает задачу, защищенную от повторного создания клиентским токеном
+//
+// Семантика та же, что у InMemoryStorage.CreateTaskWithToken.
+func (s *recordStorage) CreateTaskWithToken(token string, input CreateTaskInput) (*models.Task, bool, error) {
+	var task *models.Task
+	var created bool
+	err := s.backend.update(context.Background(), func(tx recordTx) error {
+		var err error
+		task, created, err = s.createWithToken(tx, token, input, s.cfg.now())
+		return err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return task, created, nil
+}
+
+// CreateTasksWithTokens создает несколько задач в одной транзакции с защитой
+// клиентскими токенами, см. InMemoryStorage.CreateTasksWithTokens
+func (s *recordStorage) CreateTasksWithTokens(tokens []string, inputs []CreateTaskInput) ([]*models.Task, []bool, error) {
+	var tasks []*models.Task
+	var created []bool
+	err := s.backend.update(context.Background(), func(tx recordTx) error {
+		now := s.cfg.now()
+		tasks, created = make([]*models.Task, len(inputs)), make([]bool, len(inputs))
+		for i, input := range inputs {
+			if tokens[i] == "" {
+				tasks[i], created[i] = newTask(input, now), true
+				if err := tx.insertTask(tasks[i]); err != nil {
+					return err
+				}
+				continue
+			}
+
+			var err error
+			if tasks[i], created[i], err = s.createWithToken(tx, tokens[i], input, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return tasks, created, nil
+}
+
+// createWithToken создает задачу по клиентскому токену в транзакции tx или
+// возвращает задачу, ранее созданную по этому токену
+func (s *recordStorage) createWithToken(tx recordTx, token string, input CreateTaskInput, now time.Time) (*models.Task, bool, error) {
+	// Повтор создания возвращает исходную задачу, если она еще существует
+	id, createdAt, exists, err := tx.clientToken(token)
+	if err != nil {
+		return nil, false, err
+	}
+	if exists && now.Before(createdAt.Add(s.cfg.tokenTTL)) {
+		s.tokenHits.Add(1)
+		original, exists, err := tx.task(id)
+		if err != nil {
+			return nil, false, err
+		}
+		if exists && original.DeletedAt == nil {
+			return original, false, nil
+		}
+	} else {
+		s.tokenMisses.Add(1)
+	}
+
+	task := newTask(input, now)
+	if err := tx.insertTask(task); err != nil {
+		return nil, false, err
+	}
+	if err := tx.deleteClientToken(token); err != nil {
+		return nil, false, err
+	}
+	if err := tx.pruneClientTokens(now.Add(-s.cfg.tokenTTL), s.cfg.tokenCapacity-1); err != nil {
+		return nil, false, err
+	}
+	if s.cfg.tokenCapacity <= 0 {
+		return task, true, nil
+	}
+	return task, true, tx.putClientToken(token, task.ID, now)
+}
+
+// GetAllTasks возвращает список всех задач, кроме удаленных, в порядке возрастания ID
+func (s *recordStorage) GetAllTasks(ctx context.Context) ([]*models.Task, error) {
+	var tasks []*models.Task
+	err := s.backend.view(ctx, func(tx recordTx) error {
+		return s.eachLiveTask(tx, func(task *models.Task) error {
+			tasks = append(tasks, task)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if tasks == nil {
+		tasks = []*models.Task{}
+	}
+	return tasks, nil
+}
+
+// ListTasksFunc передает задачи, кроме удаленных, функции fn по одной в порядке возрастания ID, не собирая их в срез
+func (s *recordStorage) ListTasksFunc(ctx context.Context, fn func(*models.Task) error) error {
+	return s.backend.view(ctx, func(tx recordTx) error {
+		return s.eachLiveTask(tx, func(task *models.Task) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return fn(task)
+		})
+	})
+}
+
+// GetTasksPaginated возвращает страницу списка задач в порядке возрастания ID, см. InMemoryStorage.GetTasksPaginated
+func (s *recordStorage) GetTasksPaginated(offset, limit int) ([]*models.Task, int, error) {
+	tasks := []*models.Task{}
+	var total int
+	err := s.backend.view(context.Background(), func(tx recordTx) error {
+		// Задачи до offset и после страницы только подсчитываются
+		return s.eachLiveTask(tx, func(task *models.Task) error {
+			if total >= offset && len(tasks) < limit {
+				tasks = append(tasks, task)
+			}
+			total++
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return tasks, total, nil
+}
+
+// TasksByPriority возвращает задачи с приоритетом priority в порядке возрастания ID
+//
+// Индекса по приоритету нет: задачи отбираются обходом всех задач.
+func (s *recordStorage) TasksByPriority(priority string) ([]*models.Task, error) {
+	tasks := []*models.Task{}
+	err := s.backend.view(context.Background(), func(tx recordTx) error {
+		return s.eachLiveTask(tx, func(task *models.Task) error {
+			if task.Priority == priority {
+				tasks = append(tasks, task)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// TasksByTags возвращает задачи со всеми тегами tags в порядке возрастания ID
+//
+// Индекса по тегам нет: задачи отбираются обходом всех задач.
+func (s *recordStorage) TasksByTags(tags []string) ([]*models.Task, error) {
+	tasks := []*models.Task{}
+	err := s.backend.view(context.Background(), func(tx recordTx) error {
+		return s.eachLiveTask(tx, func(task *models.Task) error {
+			if task.HasTags(tags) {
+				tasks = append(tasks, task)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// GetOverdueTasks возвращает просроченные задачи в порядке возрастания ID, см. InMemoryStorage.GetOverdueTasks
+func (s *recordStorage) GetOverdueTasks() ([]*models.Task, error) {
+	tasks := []*models.Task{}
+	err := s.backend.view(context.Background(), func(tx recordTx) error {
+		now := s.cfg.now()
+		return s.eachLiveTask(tx, func(task *models.Task) error {
+			if task.Overdue(now) {
+				tasks = append(tasks, task)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// NextID возвращает ID, который получит следующая созданная задача
+//
+// В хранилищах, общих для нескольких процессов, ID может достаться задаче,
+// созданной другим процессом раньше.
+func (s *recordStorage) NextID() (int, error) {
+	var next int
+	err := s.backend.view(context.Background(), func(tx recordTx) error {
+		lastID, err := tx.lastID()
+		next = lastID + 1
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return next, nil
+}
+
+// SetLastID задает последний выданный ID, см. CounterStorage
+func (s *recordStorage) SetLastID(id int) error {
+	return s.backend.update(context.Background(), func(tx recordTx) error {
+		lastID, err := tx.lastID()
+		if err != nil {
+			return err
+		}
+		if id < lastID {
+			return ErrLastIDDecrease
+		}
+		if id == lastID {
+			return nil
+		}
+		return tx.setLastID(id)
+	})
+}
+
+// GetTask возвращает задачу по ID
+func (s *recordStorage) GetTask(ctx context.Context, id int) (*models.Task, error) {
+	var task *models.Task
+	err := s.backend.view(ctx, func(tx recordTx) error {
+		var err error
+		task, err = s.find(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// UpdateTask обновляет существующую задачу
+func (s *recordStorage) UpdateTask(ctx context.Context, id int, input UpdateTaskInput) (*models.Task, error) {
+	return s.modify(ctx, id, func(task *models.Task) error {
+		return applyUpdate(task, input, s.cfg.completedImmutable, s.cfg.now())
+	})
+}
+
+// UpdateTaskIfVersion обновляет задачу с версией version, см. InMemoryStorage.UpdateTaskIfVersion
+func (s *recordStorage) UpdateTaskIfVersion(id, version int, input UpdateTaskInput) (*models.Task, error) {
+	return s.modify(context.Background(), id, func(task *models.Task) error {
+		if err := checkVersion(task, version); err != nil {
+			return err
+		}
+		return applyUpdate(task, input, s.cfg.completedImmutable, s.cfg.now())
+	})
+}
+
+// PatchTask частично обновляет задачу, см. InMemoryStorage.PatchTask
+func (s *recordStorage) PatchTask(id int, patch map[string]interface{}) (*models.Task, error) {
+	return s.modify(context.Background(), id, func(task *models.Task) error {
+		input, err := patchInput(task, patch)
+		if err != nil {
+			return err
+		}
+		return applyUpdate(task, input, s.cfg.completedImmutable, s.cfg.now())
+	})
+}
+
+// CompleteWithFollowUp отмечает задачу выполненной и создает продолжение в одной транзакции
+func (s *recordStorage) CompleteWithFollowUp(id int, input CreateTaskInput) (*models.Task, *models.Task, error) {
+	var task, followUp *models.Task
+	err := s.backend.update(context.Background(), func(tx recordTx) error {
+		var err error
+		if task, err = s.find(tx, id); err != nil {
+			return err
+		}
+		if task.Completed {
+			return ErrTaskAlreadyCompleted
+		}
+
+		followUp = newTask(input, s.cfg.now())
+		followUp.FollowsID = id
+		if err := tx.insertTask(followUp); err != nil {
+			return err
+		}
+		task.Completed = true
+		task.Version++
+		task.UpdatedAt = followUp.CreatedAt
+		return tx.updateTask(task)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return task, followUp, nil
+}
+
+// CompletedImmutable сообщает, запрещено ли изменять выполненные задачи
+func (s *recordStorage) CompletedImmutable() bool {
+	return s.cfg.completedImmutable
+}
+
+// AddLink добавляет ссылку к задаче
+func (s *recordStorage) AddLink(id int, link models.Link) (*models.Task, error) {
+	return s.modify(context.Background(), id, func(task *models.Task) error {
+		return addLink(task, link, s.cfg.completedImmutable, s.cfg.now())
+	})
+}
+
+// RemoveLink удаляет ссылку задачи по индексу
+func (s *recordStorage) RemoveLink(id, index int) (*models.Task, error) {
+	return s.modify(context.Background(), id, func(task *models.Task) error {
+		return removeLink(task, index, s.cfg.completedImmutable, s.cfg.now())
+	})
+}
+
+// MetadataLimit возвращает максимальный размер значения пространства метаданных в байтах
+func (s *recordStorage) MetadataLimit() int {
+	return s.cfg.metadataLimit
+}
+
+// GetMetadata возвращает копию всех метаданных задачи
+func (s *recordStorage) GetMetadata(id int) (map[string]json.RawMessage, error) {
+	task, err := s.GetTask(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+	return copyMetadata(task), nil
+}
+
+// SetMetadata заменяет значение пространства метаданных задачи
+func (s *recordStorage) SetMetadata(id int, namespace string, value json.RawMessage) error {
+	if err := checkMetadata(namespace, value, s.cfg.metadataLimit); err != nil {
+		return err
+	}
+	_, err := s.modify(context.Background(), id, func(task *models.Task) error {
+		setMetadata(task, namespace, value)
+		return nil
+	})
+	return err
+}
+
+// DeleteMetadata удаляет пространство метаданных задачи
+func (s *recordStorage) DeleteMetadata(id int, namespace string) error {
+	_, err := s.modify(context.Background(), id, func(task *models.Task) error {
+		return deleteMetadata(task, namespace)
+	})
+	return err
+}
+
+// DeleteTask удаляет задачу; защищенную задачу удаляет только DeleteTaskConfirmed
+func (s *recordStorage) DeleteTask(ctx context.Context, id int) error {
+	return s.deleteTask(ctx, id, false)
+}
+
+// DeleteTaskConfirmed удаляет задачу, в том числе защищенную
+func (s *recordStorage) DeleteTaskConfirmed(id int) error {
+	return s.deleteTask(context.Background(), id, true)
+}
+
+// RestoreTask восстанавливает мягко удаленную задачу, см. InMemoryStorage.RestoreTask
+func (s *recordStorage) RestoreTask(id int) error {
+	return s.backend.update(context.Background(), func(tx recordTx) error {
+		task, exists, err := tx.task(id)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return s.notFound(tx, id)
+		}
+		if task.DeletedAt == nil {
+			return ErrTaskNotDeleted
+		}
+
+		task.DeletedAt = nil
+		task.UpdatedAt = s.cfg.now().UTC()
+		return tx.updateTask(task)
+	})
+}
+
+// PurgeTask окончательно удаляет мягко удаленную задачу, см. InMemoryStorage.PurgeTask
+func (s *recordStorage) PurgeTask(id int) error {
+	return s.backend.update(context.Background(), func(tx recordTx) error {
+		task, exists, err := tx.task(id)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return s.notFound(tx, id)
+		}
+		if task.DeletedAt == nil {
+			return ErrTaskNotDeleted
+		}
+		return tx.deleteTask(id)
+	})
+}
+
+// GetAllTasksWithDeleted возвращает все задачи, включая мягко удаленные, в порядке возрастания ID
+func (s *recordStorage) GetAllTasksWithDeleted() ([]*models.Task, error) {
+	tasks := []*models.Task{}
+	err := s.backend.view(context.Background(), func(tx recordTx) error {
+		return tx.eachTask(func(task *models.Task) error {
+			tasks = append(tasks, task)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// TombstoneTTL возвращает время, в течение которого хранилище помнит удаленные задачи
+func (s *recordStorage) TombstoneTTL() time.Duration {
+	return s.cfg.tombstoneTTL
+}
+
+// Caches возвращает состояние токенов создания задач в формате служебных кэшей
+func (s *recordStorage) Caches() []CacheStats {
+	// Ошибка чтения не мешает отдать счетчики: размер тогда остается нулевым
+	var size int
+	s.backend.view(context.Background(), func(tx recordTx) error {
+		var err error
+		size, err = tx.countClientTokens()
+		return err
+	})
+	return []CacheStats{s.tokenStats(size)}
+}
+
+// FlushCache удаляет все токены создания задач, см. InMemoryStorage.FlushCache
+func (s *recordStorage) FlushCache(name string) (CacheStats, error) {
+	if name != ClientTokenCache {
+		return CacheStats{}, ErrCacheNotFound
+	}
+	err := s.backend.update(context.Background(), func(tx recordTx) error {
+		return tx.clearClientTokens()
+	})
+	if err != nil {
+		return CacheStats{}, err
+	}
+	s.tokenFlushes.Add(1)
+	return s.tokenStats(0), nil
+}
+
+// tokenStats возвращает состояние токенов создания с числом записей size
+func (s *recordStorage) tokenStats(size int) CacheStats {
+	return CacheStats{
+		Name:     ClientTokenCache,
+		Size:     size,
+		Capacity: s.cfg.tokenCapacity,
+		Hits:     s.tokenHits.Load(),
+		Misses:   s.tokenMisses.Load(),
+		Flushes:  s.tokenFlushes.Load(),
+	}
+}
+
+// deleteTask мягко удаляет задачу с записью об удалении
+func (s *recordStorage) deleteTask(ctx context.Context, id int, confirmed bool) error {
+	return s.backend.update(ctx, func(tx recordTx) error {
+		return s.softDelete(tx, id, confirmed)
+	})
+}
+
+// softDelete отмечает задачу удаленной и запоминает удаление в транзакции tx
+func (s *recordStorage) softDelete(tx recordTx, id int, confirmed bool) error {
+	task, err := s.find(tx, id)
+	if err != nil {
+		return err
+	}
+	if task.Protected && !confirmed {
+		return ErrTaskProtected
+	}
+
+	now := s.cfg.now()
+	deletedAt := now.UTC()
+	task.DeletedAt = &deletedAt
+	if err := tx.updateTask(task); err != nil {
+		return err
+	}
+	if err := tx.putTombstone(id, now); err != nil {
+		return err
+	}
+	return tx.pruneTombstones(now.Add(-s.cfg.tombstoneTTL), s.cfg.tombstoneCapacity)
+}
+
+// modify изменяет задачу функцией fn и сохраняет ее в одной транзакции
+func (s *recordStorage) modify(ctx context.Context, id int, fn func(task *models.Task) error) (*models.Task, error) {
+	var task *models.Task
+	err := s.backend.update(ctx, func(tx recordTx) error {
+		var err error
+		if task, err = s.find(tx, id); err != nil {
+			return err
+		}
+		if err := fn(task); err != nil {
+			return err
+		}
+		return tx.updateTask(task)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// find возвращает задачу, кроме удаленной, или ошибку, отличающую недавно удаленную задачу от несуществующей
+func (s *recordStorage) find(tx recordTx, id int) (*models.Task, error) {
+	task, exists, err := tx.task(id)
+	if err != nil {
+		return nil, err
+	}
+	if exists && task.DeletedAt == nil {
+		return task, nil
+	}
+	return nil, s.notFound(tx, id)
+}
+
+// notFound возвращает *TaskDeletedError для недавно удаленной задачи, для остальных - ErrTaskNotFound
+func (s *recordStorage) notFound(tx recordTx, id int) error {
+	deletedAt, deleted, err := tx.tombstone(id)
+	if err != nil {
+		return err
+	}
+	if deleted && s.cfg.now().Before(deletedAt.Add(s.cfg.tombstoneTTL)) {
+		return &TaskDeletedError{ID: id, DeletedAt: deletedAt}
+	}
+	return taskNotFound(id)
+}
+
+// eachLiveTask передает функции fn задачи, кроме удаленных, в порядке возрастания ID
+func (s *recordStorage) eachLiveTask(tx recordTx, fn func(*models.Task) error) error {
+	return tx.eachTask(func(task *models.Task) error {
+		if task.DeletedAt != nil {
+			return nil
+		}
+		return fn(task)
+	})
+}
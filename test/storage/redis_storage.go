@@ -0,0 +1,430 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"test/models"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Ключи хранилища в Redis
+const (
+	redisTaskIDs       = "tasks"                 // Множество ID всех задач
+	redisNextID        = "tasks:next_id"         // Счетчик ID, увеличивается INCR
+	redisTombstones    = "tombstones"            // ID удаленных задач с моментом удаления
+	redisTokens        = "client_tokens"         // ID задач по токенам создания
+	redisTokensCreated = "client_tokens:created" // Токены создания с моментом создания
+)
+
+// redisMaxRetries ограничивает число повторов транзакции, прерванной конкурентной записью
+const redisMaxRetries = 100
+
+// redisTaskKey возвращает ключ хеша задачи
+func redisTaskKey(id int) string {
+	return "task:" + strconv.Itoa(id)
+}
+
+// redisScore переводит момент времени в оценку сортированного множества
+//
+// Оценка хранится числом float64, поэтому берутся микросекунды: наносекунды
+// Unix не помещаются в его мантиссу без потери точности.
+func redisScore(t time.Time) float64 {
+	return float64(t.UnixMicro())
+}
+
+// redisTime восстанавливает момент времени из оценки redisScore
+func redisTime(score float64) time.Time {
+	return time.UnixMicro(int64(score))
+}
+
+// redisPruneTokens удаляет токены создания, созданные не позже ARGV[1], и самые
+// давние токены сверх ARGV[2]
+//
+// Скрипт выполняется внутри MULTI вместе с остальными записями транзакции и видит
+// их результат, поэтому удаленный перед этим или добавленный токен учитывается верно.
+var redisPruneTokens = redis.NewScript(`
+local expired = redis.call('ZCOUNT', KEYS[1], '-inf', ARGV[1])
+local extra = redis.call('ZCARD', KEYS[1]) - tonumber(ARGV[2])
+local count = math.max(expired, extra)
+if count <= 0 then
+	return 0
+end
+local tokens = redis.call('ZRANGE', KEYS[1], 0, count - 1)
+for _, token in ipairs(tokens) do
+	redis.call('ZREM', KEYS[1], token)
+	redis.call('HDEL', KEYS[2], token)
+end
+return #tokens
+`)
+
+// RedisStorage реализует хранилище задач в Redis
+//
+// Поддерживает те же возможности, что и InMemoryStorage, и принимает те же опции.
+// Задача хранится хешем task:{id}, поля которого - поля ее JSON-представления
+// со значениями в JSON. ID выдает INCR, поэтому несколько экземпляров сервера
+// с одним Redis не получат одинаковых ID. Записи выполняются оптимистичными
+// транзакциями WATCH/MULTI и повторяются, если прочитанные ключи успели измениться.
+type RedisStorage struct {
+	*recordStorage
+	client *redis.Client
+}
+
+// NewRedisStorage подключается к Redis
+//
+// Args:
+//
+//	url: адрес сервера, например redis://localhost:6379/0
+//	opts: опции хранилища
+//
+// Returns:
+//
+//	*RedisStorage: хранилище задач
+//	error: ошибка разбора адреса или подключения
+func NewRedisStorage(url string, opts ...Option) (*RedisStorage, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("адрес Redis: %w", err)
+	}
+
+	client := redis.NewClient(options)
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("подключение к Redis: %w", err)
+	}
+	return &RedisStorage{recordStorage: newRecordStorage(redisBackend{client: client}, opts), client: client}, nil
+}
+
+// Close закрывает подключения к Redis, см. Storage.Close
+func (s *RedisStorage) Close() error {
+	return s.shutdown(s.client.Close)
+}
+
+// Ping проверяет подключение к серверу Redis
+func (s *RedisStorage) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+// redisBackend выполняет операции с записями в Redis
+type redisBackend struct {
+	client *redis.Client
+}
+
+// view выполняет fn отдельными командами: каждое чтение видит последние записи
+func (b redisBackend) view(ctx context.Context, fn func(tx recordTx) error) error {
+	return fn(&redisTx{ctx: ctx, c: b.client})
+}
+
+// update выполняет fn в транзакции WATCH/MULTI
+//
+// Чтения выполняются сразу и отслеживают прочитанные ключи, записи накапливаются
+// и выполняются одним MULTI/EXEC. Задачи и записи об удалении, уже записанные
+// транзакцией, читаются из накопленных записей. Если отслеживаемый ключ изменила
+// другая транзакция, EXEC не применяет записи и fn выполняется заново.
+func (b redisBackend) update(ctx context.Context, fn func(tx recordTx) error) error {
+	for attempt := 0; attempt < redisMaxRetries; attempt++ {
+		err := b.client.Watch(ctx, func(watched *redis.Tx) error {
+			tx := &redisTx{ctx: ctx, c: watched, watched: watched}
+			if err := fn(tx); err != nil {
+				return err
+			}
+			_, err := watched.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				for _, write := range tx.writes {
+					write(pipe)
+				}
+				return nil
+			})
+			return err
+		})
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+	return fmt.Errorf("транзакция Redis прервана конкурентными записями %d раз подряд", redisMaxRetries)
+}
+
+// redisTx реализует recordTx командами Redis
+type redisTx struct {
+	ctx     context.Context
+	c       redis.Cmdable
+	watched *redis.Tx                    // Соединение транзакции; nil при чтении
+	writes  []func(pipe redis.Pipeliner) // Записи, выполняемые в MULTI
+
+	written    map[int]*models.Task // Задачи, записанные транзакцией; nil - удалена
+	tombstones map[int]time.Time    // Записи об удалении, сделанные транзакцией
+}
+
+// watch отслеживает ключи до конца транзакции на запись
+func (tx *redisTx) watch(keys ...string) error {
+	if tx.watched == nil {
+		return nil
+	}
+	return tx.watched.Watch(tx.ctx, keys...).Err()
+}
+
+// write добавляет запись в MULTI транзакции
+func (tx *redisTx) write(fn func(pipe redis.Pipeliner)) {
+	tx.writes = append(tx.writes, fn)
+}
+
+// scanRedisTask восстанавливает задачу из полей хеша
+func scanRedisTask(id int, fields map[string]string) (*models.Task, error) {
+	document := make(map[string]json.RawMessage, len(fields))
+	for name, value := range fields {
+		document[name] = json.RawMessage(value)
+	}
+	data, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("поврежденная запись задачи: %w", err)
+	}
+	task, err := decodeTask(data)
+	if err != nil {
+		return nil, err
+	}
+	task.ID = id
+	return task, nil
+}
+
+func (tx *redisTx) task(id int) (*models.Task, bool, error) {
+	if task, written := tx.written[id]; written {
+		return cloneTask(task), task != nil, nil
+	}
+	key := redisTaskKey(id)
+	if err := tx.watch(key); err != nil {
+		return nil, false, err
+	}
+	fields, err := tx.c.HGetAll(tx.ctx, key).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if len(fields) == 0 {
+		return nil, false, nil
+	}
+	task, err := scanRedisTask(id, fields)
+	return task, err == nil, err
+}
+
+func (tx *redisTx) eachTask(fn func(*models.Task) error) error {
+	members, err := tx.c.SMembers(tx.ctx, redisTaskIDs).Result()
+	if err != nil {
+		return err
+	}
+	ids := make([]int, 0, len(members))
+	for _, member := range members {
+		id, err := strconv.Atoi(member)
+		if err != nil {
+			return fmt.Errorf("поврежденный ID задачи %q: %w", member, err)
+		}
+		if _, written := tx.written[id]; !written {
+			ids = append(ids, id)
+		}
+	}
+	for id := range tx.written {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		if task, written := tx.written[id]; written {
+			if task == nil {
+				continue
+			}
+			if err := fn(cloneTask(task)); err != nil {
+				return err
+			}
+			continue
+		}
+		fields, err := tx.c.HGetAll(tx.ctx, redisTaskKey(id)).Result()
+		if err != nil {
+			return err
+		}
+		// Задача удалена после чтения множества ID
+		if len(fields) == 0 {
+			continue
+		}
+		task, err := scanRedisTask(id, fields)
+		if err != nil {
+			return err
+		}
+		if err := fn(task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tx *redisTx) insertTask(task *models.Task) error {
+	// INCR выполняется сразу, вне MULTI: ID прерванной транзакции просто пропускается
+	id, err := tx.c.Incr(tx.ctx, redisNextID).Result()
+	if err != nil {
+		return err
+	}
+	task.ID = int(id)
+	if err := tx.updateTask(task); err != nil {
+		return err
+	}
+	tx.write(func(pipe redis.Pipeliner) {
+		pipe.SAdd(tx.ctx, redisTaskIDs, task.ID)
+	})
+	return nil
+}
+
+func (tx *redisTx) updateTask(task *models.Task) error {
+	data, err := encodeTask(task)
+	if err != nil {
+		return err
+	}
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(data, &document); err != nil {
+		return err
+	}
+	fields := make(map[string]interface{}, len(document))
+	for name, value := range document {
+		fields[name] = string(value)
+	}
+
+	if tx.written == nil {
+		tx.written = make(map[int]*models.Task)
+	}
+	tx.written[task.ID] = cloneTask(task)
+
+	// Хеш заменяется целиком: пустые поля не сериализуются и должны исчезнуть
+	key := redisTaskKey(task.ID)
+	tx.write(func(pipe redis.Pipeliner) {
+		pipe.Del(tx.ctx, key)
+		pipe.HSet(tx.ctx, key, fields)
+	})
+	return nil
+}
+
+func (tx *redisTx) deleteTask(id int) error {
+	if tx.written == nil {
+		tx.written = make(map[int]*models.Task)
+	}
+	tx.written[id] = nil
+	tx.write(func(pipe redis.Pipeliner) {
+		pipe.Del(tx.ctx, redisTaskKey(id))
+		pipe.SRem(tx.ctx, redisTaskIDs, id)
+	})
+	return nil
+}
+
+func (tx *redisTx) tombstone(id int) (time.Time, bool, error) {
+	if deletedAt, written := tx.tombstones[id]; written {
+		return deletedAt, true, nil
+	}
+	if err := tx.watch(redisTombstones); err != nil {
+		return time.Time{}, false, err
+	}
+	score, err := tx.c.ZScore(tx.ctx, redisTombstones, strconv.Itoa(id)).Result()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return redisTime(score), true, nil
+}
+
+func (tx *redisTx) putTombstone(id int, deletedAt time.Time) error {
+	if tx.tombstones == nil {
+		tx.tombstones = make(map[int]time.Time)
+	}
+	tx.tombstones[id] = deletedAt
+	tx.write(func(pipe redis.Pipeliner) {
+		pipe.ZAdd(tx.ctx, redisTombstones, redis.Z{Score: redisScore(deletedAt), Member: strconv.Itoa(id)})
+	})
+	return nil
+}
+
+func (tx *redisTx) pruneTombstones(before time.Time, keep int) error {
+	cutoff := strconv.FormatFloat(redisScore(before), 'f', -1, 64)
+	tx.write(func(pipe redis.Pipeliner) {
+		pipe.ZRemRangeByScore(tx.ctx, redisTombstones, "-inf", cutoff)
+		// Ранг -(keep+1) - последняя запись перед keep самыми поздними
+		pipe.ZRemRangeByRank(tx.ctx, redisTombstones, 0, int64(-max(keep, 0)-1))
+	})
+	return nil
+}
+
+func (tx *redisTx) clientToken(token string) (int, time.Time, bool, error) {
+	if err := tx.watch(redisTokens, redisTokensCreated); err != nil {
+		return 0, time.Time{}, false, err
+	}
+	score, err := tx.c.ZScore(tx.ctx, redisTokensCreated, token).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, time.Time{}, false, nil
+	}
+	if err != nil {
+		return 0, time.Time{}, false, err
+	}
+	id, err := tx.c.HGet(tx.ctx, redisTokens, token).Int()
+	if errors.Is(err, redis.Nil) {
+		return 0, time.Time{}, false, nil
+	}
+	if err != nil {
+		return 0, time.Time{}, false, err
+	}
+	return id, redisTime(score), true, nil
+}
+
+func (tx *redisTx) putClientToken(token string, id int, createdAt time.Time) error {
+	tx.write(func(pipe redis.Pipeliner) {
+		pipe.HSet(tx.ctx, redisTokens, token, id)
+		pipe.ZAdd(tx.ctx, redisTokensCreated, redis.Z{Score: redisScore(createdAt), Member: token})
+	})
+	return nil
+}
+
+func (tx *redisTx) deleteClientToken(token string) error {
+	tx.write(func(pipe redis.Pipeliner) {
+		pipe.HDel(tx.ctx, redisTokens, token)
+		pipe.ZRem(tx.ctx, redisTokensCreated, token)
+	})
+	return nil
+}
+
+func (tx *redisTx) pruneClientTokens(before time.Time, keep int) error {
+	keys := []string{redisTokensCreated, redisTokens}
+	cutoff := strconv.FormatFloat(redisScore(before), 'f', -1, 64)
+	tx.write(func(pipe redis.Pipeliner) {
+		redisPruneTokens.Eval(tx.ctx, pipe, keys, cutoff, max(keep, 0))
+	})
+	return nil
+}
+
+func (tx *redisTx) countClientTokens() (int, error) {
+	count, err := tx.c.ZCard(tx.ctx, redisTokensCreated).Result()
+	return int(count), err
+}
+
+func (tx *redisTx) clearClientTokens() error {
+	tx.write(func(pipe redis.Pipeliner) {
+		pipe.Del(tx.ctx, redisTokens, redisTokensCreated)
+	})
+	return nil
+}
+
+func (tx *redisTx) lastID() (int, error) {
+	// Отслеживание счетчика прерывает транзакцию, если INCR выдал ID после чтения
+	if err := tx.watch(redisNextID); err != nil {
+		return 0, err
+	}
+	id, err := tx.c.Get(tx.ctx, redisNextID).Int()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return id, err
+}
+
+func (tx *redisTx) setLastID(id int) error {
+	tx.write(func(pipe redis.Pipeliner) {
+		pipe.Set(tx.ctx, redisNextID, id, 0)
+	})
+	return nil
+}
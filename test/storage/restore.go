@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"sort"
+	"test/models"
+)
+
+// RestoreTask восстанавливает мягко удаленную задачу
+//
+// Задача снова видна операциям с задачами с прежними ID и полями. Восстановить
+// можно и после того, как истекла запись об удалении (см. WithTombstoneTTL).
+//
+// Args:
+//
+//	id: ID удаленной задачи
+//
+// Returns:
+//
+//	error: ошибка при поиске задачи или ErrTaskNotDeleted
+func (s *InMemoryStorage) RestoreTask(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.writable(); err != nil {
+		return err
+	}
+
+	task, exists := s.tasks[id]
+	if !exists || task.Expired(s.now()) {
+		return s.notFound(id)
+	}
+	if task.DeletedAt == nil {
+		return ErrTaskNotDeleted
+	}
+
+	task = cloneTask(task)
+	task.DeletedAt = nil
+	task.UpdatedAt = s.now().UTC()
+	s.store(task)
+	return nil
+}
+
+// PurgeTask окончательно удаляет мягко удаленную задачу
+//
+// Запись об удалении остается, поэтому в течение ее времени жизни обращения
+// к задаче по-прежнему возвращают *TaskDeletedError. ID задачи повторно не выдается.
+//
+// Args:
+//
+//	id: ID удаленной задачи
+//
+// Returns:
+//
+//	error: ошибка при поиске задачи или ErrTaskNotDeleted
+func (s *InMemoryStorage) PurgeTask(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.writable(); err != nil {
+		return err
+	}
+
+	task, exists := s.tasks[id]
+	if !exists {
+		return s.notFound(id)
+	}
+	if task.DeletedAt == nil {
+		return ErrTaskNotDeleted
+	}
+
+	// Удаленная задача уже убрана из индекса приоритетов, см. store
+	delete(s.tasks, id)
+	return nil
+}
+
+// GetAllTasksWithDeleted возвращает все задачи, включая мягко удаленные, в порядке возрастания ID
+//
+// Истекшие задачи не возвращаются.
+//
+// Returns:
+//
+//	[]*models.Task: список всех задач, у удаленных задан DeletedAt
+//	error: ошибка при получении задач
+func (s *InMemoryStorage) GetAllTasksWithDeleted() ([]*models.Task, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.now()
+	tasks := make([]*models.Task, 0, len(s.tasks))
+	for _, task := range s.tasks {
+		if !task.Expired(now) {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks, nil
+}
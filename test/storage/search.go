@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"test/models"
+)
+
+// SearchTasks возвращает задачи, в названии или описании которых есть query
+// без учета регистра, в порядке возрастания ID
+//
+// Поиск - простой перебор всех задач со strings.Contains, время растет линейно
+// с числом задач. Для больших хранилищ его стоит заменить индексом по
+// триграммам (например, pg_trgm в PostgreSQL или FTS в SQLite), который
+// находит подстроки, не просматривая каждую задачу.
+//
+// Args:
+//
+//	query: искомая подстрока
+//
+// Returns:
+//
+//	[]*models.Task: найденные задачи
+//	error: ошибка при получении задач
+func (s *InMemoryStorage) SearchTasks(query string) ([]*models.Task, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	query = strings.ToLower(query)
+	now := s.now()
+	tasks := []*models.Task{}
+	for _, task := range s.tasks {
+		if visible(task, now) && matchesQuery(task, query) {
+			tasks = append(tasks, cloneTask(task))
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks, nil
+}
+
+// SearchTasks возвращает задачи с query в названии или описании в порядке возрастания ID,
+// см. InMemoryStorage.SearchTasks
+func (s *recordStorage) SearchTasks(query string) ([]*models.Task, error) {
+	query = strings.ToLower(query)
+	tasks := []*models.Task{}
+	err := s.backend.view(context.Background(), func(tx recordTx) error {
+		return s.eachLiveTask(tx, func(task *models.Task) error {
+			if matchesQuery(task, query) {
+				tasks = append(tasks, task)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// matchesQuery проверяет, что название или описание задачи содержит query в нижнем регистре
+func matchesQuery(task *models.Task, query string) bool {
+	return strings.Contains(strings.ToLower(task.Title), query) ||
+		strings.Contains(strings.ToLower(task.Description), query)
+}
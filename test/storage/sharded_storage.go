@@ -0,0 +1,319 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"test/models"
+	"time"
+)
+
+// ShardedStorage реализует хранилище задач в памяти, разделенное на сегменты
+//
+// Задача с ID id хранится в сегменте id % n под собственным мьютексом сегмента,
+// поэтому операции с задачами разных сегментов не ждут друг друга, а ID выдаются
+// атомарным счетчиком без общей блокировки. Как и в InMemoryStorage, сохраненная
+// задача не изменяется, а GetTask и GetAllTasks возвращают копии.
+//
+// Хранилище поддерживает только основные операции, защиту от удаления, политику
+// выполненных задач и условное обновление по версии; на остальные возможности
+// обработчики отвечают 501.
+type ShardedStorage struct {
+	shards []*taskShard     // Сегменты задач
+	lastID atomic.Int64     // Последний использованный ID
+	now    func() time.Time // Источник текущего времени
+
+	completedImmutable bool // Выполненные задачи можно только возобновить
+
+	closed atomic.Bool // Хранилище закрыто Close и не принимает изменений
+}
+
+// taskShard - сегмент ShardedStorage со своими задачами и мьютексом
+type taskShard struct {
+	tasks map[int]*models.Task // Задачи сегмента
+	mu    sync.RWMutex         // Мьютекс для синхронизации доступа к сегменту
+}
+
+// NewShardedStorage создает хранилище задач в памяти из nShards сегментов
+//
+// Args:
+//
+//	nShards: число сегментов, значения меньше 1 заменяются на 1
+//	opts: опции хранилища; учитываются WithClock и WithCompletedImmutable
+//
+// Returns:
+//
+//	*ShardedStorage: новое пустое хранилище
+func NewShardedStorage(nShards int, opts ...Option) *ShardedStorage {
+	cfg := newSettings(opts)
+	if nShards < 1 {
+		nShards = 1
+	}
+	shards := make([]*taskShard, nShards)
+	for i := range shards {
+		shards[i] = &taskShard{tasks: make(map[int]*models.Task)}
+	}
+	return &ShardedStorage{
+		shards: shards,
+		now:    cfg.now,
+
+		completedImmutable: cfg.completedImmutable,
+	}
+}
+
+// shard возвращает сегмент задачи с ID id
+func (s *ShardedStorage) shard(id int) *taskShard {
+	return s.shards[id%len(s.shards)]
+}
+
+// CreateTask создает новую задачу в хранилище
+//
+// Args:
+//
+//	ctx: контекст запроса, отмененный контекст прерывает операцию до блокировки
+//	input: поля новой задачи
+//
+// Returns:
+//
+//	*models.Task: созданная задача
+//	error: ошибка при создании задачи
+func (s *ShardedStorage) CreateTask(ctx context.Context, input CreateTaskInput) (*models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if s.closed.Load() {
+		return nil, ErrStorageClosed
+	}
+	task := newTask(input, s.now())
+	task.ID = int(s.lastID.Add(1))
+
+	// Блокируется только сегмент новой задачи
+	shard := s.shard(task.ID)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	shard.tasks[task.ID] = task
+	return task, nil
+}
+
+// GetAllTasks возвращает список всех задач из хранилища в порядке ID
+//
+// Блокировки на чтение всех сегментов берутся в порядке сегментов и держатся до
+// конца сбора, поэтому список соответствует одному моменту времени: задача,
+// созданная или удаленная во время сбора, либо видна целиком, либо не видна.
+//
+// Args:
+//
+//	ctx: контекст запроса, отмененный контекст прерывает операцию до блокировки
+//
+// Returns:
+//
+//	[]*models.Task: список всех задач
+//	error: ошибка при получении задач
+func (s *ShardedStorage) GetAllTasks(ctx context.Context) ([]*models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, shard := range s.shards {
+		shard.mu.RLock()
+		defer shard.mu.RUnlock()
+	}
+
+	count := 0
+	for _, shard := range s.shards {
+		count += len(shard.tasks)
+	}
+	tasks := make([]*models.Task, 0, count)
+	for _, shard := range s.shards {
+		for _, task := range shard.tasks {
+			tasks = append(tasks, cloneTask(task))
+		}
+	}
+
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks, nil
+}
+
+// GetTask возвращает задачу по ID
+//
+// Args:
+//
+//	ctx: контекст запроса, отмененный контекст прерывает операцию до блокировки
+//	id: ID задачи
+//
+// Returns:
+//
+//	*models.Task: найденная задача
+//	error: ошибка при поиске задачи
+func (s *ShardedStorage) GetTask(ctx context.Context, id int) (*models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	shard := s.shard(id)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	task, err := shard.find(id)
+	if err != nil {
+		return nil, err
+	}
+	return cloneTask(task), nil
+}
+
+// CompletedImmutable сообщает, запрещено ли изменение выполненных задач, см. WithCompletedImmutable
+func (s *ShardedStorage) CompletedImmutable() bool {
+	return s.completedImmutable
+}
+
+// UpdateTask обновляет существующую задачу, см. InMemoryStorage.UpdateTask
+//
+// Args:
+//
+//	ctx: контекст запроса, отмененный контекст прерывает операцию до блокировки
+//	id: ID задачи
+//	input: новые значения полей задачи
+//
+// Returns:
+//
+//	*models.Task: обновленная задача
+//	error: ошибка при поиске задачи или ErrTaskCompletedImmutable
+func (s *ShardedStorage) UpdateTask(ctx context.Context, id int, input UpdateTaskInput) (*models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if s.closed.Load() {
+		return nil, ErrStorageClosed
+	}
+	shard := s.shard(id)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	task, err := shard.find(id)
+	if err != nil {
+		return nil, err
+	}
+	task = cloneTask(task)
+	if err := applyUpdate(task, input, s.completedImmutable, s.now()); err != nil {
+		return nil, err
+	}
+	shard.tasks[id] = task
+	return task, nil
+}
+
+// UpdateTaskIfVersion обновляет задачу с версией version, см. InMemoryStorage.UpdateTaskIfVersion
+func (s *ShardedStorage) UpdateTaskIfVersion(id, version int, input UpdateTaskInput) (*models.Task, error) {
+	if s.closed.Load() {
+		return nil, ErrStorageClosed
+	}
+	shard := s.shard(id)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	task, err := shard.find(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(task, version); err != nil {
+		return nil, err
+	}
+	task = cloneTask(task)
+	if err := applyUpdate(task, input, s.completedImmutable, s.now()); err != nil {
+		return nil, err
+	}
+	shard.tasks[id] = task
+	return task, nil
+}
+
+// DeleteTask удаляет задачу по ID
+//
+// Args:
+//
+//	ctx: контекст запроса, отмененный контекст прерывает операцию до блокировки
+//	id: ID задачи для удаления
+//
+// Returns:
+//
+//	error: ошибка при поиске задачи или ErrTaskProtected
+func (s *ShardedStorage) DeleteTask(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.deleteTask(id, false)
+}
+
+// DeleteTaskConfirmed удаляет задачу, в том числе защищенную, после явного подтверждения клиента
+//
+// Args:
+//
+//	id: ID задачи для удаления
+//
+// Returns:
+//
+//	error: ошибка при удалении задачи
+func (s *ShardedStorage) DeleteTaskConfirmed(id int) error {
+	return s.deleteTask(id, true)
+}
+
+// deleteTask удаляет задачу из сегмента, проверяя защиту под той же блокировкой
+func (s *ShardedStorage) deleteTask(id int, confirmed bool) error {
+	if s.closed.Load() {
+		return ErrStorageClosed
+	}
+	shard := s.shard(id)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	task, err := shard.find(id)
+	if err != nil {
+		return err
+	}
+	if task.Protected && !confirmed {
+		return ErrTaskProtected
+	}
+	delete(shard.tasks, id)
+	return nil
+}
+
+// NextID возвращает ID, который получит следующая созданная задача
+//
+// Returns:
+//
+//	int: следующий ID
+//	error: всегда nil
+func (s *ShardedStorage) NextID() (int, error) {
+	return int(s.lastID.Load()) + 1, nil
+}
+
+// SetLastID задает последний выданный ID, см. CounterStorage
+//
+// Args:
+//
+//	id: последний выданный ID, не меньше текущего
+//
+// Returns:
+//
+//	error: ErrLastIDDecrease, если id меньше текущего значения
+func (s *ShardedStorage) SetLastID(id int) error {
+	if s.closed.Load() {
+		return ErrStorageClosed
+	}
+	for {
+		current := s.lastID.Load()
+		if int64(id) < current {
+			return ErrLastIDDecrease
+		}
+		if s.lastID.CompareAndSwap(current, int64(id)) {
+			return nil
+		}
+	}
+}
+
+// find возвращает задачу сегмента, вызывается под блокировкой сегмента
+func (shard *taskShard) find(id int) (*models.Task, error) {
+	task, exists := shard.tasks[id]
+	if !exists {
+		return nil, taskNotFound(id)
+	}
+	return task, nil
+}
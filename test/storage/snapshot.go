@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"test/models"
+)
+
+// SnapshotVersion - версия формата, которую пишет SaveSnapshot
+//
+// Номер увеличивается, только если старый снимок нельзя прочитать как новый:
+// добавленные поля задачи в старом снимке просто отсутствуют и получают нулевое
+// значение, неизвестные поля нового снимка игнорируются.
+const SnapshotVersion = 1
+
+// snapshot - содержимое снимка хранилища в памяти
+//
+// Задачи записываются в порядке ID в том же виде, что и в остальных хранилищах
+// (см. encodeTask), вместе с метаданными интеграций и мягко удаленными задачами.
+type snapshot struct {
+	Version int               `json:"version"`
+	LastID  int               `json:"last_id"`
+	Tasks   []json.RawMessage `json:"tasks"`
+}
+
+// SaveSnapshot записывает все задачи и последний выданный ID
+//
+// Args:
+//
+//	w: получатель снимка
+//
+// Returns:
+//
+//	error: ошибка сериализации или записи
+func (s *InMemoryStorage) SaveSnapshot(w io.Writer) error {
+	s.mu.RLock()
+	snap := snapshot{Version: SnapshotVersion, LastID: s.lastID, Tasks: make([]json.RawMessage, 0, len(s.tasks))}
+	ids := make([]int, 0, len(s.tasks))
+	for id := range s.tasks {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		data, err := encodeTask(s.tasks[id])
+		if err != nil {
+			s.mu.RUnlock()
+			return err
+		}
+		snap.Tasks = append(snap.Tasks, data)
+	}
+	s.mu.RUnlock()
+
+	return json.NewEncoder(w).Encode(snap)
+}
+
+// LoadSnapshot заменяет содержимое хранилища снимком, записанным SaveSnapshot
+//
+// Нумерация продолжается с сохраненного последнего ID. Для удаленных задач
+// восстанавливаются записи об удалении, еще не истекшие по часам хранилища;
+// клиентские токены создания сбрасываются, так как относятся к прежнему содержимому.
+// При ошибке содержимое хранилища не меняется.
+//
+// Args:
+//
+//	r: источник снимка
+//
+// Returns:
+//
+//	error: ошибка чтения, неподдерживаемая версия или поврежденный снимок
+func (s *InMemoryStorage) LoadSnapshot(r io.Reader) error {
+	var snap snapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return fmt.Errorf("чтение снимка: %w", err)
+	}
+	if snap.Version < 1 || snap.Version > SnapshotVersion {
+		return fmt.Errorf("неподдерживаемая версия снимка %d", snap.Version)
+	}
+
+	tasks := make(map[int]*models.Task, len(snap.Tasks))
+	for _, data := range snap.Tasks {
+		task, err := decodeTask(data)
+		if err != nil {
+			return fmt.Errorf("чтение снимка: %w", err)
+		}
+		if task.ID <= 0 {
+			return fmt.Errorf("чтение снимка: неверный ID задачи %d", task.ID)
+		}
+		if _, exists := tasks[task.ID]; exists {
+			return fmt.Errorf("чтение снимка: задача с ID %d повторяется", task.ID)
+		}
+		tasks[task.ID] = task
+	}
+
+	// Записи об удалении добавляются от давних к недавним, как при удалении
+	var deleted []*models.Task
+	for _, task := range tasks {
+		if task.DeletedAt != nil {
+			deleted = append(deleted, task)
+		}
+	}
+	sort.Slice(deleted, func(i, j int) bool { return deleted[i].DeletedAt.Before(*deleted[j].DeletedAt) })
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.writable(); err != nil {
+		return err
+	}
+
+	s.tasks = tasks
+	s.lastID = snap.LastID
+	for id := range tasks {
+		s.reserveID(id)
+	}
+	s.byPriority = make(map[string]map[int]*models.Task)
+	s.byTag = make(map[string]map[int]*models.Task)
+	for _, task := range tasks {
+		if task.DeletedAt == nil {
+			s.indexPriority(task)
+			s.indexTags(task)
+		}
+	}
+	s.tombstones = newTombstoneSet(s.tombstones.ttl, s.tombstones.capacity)
+	for _, task := range deleted {
+		if s.now().Before(task.DeletedAt.Add(s.tombstones.ttl)) {
+			s.tombstones.add(task.ID, *task.DeletedAt)
+		}
+	}
+	s.tokens.flush()
+	return nil
+}
@@ -0,0 +1,379 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"test/models"
+	"time"
+)
+
+// sqlDialect описывает отличия базы данных, поверх которой работает sqlBackend
+//
+// Запросы пишутся с параметрами ? и синтаксисом, общим для SQLite и PostgreSQL;
+// задача хранится JSON-документом, моменты времени - числом наносекунд Unix.
+type sqlDialect struct {
+	// migrations - операторы создания схемы по версиям, начиная с 1; примененные
+	// версии записываются в schema_migrations и повторно не выполняются
+	migrations []string
+
+	// numbered - параметры записываются как $1, $2, ... вместо ?
+	numbered bool
+
+	// writeLock - запрос, выполняемый в начале транзакции на запись, чтобы
+	// записи выполнялись по одной; пусто - база данных делает это сама
+	writeLock string
+
+	// lastID - запрос последнего выданного ID задачи, 0 - ID еще не выдавались
+	lastID string
+
+	// setLastID - запросы, задающие последний выданный ID параметром
+	setLastID []string
+}
+
+// rebind приводит параметры запроса к синтаксису базы данных
+func (d sqlDialect) rebind(query string) string {
+	if !d.numbered {
+		return query
+	}
+
+	var out strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			out.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		out.WriteRune(r)
+	}
+	return out.String()
+}
+
+// sqlBackend выполняет операции с записями в базе данных через database/sql
+//
+// Все запросы к данным выполняются подготовленными операторами из stmts.
+type sqlBackend struct {
+	db      *sql.DB
+	dialect sqlDialect
+	stmts   *sqlStatements
+}
+
+// newSQLBackend создает sqlBackend над открытой базой данных
+func newSQLBackend(db *sql.DB, dialect sqlDialect) sqlBackend {
+	return sqlBackend{db: db, dialect: dialect, stmts: &sqlStatements{db: db, prepared: map[string]*sql.Stmt{}}}
+}
+
+// sqlStatements готовит каждый запрос один раз при первом использовании
+//
+// Подготовленные операторы принадлежат *sql.DB и используются всеми
+// транзакциями; database/sql сам готовит их заново на новых соединениях.
+type sqlStatements struct {
+	db       *sql.DB
+	mu       sync.Mutex
+	prepared map[string]*sql.Stmt
+}
+
+// get возвращает подготовленный оператор для запроса в синтаксисе базы данных
+func (s *sqlStatements) get(ctx context.Context, query string) (*sql.Stmt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stmt, ok := s.prepared[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := s.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	s.prepared[query] = stmt
+	return stmt, nil
+}
+
+// close закрывает подготовленные операторы
+func (s *sqlStatements) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var errs []error
+	for query, stmt := range s.prepared {
+		errs = append(errs, stmt.Close())
+		delete(s.prepared, query)
+	}
+	return errors.Join(errs...)
+}
+
+// close закрывает подготовленные операторы и базу данных
+func (b sqlBackend) close() error {
+	return errors.Join(b.stmts.close(), b.db.Close())
+}
+
+// ping проверяет подключение к базе данных
+func (b sqlBackend) ping(ctx context.Context) error {
+	return b.db.PingContext(ctx)
+}
+
+// migrate применяет еще не выполненные версии схемы в одной транзакции
+//
+// Операторы схемы выполняются по одному разу и не готовятся заранее.
+func (b sqlBackend) migrate(ctx context.Context) error {
+	tx, err := b.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return err
+	}
+	var current int
+	if err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return err
+	}
+	for version := current + 1; version <= len(b.dialect.migrations); version++ {
+		if _, err := tx.ExecContext(ctx, b.dialect.migrations[version-1]); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, b.dialect.rebind(`INSERT INTO schema_migrations (version) VALUES (?)`), version); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// view выполняет fn запросами без транзакции: каждое чтение видит зафиксированные данные
+func (b sqlBackend) view(ctx context.Context, fn func(tx recordTx) error) error {
+	return fn(sqlTx{ctx: ctx, dialect: b.dialect, stmts: b.stmts})
+}
+
+// update выполняет fn в транзакции, откатывая ее при ошибке
+func (b sqlBackend) update(ctx context.Context, fn func(tx recordTx) error) error {
+	tx, err := b.begin(ctx)
+	if err != nil {
+		return err
+	}
+	if err := fn(sqlTx{ctx: ctx, tx: tx, dialect: b.dialect, stmts: b.stmts}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// begin начинает транзакцию на запись
+func (b sqlBackend) begin(ctx context.Context) (*sql.Tx, error) {
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if b.dialect.writeLock != "" {
+		if _, err := tx.ExecContext(ctx, b.dialect.writeLock); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	return tx, nil
+}
+
+// sqlTx реализует recordTx подготовленными операторами
+type sqlTx struct {
+	ctx     context.Context
+	tx      *sql.Tx // Транзакция на запись; nil - чтение без транзакции
+	dialect sqlDialect
+	stmts   *sqlStatements
+}
+
+// stmt возвращает подготовленный оператор запроса, привязанный к транзакции, если она есть
+func (tx sqlTx) stmt(query string) (*sql.Stmt, error) {
+	stmt, err := tx.stmts.get(tx.ctx, tx.dialect.rebind(query))
+	if err != nil {
+		return nil, err
+	}
+	if tx.tx != nil {
+		// Оператор транзакции закрывается вместе с ней
+		return tx.tx.StmtContext(tx.ctx, stmt), nil
+	}
+	return stmt, nil
+}
+
+// exec выполняет запрос, не возвращающий строк
+func (tx sqlTx) exec(query string, args ...interface{}) error {
+	stmt, err := tx.stmt(query)
+	if err != nil {
+		return err
+	}
+	_, err = stmt.ExecContext(tx.ctx, args...)
+	return err
+}
+
+// queryRow выполняет запрос, возвращающий одну строку; ошибка подготовки
+// возвращается из Scan
+func (tx sqlTx) queryRow(query string, args ...interface{}) sqlRow {
+	stmt, err := tx.stmt(query)
+	if err != nil {
+		return sqlRow{err: err}
+	}
+	return sqlRow{row: stmt.QueryRowContext(tx.ctx, args...)}
+}
+
+// sqlRow - результат queryRow
+type sqlRow struct {
+	row *sql.Row
+	err error
+}
+
+// Scan копирует значения строки, как (*sql.Row).Scan
+func (r sqlRow) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.row.Scan(dest...)
+}
+
+// scanTask восстанавливает задачу из строки таблицы tasks
+func scanTask(id int, data string) (*models.Task, error) {
+	task, err := decodeTask([]byte(data))
+	if err != nil {
+		return nil, err
+	}
+	task.ID = id
+	return task, nil
+}
+
+func (tx sqlTx) task(id int) (*models.Task, bool, error) {
+	var data string
+	err := tx.queryRow(`SELECT task FROM tasks WHERE id = ?`, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	task, err := scanTask(id, data)
+	return task, err == nil, err
+}
+
+func (tx sqlTx) eachTask(fn func(*models.Task) error) error {
+	stmt, err := tx.stmt(`SELECT id, task FROM tasks ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	rows, err := stmt.QueryContext(tx.ctx)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var data string
+		if err := rows.Scan(&id, &data); err != nil {
+			return err
+		}
+		task, err := scanTask(id, data)
+		if err != nil {
+			return err
+		}
+		if err := fn(task); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (tx sqlTx) insertTask(task *models.Task) error {
+	// ID назначается базой данных, поэтому документ дописывается после вставки
+	var id int
+	if err := tx.queryRow(`INSERT INTO tasks (task) VALUES ('{}') RETURNING id`).Scan(&id); err != nil {
+		return err
+	}
+	task.ID = id
+	return tx.updateTask(task)
+}
+
+func (tx sqlTx) updateTask(task *models.Task) error {
+	data, err := encodeTask(task)
+	if err != nil {
+		return err
+	}
+	return tx.exec(`UPDATE tasks SET task = ? WHERE id = ?`, string(data), task.ID)
+}
+
+func (tx sqlTx) deleteTask(id int) error {
+	return tx.exec(`DELETE FROM tasks WHERE id = ?`, id)
+}
+
+func (tx sqlTx) tombstone(id int) (time.Time, bool, error) {
+	var deletedAt int64
+	err := tx.queryRow(`SELECT deleted_at FROM tombstones WHERE id = ?`, id).Scan(&deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return time.Unix(0, deletedAt), true, nil
+}
+
+func (tx sqlTx) putTombstone(id int, deletedAt time.Time) error {
+	return tx.exec(`INSERT INTO tombstones (id, deleted_at) VALUES (?, ?)
+		ON CONFLICT (id) DO UPDATE SET deleted_at = excluded.deleted_at`, id, deletedAt.UnixNano())
+}
+
+func (tx sqlTx) pruneTombstones(before time.Time, keep int) error {
+	return tx.exec(`DELETE FROM tombstones WHERE deleted_at <= ? OR id NOT IN (
+		SELECT id FROM tombstones ORDER BY deleted_at DESC LIMIT ?)`, before.UnixNano(), max(keep, 0))
+}
+
+func (tx sqlTx) clientToken(token string) (int, time.Time, bool, error) {
+	var id int
+	var createdAt int64
+	err := tx.queryRow(`SELECT task_id, created_at FROM client_tokens WHERE token = ?`, token).Scan(&id, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, time.Time{}, false, nil
+	}
+	if err != nil {
+		return 0, time.Time{}, false, err
+	}
+	return id, time.Unix(0, createdAt), true, nil
+}
+
+func (tx sqlTx) putClientToken(token string, id int, createdAt time.Time) error {
+	return tx.exec(`INSERT INTO client_tokens (token, task_id, created_at) VALUES (?, ?, ?)
+		ON CONFLICT (token) DO UPDATE SET task_id = excluded.task_id, created_at = excluded.created_at`,
+		token, id, createdAt.UnixNano())
+}
+
+func (tx sqlTx) deleteClientToken(token string) error {
+	return tx.exec(`DELETE FROM client_tokens WHERE token = ?`, token)
+}
+
+func (tx sqlTx) pruneClientTokens(before time.Time, keep int) error {
+	return tx.exec(`DELETE FROM client_tokens WHERE created_at <= ? OR token NOT IN (
+		SELECT token FROM client_tokens ORDER BY created_at DESC LIMIT ?)`, before.UnixNano(), max(keep, 0))
+}
+
+func (tx sqlTx) countClientTokens() (int, error) {
+	var count int
+	err := tx.queryRow(`SELECT COUNT(*) FROM client_tokens`).Scan(&count)
+	return count, err
+}
+
+func (tx sqlTx) clearClientTokens() error {
+	return tx.exec(`DELETE FROM client_tokens`)
+}
+
+func (tx sqlTx) lastID() (int, error) {
+	var id int
+	err := tx.queryRow(tx.dialect.lastID).Scan(&id)
+	return id, err
+}
+
+func (tx sqlTx) setLastID(id int) error {
+	for _, query := range tx.dialect.setLastID {
+		if err := tx.exec(query, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteDialect - схема хранилища в SQLite
+//
+// AUTOINCREMENT гарантирует, что ID удаленной задачи не будет выдан повторно,
+// как и в InMemoryStorage. Задача хранится JSON-документом вместе с метаданными.
+var sqliteDialect = sqlDialect{
+	migrations: []string{
+		`CREATE TABLE IF NOT EXISTS tasks (
+			id   INTEGER PRIMARY KEY AUTOINCREMENT,
+			task TEXT NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS tombstones (
+			id         INTEGER PRIMARY KEY,
+			deleted_at INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS client_tokens (
+			token      TEXT PRIMARY KEY,
+			task_id    INTEGER NOT NULL,
+			created_at INTEGER NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS client_tokens_created_at ON client_tokens (created_at)`,
+	},
+
+	// Счетчик AUTOINCREMENT хранится в sqlite_sequence; строка появляется при первой вставке
+	lastID: `SELECT COALESCE((SELECT seq FROM sqlite_sequence WHERE name = 'tasks'), 0)`,
+	setLastID: []string{
+		`INSERT INTO sqlite_sequence (name, seq) SELECT 'tasks', 0
+			WHERE NOT EXISTS (SELECT 1 FROM sqlite_sequence WHERE name = 'tasks')`,
+		`UPDATE sqlite_sequence SET seq = ? WHERE name = 'tasks'`,
+	},
+}
+
+// SQLiteStorage реализует хранилище задач в файле базы данных SQLite
+//
+// Поддерживает те же возможности, что и InMemoryStorage, и принимает те же опции.
+// Данные сохраняются между перезапусками; несколько процессов могут работать
+// с одним файлом, записи выполняются транзакциями.
+type SQLiteStorage struct {
+	*recordStorage
+	backend sqlBackend
+}
+
+// NewSQLiteStorage открывает базу данных SQLite и создает таблицы через MigrateUp
+//
+// Args:
+//
+//	path: путь к файлу базы данных, создается при отсутствии
+//	opts: опции хранилища
+//
+// Returns:
+//
+//	*SQLiteStorage: хранилище задач
+//	error: ошибка открытия базы данных или создания таблиц
+func NewSQLiteStorage(path string, opts ...Option) (*SQLiteStorage, error) {
+	// Ожидание блокировки вместо немедленной ошибки SQLITE_BUSY, журнал WAL для чтения
+	// во время записи и блокировка на запись в начале транзакции, чтобы проверка
+	// и изменение задачи не пересекались с другими записями
+	dsn := "file:" + (&url.URL{Path: path}).EscapedPath() +
+		"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("открытие базы данных SQLite: %w", err)
+	}
+
+	backend := newSQLBackend(db, sqliteDialect)
+	s := &SQLiteStorage{recordStorage: newRecordStorage(backend, opts), backend: backend}
+	if err := MigrateUp(s); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// MigrateUp применяет к базе данных SQLite еще не выполненные версии схемы
+//
+// Примененные версии записываются в таблицу schema_migrations, поэтому повторный
+// вызов ничего не меняет. NewSQLiteStorage вызывает MigrateUp сам.
+//
+// Args:
+//
+//	s: открытое хранилище SQLite
+//
+// Returns:
+//
+//	error: ошибка создания таблиц
+func MigrateUp(s *SQLiteStorage) error {
+	if err := s.backend.migrate(context.Background()); err != nil {
+		return fmt.Errorf("создание таблиц SQLite: %w", err)
+	}
+	return nil
+}
+
+// Ping проверяет, что база данных открыта и доступна
+func (s *SQLiteStorage) Ping(ctx context.Context) error {
+	return s.backend.ping(ctx)
+}
+
+// Close закрывает подготовленные операторы и базу данных, см. Storage.Close
+func (s *SQLiteStorage) Close() error {
+	return s.shutdown(s.backend.close)
+}
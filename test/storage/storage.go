@@ -1,23 +1,89 @@
-// Package storage предоставляет реализацию хранилища задач в памяти
+// Package storage предоставляет хранилища задач: в памяти, в том числе разделенное
+// на сегменты, в памяти с сохранением в JSON-файл или с журналом изменений, в базах
+// данных SQLite и PostgreSQL, в Redis, в MongoDB и во встроенной базе данных bbolt
 package storage
 
 import (
+	"context"
 	"fmt"
+	"slices"
+	"sort"
 	"sync"
 	"test/models"
+	"time"
 )
 
 // InMemoryStorage реализует хранилище задач в памяти с поддержкой конкурентного доступа
+//
+// Сохраненная задача после записи в хранилище не изменяется: изменения вносятся
+// в копию, которая затем заменяет задачу (см. store). Поэтому задачу, полученную
+// из хранилища, можно читать без блокировки, пока другой запрос ее обновляет.
+// GetTask и GetAllTasks к тому же возвращают копии, которые вызывающий код может
+// менять, не затрагивая хранилище.
 type InMemoryStorage struct {
-	tasks  map[int]*models.Task // Хранилище задач
-	lastID int                  // Последний использованный ID
-	mu     sync.RWMutex         // Мьютекс для синхронизации доступа
+	tasks      map[int]*models.Task            // Хранилище задач
+	lastID     int                             // Последний использованный ID
+	tokens     *tokenCache                     // Клиентские токены создания задач
+	tombstones *tombstoneSet                   // Недавно удаленные задачи
+	byPriority map[string]map[int]*models.Task // Задачи с заданным приоритетом, см. TasksByPriority
+	byTag      map[string]map[int]*models.Task // Задачи с заданным тегом, см. TasksByTags
+	now        func() time.Time                // Источник текущего времени
+	mu         sync.RWMutex                    // Мьютекс для синхронизации доступа
+
+	completedImmutable bool // Выполненные задачи можно только возобновить
+	metadataLimit      int  // Максимальный размер пространства метаданных
+
+	maxTasks int            // Максимальное число задач, 0 - без лимита
+	eviction EvictionPolicy // Поведение при достижении лимита, см. NewInMemoryStorageWithLimit
+
+	expiryInterval time.Duration // Период фонового удаления истекших задач
+	expiryMu       sync.Mutex    // Защищает stopExpiry
+	stopExpiry     func()        // Останавливает фоновое удаление, nil - не запущено
+
+	preferences models.Preferences // Настройки по умолчанию для новых задач, см. SetPreferences
+
+	closed bool // Хранилище закрыто Close и не принимает изменений
+}
+
+// CreateTaskInput содержит поля, задаваемые клиентом при создании задачи
+type CreateTaskInput struct {
+	Title       string        // Название задачи
+	Description string        // Описание задачи
+	Links       []models.Link // Внешние ссылки, уже прошедшие models.NormalizeLinks
+	Protected   bool          // Удаление только с подтверждением
+	Priority    string        // Приоритет из models.Priorities или пусто
+	Tags        []string      // Теги, уже прошедшие models.NormalizeTags
+	DueDate     *time.Time    // Срок выполнения, nil - без срока
+	ExpiresAt   *time.Time    // Момент истечения, nil - задача не истекает
+	ExpiresIn   time.Duration // Время жизни от момента создания, если ExpiresAt не задан; 0 - бессрочно
+}
+
+// UpdateTaskInput содержит поля, заменяемые при полном обновлении задачи
+type UpdateTaskInput struct {
+	Title       string        // Новое название задачи
+	Description string        // Новое описание задачи
+	Completed   bool          // Новый статус выполнения
+	Links       []models.Link // Новый список ссылок; nil оставляет ссылки без изменений
+	Protected   *bool         // Новая защита от удаления; nil оставляет без изменений
+	Priority    *string       // Новый приоритет, пусто - снять; nil оставляет без изменений
+	Tags        []string      // Новые теги, уже прошедшие models.NormalizeTags; nil оставляет без изменений
+	DueDate     *time.Time    // Новый срок, нулевое время - снять; nil оставляет без изменений
 }
 
 // NewInMemoryStorage создает новое хранилище задач в памяти
-func NewInMemoryStorage() *InMemoryStorage {
+func NewInMemoryStorage(opts ...Option) *InMemoryStorage {
+	cfg := newSettings(opts)
 	return &InMemoryStorage{
-		tasks: make(map[int]*models.Task),
+		tasks:      make(map[int]*models.Task),
+		byPriority: make(map[string]map[int]*models.Task),
+		byTag:      make(map[string]map[int]*models.Task),
+		tokens:     newTokenCache(cfg.tokenTTL, cfg.tokenCapacity),
+		tombstones: newTombstoneSet(cfg.tombstoneTTL, cfg.tombstoneCapacity),
+		now:        cfg.now,
+
+		completedImmutable: cfg.completedImmutable,
+		metadataLimit:      cfg.metadataLimit,
+		expiryInterval:     cfg.expiryInterval,
 	}
 }
 
@@ -25,41 +91,125 @@ func NewInMemoryStorage() *InMemoryStorage {
 //
 // Args:
 //
-//	title: название задачи
-//	description: описание задачи
+//	ctx: контекст запроса, отмененный контекст прерывает операцию до блокировки
+//	input: поля новой задачи
 //
 // Returns:
 //
 //	*models.Task: созданная задача
-//	error: ошибка при создании задачи
-func (s *InMemoryStorage) CreateTask(title, description string) (*models.Task, error) {
+//	error: ошибка при создании задачи, ErrStorageFull при достижении лимита задач
+func (s *InMemoryStorage) CreateTask(ctx context.Context, input CreateTaskInput) (*models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Блокировка на запись для атомарного создания задачи
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
 
-	// Генерация нового ID
-	s.lastID++
+	return s.createTask(input)
+}
 
-	// Создание новой задачи
-	task := &models.Task{
-		ID:          s.lastID,
-		Title:       title,
-		Description: description,
-		Completed:   false,
+// CreateTaskWithToken создает задачу, защищенную от повторного создания клиентским токеном
+//
+// Если задача с тем же токеном уже создавалась и токен еще не истек, возвращается
+// исходная задача без создания новой. Это позволяет клиенту безопасно повторить
+// запрос, ответ на который был потерян.
+//
+// Args:
+//
+//	token: клиентский токен создания
+//	input: поля новой задачи
+//
+// Returns:
+//
+//	*models.Task: созданная или ранее созданная задача
+//	bool: true, если задача создана этим вызовом
+//	error: ошибка при создании задачи, ErrStorageFull при достижении лимита задач
+func (s *InMemoryStorage) CreateTaskWithToken(token string, input CreateTaskInput) (*models.Task, bool, error) {
+	// Блокировка на запись, чтобы проверка токена и создание задачи были атомарны
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.writable(); err != nil {
+		return nil, false, err
+	}
+
+	now := s.now()
+
+	// Повтор создания возвращает исходную задачу, если она еще существует
+	if id, exists := s.tokens.lookup(token, now); exists {
+		if task, err := s.find(id); err == nil {
+			return task, false, nil
+		}
+		s.tokens.forget(token)
+	}
+
+	task, err := s.createTask(input)
+	if err != nil {
+		return nil, false, err
 	}
+	s.tokens.remember(token, task.ID, now)
+	return task, true, nil
+}
+
+// createTask создает задачу с новым ID, вызывается под блокировкой на запись
+//
+// Возвращает ErrStorageFull, если для задачи нет места, см. NewInMemoryStorageWithLimit.
+func (s *InMemoryStorage) createTask(input CreateTaskInput) (*models.Task, error) {
+	if err := s.reserve(1, nil); err != nil {
+		return nil, err
+	}
+
+	// Создание новой задачи с новым ID
+	task := newTask(input, s.now())
+	task.ID = s.allocateID()
 
 	// Сохранение задачи в хранилище
-	s.tasks[s.lastID] = task
+	s.tasks[task.ID] = task
+	s.indexPriority(task)
+	s.indexTags(task)
 	return task, nil
 }
 
-// GetAllTasks возвращает список всех задач из хранилища
+// newTask создает задачу с полями input без ID, созданную в момент now
+func newTask(input CreateTaskInput, now time.Time) *models.Task {
+	task := &models.Task{
+		Title:       input.Title,
+		Description: input.Description,
+		Completed:   false,
+		Links:       copyLinks(input.Links),
+		Protected:   input.Protected,
+		Priority:    input.Priority,
+		Tags:        copyTags(input.Tags),
+		DueDate:     utcDueDate(input.DueDate),
+		ExpiresAt:   expiresAt(input, now),
+		Version:     1,
+		CreatedAt:   now.UTC(),
+		UpdatedAt:   now.UTC(),
+	}
+	deriveFromDescription(task)
+	return task
+}
+
+// GetAllTasks возвращает список всех задач из хранилища, кроме удаленных и истекших,
+// в порядке возрастания ID
+//
+// Args:
+//
+//	ctx: контекст запроса, отмененный контекст прерывает операцию до блокировки
 //
 // Returns:
 //
 //	[]*models.Task: список всех задач
 //	error: ошибка при получении задач
-func (s *InMemoryStorage) GetAllTasks() ([]*models.Task, error) {
+func (s *InMemoryStorage) GetAllTasks(ctx context.Context) ([]*models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Блокировка на чтение для безопасного получения всех задач
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -68,70 +218,452 @@ func (s *InMemoryStorage) GetAllTasks() ([]*models.Task, error) {
 	tasks := make([]*models.Task, 0, len(s.tasks))
 
 	// Копирование всех задач в новый срез
+	now := s.now()
 	for _, task := range s.tasks {
-		tasks = append(tasks, task)
+		if visible(task, now) {
+			tasks = append(tasks, cloneTask(task))
+		}
 	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
 
 	return tasks, nil
 }
 
+// ListTasksFunc передает задачи, кроме удаленных и истекших, функции fn по одной, не собирая их в срез
+//
+// Итерация идет в порядке возрастания ID по снимку, сделанному под блокировкой
+// на чтение: изменения, внесенные во время обхода, на него не влияют, а блокировка
+// не удерживается, пока fn пишет ответ клиенту.
+//
+// Args:
+//
+//	ctx: контекст запроса, отмененный контекст прерывает операцию до блокировки и обход
+//	fn: функция, вызываемая для каждой задачи; ошибка fn прерывает обход
+//
+// Returns:
+//
+//	error: ошибка fn или контекста
+func (s *InMemoryStorage) ListTasksFunc(ctx context.Context, fn func(*models.Task) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	now := s.now()
+	snapshot := make([]*models.Task, 0, len(s.tasks))
+	for _, task := range s.tasks {
+		if visible(task, now) {
+			snapshot = append(snapshot, task)
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(snapshot, func(i, j int) bool { return snapshot[i].ID < snapshot[j].ID })
+
+	for _, task := range snapshot {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetTask возвращает задачу по ID
 //
 // Args:
 //
+//	ctx: контекст запроса, отмененный контекст прерывает операцию до блокировки
 //	id: ID задачи
 //
 // Returns:
 //
 //	*models.Task: найденная задача
-//	error: ошибка при поиске задачи
-func (s *InMemoryStorage) GetTask(id int) (*models.Task, error) {
+//	error: ошибка при поиске задачи, *TaskDeletedError для недавно удаленной задачи
+func (s *InMemoryStorage) GetTask(ctx context.Context, id int) (*models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Блокировка на чтение для безопасного получения задачи
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	// Поиск задачи по ID
-	task, exists := s.tasks[id]
-	if !exists {
-		return nil, fmt.Errorf("задача с ID %d не найдена", id)
+	task, err := s.find(id)
+	if err != nil {
+		return nil, err
 	}
 
-	return task, nil
+	return cloneTask(task), nil
+}
+
+// CompleteWithFollowUp отмечает задачу выполненной и создает продолжение, ссылающееся на нее
+//
+// Обе операции выполняются под одной блокировкой на запись: если исходная задача
+// не найдена или уже выполнена, не меняется ничего, а другие клиенты не увидят
+// выполненную задачу без продолжения.
+//
+// Args:
+//
+//	id: ID завершаемой задачи
+//	input: поля задачи-продолжения
+//
+// Returns:
+//
+//	*models.Task: выполненная исходная задача
+//	*models.Task: созданное продолжение с FollowsID = id
+//	error: ошибка при поиске задачи, ErrTaskAlreadyCompleted или ErrStorageFull
+func (s *InMemoryStorage) CompleteWithFollowUp(id int, input CreateTaskInput) (*models.Task, *models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.writable(); err != nil {
+		return nil, nil, err
+	}
+
+	task, err := s.findCopy(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if task.Completed {
+		return nil, nil, ErrTaskAlreadyCompleted
+	}
+
+	followUp, err := s.createTask(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	followUp.FollowsID = id
+	task.Completed = true
+	task.Version++
+	task.UpdatedAt = followUp.CreatedAt
+	s.store(task)
+	return task, followUp, nil
+}
+
+// CompletedImmutable сообщает, запрещено ли изменение выполненных задач, см. WithCompletedImmutable
+func (s *InMemoryStorage) CompletedImmutable() bool {
+	return s.completedImmutable
 }
 
 // UpdateTask обновляет существующую задачу
 //
+// С опцией WithCompletedImmutable выполненную задачу можно только возобновить:
+// запрос с completed=false без изменения остальных полей.
+//
 // Args:
 //
+//	ctx: контекст запроса, отмененный контекст прерывает операцию до блокировки
 //	id: ID задачи
-//	title: новое название задачи
-//	description: новое описание задачи
-//	completed: новый статус выполнения
+//	input: новые значения полей задачи
 //
 // Returns:
 //
 //	*models.Task: обновленная задача
-//	error: ошибка при обновлении задачи
-func (s *InMemoryStorage) UpdateTask(id int, title, description string, completed bool) (*models.Task, error) {
+//	error: ошибка при поиске задачи или ErrTaskCompletedImmutable
+func (s *InMemoryStorage) UpdateTask(ctx context.Context, id int, input UpdateTaskInput) (*models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Блокировка на запись для атомарного обновления задачи
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
 
-	// Поиск задачи по ID
-	task, exists := s.tasks[id]
-	if !exists {
-		return nil, fmt.Errorf("задача с ID %d не найдена", id)
+	// Поиск задачи по ID; изменяется копия, заменяющая задачу в хранилище
+	task, err := s.findCopy(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyUpdate(task, input, s.completedImmutable, s.now()); err != nil {
+		return nil, err
+	}
+	s.store(task)
+	return task, nil
+}
+
+// PatchTask частично обновляет задачу: меняются только поля, присутствующие в patch
+//
+// Допустимые ключи: "title", "description" и "priority" со значением string,
+// "completed" со значением bool, "due_date" со значением time.Time (нулевое
+// снимает срок), "links" со значением []models.Link и "tags" со значением
+// []string, уже прошедшими models.NormalizeLinks и models.NormalizeTags.
+// Отсутствующий ключ оставляет поле без изменений, в том числе
+// completed. Политика WithCompletedImmutable применяется так же, как в UpdateTask.
+//
+// Args:
+//
+//	id: ID задачи
+//	patch: новые значения изменяемых полей
+//
+// Returns:
+//
+//	*models.Task: обновленная задача
+//	error: ошибка при поиске задачи, ErrInvalidPatch или ErrTaskCompletedImmutable
+func (s *InMemoryStorage) PatchTask(id int, patch map[string]interface{}) (*models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
+
+	task, err := s.findCopy(id)
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := patchInput(task, patch)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyUpdate(task, input, s.completedImmutable, s.now()); err != nil {
+		return nil, err
+	}
+	s.store(task)
+	return task, nil
+}
+
+// patchInput строит полное обновление задачи из частичного: незатронутые поля
+// сохраняют текущие значения
+func patchInput(task *models.Task, patch map[string]interface{}) (UpdateTaskInput, error) {
+	input := UpdateTaskInput{Title: task.Title, Description: task.Description, Completed: task.Completed}
+	for key, value := range patch {
+		var ok bool
+		switch key {
+		case "title":
+			input.Title, ok = value.(string)
+		case "description":
+			input.Description, ok = value.(string)
+		case "completed":
+			input.Completed, ok = value.(bool)
+		case "priority":
+			var priority string
+			priority, ok = value.(string)
+			input.Priority = &priority
+		case "due_date":
+			var due time.Time
+			due, ok = value.(time.Time)
+			input.DueDate = &due
+		case "links":
+			input.Links, ok = value.([]models.Link)
+			ok = ok && input.Links != nil
+		case "tags":
+			input.Tags, ok = value.([]string)
+			ok = ok && input.Tags != nil
+		}
+		if !ok {
+			return UpdateTaskInput{}, fmt.Errorf("%w: %s", ErrInvalidPatch, key)
+		}
+	}
+	return input, nil
+}
+
+// applyUpdate заменяет поля задачи значениями input и отмечает изменение моментом now
+//
+// Вызывается под блокировкой (в транзакции) хранилища: проверка политики
+// выполняется вместе с записью, чтобы правка не проскочила между проверкой
+// и отметкой о выполнении.
+func applyUpdate(task *models.Task, input UpdateTaskInput, completedImmutable bool, now time.Time) error {
+	if completedImmutable && task.Completed && !isReopen(task, input) {
+		return ErrTaskCompletedImmutable
 	}
 
 	// Обновление полей задачи
-	task.Title = title
-	task.Description = description
-	task.Completed = completed
+	task.Title = input.Title
+	if input.Description != task.Description {
+		task.Description = input.Description
+		deriveFromDescription(task)
+	}
+	task.Completed = input.Completed
+	if input.Links != nil {
+		task.Links = copyLinks(input.Links)
+	}
+	if input.Protected != nil {
+		task.Protected = *input.Protected
+	}
+	if input.Priority != nil {
+		task.Priority = *input.Priority
+	}
+	if input.Tags != nil {
+		task.Tags = copyTags(input.Tags)
+	}
+	if input.DueDate != nil {
+		task.DueDate = utcDueDate(input.DueDate)
+	}
+	task.Version++
+	task.UpdatedAt = now.UTC()
+	return nil
+}
+
+// AddLink добавляет внешнюю ссылку к задаче
+//
+// Args:
+//
+//	id: ID задачи
+//	link: ссылка, уже прошедшая models.NormalizeLink
+//
+// Returns:
+//
+//	*models.Task: задача с добавленной ссылкой
+//	error: ошибка при поиске задачи, ErrTooManyLinks, ErrDuplicateLink или ErrTaskCompletedImmutable
+func (s *InMemoryStorage) AddLink(id int, link models.Link) (*models.Task, error) {
+	// Блокировка на запись, чтобы проверки лимита и дубликатов были атомарны с добавлением
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
 
+	task, err := s.findCopy(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := addLink(task, link, s.completedImmutable, s.now()); err != nil {
+		return nil, err
+	}
+	s.store(task)
 	return task, nil
 }
 
-// DeleteTask удаляет задачу из хранилища
+// addLink добавляет ссылку к задаче с проверкой лимита и дубликатов
+func addLink(task *models.Task, link models.Link, completedImmutable bool, now time.Time) error {
+	if completedImmutable && task.Completed {
+		return ErrTaskCompletedImmutable
+	}
+
+	if len(task.Links) >= models.MaxLinks {
+		return ErrTooManyLinks
+	}
+	for _, existing := range task.Links {
+		if existing.URL == link.URL {
+			return ErrDuplicateLink
+		}
+	}
+
+	task.Links = append(copyLinks(task.Links), link)
+	task.Version++
+	task.UpdatedAt = now.UTC()
+	return nil
+}
+
+// RemoveLink удаляет ссылку задачи по ее индексу в списке
+//
+// Args:
+//
+//	id: ID задачи
+//	index: индекс ссылки, начиная с 0
+//
+// Returns:
+//
+//	*models.Task: задача без удаленной ссылки
+//	error: ошибка при поиске задачи, ErrLinkNotFound или ErrTaskCompletedImmutable
+func (s *InMemoryStorage) RemoveLink(id, index int) (*models.Task, error) {
+	// Блокировка на запись для атомарного удаления ссылки
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
+
+	task, err := s.findCopy(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := removeLink(task, index, s.completedImmutable, s.now()); err != nil {
+		return nil, err
+	}
+	s.store(task)
+	return task, nil
+}
+
+// removeLink удаляет ссылку задачи по индексу
+func removeLink(task *models.Task, index int, completedImmutable bool, now time.Time) error {
+	if completedImmutable && task.Completed {
+		return ErrTaskCompletedImmutable
+	}
+
+	if index < 0 || index >= len(task.Links) {
+		return ErrLinkNotFound
+	}
+
+	links := make([]models.Link, 0, len(task.Links)-1)
+	links = append(links, task.Links[:index]...)
+	task.Links = append(links, task.Links[index+1:]...)
+	task.Version++
+	task.UpdatedAt = now.UTC()
+	return nil
+}
+
+// deriveFromDescription пересчитывает поля задачи, извлекаемые из описания
+func deriveFromDescription(task *models.Task) {
+	task.Mentions = models.ExtractMentions(task.Description)
+	task.DetectedLinks = models.ExtractLinks(task.Description)
+}
+
+// isReopen проверяет, что обновление только снимает отметку о выполнении задачи
+func isReopen(task *models.Task, input UpdateTaskInput) bool {
+	if input.Completed || input.Title != task.Title || input.Description != task.Description {
+		return false
+	}
+	if input.Protected != nil && *input.Protected != task.Protected {
+		return false
+	}
+	if input.Priority != nil && *input.Priority != task.Priority {
+		return false
+	}
+	if input.DueDate != nil && !sameDueDate(utcDueDate(input.DueDate), task.DueDate) {
+		return false
+	}
+	if input.Tags != nil && !slices.Equal(input.Tags, task.Tags) {
+		return false
+	}
+	if input.Links == nil {
+		return true
+	}
+	if len(input.Links) != len(task.Links) {
+		return false
+	}
+	for i := range input.Links {
+		if input.Links[i] != task.Links[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// copyLinks копирует список ссылок, чтобы задача не разделяла его с вызывающим кодом
+func copyLinks(links []models.Link) []models.Link {
+	if len(links) == 0 {
+		return nil
+	}
+	return append([]models.Link(nil), links...)
+}
+
+// DeleteTask мягко удаляет задачу, см. RestoreTask
+//
+// Защищенную задачу (Protected) этот метод не удаляет, см. DeleteTaskConfirmed.
+//
+// Args:
+//
+//	ctx: контекст запроса, отмененный контекст прерывает операцию до блокировки
+//	id: ID задачи для удаления
+//
+// Returns:
+//
+//	error: ошибка при поиске задачи или ErrTaskProtected
+func (s *InMemoryStorage) DeleteTask(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.deleteTask(id, false)
+}
+
+// DeleteTaskConfirmed удаляет задачу, в том числе защищенную, после явного подтверждения клиента
 //
 // Args:
 //
@@ -140,17 +672,145 @@ func (s *InMemoryStorage) UpdateTask(id int, title, description string, complete
 // Returns:
 //
 //	error: ошибка при удалении задачи
-func (s *InMemoryStorage) DeleteTask(id int) error {
+func (s *InMemoryStorage) DeleteTaskConfirmed(id int) error {
+	return s.deleteTask(id, true)
+}
+
+// deleteTask мягко удаляет задачу, проверяя защиту под той же блокировкой
+//
+// Задача остается в хранилище с отметкой DeletedAt и может быть восстановлена
+// RestoreTask; до восстановления она не видна операциям с задачами.
+func (s *InMemoryStorage) deleteTask(id int, confirmed bool) error {
 	// Блокировка на запись для атомарного удаления задачи
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if err := s.writable(); err != nil {
+		return err
+	}
 
 	// Проверка существования задачи
-	if _, exists := s.tasks[id]; !exists {
-		return fmt.Errorf("задача с ID %d не найдена", id)
+	task, err := s.findCopy(id)
+	if err != nil {
+		return err
+	}
+	if task.Protected && !confirmed {
+		return ErrTaskProtected
+	}
+
+	// Отметка об удалении вместо удаления из хранилища и запись об удалении
+	now := s.now()
+	deletedAt := now.UTC()
+	task.DeletedAt = &deletedAt
+	s.store(task)
+	s.tombstones.add(id, now)
+	return nil
+}
+
+// UpdateTaskIfVersion обновляет задачу, только если ее версия равна version
+//
+// Проверка версии и запись выполняются под одной блокировкой на запись, поэтому
+// из двух клиентов, прочитавших одну версию, обновить задачу сможет только первый.
+//
+// Args:
+//
+//	id: ID задачи
+//	version: версия задачи, которую видел клиент
+//	input: новые значения полей задачи
+//
+// Returns:
+//
+//	*models.Task: обновленная задача
+//	error: ошибка при поиске задачи, *VersionConflictError или ErrTaskCompletedImmutable
+func (s *InMemoryStorage) UpdateTaskIfVersion(id, version int, input UpdateTaskInput) (*models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
+
+	task, err := s.findCopy(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(task, version); err != nil {
+		return nil, err
 	}
+	if err := applyUpdate(task, input, s.completedImmutable, s.now()); err != nil {
+		return nil, err
+	}
+	s.store(task)
+	return task, nil
+}
 
-	// Удаление задачи из хранилища
-	delete(s.tasks, id)
+// checkVersion проверяет, что версия задачи равна version
+func checkVersion(task *models.Task, version int) error {
+	if task.Version != version {
+		return &VersionConflictError{ID: task.ID, Version: task.Version}
+	}
 	return nil
 }
+
+// find возвращает задачу, кроме удаленной и истекшей, вызывается под блокировкой
+//
+// Истекшая, но еще не удаленная PurgeExpired задача считается несуществующей.
+func (s *InMemoryStorage) find(id int) (*models.Task, error) {
+	task, exists := s.tasks[id]
+	if !exists || !visible(task, s.now()) {
+		return nil, s.notFound(id)
+	}
+	return task, nil
+}
+
+// findCopy возвращает копию задачи для изменения, вызывается под блокировкой на запись
+//
+// Изменения копии попадают в хранилище только после store: при ошибке проверки
+// задача остается прежней.
+func (s *InMemoryStorage) findCopy(id int) (*models.Task, error) {
+	task, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+	return cloneTask(task), nil
+}
+
+// store сохраняет задачу вместо прежней с тем же ID и обновляет индексы приоритета
+// и тегов, вызывается под блокировкой на запись
+//
+// Прежняя задача не изменяется и остается корректной у тех, кто ее уже получил.
+// Мягко удаленная задача в индексы не попадает.
+func (s *InMemoryStorage) store(task *models.Task) {
+	if previous, exists := s.tasks[task.ID]; exists {
+		s.removeFromPriority(previous.ID, previous.Priority)
+		s.removeFromTags(previous.ID, previous.Tags)
+	}
+	s.tasks[task.ID] = task
+	if task.DeletedAt == nil {
+		s.indexPriority(task)
+		s.indexTags(task)
+	}
+}
+
+// cloneTask возвращает копию задачи, не разделяющую с ней ссылки, теги и метаданные
+//
+// Остальные ссылочные поля (срок, момент удаления, упоминания) при изменении
+// задачи заменяются целиком, а не меняются на месте, и копируются как есть.
+func cloneTask(task *models.Task) *models.Task {
+	clone := *task
+	clone.Links = copyLinks(task.Links)
+	clone.Tags = copyTags(task.Tags)
+	if task.Metadata != nil {
+		clone.Metadata = copyMetadata(task)
+	}
+	return &clone
+}
+
+// notFound возвращает ошибку отсутствия задачи, вызывается под блокировкой
+//
+// Для недавно удаленной задачи возвращается *TaskDeletedError с моментом удаления,
+// для остальных - ErrTaskNotFound.
+func (s *InMemoryStorage) notFound(id int) error {
+	if deletedAt, deleted := s.tombstones.lookup(id, s.now()); deleted {
+		return &TaskDeletedError{ID: id, DeletedAt: deletedAt}
+	}
+	return taskNotFound(id)
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"sort"
+	"test/models"
+)
+
+// TasksByTags возвращает задачи, у которых есть все теги tags, в порядке возрастания ID
+//
+// Задачи берутся из индекса по тегам: обходится только самый маленький из
+// списков задач с одним из тегов, а остальные теги проверяются по индексу,
+// поэтому время не зависит от общего числа задач.
+//
+// Args:
+//
+//	tags: теги, уже прошедшие models.NormalizeTags
+//
+// Returns:
+//
+//	[]*models.Task: задачи со всеми тегами
+//	error: ошибка при получении задач
+func (s *InMemoryStorage) TasksByTags(tags []string) ([]*models.Task, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tasks := []*models.Task{}
+	if len(tags) == 0 {
+		return tasks, nil
+	}
+	smallest := s.byTag[tags[0]]
+	for _, tag := range tags[1:] {
+		if len(s.byTag[tag]) < len(smallest) {
+			smallest = s.byTag[tag]
+		}
+	}
+
+	now := s.now()
+	for id, task := range smallest {
+		if task.Expired(now) {
+			continue
+		}
+		matches := true
+		for _, tag := range tags {
+			if _, ok := s.byTag[tag][id]; !ok {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks, nil
+}
+
+// indexTags добавляет задачу в индексы ее тегов, вызывается под блокировкой на запись
+func (s *InMemoryStorage) indexTags(task *models.Task) {
+	for _, tag := range task.Tags {
+		bucket := s.byTag[tag]
+		if bucket == nil {
+			bucket = make(map[int]*models.Task)
+			s.byTag[tag] = bucket
+		}
+		bucket[task.ID] = task
+	}
+}
+
+// removeFromTags удаляет задачу id из индексов тегов tags
+func (s *InMemoryStorage) removeFromTags(id int, tags []string) {
+	for _, tag := range tags {
+		bucket := s.byTag[tag]
+		delete(bucket, id)
+		if len(bucket) == 0 {
+			delete(s.byTag, tag)
+		}
+	}
+}
+
+// copyTags возвращает копию тегов, не разделяющую с ними память; nil для пустого списка
+func copyTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	return append([]string(nil), tags...)
+}
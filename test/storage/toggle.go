@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"test/models"
+)
+
+// ToggleTask инвертирует отметку о выполнении задачи
+//
+// Текущее значение читается и заменяется под одной блокировкой на запись,
+// поэтому параллельные переключения не теряются: после четного числа вызовов
+// задача возвращается в исходное состояние. При WithCompletedImmutable
+// переключение тоже разрешено: снятие отметки переоткрывает задачу.
+//
+// Args:
+//
+//	id: ID задачи
+//
+// Returns:
+//
+//	*models.Task: обновленная задача
+//	error: ошибка при поиске задачи
+func (s *InMemoryStorage) ToggleTask(id int) (*models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
+
+	task, err := s.findCopy(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyUpdate(task, toggleInput(task), s.completedImmutable, s.now()); err != nil {
+		return nil, err
+	}
+	s.store(task)
+	return task, nil
+}
+
+// ToggleTask инвертирует отметку о выполнении задачи в транзакции на запись,
+// см. InMemoryStorage.ToggleTask
+func (s *recordStorage) ToggleTask(id int) (*models.Task, error) {
+	return s.modify(context.Background(), id, func(task *models.Task) error {
+		return applyUpdate(task, toggleInput(task), s.cfg.completedImmutable, s.cfg.now())
+	})
+}
+
+// ToggleTask инвертирует отметку о выполнении задачи под блокировкой ее сегмента,
+// см. InMemoryStorage.ToggleTask
+func (s *ShardedStorage) ToggleTask(id int) (*models.Task, error) {
+	if s.closed.Load() {
+		return nil, ErrStorageClosed
+	}
+	shard := s.shard(id)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	task, err := shard.find(id)
+	if err != nil {
+		return nil, err
+	}
+	task = cloneTask(task)
+	if err := applyUpdate(task, toggleInput(task), s.completedImmutable, s.now()); err != nil {
+		return nil, err
+	}
+	shard.tasks[id] = task
+	return task, nil
+}
+
+// toggleInput строит обновление, меняющее только отметку о выполнении задачи
+func toggleInput(task *models.Task) UpdateTaskInput {
+	return UpdateTaskInput{Title: task.Title, Description: task.Description, Completed: !task.Completed}
+}
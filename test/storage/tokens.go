@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"container/list"
+	"time"
+)
+
+// tokenEntry хранит соответствие клиентского токена созданной задаче
+type tokenEntry struct {
+	token     string    // Клиентский токен
+	taskID    int       // ID созданной по токену задачи
+	expiresAt time.Time // Момент, после которого токен забывается
+}
+
+// tokenCache запоминает клиентские токены создания задач на ограниченное время
+//
+// Размер кэша ограничен: при переполнении вытесняется токен, который дольше всех
+// не использовался (LRU). Кэш не синхронизирован и используется под мьютексом хранилища.
+type tokenCache struct {
+	ttl      time.Duration            // Время жизни токена
+	capacity int                      // Максимальное число токенов
+	order    *list.List               // Токены от недавно использованных к давним
+	entries  map[string]*list.Element // Индекс токенов для поиска
+	hits     uint64                   // Число найденных токенов
+	misses   uint64                   // Число неизвестных или истекших токенов
+	flushes  uint64                   // Число очисток кэша
+}
+
+// newTokenCache создает кэш токенов с заданным временем жизни и размером
+func newTokenCache(ttl time.Duration, capacity int) *tokenCache {
+	return &tokenCache{
+		ttl:      ttl,
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// lookup возвращает ID задачи, созданной по токену, если токен еще не истек
+func (c *tokenCache) lookup(token string, now time.Time) (int, bool) {
+	elem, exists := c.entries[token]
+	if !exists {
+		c.misses++
+		return 0, false
+	}
+
+	entry := elem.Value.(*tokenEntry)
+	if !now.Before(entry.expiresAt) {
+		c.remove(elem)
+		c.misses++
+		return 0, false
+	}
+
+	c.order.MoveToFront(elem)
+	c.hits++
+	return entry.taskID, true
+}
+
+// remember сохраняет токен, вытесняя давно не использованные при переполнении
+func (c *tokenCache) remember(token string, taskID int, now time.Time) {
+	if elem, exists := c.entries[token]; exists {
+		c.remove(elem)
+	}
+
+	for c.order.Len() >= c.capacity && c.order.Len() > 0 {
+		c.remove(c.order.Back())
+	}
+
+	c.entries[token] = c.order.PushFront(&tokenEntry{
+		token:     token,
+		taskID:    taskID,
+		expiresAt: now.Add(c.ttl),
+	})
+}
+
+// forget удаляет токен из кэша
+func (c *tokenCache) forget(token string) {
+	if elem, exists := c.entries[token]; exists {
+		c.remove(elem)
+	}
+}
+
+// flush удаляет все токены, сохраняя счетчики обращений
+func (c *tokenCache) flush() {
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+	c.flushes++
+}
+
+// stats возвращает состояние кэша
+func (c *tokenCache) stats() CacheStats {
+	return CacheStats{
+		Name:     ClientTokenCache,
+		Size:     c.order.Len(),
+		Capacity: c.capacity,
+		Hits:     c.hits,
+		Misses:   c.misses,
+		Flushes:  c.flushes,
+	}
+}
+
+// remove удаляет элемент из списка и индекса
+func (c *tokenCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*tokenEntry).token)
+}
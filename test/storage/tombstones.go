@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"container/list"
+	"time"
+)
+
+// TombstoneTTL возвращает время, в течение которого хранилище помнит удаленные задачи
+func (s *InMemoryStorage) TombstoneTTL() time.Duration {
+	return s.tombstones.ttl
+}
+
+// tombstone хранит ID удаленной задачи и момент удаления
+type tombstone struct {
+	id        int       // ID удаленной задачи
+	deletedAt time.Time // Момент удаления
+}
+
+// tombstoneSet помнит недавно удаленные задачи, чтобы отличать их от никогда не существовавших
+//
+// Размер ограничен: при переполнении вытесняется самое давнее удаление. Поиск не меняет
+// порядок записей, поэтому безопасен под блокировкой хранилища на чтение;
+// истекшие записи удаляются при следующем добавлении.
+type tombstoneSet struct {
+	ttl      time.Duration         // Время, в течение которого удаление помнится
+	capacity int                   // Максимальное число записей
+	order    *list.List            // Записи от новых удалений к давним
+	entries  map[int]*list.Element // Индекс записей по ID задачи
+}
+
+// newTombstoneSet создает набор записей об удалении с заданным временем жизни и размером
+func newTombstoneSet(ttl time.Duration, capacity int) *tombstoneSet {
+	return &tombstoneSet{
+		ttl:      ttl,
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[int]*list.Element),
+	}
+}
+
+// lookup возвращает момент удаления задачи, если запись еще не истекла
+func (t *tombstoneSet) lookup(id int, now time.Time) (time.Time, bool) {
+	elem, exists := t.entries[id]
+	if !exists {
+		return time.Time{}, false
+	}
+
+	entry := elem.Value.(*tombstone)
+	if !now.Before(entry.deletedAt.Add(t.ttl)) {
+		return time.Time{}, false
+	}
+	return entry.deletedAt, true
+}
+
+// add запоминает удаление задачи, вытесняя истекшие и самые давние записи
+func (t *tombstoneSet) add(id int, now time.Time) {
+	if elem, exists := t.entries[id]; exists {
+		t.remove(elem)
+	}
+
+	for back := t.order.Back(); back != nil; back = t.order.Back() {
+		expired := !now.Before(back.Value.(*tombstone).deletedAt.Add(t.ttl))
+		if !expired && t.order.Len() < t.capacity {
+			break
+		}
+		t.remove(back)
+	}
+
+	if t.capacity > 0 {
+		t.entries[id] = t.order.PushFront(&tombstone{id: id, deletedAt: now})
+	}
+}
+
+// remove удаляет запись из списка и индекса
+func (t *tombstoneSet) remove(elem *list.Element) {
+	t.order.Remove(elem)
+	delete(t.entries, elem.Value.(*tombstone).id)
+}
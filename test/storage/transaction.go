@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"context"
+	"test/models"
+	"time"
+)
+
+// RunInTransaction выполняет операции fn с задачами атомарно
+//
+// Изменения, сделанные через tx, накапливаются в транзакции и применяются все
+// вместе, только если fn вернула nil; при ошибке fn хранилище не меняется.
+// Транзакция выполняется под блокировкой на запись, поэтому другие запросы
+// не видят ее промежуточного состояния, а транзакции не перемежаются. fn не
+// должна обращаться к хранилищу в обход tx: блокировка уже занята.
+//
+// Лимит задач (см. NewInMemoryStorageWithLimit) проверяется при применении:
+// если для созданных задач нет места, возвращается ErrStorageFull, а задачи,
+// измененные транзакцией, не вытесняются.
+//
+// Args:
+//
+//	fn: операции транзакции
+//
+// Returns:
+//
+//	error: ошибка fn или ErrStorageFull
+func (s *InMemoryStorage) RunInTransaction(fn func(tx TaskTx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.writable(); err != nil {
+		return err
+	}
+
+	tx := &memoryTx{s: s, now: s.now(), lastID: s.lastID, staged: make(map[int]*models.Task)}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := s.reserve(tx.created, tx.staged); err != nil {
+		return err
+	}
+
+	s.lastID = tx.lastID
+	for _, id := range tx.order {
+		s.store(tx.staged[id])
+	}
+	for _, id := range tx.deleted {
+		s.tombstones.add(id, tx.now)
+	}
+	return nil
+}
+
+// memoryTx - транзакция InMemoryStorage: измененные копии задач до применения
+type memoryTx struct {
+	s       *InMemoryStorage
+	now     time.Time            // Момент транзакции
+	lastID  int                  // Последний ID с учетом созданных в транзакции задач
+	staged  map[int]*models.Task // Созданные и измененные задачи по ID
+	order   []int                // ID задач staged в порядке первого изменения
+	created int                  // Число созданных задач
+	deleted []int                // ID удаленных задач для записей об удалении
+}
+
+// CreateTask создает задачу в транзакции, ID выдается сразу
+func (tx *memoryTx) CreateTask(input CreateTaskInput) (*models.Task, error) {
+	task := newTask(input, tx.now)
+	task.ID = tx.allocateID()
+	tx.stage(task)
+	tx.created++
+	return cloneTask(task), nil
+}
+
+// GetTask возвращает задачу с учетом изменений транзакции
+func (tx *memoryTx) GetTask(id int) (*models.Task, error) {
+	task, err := tx.find(id)
+	if err != nil {
+		return nil, err
+	}
+	return cloneTask(task), nil
+}
+
+// UpdateTask обновляет задачу в транзакции, см. InMemoryStorage.UpdateTask
+func (tx *memoryTx) UpdateTask(id int, input UpdateTaskInput) (*models.Task, error) {
+	task, err := tx.find(id)
+	if err != nil {
+		return nil, err
+	}
+	task = cloneTask(task)
+	if err := applyUpdate(task, input, tx.s.completedImmutable, tx.now); err != nil {
+		return nil, err
+	}
+	tx.stage(task)
+	return cloneTask(task), nil
+}
+
+// DeleteTask мягко удаляет задачу в транзакции, защищенная задача не удаляется
+func (tx *memoryTx) DeleteTask(id int) error {
+	task, err := tx.find(id)
+	if err != nil {
+		return err
+	}
+	if task.Protected {
+		return ErrTaskProtected
+	}
+	task = cloneTask(task)
+	deletedAt := tx.now.UTC()
+	task.DeletedAt = &deletedAt
+	tx.stage(task)
+	tx.deleted = append(tx.deleted, id)
+	return nil
+}
+
+// find возвращает задачу с учетом изменений транзакции
+func (tx *memoryTx) find(id int) (*models.Task, error) {
+	task, staged := tx.staged[id]
+	if !staged {
+		return tx.s.find(id)
+	}
+	if task.DeletedAt != nil {
+		return nil, &TaskDeletedError{ID: id, DeletedAt: *task.DeletedAt}
+	}
+	return task, nil
+}
+
+// stage запоминает новое состояние задачи до применения транзакции
+func (tx *memoryTx) stage(task *models.Task) {
+	if _, exists := tx.staged[task.ID]; !exists {
+		tx.order = append(tx.order, task.ID)
+	}
+	tx.staged[task.ID] = task
+}
+
+// allocateID выдает ID, свободный и в хранилище, и среди задач транзакции
+func (tx *memoryTx) allocateID() int {
+	for {
+		tx.lastID++
+		_, stored := tx.s.tasks[tx.lastID]
+		_, staged := tx.staged[tx.lastID]
+		if !stored && !staged {
+			return tx.lastID
+		}
+	}
+}
+
+// RunInTransaction выполняет операции fn с задачами в одной транзакции хранилища
+//
+// Изменения сохраняются, только если fn вернула nil, см. InMemoryStorage.RunInTransaction.
+func (s *recordStorage) RunInTransaction(fn func(tx TaskTx) error) error {
+	return s.backend.update(context.Background(), func(tx recordTx) error {
+		return fn(&recordTaskTx{s: s, tx: tx, now: s.cfg.now()})
+	})
+}
+
+// recordTaskTx - операции с задачами внутри транзакции recordBackend
+type recordTaskTx struct {
+	s   *recordStorage
+	tx  recordTx
+	now time.Time // Момент транзакции
+}
+
+// CreateTask создает задачу в транзакции
+func (t *recordTaskTx) CreateTask(input CreateTaskInput) (*models.Task, error) {
+	task := newTask(input, t.now)
+	if err := t.tx.insertTask(task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// GetTask возвращает задачу с учетом изменений транзакции
+func (t *recordTaskTx) GetTask(id int) (*models.Task, error) {
+	return t.s.find(t.tx, id)
+}
+
+// UpdateTask обновляет задачу в транзакции
+func (t *recordTaskTx) UpdateTask(id int, input UpdateTaskInput) (*models.Task, error) {
+	task, err := t.s.find(t.tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyUpdate(task, input, t.s.cfg.completedImmutable, t.now); err != nil {
+		return nil, err
+	}
+	if err := t.tx.updateTask(task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// DeleteTask мягко удаляет задачу в транзакции, защищенная задача не удаляется
+func (t *recordTaskTx) DeleteTask(id int) error {
+	return t.s.softDelete(t.tx, id, false)
+}
@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"net/http"
+	"test/handlers"
+	"test/storage"
+	"testing"
+)
+
+// TestAllowHeader проверяет заголовок Allow в ответах 405 и OPTIONS
+//
+// Проверяет:
+// - Ответ 405 на неподдерживаемый метод несет заголовок Allow с методами маршрута и OPTIONS
+// - OPTIONS отвечает 204 с тем же заголовком
+// - Поддерживаемый метод не получает заголовка Allow
+func TestAllowHeader(t *testing.T) {
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage())
+	expectCode(t, postTask(t, mux, map[string]string{"title": "Задача", "description": "Описание"}), http.StatusCreated)
+
+	routes := map[string]string{
+		"/tasks":                          "GET, POST, OPTIONS",
+		"/tasks/1":                        "GET, PUT, PATCH, DELETE, OPTIONS",
+		"/tasks/quick":                    "POST, OPTIONS",
+		"/tasks/bulk":                     "POST, DELETE, OPTIONS",
+		"/tasks/overdue":                  "GET, OPTIONS",
+		"/tasks/search":                   "GET, OPTIONS",
+		"/tasks/duplicates":               "GET, OPTIONS",
+		"/tasks/1/links":                  "POST, OPTIONS",
+		"/tasks/1/links/0":                "DELETE, OPTIONS",
+		"/tasks/1/complete-with-followup": "POST, OPTIONS",
+		"/tasks/1/metadata":               "GET, OPTIONS",
+		"/tasks/1/metadata/crm":           "GET, PUT, DELETE, OPTIONS",
+		"/tasks/1/restore":                "POST, OPTIONS",
+		"/tasks/1/toggle":                 "PUT, OPTIONS",
+		"/preferences":                    "GET, PUT, OPTIONS",
+		"/schemas/task":                   "GET, OPTIONS",
+		"/capabilities":                   "GET, OPTIONS",
+		"/admin/config/reload":            "POST, OPTIONS",
+		"/admin/caches":                   "GET, OPTIONS",
+		"/admin/caches/tasks/flush":       "POST, OPTIONS",
+		"/healthz":                        "GET, OPTIONS",
+		"/readyz":                         "GET, OPTIONS",
+	}
+	for path, allow := range routes {
+		for method, status := range map[string]int{http.MethodTrace: http.StatusMethodNotAllowed, http.MethodOptions: http.StatusNoContent} {
+			w := doJSON(t, mux, method, path, nil)
+			if w.Code != status {
+				t.Errorf("%s %s: ожидался код %d, получен %d", method, path, status, w.Code)
+			}
+			if got := w.Header().Get("Allow"); got != allow {
+				t.Errorf("%s %s: ожидался заголовок Allow %q, получен %q", method, path, allow, got)
+			}
+		}
+	}
+
+	if w := doJSON(t, mux, "GET", "/tasks/1", nil); w.Header().Get("Allow") != "" {
+		t.Errorf("GET /tasks/1: неожиданный заголовок Allow %q", w.Header().Get("Allow"))
+	}
+}
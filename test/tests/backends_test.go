@@ -0,0 +1,529 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"test/handlers"
+	"test/models"
+	"test/storage"
+	"testing"
+	"time"
+)
+
+// storageFactory создает новое пустое хранилище с заданными опциями
+type storageFactory func(t *testing.T, opts ...storage.Option) storage.Storage
+
+// newMemoryStorage создает хранилище в памяти
+func newMemoryStorage(t *testing.T, opts ...storage.Option) storage.Storage {
+	return storage.NewInMemoryStorage(opts...)
+}
+
+// decodeTask разбирает задачу из тела ответа
+func decodeTask(t *testing.T, w *httptest.ResponseRecorder) models.Task {
+	t.Helper()
+	var task models.Task
+	if err := json.Unmarshal(w.Body.Bytes(), &task); err != nil {
+		t.Fatalf("Неверное тело ответа %q: %v", w.Body.String(), err)
+	}
+	return task
+}
+
+// expectCode проверяет код ответа
+func expectCode(t *testing.T, w *httptest.ResponseRecorder, expected int) {
+	t.Helper()
+	if w.Code != expected {
+		t.Fatalf("Ожидался код %d, получен %d: %s", expected, w.Code, w.Body.String())
+	}
+}
+
+// testStorageBackend проверяет реализацию хранилища с полным набором возможностей
+// через обработчики: ответы должны совпадать с ответами для InMemoryStorage
+//
+// Проверяет:
+// - Все возможности отмечены в GET /capabilities как доступные
+// - Таблицу маршрутов /tasks/{id}
+// - Создание, чтение, список в порядке ID, обновление, частичное обновление и удаление
+// - 404 для несуществующей задачи, 410 для удаленной и отсутствие повторного использования ID
+// - Восстановление мягко удаленной задачи и окончательное удаление повторным DELETE и permanent=true
+// - Страницы списка в порядке ID с общим числом задач
+// - Быстрое добавление нескольких задач одной операцией
+// - Повтор создания с client_token и очистку токенов
+// - Подтверждение удаления защищенной задачи, завершение с продолжением, ссылки и метаданные
+// - Опции WithCompletedImmutable и WithTombstoneTTL
+// - Счетчик ID: NextID, подъем SetLastID и отказ его уменьшить
+// - Транзакции: изменения применяются вместе, ошибка посередине не меняет хранилище
+func testStorageBackend(t *testing.T, newStorage storageFactory) {
+	t.Run("Capabilities", func(t *testing.T) {
+		w := doJSON(t, handlers.SetupHandlers(newStorage(t)), "GET", "/capabilities", nil)
+		expectCode(t, w, http.StatusOK)
+
+		var capabilities map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &capabilities); err != nil {
+			t.Fatal(err)
+		}
+		features := []string{"client_token", "streaming_list", "tombstones", "links", "patch", "complete_with_followup", "metadata", "admin_caches", "quick_add", "bulk_create", "bulk_delete", "soft_delete", "overdue", "search", "optimistic_concurrency"}
+		for _, name := range features {
+			if capabilities[name] != true {
+				t.Errorf("Возможность %s недоступна", name)
+			}
+		}
+	})
+
+	t.Run("Routing", func(t *testing.T) {
+		testTaskRoutingMatrix(t, newStorage)
+	})
+
+	t.Run("CRUD", func(t *testing.T) {
+		mux := handlers.SetupHandlers(newStorage(t))
+
+		for i := 1; i <= 3; i++ {
+			w := postTask(t, mux, map[string]string{"title": fmt.Sprintf("Задача %d", i), "description": "Позвонить @anna"})
+			expectCode(t, w, http.StatusCreated)
+			if task := decodeTask(t, w); task.ID != i || task.Completed || len(task.Mentions) != 1 || task.CreatedAt.IsZero() {
+				t.Errorf("Неверная созданная задача: %+v", task)
+			}
+		}
+
+		w := doJSON(t, mux, "PUT", "/tasks/2", map[string]interface{}{"title": "Обновлена", "description": "Без упоминаний", "completed": true})
+		expectCode(t, w, http.StatusOK)
+		if task := decodeTask(t, w); task.Title != "Обновлена" || !task.Completed || len(task.Mentions) != 0 {
+			t.Errorf("Неверная обновленная задача: %+v", task)
+		}
+
+		w = patchTask(t, mux, "/tasks/3", `{"completed": true}`)
+		expectCode(t, w, http.StatusOK)
+		if task := decodeTask(t, w); task.Title != "Задача 3" || !task.Completed {
+			t.Errorf("Неверная частично обновленная задача: %+v", task)
+		}
+		expectCode(t, patchTask(t, mux, "/tasks/3", `{"title": 42}`), http.StatusBadRequest)
+
+		w = doJSON(t, mux, "GET", "/tasks/2", nil)
+		expectCode(t, w, http.StatusOK)
+		if task := decodeTask(t, w); task.Title != "Обновлена" || task.UpdatedAt.Before(task.CreatedAt) {
+			t.Errorf("Изменение не сохранено: %+v", task)
+		}
+
+		w = doJSON(t, mux, "GET", "/tasks?sort=title", nil)
+		expectCode(t, w, http.StatusOK)
+		var tasks []models.Task
+		if err := json.Unmarshal(w.Body.Bytes(), &tasks); err != nil {
+			t.Fatal(err)
+		}
+		if len(tasks) != 3 || tasks[0].Title != "Задача 1" || tasks[2].Title != "Обновлена" {
+			t.Errorf("Неверный список задач: %+v", tasks)
+		}
+
+		expectCode(t, doJSON(t, mux, "DELETE", "/tasks/3", nil), http.StatusNoContent)
+		expectCode(t, doJSON(t, mux, "GET", "/tasks/3", nil), http.StatusGone)
+		expectCode(t, doJSON(t, mux, "GET", "/tasks/99", nil), http.StatusNotFound)
+
+		w = postTask(t, mux, map[string]string{"title": "Новая", "description": "Описание"})
+		expectCode(t, w, http.StatusCreated)
+		if task := decodeTask(t, w); task.ID != 4 {
+			t.Errorf("Ожидался ID 4, получен %d: ID удаленной задачи использован повторно", task.ID)
+		}
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		mux := handlers.SetupHandlers(newStorage(t))
+		for i := 1; i <= 5; i++ {
+			expectCode(t, postTask(t, mux, map[string]string{"title": fmt.Sprintf("Задача %d", i), "description": "Описание"}), http.StatusCreated)
+		}
+		expectCode(t, doJSON(t, mux, "DELETE", "/tasks/2", nil), http.StatusNoContent)
+
+		w := doJSON(t, mux, "GET", "/tasks?page=2&limit=2", nil)
+		expectCode(t, w, http.StatusOK)
+		var tasks []models.Task
+		if err := json.Unmarshal(w.Body.Bytes(), &tasks); err != nil {
+			t.Fatal(err)
+		}
+		if len(tasks) != 2 || tasks[0].ID != 4 || tasks[1].ID != 5 {
+			t.Errorf("Ожидались задачи 4 и 5, получены %+v", tasks)
+		}
+		if total := w.Header().Get("X-Total-Count"); total != "4" {
+			t.Errorf("Ожидался X-Total-Count 4, получен %q", total)
+		}
+	})
+
+	t.Run("QuickAdd", func(t *testing.T) {
+		mux := handlers.SetupHandlers(newStorage(t))
+		expectCode(t, postTask(t, mux, map[string]string{"title": "Задача 1", "description": "Описание"}), http.StatusCreated)
+
+		w := postQuick(t, mux, "text/plain", "Задача 2\n\nЗадача 3")
+		expectCode(t, w, http.StatusCreated)
+		var tasks []models.Task
+		if err := json.Unmarshal(w.Body.Bytes(), &tasks); err != nil {
+			t.Fatal(err)
+		}
+		if len(tasks) != 2 || tasks[0].ID != 2 || tasks[1].ID != 3 || tasks[1].Title != "Задача 3" {
+			t.Errorf("Неверные созданные задачи: %+v", tasks)
+		}
+		expectCode(t, doJSON(t, mux, "GET", "/tasks/3", nil), http.StatusOK)
+	})
+
+	t.Run("BulkDelete", func(t *testing.T) {
+		mux := handlers.SetupHandlers(newStorage(t))
+		expectCode(t, postQuick(t, mux, "text/plain", "Задача 1\nЗадача 2\nЗадача 3"), http.StatusCreated)
+		if got := bulkDelete(t, mux, "", 3, 4, 1); fmt.Sprint(got) != "{[3 1] [4]}" {
+			t.Errorf("Ожидалось {[3 1] [4]}, получено %v", got)
+		}
+		expectCode(t, doJSON(t, mux, "GET", "/tasks/2", nil), http.StatusOK)
+		expectCode(t, doJSON(t, mux, "GET", "/tasks/3", nil), http.StatusGone)
+	})
+
+	t.Run("Priority", func(t *testing.T) {
+		mux := handlers.SetupHandlers(newStorage(t))
+		for _, priority := range []string{"high", "low", "high"} {
+			expectCode(t, postTask(t, mux, map[string]string{"title": "Задача", "description": "Описание", "priority": priority}), http.StatusCreated)
+		}
+		expectCode(t, patchTask(t, mux, "/tasks/1", `{"priority": "critical"}`), http.StatusOK)
+
+		if task := decodeTask(t, doJSON(t, mux, "GET", "/tasks/1", nil)); task.Priority != models.PriorityCritical {
+			t.Errorf("Ожидался приоритет critical, получен %q", task.Priority)
+		}
+		if ids, _ := getPage(t, mux, "/tasks?priority=high"); fmt.Sprint(ids) != "[3]" {
+			t.Errorf("Ожидалась задача [3], получены %v", ids)
+		}
+	})
+
+	t.Run("Overdue", func(t *testing.T) {
+		mux := handlers.SetupHandlers(newStorage(t))
+		for _, due := range []string{"2000-01-01T00:00:00Z", "2999-01-01T00:00:00Z"} {
+			expectCode(t, postTask(t, mux, map[string]string{"title": "Задача", "description": "Описание", "due_date": due}), http.StatusCreated)
+		}
+		if ids, _ := getPage(t, mux, "/tasks/overdue"); fmt.Sprint(ids) != "[1]" {
+			t.Errorf("Ожидалась задача [1], получены %v", ids)
+		}
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		mux := handlers.SetupHandlers(newStorage(t))
+		expectCode(t, postTask(t, mux, map[string]string{"title": "Квартальный ОТЧЕТ", "description": "Описание"}), http.StatusCreated)
+		expectCode(t, postTask(t, mux, map[string]string{"title": "Звонок", "description": "Описание"}), http.StatusCreated)
+		expectCode(t, postTask(t, mux, map[string]string{"title": "Письмо", "description": "Приложить отчет"}), http.StatusCreated)
+		expectCode(t, doJSON(t, mux, "DELETE", "/tasks/3", nil), http.StatusNoContent)
+		if ids, _ := getPage(t, mux, "/tasks/search?q=Отчет"); fmt.Sprint(ids) != "[1]" {
+			t.Errorf("Ожидалась задача [1], получены %v", ids)
+		}
+	})
+
+	t.Run("Counter", func(t *testing.T) {
+		taskStorage := newStorage(t)
+		counter := taskStorage.(storage.CounterStorage)
+		expectNextID := func(expected int) {
+			t.Helper()
+			if next, err := counter.NextID(); err != nil || next != expected {
+				t.Errorf("Ожидался следующий ID %d, получен %d (%v)", expected, next, err)
+			}
+		}
+
+		expectNextID(1)
+		if err := counter.SetLastID(10); err != nil {
+			t.Fatal(err)
+		}
+		expectNextID(11)
+		task, err := taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Задача", Description: "Описание"})
+		if err != nil || task.ID != 11 {
+			t.Fatalf("Ожидался ID 11, получен %v (%v)", task, err)
+		}
+		expectNextID(12)
+
+		if err := counter.SetLastID(5); !errors.Is(err, storage.ErrLastIDDecrease) {
+			t.Errorf("Ожидалась ErrLastIDDecrease, получена %v", err)
+		}
+		if err := counter.SetLastID(11); err != nil {
+			t.Errorf("Текущее значение должно приниматься: %v", err)
+		}
+		expectNextID(12)
+	})
+
+	t.Run("Transaction", func(t *testing.T) {
+		taskStorage := newStorage(t)
+		transactions := taskStorage.(storage.TransactionalStorage)
+		for _, title := range []string{"Первая", "Вторая"} {
+			if _, err := taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: title, Description: "Описание"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		expectTasks := func(expected string) {
+			t.Helper()
+			tasks, err := taskStorage.GetAllTasks(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+			titles := make([]string, len(tasks))
+			for i, task := range tasks {
+				titles[i] = task.Title
+			}
+			if fmt.Sprint(titles) != expected {
+				t.Errorf("Ожидались задачи %s, получены %v", expected, titles)
+			}
+		}
+
+		// Ошибка посередине отменяет уже сделанные изменения
+		failure := errors.New("отмена")
+		err := transactions.RunInTransaction(func(tx storage.TaskTx) error {
+			if _, err := tx.CreateTask(storage.CreateTaskInput{Title: "Третья", Description: "Описание"}); err != nil {
+				return err
+			}
+			if _, err := tx.UpdateTask(1, storage.UpdateTaskInput{Title: "Изменена", Description: "Описание"}); err != nil {
+				return err
+			}
+			if err := tx.DeleteTask(2); err != nil {
+				return err
+			}
+			return failure
+		})
+		if !errors.Is(err, failure) {
+			t.Errorf("Ожидалась ошибка fn, получена %v", err)
+		}
+		expectTasks("[Первая Вторая]")
+
+		err = transactions.RunInTransaction(func(tx storage.TaskTx) error {
+			created, err := tx.CreateTask(storage.CreateTaskInput{Title: "Третья", Description: "Описание"})
+			if err != nil {
+				return err
+			}
+			if read, err := tx.GetTask(created.ID); err != nil || read.Title != "Третья" {
+				t.Errorf("Транзакция не видит созданную задачу: %v, %v", read, err)
+			}
+			if _, err := tx.UpdateTask(1, storage.UpdateTaskInput{Title: "Изменена", Description: "Описание"}); err != nil {
+				return err
+			}
+			if err := tx.DeleteTask(2); err != nil {
+				return err
+			}
+			if _, err := tx.GetTask(2); err == nil {
+				t.Error("Транзакция видит удаленную задачу")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectTasks("[Изменена Третья]")
+		if _, err := taskStorage.GetTask(t.Context(), 2); err == nil {
+			t.Error("Удаленная в транзакции задача найдена")
+		}
+
+		protected, err := taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Защищенная", Description: "Описание", Protected: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = transactions.RunInTransaction(func(tx storage.TaskTx) error {
+			return tx.DeleteTask(protected.ID)
+		})
+		if !errors.Is(err, storage.ErrTaskProtected) {
+			t.Errorf("Ожидалась ErrTaskProtected, получена %v", err)
+		}
+	})
+
+	t.Run("SoftDelete", func(t *testing.T) {
+		mux := handlers.SetupHandlers(newStorage(t))
+		for _, title := range []string{"Первая", "Вторая"} {
+			expectCode(t, postTask(t, mux, map[string]string{"title": title, "description": "Описание"}), http.StatusCreated)
+		}
+		expectCode(t, doJSON(t, mux, "DELETE", "/tasks/1", nil), http.StatusNoContent)
+		expectCode(t, doJSON(t, mux, "GET", "/tasks/1", nil), http.StatusGone)
+		if ids, _ := getPage(t, mux, "/tasks?page=1"); fmt.Sprint(ids) != "[2]" {
+			t.Errorf("Ожидалась задача [2], получены %v", ids)
+		}
+		if ids, _ := getPage(t, mux, "/tasks?include_deleted=true"); fmt.Sprint(ids) != "[1 2]" {
+			t.Errorf("Ожидались задачи [1 2], получены %v", ids)
+		}
+
+		expectCode(t, doJSON(t, mux, "POST", "/tasks/1/restore", nil), http.StatusOK)
+		if task := decodeTask(t, doJSON(t, mux, "GET", "/tasks/1", nil)); task.Title != "Первая" || task.DeletedAt != nil {
+			t.Errorf("Неверная восстановленная задача: %+v", task)
+		}
+		expectCode(t, doJSON(t, mux, "POST", "/tasks/1/restore", nil), http.StatusConflict)
+
+		expectCode(t, doJSON(t, mux, "DELETE", "/tasks/1", nil), http.StatusNoContent)
+		expectCode(t, doJSON(t, mux, "DELETE", "/tasks/1", nil), http.StatusNoContent)
+		expectCode(t, doJSON(t, mux, "DELETE", "/tasks/2?permanent=true", nil), http.StatusNoContent)
+		if ids, _ := getPage(t, mux, "/tasks?include_deleted=true"); len(ids) != 0 {
+			t.Errorf("Окончательно удаленные задачи в списке: %v", ids)
+		}
+		expectCode(t, doJSON(t, mux, "POST", "/tasks/1/restore", nil), http.StatusGone)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		taskStorage := newStorage(t)
+		if _, err := taskStorage.GetTask(t.Context(), 1); !errors.Is(err, storage.ErrTaskNotFound) || err.Error() != "задача не найдена: ID 1" {
+			t.Errorf("Ожидалась ошибка отсутствия задачи, получена %v", err)
+		}
+		if _, err := taskStorage.UpdateTask(t.Context(), 1, storage.UpdateTaskInput{Title: "Задача"}); err == nil {
+			t.Error("Ожидалась ошибка обновления несуществующей задачи")
+		}
+		if err := taskStorage.DeleteTask(t.Context(), 1); err == nil {
+			t.Error("Ожидалась ошибка удаления несуществующей задачи")
+		}
+	})
+
+	t.Run("ClientToken", func(t *testing.T) {
+		mux := handlers.SetupHandlers(newStorage(t))
+		body := map[string]string{"title": "Задача", "description": "Описание", "client_token": "token-1"}
+
+		expectCode(t, postTask(t, mux, body), http.StatusCreated)
+		w := postTask(t, mux, body)
+		expectCode(t, w, http.StatusOK)
+		if task := decodeTask(t, w); task.ID != 1 {
+			t.Errorf("Ожидалась исходная задача с ID 1, получена %d", task.ID)
+		}
+
+		caches := getCaches(t, mux)
+		if stats := caches[storage.ClientTokenCache]; stats.Size != 1 || stats.Hits != 1 || stats.Misses != 1 {
+			t.Errorf("Неверное состояние токенов: %+v", stats)
+		}
+		expectCode(t, doJSON(t, mux, "POST", "/admin/caches/"+storage.ClientTokenCache+"/flush", nil), http.StatusOK)
+		w = postTask(t, mux, body)
+		expectCode(t, w, http.StatusCreated)
+		if task := decodeTask(t, w); task.ID != 2 {
+			t.Errorf("После очистки ожидалась новая задача с ID 2, получена %d", task.ID)
+		}
+	})
+
+	t.Run("ProtectedDelete", func(t *testing.T) {
+		mux := handlers.SetupHandlers(newStorage(t))
+		expectCode(t, postTask(t, mux, map[string]interface{}{"title": "Бэклог", "description": "Описание", "protected": true}), http.StatusCreated)
+
+		expectCode(t, doJSON(t, mux, "DELETE", "/tasks/1", nil), http.StatusPreconditionRequired)
+		expectCode(t, doJSON(t, mux, "GET", "/tasks/1", nil), http.StatusOK)
+
+		req := httptest.NewRequest("DELETE", "/tasks/1", nil)
+		req.Header.Set(handlers.ConfirmDeleteHeader, "1")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		expectCode(t, w, http.StatusNoContent)
+	})
+
+	t.Run("FollowUp", func(t *testing.T) {
+		mux := handlers.SetupHandlers(newStorage(t))
+		expectCode(t, postTask(t, mux, map[string]string{"title": "Ревью", "description": "Описание"}), http.StatusCreated)
+
+		body := map[string]string{"title": "Ревью - раунд 2", "description": "Описание"}
+		w := doJSON(t, mux, "POST", "/tasks/1/complete-with-followup", body)
+		expectCode(t, w, http.StatusCreated)
+		var response handlers.FollowUpResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if !response.Completed.Completed || response.FollowUp.ID != 2 || response.FollowUp.FollowsID != 1 {
+			t.Errorf("Неверный ответ: %+v", response)
+		}
+		expectCode(t, doJSON(t, mux, "POST", "/tasks/1/complete-with-followup", body), http.StatusConflict)
+
+		w = doJSON(t, mux, "GET", "/tasks/1", nil)
+		if task := decodeTask(t, w); !task.Completed {
+			t.Errorf("Исходная задача не сохранена выполненной: %+v", task)
+		}
+	})
+
+	t.Run("Links", func(t *testing.T) {
+		mux := handlers.SetupHandlers(newStorage(t))
+		expectCode(t, postTask(t, mux, map[string]string{"title": "Задача", "description": "Описание"}), http.StatusCreated)
+
+		expectCode(t, doJSON(t, mux, "POST", "/tasks/1/links", models.Link{URL: "https://example.com/a"}), http.StatusCreated)
+		expectCode(t, doJSON(t, mux, "POST", "/tasks/1/links", models.Link{URL: "HTTPS://EXAMPLE.COM/a"}), http.StatusConflict)
+		expectCode(t, doJSON(t, mux, "POST", "/tasks/1/links", models.Link{URL: "https://example.com/b"}), http.StatusCreated)
+		expectCode(t, doJSON(t, mux, "DELETE", "/tasks/1/links/0", nil), http.StatusNoContent)
+
+		task := decodeTask(t, doJSON(t, mux, "GET", "/tasks/1", nil))
+		if len(task.Links) != 1 || task.Links[0].URL != "https://example.com/b" {
+			t.Errorf("Неверные ссылки: %+v", task.Links)
+		}
+	})
+
+	t.Run("Metadata", func(t *testing.T) {
+		mux := handlers.SetupHandlers(newStorage(t))
+		expectCode(t, postTask(t, mux, map[string]string{"title": "Сделка", "description": "Описание"}), http.StatusCreated)
+
+		expectCode(t, putRaw(t, mux, "/tasks/1/metadata/crm", []byte(`{"deal_id":42}`)), http.StatusNoContent)
+		expectCode(t, putRaw(t, mux, "/tasks/1/metadata/slack", []byte(`"C01"`)), http.StatusNoContent)
+		expectCode(t, doJSON(t, mux, "DELETE", "/tasks/1/metadata/slack", nil), http.StatusNoContent)
+
+		w := doJSON(t, mux, "GET", "/tasks/1/metadata/crm", nil)
+		expectCode(t, w, http.StatusOK)
+		if w.Body.String() != `{"deal_id":42}` {
+			t.Errorf("Значение изменилось: %s", w.Body.String())
+		}
+		expectCode(t, doJSON(t, mux, "GET", "/tasks/1/metadata/slack", nil), http.StatusNotFound)
+
+		// Обновление задачи не теряет метаданные
+		expectCode(t, patchTask(t, mux, "/tasks/1", `{"completed": true}`), http.StatusOK)
+		w = doJSON(t, mux, "GET", "/tasks/1?expand=metadata", nil)
+		var expanded struct {
+			Metadata map[string]json.RawMessage `json:"metadata"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &expanded); err != nil {
+			t.Fatal(err)
+		}
+		if len(expanded.Metadata) != 1 || string(expanded.Metadata["crm"]) != `{"deal_id":42}` {
+			t.Errorf("Неверные метаданные: %s", w.Body.String())
+		}
+	})
+
+	t.Run("CompletedImmutable", func(t *testing.T) {
+		mux := handlers.SetupHandlers(newStorage(t, storage.WithCompletedImmutable()))
+		expectCode(t, postTask(t, mux, map[string]string{"title": "Задача", "description": "Описание"}), http.StatusCreated)
+		expectCode(t, patchTask(t, mux, "/tasks/1", `{"completed": true}`), http.StatusOK)
+
+		expectCode(t, patchTask(t, mux, "/tasks/1", `{"title": "Правка"}`), http.StatusConflict)
+		expectCode(t, doJSON(t, mux, "POST", "/tasks/1/links", models.Link{URL: "https://example.com/"}), http.StatusConflict)
+		expectCode(t, patchTask(t, mux, "/tasks/1", `{"completed": false}`), http.StatusOK)
+		expectCode(t, patchTask(t, mux, "/tasks/1", `{"title": "Правка"}`), http.StatusOK)
+	})
+
+	t.Run("TombstoneTTL", func(t *testing.T) {
+		clock := &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+		mux := handlers.SetupHandlers(newStorage(t, storage.WithClock(clock.Now), storage.WithTombstoneTTL(time.Hour)))
+		expectCode(t, postTask(t, mux, map[string]string{"title": "Задача", "description": "Описание"}), http.StatusCreated)
+		expectCode(t, doJSON(t, mux, "DELETE", "/tasks/1", nil), http.StatusNoContent)
+
+		clock.Advance(10 * time.Minute)
+		w := doJSON(t, mux, "GET", "/tasks/1", nil)
+		expectCode(t, w, http.StatusGone)
+		var body map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if body["deleted_at"] != "2024-05-01T12:00:00Z" {
+			t.Errorf("Неверный момент удаления: %v", body)
+		}
+
+		clock.Advance(time.Hour)
+		expectCode(t, doJSON(t, mux, "GET", "/tasks/1", nil), http.StatusNotFound)
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		taskStorage := newStorage(t)
+		mux := handlers.SetupHandlers(taskStorage)
+		expectCode(t, postTask(t, mux, map[string]string{"title": "Задача", "description": "Описание"}), http.StatusCreated)
+		for i := 0; i < 2; i++ {
+			if err := taskStorage.Close(); err != nil {
+				t.Fatalf("Ошибка закрытия %d: %v", i+1, err)
+			}
+		}
+
+		if _, err := taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Задача", Description: "Описание"}); !errors.Is(err, storage.ErrStorageClosed) {
+			t.Errorf("Ожидалась ошибка ErrStorageClosed, получено %v", err)
+		}
+		w := postTask(t, mux, map[string]string{"title": "Задача", "description": "Описание"})
+		expectCode(t, w, http.StatusServiceUnavailable)
+		var body handlers.ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != handlers.CodeStorageClosed {
+			t.Errorf("Неверное тело ответа: %s", w.Body.String())
+		}
+		expectCode(t, doJSON(t, mux, "DELETE", "/tasks/1", nil), http.StatusServiceUnavailable)
+	})
+}
+
+// TestInMemoryBackend проверяет общий набор проверок хранилищ на InMemoryStorage
+func TestInMemoryBackend(t *testing.T) {
+	testStorageBackend(t, newMemoryStorage)
+}
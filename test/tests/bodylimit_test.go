@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test/handlers"
+	"test/handlers/middleware"
+	"test/storage"
+	"testing"
+)
+
+// TestBodyLimit проверяет ограничение размера тела запроса middleware.NewBodyLimit
+//
+// Проверяет:
+// - Тело 2 МБ отклоняется с 413 и кодом body_too_large по умолчанию, обработчик не вызывается
+// - Тело без Content-Length, превысившее лимит при чтении, отклоняется так же
+// - Тело в пределах лимита доходит до обработчика без изменений
+// - WithMaxBodyBytes задает лимит
+func TestBodyLimit(t *testing.T) {
+	var called bool
+	var received []byte
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		received, _ = io.ReadAll(r.Body)
+	})
+	post := func(handler http.Handler, body io.Reader) *httptest.ResponseRecorder {
+		called, received = false, nil
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/tasks", body))
+		return w
+	}
+	limited := middleware.NewBodyLimit()(echo)
+	large := bytes.Repeat([]byte("a"), 2<<20)
+
+	w := post(limited, bytes.NewReader(large))
+	expectCode(t, w, http.StatusRequestEntityTooLarge)
+	var body handlers.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != middleware.CodeBodyTooLarge {
+		t.Errorf("Неверное тело ответа: %s", w.Body.String())
+	}
+	if called {
+		t.Error("Обработчик вызван для слишком большого тела")
+	}
+
+	// io.MultiReader скрывает размер тела: Content-Length неизвестен
+	expectCode(t, post(limited, io.MultiReader(bytes.NewReader(large))), http.StatusRequestEntityTooLarge)
+	if called {
+		t.Error("Обработчик вызван для слишком большого тела без Content-Length")
+	}
+
+	small := `{"title":"Задача","description":"Описание"}`
+	expectCode(t, post(limited, io.MultiReader(strings.NewReader(small))), http.StatusOK)
+	if !called || string(received) != small {
+		t.Errorf("Обработчик получил %q, ожидалось %q", received, small)
+	}
+
+	tiny := middleware.NewBodyLimit(middleware.WithMaxBodyBytes(10))(echo)
+	expectCode(t, post(tiny, strings.NewReader("0123456789")), http.StatusOK)
+	expectCode(t, post(tiny, strings.NewReader("0123456789A")), http.StatusRequestEntityTooLarge)
+}
+
+// TestBodyLimitHandlers проверяет ограничение размера тела с обработчиками API
+//
+// Проверяет:
+// - Создание задачи с телом в пределах лимита работает
+// - Задача с описанием больше лимита не создается
+func TestBodyLimitHandlers(t *testing.T) {
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage(), middleware.NewBodyLimit(middleware.WithMaxBodyBytes(1024)))
+
+	expectCode(t, postTask(t, mux, map[string]string{"title": "Задача", "description": "Описание"}), http.StatusCreated)
+	expectCode(t, postTask(t, mux, map[string]string{"title": "Задача", "description": strings.Repeat("д", 1024)}), http.StatusRequestEntityTooLarge)
+	if ids, _ := getPage(t, mux, "/tasks"); len(ids) != 1 {
+		t.Errorf("Ожидалась 1 задача, получено %d", len(ids))
+	}
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"net/http"
+	"path/filepath"
+	"test/handlers"
+	"test/storage"
+	"testing"
+	"time"
+)
+
+// newBoltStorage создает хранилище bbolt во временном файле теста
+func newBoltStorage(t *testing.T, opts ...storage.Option) storage.Storage {
+	return openBoltStorage(t, filepath.Join(t.TempDir(), "tasks.bolt"), opts...)
+}
+
+// openBoltStorage открывает хранилище bbolt, закрываемое по завершении теста
+func openBoltStorage(t *testing.T, path string, opts ...storage.Option) *storage.BoltStorage {
+	t.Helper()
+	taskStorage, err := storage.NewBoltStorage(path, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { taskStorage.Close() })
+	return taskStorage
+}
+
+// TestBoltStorage проверяет хранилище bbolt общим набором проверок хранилищ
+func TestBoltStorage(t *testing.T) {
+	testStorageBackend(t, newBoltStorage)
+}
+
+// TestBoltStorageReopen проверяет сохранение данных между открытиями базы данных
+//
+// Проверяет:
+// - Задачи, их метаданные и клиентские токены доступны после повторного открытия
+// - Удаленная задача по-прежнему возвращает 410
+// - ID удаленной последней задачи не выдается повторно после повторного открытия
+func TestBoltStorageReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.bolt")
+
+	first := openBoltStorage(t, path)
+	mux := handlers.SetupHandlers(first)
+	expectCode(t, postTask(t, mux, map[string]string{"title": "Первая", "description": "Описание", "client_token": "abc"}), http.StatusCreated)
+	expectCode(t, postTask(t, mux, map[string]string{"title": "Вторая", "description": "Описание"}), http.StatusCreated)
+	expectCode(t, putRaw(t, mux, "/tasks/1/metadata/crm", []byte(`{"deal_id":42}`)), http.StatusNoContent)
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/2", nil), http.StatusNoContent)
+	if err := first.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mux = handlers.SetupHandlers(openBoltStorage(t, path))
+	w := doJSON(t, mux, "GET", "/tasks/1", nil)
+	expectCode(t, w, http.StatusOK)
+	if task := decodeTask(t, w); task.Title != "Первая" {
+		t.Errorf("Неверная задача после открытия: %+v", task)
+	}
+	if w := doJSON(t, mux, "GET", "/tasks/1/metadata/crm", nil); w.Body.String() != `{"deal_id":42}` {
+		t.Errorf("Метаданные потеряны: %d %s", w.Code, w.Body.String())
+	}
+	expectCode(t, doJSON(t, mux, "GET", "/tasks/2", nil), http.StatusGone)
+
+	// Повтор с тем же токеном возвращает исходную задачу
+	w = postTask(t, mux, map[string]string{"title": "Первая", "description": "Описание", "client_token": "abc"})
+	expectCode(t, w, http.StatusOK)
+	if task := decodeTask(t, w); task.ID != 1 {
+		t.Errorf("Ожидалась задача 1 по токену, получена %d", task.ID)
+	}
+
+	w = postTask(t, mux, map[string]string{"title": "Третья", "description": "Описание"})
+	expectCode(t, w, http.StatusCreated)
+	if task := decodeTask(t, w); task.ID != 3 {
+		t.Errorf("Ожидался ID 3, получен %d", task.ID)
+	}
+}
+
+// TestBoltStorageCapacity проверяет вытеснение самых давних токенов и записей об удалении
+//
+// Проверяет:
+// - Сверх WithClientTokenCapacity забывается самый давний токен
+// - Сверх WithTombstoneCapacity удаленная раньше всех задача возвращает 404 вместо 410
+func TestBoltStorageCapacity(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	taskStorage := openBoltStorage(t, filepath.Join(t.TempDir(), "tasks.bolt"),
+		storage.WithClock(clock.Now), storage.WithClientTokenCapacity(2), storage.WithTombstoneCapacity(2))
+
+	for _, token := range []string{"a", "b", "c"} {
+		clock.Advance(time.Second)
+		if _, _, err := taskStorage.CreateTaskWithToken(token, storage.CreateTaskInput{Title: token, Description: "Описание"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repeats := []struct {
+		token   string
+		created bool
+	}{{"c", false}, {"a", true}}
+	for _, r := range repeats {
+		if _, created, err := taskStorage.CreateTaskWithToken(r.token, storage.CreateTaskInput{Title: r.token, Description: "Описание"}); err != nil || created != r.created {
+			t.Errorf("Токен %s: ожидалось создание %v, получено %v (%v)", r.token, r.created, created, err)
+		}
+	}
+
+	for id := 1; id <= 3; id++ {
+		clock.Advance(time.Second)
+		if err := taskStorage.DeleteTask(t.Context(), id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mux := handlers.SetupHandlers(taskStorage)
+	expectCode(t, doJSON(t, mux, "GET", "/tasks/1", nil), http.StatusNotFound)
+	for _, path := range []string{"/tasks/2", "/tasks/3"} {
+		expectCode(t, doJSON(t, mux, "GET", path, nil), http.StatusGone)
+	}
+}
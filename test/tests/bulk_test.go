@@ -0,0 +1,258 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test/handlers"
+	"test/storage"
+	"testing"
+)
+
+// bulkItemErrors - тело ответа на элементы POST /tasks/bulk, не прошедшие валидацию
+type bulkItemErrors struct {
+	Error  string `json:"error"`
+	Code   string `json:"code"`
+	Errors []struct {
+		Index int    `json:"index"`
+		Field string `json:"field"`
+		Rule  string `json:"rule"`
+	} `json:"errors"`
+}
+
+// TestBulkCreate проверяет создание нескольких задач запросом POST /tasks/bulk
+//
+// Проверяет:
+// - Код 201 и созданные задачи в порядке элементов с последовательными ID
+// - Поля элементов сохраняются так же, как при POST /tasks
+// - Созданные задачи видны в списке
+func TestBulkCreate(t *testing.T) {
+	for name, newStorage := range map[string]storageFactory{
+		"InMemory": newMemoryStorage,
+		"SQLite":   newSQLiteStorage,
+	} {
+		t.Run(name, func(t *testing.T) {
+			mux := handlers.SetupHandlers(newStorage(t))
+			w := doJSON(t, mux, "POST", "/tasks/bulk", []map[string]interface{}{
+				{"title": "Первая", "description": "Описание"},
+				{"title": "Вторая", "description": "Описание", "priority": "high", "tags": []string{"дом"}},
+				{"title": "Третья", "description": "Описание"},
+			})
+			expectCode(t, w, http.StatusCreated)
+
+			var created []quickTask
+			if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+				t.Fatal(err)
+			}
+			if len(created) != 3 {
+				t.Fatalf("Ожидалось 3 задачи, получено %d", len(created))
+			}
+			for i, title := range []string{"Первая", "Вторая", "Третья"} {
+				if created[i].ID != i+1 || created[i].Title != title {
+					t.Errorf("Задача %d: ожидалась %d %q, получена %d %q", i, i+1, title, created[i].ID, created[i].Title)
+				}
+			}
+			if created[1].Priority != "high" || fmt.Sprint(created[1].Tags) != "[дом]" {
+				t.Errorf("Поля элемента не сохранены: %+v", created[1].Task)
+			}
+
+			if got := listTitles(t, mux, "/tasks?sort=title", "ru"); fmt.Sprint(got) != "[Вторая Первая Третья]" {
+				t.Errorf("Ожидались задачи [Вторая Первая Третья], получены %v", got)
+			}
+		})
+	}
+}
+
+// TestBulkCreateValidation проверяет отклонение POST /tasks/bulk целиком
+//
+// Проверяет:
+// - Элементы с пустым названием отклоняют весь запрос кодом 422, в ошибках - их индексы
+// - Ни одна задача запроса с ошибкой не создается, включая верные элементы
+// - Все нарушения элемента возвращаются вместе, а не только первое
+// - Пустой массив и массив сверх Config.BulkCreateMaxItems отклоняются кодом 400
+// - Нехватка места в хранилище отклоняет запрос кодом 507 без создания задач
+func TestBulkCreateValidation(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorageWithLimit(3, storage.RejectNew)
+	mux := handlers.SetupHandlersWithConfig(taskStorage, handlers.Config{BulkCreateMaxItems: 3})
+
+	w := doJSON(t, mux, "POST", "/tasks/bulk", []map[string]interface{}{
+		{"title": "Верная", "description": "Описание"},
+		{"title": "", "description": "Описание"},
+		{"title": "", "description": "", "tags": []string{"не тег!"}, "client_token": "t-1"},
+	})
+	expectCode(t, w, http.StatusUnprocessableEntity)
+	var body bulkItemErrors
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Code != handlers.CodeInvalidItems || len(body.Errors) != 4 {
+		t.Fatalf("Неверное тело ответа: %s", w.Body.String())
+	}
+	if e := body.Errors[0]; e.Index != 1 || e.Field != "title" || e.Rule != "required" {
+		t.Errorf("Неверная ошибка элемента: %+v", e)
+	}
+	for i, field := range []string{"title", "description", "tags"} {
+		if e := body.Errors[i+1]; e.Index != 2 || e.Field != field {
+			t.Errorf("Ожидалась ошибка поля %s элемента 2, получена %+v", field, e)
+		}
+	}
+	if !strings.Contains(body.Error, "элемент 1") {
+		t.Errorf("Сообщение не называет элемент: %q", body.Error)
+	}
+	if n := storedTasks(t, taskStorage); n != 0 {
+		t.Errorf("Ожидалось 0 задач после отказа, получено %d", n)
+	}
+
+	item := map[string]string{"title": "Задача", "description": "Описание"}
+	expectCode(t, doJSON(t, mux, "POST", "/tasks/bulk", []map[string]string{}), http.StatusBadRequest)
+	expectCode(t, doJSON(t, mux, "POST", "/tasks/bulk", []map[string]string{item, item, item, item}), http.StatusBadRequest)
+	expectCode(t, doJSON(t, mux, "POST", "/tasks/bulk", item), http.StatusBadRequest)
+
+	createTasks(t, taskStorage, 2)
+	expectCode(t, doJSON(t, mux, "POST", "/tasks/bulk", []map[string]string{item, item}), http.StatusInsufficientStorage)
+	if n := storedTasks(t, taskStorage); n != 2 {
+		t.Errorf("Ожидалось 2 задачи после отказа, получено %d", n)
+	}
+}
+
+// TestBulkCreateClientToken проверяет client_token в элементах POST /tasks/bulk
+//
+// Проверяет:
+// - Элемент с токеном создает задачу, повтор запроса возвращает исходную задачу
+// - Элементы без токена при повторе создаются заново, код ответа 201
+// - Повтор запроса, все задачи которого уже созданы, отвечает кодом 200
+// - Один токен в двух элементах создает одну задачу
+func TestBulkCreateClientToken(t *testing.T) {
+	for name, newStorage := range map[string]storageFactory{
+		"InMemory": newMemoryStorage,
+		"SQLite":   newSQLiteStorage,
+	} {
+		t.Run(name, func(t *testing.T) {
+			mux := handlers.SetupHandlers(newStorage(t))
+			create := func(status int, items ...map[string]string) []int {
+				t.Helper()
+				w := doJSON(t, mux, "POST", "/tasks/bulk", items)
+				expectCode(t, w, status)
+				var created []quickTask
+				if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+					t.Fatal(err)
+				}
+				ids := make([]int, len(created))
+				for i, task := range created {
+					ids[i] = task.ID
+				}
+				return ids
+			}
+			withToken := map[string]string{"title": "С токеном", "description": "Описание", "client_token": "t-1"}
+			plain := map[string]string{"title": "Без токена", "description": "Описание"}
+
+			if ids := create(http.StatusCreated, withToken, plain); fmt.Sprint(ids) != "[1 2]" {
+				t.Errorf("Ожидались задачи [1 2], получены %v", ids)
+			}
+			if ids := create(http.StatusCreated, withToken, plain); fmt.Sprint(ids) != "[1 3]" {
+				t.Errorf("Повтор: ожидались задачи [1 3], получены %v", ids)
+			}
+			if ids := create(http.StatusOK, withToken); fmt.Sprint(ids) != "[1]" {
+				t.Errorf("Полный повтор: ожидалась задача [1], получены %v", ids)
+			}
+
+			other := map[string]string{"title": "Другая", "description": "Описание", "client_token": "t-2"}
+			if ids := create(http.StatusCreated, other, other); fmt.Sprint(ids) != "[4 4]" {
+				t.Errorf("Один токен: ожидались задачи [4 4], получены %v", ids)
+			}
+		})
+	}
+}
+
+// bulkDelete отправляет DELETE /tasks/bulk с параметрами query и возвращает ответ 200
+func bulkDelete(t *testing.T, mux http.Handler, query string, ids ...int) handlers.BulkDeleteResponse {
+	t.Helper()
+	w := doJSON(t, mux, "DELETE", "/tasks/bulk"+query, handlers.BulkDeleteRequest{IDs: ids})
+	expectCode(t, w, http.StatusOK)
+	var response handlers.BulkDeleteResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+// TestBulkDelete проверяет удаление нескольких задач запросом DELETE /tasks/bulk
+//
+// Проверяет:
+// - Все найденные задачи удаляются, not_found пуст
+// - При части отсутствующих ID остальные задачи удаляются, код 200 и оба списка в ответе
+// - Если ни одной задачи нет, код 200 с пустым deleted; уже удаленная задача считается отсутствующей
+// - Удаленные задачи пропадают из списка и отвечают 410, как после DELETE /tasks/{id}
+func TestBulkDelete(t *testing.T) {
+	for name, newStorage := range map[string]storageFactory{
+		"InMemory": newMemoryStorage,
+		"SQLite":   newSQLiteStorage,
+	} {
+		t.Run(name, func(t *testing.T) {
+			taskStorage := newStorage(t)
+			mux := handlers.SetupHandlers(taskStorage)
+			createTasks(t, taskStorage, 6)
+
+			if got := bulkDelete(t, mux, "", 1, 2); fmt.Sprint(got) != "{[1 2] []}" {
+				t.Errorf("Все найдены: ожидалось {[1 2] []}, получено %v", got)
+			}
+			if got := bulkDelete(t, mux, "", 3, 99, 5, 3); fmt.Sprint(got) != "{[3 5] [99]}" {
+				t.Errorf("Часть отсутствует: ожидалось {[3 5] [99]}, получено %v", got)
+			}
+			if got := bulkDelete(t, mux, "", 1, 99); fmt.Sprint(got) != "{[] [1 99]}" {
+				t.Errorf("Все отсутствуют: ожидалось {[] [1 99]}, получено %v", got)
+			}
+
+			if got := listTitles(t, mux, "/tasks?sort=title", "ru"); fmt.Sprint(got) != "[Задача 4 Задача 6]" {
+				t.Errorf("Ожидались задачи [Задача 4 Задача 6], получены %v", got)
+			}
+			expectCode(t, doJSON(t, mux, "GET", "/tasks/5", nil), http.StatusGone)
+		})
+	}
+}
+
+// TestBulkDeleteRejected проверяет отказы DELETE /tasks/bulk
+//
+// Проверяет:
+// - Защищенная задача среди ids отклоняет запрос кодом 428, не удаляется ни одна задача
+// - Пустой список ids и неположительный ID отклоняются кодом 400
+// - При Config.ConfirmDeletes запрос без ?confirm=true отклоняется кодом 428, заголовок X-Confirm-Delete его не подтверждает
+// - Неверное значение confirm отклоняется кодом 400
+func TestBulkDeleteRejected(t *testing.T) {
+	taskStorage := newMemoryStorage(t)
+	mux := handlers.SetupHandlers(taskStorage)
+	createTasks(t, taskStorage, 2)
+	w := doJSON(t, mux, "POST", "/tasks", map[string]interface{}{"title": "Защищенная", "description": "Описание", "protected": true})
+	expectCode(t, w, http.StatusCreated)
+
+	w = doJSON(t, mux, "DELETE", "/tasks/bulk", handlers.BulkDeleteRequest{IDs: []int{1, 3}})
+	expectCode(t, w, http.StatusPreconditionRequired)
+	var body handlers.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != handlers.CodeDeleteConfirmationRequired {
+		t.Errorf("Неверное тело ответа: %s", w.Body.String())
+	}
+	expectCode(t, doJSON(t, mux, "GET", "/tasks/1", nil), http.StatusOK)
+
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/bulk", handlers.BulkDeleteRequest{}), http.StatusBadRequest)
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/bulk", handlers.BulkDeleteRequest{IDs: []int{1, 0}}), http.StatusBadRequest)
+
+	mux = handlers.SetupHandlersWithConfig(taskStorage, handlers.Config{ConfirmDeletes: true})
+	w = doJSON(t, mux, "DELETE", "/tasks/bulk", handlers.BulkDeleteRequest{IDs: []int{1, 2}})
+	expectCode(t, w, http.StatusPreconditionRequired)
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != handlers.CodeDeleteConfirmationRequired || !strings.Contains(body.Error, "?confirm=true") {
+		t.Errorf("Неверное тело ответа: %s", w.Body.String())
+	}
+	req := httptest.NewRequest("DELETE", "/tasks/bulk", strings.NewReader(`{"ids":[1,2]}`))
+	req.Header.Set(handlers.ConfirmDeleteHeader, "1,2")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	expectCode(t, w, http.StatusPreconditionRequired)
+
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/bulk?confirm=yes", handlers.BulkDeleteRequest{IDs: []int{1, 2}}), http.StatusBadRequest)
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/bulk?confirm=false", handlers.BulkDeleteRequest{IDs: []int{1, 2}}), http.StatusPreconditionRequired)
+	if response := bulkDelete(t, mux, "?confirm=true", 1, 2); len(response.Deleted) != 2 {
+		t.Errorf("Ожидалось удаление двух задач, получено %+v", response)
+	}
+}
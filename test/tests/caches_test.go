@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"test/handlers"
+	"test/storage"
+	"testing"
+)
+
+// getCaches запрашивает GET /admin/caches и возвращает состояние кэшей по имени
+func getCaches(t *testing.T, mux http.Handler) map[string]storage.CacheStats {
+	w := doJSON(t, mux, "GET", "/admin/caches", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался код %d, получен %d", http.StatusOK, w.Code)
+	}
+
+	var caches []storage.CacheStats
+	if err := json.Unmarshal(w.Body.Bytes(), &caches); err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]storage.CacheStats, len(caches))
+	for _, cache := range caches {
+		byName[cache.Name] = cache
+	}
+	return byName
+}
+
+// TestClientTokenCacheFlush проверяет просмотр и очистку кэша клиентских токенов
+//
+// Проверяет:
+// - Размер и счетчики попаданий и промахов в GET /admin/caches
+// - Очистку во время параллельных запросов без гонок и потерь ответов
+// - Повтор токена после очистки создает новую задачу
+// - 404 для неизвестного кэша и 405 для неверного метода
+func TestClientTokenCacheFlush(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+	mux := handlers.SetupHandlers(taskStorage)
+
+	const filled = 20
+	for i := 0; i < filled; i++ {
+		postTask(t, mux, map[string]string{"title": "Задача", "description": "Описание", "client_token": fmt.Sprintf("fill-%d", i)})
+	}
+	postTask(t, mux, map[string]string{"title": "Задача", "description": "Описание", "client_token": "fill-0"})
+
+	stats := getCaches(t, mux)[storage.ClientTokenCache]
+	if stats.Size != filled || stats.Hits != 1 || stats.Misses != filled {
+		t.Fatalf("Неверное состояние кэша: %+v", stats)
+	}
+
+	// Очистка посреди параллельных созданий
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				w := postTask(t, mux, map[string]string{
+					"title":        "Задача",
+					"description":  "Описание",
+					"client_token": fmt.Sprintf("traffic-%d-%d", worker, i%10),
+				})
+				if w.Code != http.StatusCreated && w.Code != http.StatusOK {
+					t.Errorf("Ожидался код 201 или 200, получен %d", w.Code)
+				}
+			}
+		}(worker)
+	}
+	for i := 0; i < 5; i++ {
+		if w := doJSON(t, mux, "POST", "/admin/caches/client_tokens/flush", nil); w.Code != http.StatusOK {
+			t.Errorf("Ожидался код %d, получен %d", http.StatusOK, w.Code)
+		}
+	}
+	wg.Wait()
+
+	w := doJSON(t, mux, "POST", "/admin/caches/client_tokens/flush", nil)
+	var flushed storage.CacheStats
+	if err := json.Unmarshal(w.Body.Bytes(), &flushed); err != nil {
+		t.Fatal(err)
+	}
+	if flushed.Size != 0 || flushed.Flushes != 6 {
+		t.Errorf("Неверное состояние после очистки: %+v", flushed)
+	}
+	if flushed.Hits+flushed.Misses != filled+1+8*50 {
+		t.Errorf("Ожидалось %d обращений к кэшу, получено %d", filled+1+8*50, flushed.Hits+flushed.Misses)
+	}
+
+	// Токен, созданный до очистки, больше не защищает от повторного создания
+	w = postTask(t, mux, map[string]string{"title": "Задача", "description": "Описание", "client_token": "fill-0"})
+	if w.Code != http.StatusCreated {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusCreated, w.Code)
+	}
+	w = postTask(t, mux, map[string]string{"title": "Задача", "description": "Описание", "client_token": "fill-0"})
+	if w.Code != http.StatusOK {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusOK, w.Code)
+	}
+	if stats := getCaches(t, mux)[storage.ClientTokenCache]; stats.Size != 1 {
+		t.Errorf("Ожидался 1 токен в кэше, получено %d", stats.Size)
+	}
+
+	if w := doJSON(t, mux, "POST", "/admin/caches/unknown/flush", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusNotFound, w.Code)
+	}
+	if w := doJSON(t, mux, "GET", "/admin/caches/client_tokens/flush", nil); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
@@ -0,0 +1,46 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"test/handlers"
+	"test/models"
+	"test/storage"
+	"testing"
+)
+
+// TestCapabilities проверяет, что GET /capabilities отражает настройку сервера
+//
+// Проверяет:
+// - Флаг strict_schema следует за Config.StrictSchema
+// - Числовые ограничения публикуются вместе с флагами
+// - Неподдерживаемый метод отклоняется с кодом 405
+func TestCapabilities(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		mux := handlers.SetupHandlersWithConfig(storage.NewInMemoryStorage(), handlers.Config{StrictSchema: strict})
+
+		w := doJSON(t, mux, "GET", "/capabilities", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Ожидался код %d, получен %d", http.StatusOK, w.Code)
+		}
+
+		var caps map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &caps); err != nil {
+			t.Fatal(err)
+		}
+		if caps["strict_schema"] != strict {
+			t.Errorf("Ожидалось strict_schema=%v, получено %v", strict, caps["strict_schema"])
+		}
+		if caps["client_token"] != true || caps["links"] != true {
+			t.Errorf("Ожидались возможности client_token и links: %v", caps)
+		}
+		if caps["max_links"] != float64(models.MaxLinks) {
+			t.Errorf("Ожидалось max_links=%d, получено %v", models.MaxLinks, caps["max_links"])
+		}
+	}
+
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage())
+	if w := doJSON(t, mux, "POST", "/capabilities", nil); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"test/handlers"
+	"test/storage"
+	"testing"
+)
+
+// createTasks создает в хранилище n задач с названиями "Задача 1", "Задача 2", ...
+func createTasks(t *testing.T, s storage.Storage, n int) {
+	t.Helper()
+	for i := 1; i <= n; i++ {
+		if _, err := s.CreateTask(t.Context(), storage.CreateTaskInput{Title: fmt.Sprintf("Задача %d", i), Description: "Описание"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestStorageLimitRejectNew проверяет лимит задач с политикой RejectNew
+//
+// Проверяет:
+// - Задача сверх лимита не создается, CreateTask возвращает ErrStorageFull
+// - POST /tasks в заполненное хранилище отвечает 507 с кодом storage_full
+// - Быстрое добавление, которому не хватает места, не создает ни одной задачи
+// - Мягко удаленная задача занимает место, пока не удалена безвозвратно
+func TestStorageLimitRejectNew(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorageWithLimit(3, storage.RejectNew)
+	mux := handlers.SetupHandlers(taskStorage)
+	createTasks(t, taskStorage, 2)
+
+	w := postQuick(t, mux, "text/plain", "Первая\nВторая")
+	expectCode(t, w, http.StatusInsufficientStorage)
+	if n := storedTasks(t, taskStorage); n != 2 {
+		t.Errorf("Ожидалось 2 задачи после отказа быстрого добавления, получено %d", n)
+	}
+
+	createTasks(t, taskStorage, 1)
+	if _, err := taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Лишняя", Description: "Описание"}); !errors.Is(err, storage.ErrStorageFull) {
+		t.Errorf("Ожидалась ошибка ErrStorageFull, получено %v", err)
+	}
+
+	w = postTask(t, mux, map[string]string{"title": "Лишняя", "description": "Описание"})
+	expectCode(t, w, http.StatusInsufficientStorage)
+	var body handlers.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != handlers.CodeStorageFull {
+		t.Errorf("Неверное тело ответа: %s", w.Body.String())
+	}
+
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/1", nil), http.StatusNoContent)
+	expectCode(t, postTask(t, mux, map[string]string{"title": "Лишняя", "description": "Описание"}), http.StatusInsufficientStorage)
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/1", nil), http.StatusNoContent)
+	expectCode(t, postTask(t, mux, map[string]string{"title": "Новая", "description": "Описание"}), http.StatusCreated)
+}
+
+// TestStorageLimitEvictOldestCompleted проверяет лимит задач с политикой EvictOldestCompleted
+//
+// Проверяет:
+// - Новая задача вытесняет выполненную задачу с наименьшим ID, невыполненные остаются
+// - Вытесненная задача не находится и пропадает из индексов
+// - Без выполненных задач создание отклоняется ErrStorageFull и ничего не вытесняется
+// - Быстрое добавление вытесняет столько задач, сколько создает, или не создает ничего
+func TestStorageLimitEvictOldestCompleted(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorageWithLimit(3, storage.EvictOldestCompleted)
+	mux := handlers.SetupHandlers(taskStorage)
+	createTasks(t, taskStorage, 3)
+	for _, id := range []int{3, 2} {
+		body := map[string]interface{}{"title": fmt.Sprintf("Задача %d", id), "description": "Описание", "completed": true, "priority": "high"}
+		expectCode(t, doJSON(t, mux, "PUT", fmt.Sprintf("/tasks/%d", id), body), http.StatusOK)
+	}
+
+	expectCode(t, postTask(t, mux, map[string]string{"title": "Задача 4", "description": "Описание"}), http.StatusCreated)
+	expectCode(t, doJSON(t, mux, "GET", "/tasks/2", nil), http.StatusNotFound)
+	if got := priorityIDs(t, mux, "/tasks?sort=title"); got != "[1 3 4]" {
+		t.Errorf("Ожидались задачи [1 3 4], получены %s", got)
+	}
+	if got := priorityIDs(t, mux, "/tasks?priority=high"); got != "[3]" {
+		t.Errorf("Ожидалась задача [3] в индексе приоритета, получены %s", got)
+	}
+
+	// Места хватает только на одну из двух задач: не создается ни одна
+	expectCode(t, postQuick(t, mux, "text/plain", "Задача 5\nЗадача 6"), http.StatusInsufficientStorage)
+	expectCode(t, doJSON(t, mux, "GET", "/tasks/3", nil), http.StatusOK)
+
+	expectCode(t, postQuick(t, mux, "text/plain", "Задача 5"), http.StatusCreated)
+	expectCode(t, doJSON(t, mux, "GET", "/tasks/3", nil), http.StatusNotFound)
+
+	if _, err := taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Лишняя", Description: "Описание"}); !errors.Is(err, storage.ErrStorageFull) {
+		t.Errorf("Ожидалась ошибка ErrStorageFull, получено %v", err)
+	}
+	if got := priorityIDs(t, mux, "/tasks?sort=title"); got != "[1 4 5]" {
+		t.Errorf("Ожидались задачи [1 4 5], получены %s", got)
+	}
+}
+
+// TestStorageLimitConcurrent проверяет, что конкурентное создание не превышает лимит
+//
+// Проверяет:
+// - Из 100 параллельных созданий при лимите 50 успешны ровно 50, остальные получают ErrStorageFull
+func TestStorageLimitConcurrent(t *testing.T) {
+	const limit, workers = 50, 100
+	taskStorage := storage.NewInMemoryStorageWithLimit(limit, storage.RejectNew)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var created, full int
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: fmt.Sprintf("Задача %d", i), Description: "Описание"})
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				created++
+			case errors.Is(err, storage.ErrStorageFull):
+				full++
+			default:
+				t.Errorf("Неожиданная ошибка: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if created != limit || full != workers-limit {
+		t.Errorf("Ожидалось %d созданных и %d отказов, получено %d и %d", limit, workers-limit, created, full)
+	}
+	if n := storedTasks(t, taskStorage); n != limit {
+		t.Errorf("Ожидалось %d задач в хранилище, получено %d", limit, n)
+	}
+}
@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"test/handlers"
+	"test/models"
+	"test/storage"
+	"testing"
+	"time"
+)
+
+// fakeClock - управляемый источник времени для тестов
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// postTask отправляет POST /tasks с заданным телом и возвращает ответ
+func postTask(t *testing.T, mux http.Handler, body interface{}) *httptest.ResponseRecorder {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("POST", "/tasks", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+// TestCreateTaskClientTokenReplay проверяет повтор создания с тем же client_token
+//
+// Проверяет:
+// - Первый запрос создает задачу с кодом 201
+// - Повтор после потерянного ответа возвращает ту же задачу с кодом 200
+// - Дубликат задачи не создается
+// - Другой токен создает новую задачу
+func TestCreateTaskClientTokenReplay(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+	mux := handlers.SetupHandlers(taskStorage)
+
+	body := map[string]string{
+		"title":        "Купить продукты",
+		"description":  "Молоко, хлеб",
+		"client_token": "token-1",
+	}
+
+	// Первый запрос: ответ клиентом "потерян"
+	w := postTask(t, mux, body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Ожидался код %d, получен %d", http.StatusCreated, w.Code)
+	}
+
+	// Повтор того же запроса через новый обработчик (другое соединение)
+	w = postTask(t, handlers.SetupHandlers(taskStorage), body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался код %d, получен %d", http.StatusOK, w.Code)
+	}
+
+	var task models.Task
+	if err := json.Unmarshal(w.Body.Bytes(), &task); err != nil {
+		t.Fatal(err)
+	}
+	if task.ID != 1 {
+		t.Errorf("Ожидалась исходная задача с ID 1, получена %d", task.ID)
+	}
+
+	tasks, _ := taskStorage.GetAllTasks(t.Context())
+	if len(tasks) != 1 {
+		t.Errorf("Ожидалась 1 задача, получено %d", len(tasks))
+	}
+
+	// Другой токен создает новую задачу
+	body["client_token"] = "token-2"
+	w = postTask(t, mux, body)
+	if w.Code != http.StatusCreated {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusCreated, w.Code)
+	}
+}
+
+// TestClientTokenExpiry проверяет, что истекший токен больше не защищает от повтора
+func TestClientTokenExpiry(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	taskStorage := storage.NewInMemoryStorage(
+		storage.WithClock(clock.Now),
+		storage.WithClientTokenTTL(time.Hour),
+	)
+
+	first, created, err := taskStorage.CreateTaskWithToken("token", storage.CreateTaskInput{Title: "Задача", Description: "Описание"})
+	if err != nil || !created {
+		t.Fatalf("Ожидалось создание задачи: created=%v, err=%v", created, err)
+	}
+
+	clock.Advance(59 * time.Minute)
+	replay, created, _ := taskStorage.CreateTaskWithToken("token", storage.CreateTaskInput{Title: "Задача", Description: "Описание"})
+	if created || replay.ID != first.ID {
+		t.Errorf("До истечения TTL ожидался повтор задачи %d, получена %d (created=%v)", first.ID, replay.ID, created)
+	}
+
+	clock.Advance(time.Minute)
+	fresh, created, _ := taskStorage.CreateTaskWithToken("token", storage.CreateTaskInput{Title: "Задача", Description: "Описание"})
+	if !created || fresh.ID == first.ID {
+		t.Errorf("После истечения TTL ожидалась новая задача, получена %d (created=%v)", fresh.ID, created)
+	}
+}
+
+// TestClientTokenEviction проверяет вытеснение давно не использованных токенов
+//
+// Проверяет:
+// - При переполнении вытесняется токен, который дольше всех не использовался
+// - Недавно использованный токен сохраняется
+func TestClientTokenEviction(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage(storage.WithClientTokenCapacity(2))
+
+	a, _, _ := taskStorage.CreateTaskWithToken("a", storage.CreateTaskInput{Title: "Задача A", Description: "Описание"})
+	taskStorage.CreateTaskWithToken("b", storage.CreateTaskInput{Title: "Задача B", Description: "Описание"})
+
+	// Обращение к "a" делает "b" самым давним
+	taskStorage.CreateTaskWithToken("a", storage.CreateTaskInput{Title: "Задача A", Description: "Описание"})
+
+	// Третий токен вытесняет "b"
+	taskStorage.CreateTaskWithToken("c", storage.CreateTaskInput{Title: "Задача C", Description: "Описание"})
+
+	if task, created, _ := taskStorage.CreateTaskWithToken("a", storage.CreateTaskInput{Title: "Задача A", Description: "Описание"}); created || task.ID != a.ID {
+		t.Errorf("Токен a должен был сохраниться")
+	}
+	if _, created, _ := taskStorage.CreateTaskWithToken("b", storage.CreateTaskInput{Title: "Задача B", Description: "Описание"}); !created {
+		t.Errorf("Токен b должен был быть вытеснен")
+	}
+
+	tasks, _ := taskStorage.GetAllTasks(t.Context())
+	if len(tasks) != 4 {
+		t.Errorf("Ожидалось 4 задачи, получено %d", len(tasks))
+	}
+}
+
+// TestClientTokenDeletedTask проверяет повтор токена после удаления созданной задачи
+func TestClientTokenDeletedTask(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+
+	first, _, _ := taskStorage.CreateTaskWithToken("token", storage.CreateTaskInput{Title: "Задача", Description: "Описание"})
+	taskStorage.DeleteTask(t.Context(), first.ID)
+
+	task, created, err := taskStorage.CreateTaskWithToken("token", storage.CreateTaskInput{Title: "Задача", Description: "Описание"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created || task.ID == first.ID {
+		t.Errorf("Ожидалась новая задача вместо удаленной")
+	}
+}
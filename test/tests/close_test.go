@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"test/storage"
+	"testing"
+)
+
+// TestStorageCloseReopen проверяет, что хранилища с файлом сохраняют все изменения до Close
+//
+// Проверяет:
+// - Задачи, созданные параллельно перед Close, доступны после повторного открытия
+// - Изменения после Close не применяются и не попадают в файл
+func TestStorageCloseReopen(t *testing.T) {
+	for name, open := range map[string]func(t *testing.T, path string) storage.Storage{
+		"File":      func(t *testing.T, path string) storage.Storage { return openFileStorage(t, path) },
+		"Journaled": func(t *testing.T, path string) storage.Storage { return openJournaledStorage(t, path) },
+		"SQLite":    func(t *testing.T, path string) storage.Storage { return openSQLiteStorage(t, path) },
+		"Bolt":      func(t *testing.T, path string) storage.Storage { return openBoltStorage(t, path) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tasks")
+			first := open(t, path)
+
+			const workers = 8
+			var wg sync.WaitGroup
+			for i := 0; i < workers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := first.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Задача", Description: "Описание"}); err != nil {
+						t.Errorf("Ошибка создания: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+			if err := first.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if err := first.DeleteTask(t.Context(), 1); !errors.Is(err, storage.ErrStorageClosed) {
+				t.Errorf("Ожидалась ошибка ErrStorageClosed, получено %v", err)
+			}
+
+			tasks, err := open(t, path).GetAllTasks(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tasks) != workers {
+				t.Errorf("Ожидалось %d задач после открытия, получено %d", workers, len(tasks))
+			}
+		})
+	}
+}
+
+// TestShardedStorageClose проверяет закрытие ShardedStorage
+//
+// Проверяет:
+// - Повторный Close возвращает nil
+// - Создание, обновление и удаление после Close возвращают ErrStorageClosed, а чтение работает
+func TestShardedStorageClose(t *testing.T) {
+	taskStorage := storage.NewShardedStorage(4)
+	createTasks(t, taskStorage, 2)
+	for i := 0; i < 2; i++ {
+		if err := taskStorage.Close(); err != nil {
+			t.Fatalf("Ошибка закрытия %d: %v", i+1, err)
+		}
+	}
+
+	if _, err := taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Задача", Description: "Описание"}); !errors.Is(err, storage.ErrStorageClosed) {
+		t.Errorf("Создание: ожидалась ошибка ErrStorageClosed, получено %v", err)
+	}
+	if _, err := taskStorage.UpdateTask(t.Context(), 1, storage.UpdateTaskInput{Title: "Задача", Description: "Описание", Completed: true}); !errors.Is(err, storage.ErrStorageClosed) {
+		t.Errorf("Обновление: ожидалась ошибка ErrStorageClosed, получено %v", err)
+	}
+	if err := taskStorage.DeleteTask(t.Context(), 2); !errors.Is(err, storage.ErrStorageClosed) {
+		t.Errorf("Удаление: ожидалась ошибка ErrStorageClosed, получено %v", err)
+	}
+	if tasks, err := taskStorage.GetAllTasks(t.Context()); err != nil || len(tasks) != 2 {
+		t.Errorf("Ожидалось 2 задачи после закрытия, получено %d, %v", len(tasks), err)
+	}
+}
@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"test/handlers"
+	"testing"
+)
+
+// TestConcurrentReadUpdate проверяет одновременное чтение и обновление одной задачи
+//
+// Запускать с -race: обработчик кодирует задачу в JSON без блокировки хранилища,
+// поэтому хранилище не должно менять на месте задачу, которую уже вернуло.
+//
+// Проверяет:
+// - Параллельные GET /tasks/1, GET /tasks и PUT /tasks/1 не приводят к гонке данных
+// - Изменение полученной задачи не меняет задачу в хранилище
+func TestConcurrentReadUpdate(t *testing.T) {
+	for name, newStorage := range map[string]storageFactory{
+		"InMemory": newMemoryStorage,
+		"Sharded":  newShardedStorage,
+	} {
+		t.Run(name, func(t *testing.T) {
+			taskStorage := newStorage(t)
+			mux := handlers.SetupHandlers(taskStorage)
+			expectCode(t, postTask(t, mux, map[string]string{"title": "Задача", "description": "Описание"}), http.StatusCreated)
+
+			// expectCode завершает тест и не вызывается из горутин
+			request := func(method, path string, body interface{}) {
+				if w := doJSON(t, mux, method, path, body); w.Code != http.StatusOK {
+					t.Errorf("%s %s: ожидался код %d, получен %d: %s", method, path, http.StatusOK, w.Code, w.Body.String())
+				}
+			}
+
+			const workers, iterations = 4, 200
+			var wg sync.WaitGroup
+			for w := 0; w < workers; w++ {
+				wg.Add(3)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < iterations; i++ {
+						request("GET", "/tasks/1", nil)
+					}
+				}()
+				go func() {
+					defer wg.Done()
+					for i := 0; i < iterations; i++ {
+						request("GET", "/tasks", nil)
+					}
+				}()
+				go func(w int) {
+					defer wg.Done()
+					for i := 0; i < iterations; i++ {
+						body := map[string]interface{}{"title": fmt.Sprintf("Задача %d-%d", w, i), "description": "Описание", "completed": i%2 == 0}
+						request("PUT", "/tasks/1", body)
+					}
+				}(w)
+			}
+			wg.Wait()
+
+			task, err := taskStorage.GetTask(t.Context(), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			title := task.Title
+			task.Title = "Изменена вызывающим кодом"
+			if again, _ := taskStorage.GetTask(t.Context(), 1); again.Title != title {
+				t.Errorf("Изменение полученной задачи попало в хранилище: %q", again.Title)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test/handlers"
+	"testing"
+	"time"
+)
+
+// TestRequestContextCanceled проверяет прерывание операций хранилища контекстом запроса
+//
+// Проверяет:
+// - POST /tasks с отмененным контекстом отвечает 499 с кодом request_canceled и не создает задачу
+// - Истекший срок контекста дает 504 с кодом deadline_exceeded
+// - Чтение и удаление задачи с отмененным контекстом тоже прерываются, задача остается
+func TestRequestContextCanceled(t *testing.T) {
+	for name, newStorage := range map[string]storageFactory{
+		"InMemory": newMemoryStorage,
+		"Sharded":  newShardedStorage,
+		"SQLite":   newSQLiteStorage,
+	} {
+		t.Run(name, func(t *testing.T) {
+			taskStorage := newStorage(t)
+			mux := handlers.SetupHandlers(taskStorage)
+			expectCode(t, postTask(t, mux, map[string]string{"title": "Задача", "description": "Описание"}), http.StatusCreated)
+
+			canceled, cancel := context.WithCancel(context.Background())
+			cancel()
+			expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+			defer cancelExpired()
+
+			requests := []struct {
+				ctx    context.Context
+				method string
+				path   string
+				body   string
+				status int
+				code   string
+			}{
+				{canceled, "POST", "/tasks", `{"title": "Отмененная", "description": "Описание"}`, handlers.StatusClientClosedRequest, handlers.CodeRequestCanceled},
+				{expired, "POST", "/tasks", `{"title": "Просроченная", "description": "Описание"}`, http.StatusGatewayTimeout, handlers.CodeDeadlineExceeded},
+				{canceled, "GET", "/tasks/1", "", handlers.StatusClientClosedRequest, handlers.CodeRequestCanceled},
+				{canceled, "DELETE", "/tasks/1", "", handlers.StatusClientClosedRequest, handlers.CodeRequestCanceled},
+			}
+			for _, req := range requests {
+				r := httptest.NewRequest(req.method, req.path, strings.NewReader(req.body)).WithContext(req.ctx)
+				w := httptest.NewRecorder()
+				mux.ServeHTTP(w, r)
+				if w.Code != req.status {
+					t.Errorf("%s %s: ожидался код %d, получен %d", req.method, req.path, req.status, w.Code)
+					continue
+				}
+				var body handlers.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != req.code {
+					t.Errorf("%s %s: неверное тело ответа: %s", req.method, req.path, w.Body.String())
+				}
+			}
+
+			tasks, err := taskStorage.GetAllTasks(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tasks) != 1 || tasks[0].ID != 1 {
+				t.Errorf("Ожидалась только задача 1, получено %d задач", len(tasks))
+			}
+		})
+	}
+}
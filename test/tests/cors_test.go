@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"test/handlers"
+	"test/handlers/middleware"
+	"test/storage"
+	"testing"
+)
+
+// corsRequest выполняет запрос method к path с заголовками header
+func corsRequest(t *testing.T, mux http.Handler, method, path string, header map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	req, err := http.NewRequest(method, path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+// TestCORSMiddleware проверяет заголовки CORS для разрешенного списка источников
+//
+// Проверяет:
+// - Разрешенный источник получает Access-Control-Allow-Origin со своим значением и Vary: Origin
+// - Предварительный запрос получает 204 с методами, заголовками и Max-Age и не доходит до обработчиков
+// - Предварительный запрос с чужого источника получает 403, обычный запрос - ответ без заголовков CORS
+// - Запрос без Origin не меняется
+// - OPTIONS без Access-Control-Request-Method отвечает маршрут: 204 с заголовком Allow
+func TestCORSMiddleware(t *testing.T) {
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage(), middleware.NewCORSMiddleware(middleware.CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Content-Type"},
+		MaxAge:         600,
+	}))
+	const allowed, foreign = "https://app.example.com", "https://evil.example.com"
+
+	w := corsRequest(t, mux, "OPTIONS", "/tasks", map[string]string{"Origin": allowed, "Access-Control-Request-Method": "POST"})
+	expectCode(t, w, http.StatusNoContent)
+	expected := map[string]string{
+		"Access-Control-Allow-Origin":  allowed,
+		"Access-Control-Allow-Methods": "GET, POST",
+		"Access-Control-Allow-Headers": "Content-Type",
+		"Access-Control-Max-Age":       "600",
+		"Vary":                         "Origin",
+	}
+	for name, value := range expected {
+		if got := w.Header().Get(name); got != value {
+			t.Errorf("Предварительный запрос: %s = %q, ожидалось %q", name, got, value)
+		}
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("Ответ на предварительный запрос должен быть пустым: %q", w.Body.String())
+	}
+
+	w = corsRequest(t, mux, "GET", "/tasks", map[string]string{"Origin": allowed})
+	expectCode(t, w, http.StatusOK)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != allowed {
+		t.Errorf("Ожидался Access-Control-Allow-Origin %q, получен %q", allowed, got)
+	}
+	if got := w.Header().Get("Access-Control-Expose-Headers"); got == "" {
+		t.Error("Заголовки API не открыты скрипту")
+	}
+
+	w = corsRequest(t, mux, "OPTIONS", "/tasks", map[string]string{"Origin": foreign, "Access-Control-Request-Method": "POST"})
+	expectCode(t, w, http.StatusForbidden)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Чужой источник получил Access-Control-Allow-Origin %q", got)
+	}
+
+	w = corsRequest(t, mux, "GET", "/tasks", map[string]string{"Origin": foreign})
+	expectCode(t, w, http.StatusOK)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Чужой источник получил Access-Control-Allow-Origin %q", got)
+	}
+
+	w = corsRequest(t, mux, "GET", "/tasks", nil)
+	expectCode(t, w, http.StatusOK)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Запрос без Origin получил Access-Control-Allow-Origin %q", got)
+	}
+	if slices.Contains(w.Header().Values("Vary"), "Origin") {
+		t.Errorf("Запрос без Origin получил Vary: Origin")
+	}
+
+	// OPTIONS без Access-Control-Request-Method не предварительный запрос и доходит до маршрутов
+	w = corsRequest(t, mux, "OPTIONS", "/tasks", map[string]string{"Origin": allowed})
+	expectCode(t, w, http.StatusNoContent)
+	if got := w.Header().Get("Allow"); got != "GET, POST, OPTIONS" {
+		t.Errorf("Ожидался заголовок Allow маршрута, получен %q", got)
+	}
+}
+
+// TestCORSMiddlewareWildcard проверяет разрешение любого источника и настройки по умолчанию
+//
+// Проверяет:
+// - С "*" любой источник получает Access-Control-Allow-Origin: *
+// - Без настроек методов и заголовков разрешаются методы API и его заголовки запроса
+// - Без MaxAge заголовок Access-Control-Max-Age не отправляется
+func TestCORSMiddlewareWildcard(t *testing.T) {
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage(), middleware.NewCORSMiddleware(middleware.CORSConfig{
+		AllowedOrigins: []string{"*"},
+	}))
+
+	w := corsRequest(t, mux, "OPTIONS", "/tasks/1", map[string]string{"Origin": "http://localhost:3000", "Access-Control-Request-Method": "PATCH"})
+	expectCode(t, w, http.StatusNoContent)
+	expected := map[string]string{
+		"Access-Control-Allow-Origin":  "*",
+		"Access-Control-Allow-Methods": "GET, HEAD, POST, PUT, PATCH, DELETE",
+		"Access-Control-Allow-Headers": "Content-Type, " + handlers.ConfirmDeleteHeader + ", " + handlers.TimezoneHeader,
+		"Access-Control-Max-Age":       "",
+	}
+	for name, value := range expected {
+		if got := w.Header().Get(name); got != value {
+			t.Errorf("%s = %q, ожидалось %q", name, got, value)
+		}
+	}
+}
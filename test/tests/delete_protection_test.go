@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"test/handlers"
+	"test/storage"
+	"testing"
+)
+
+// TestDeleteConfirmation проверяет подтверждение удаления задач
+//
+// Для каждого сочетания режима ConfirmDeletes и флага protected задачи проверяет:
+// - Удаление без заголовка: 204 или 428 с кодом delete_confirmation_required
+// - Заголовок с чужим ID не считается подтверждением
+// - Удаление с заголовком X-Confirm-Delete: {id} проходит всегда
+func TestDeleteConfirmation(t *testing.T) {
+	tests := []struct {
+		confirmDeletes bool
+		protected      bool
+		needsConfirm   bool
+	}{
+		{false, false, false},
+		{false, true, true},
+		{true, false, true},
+		{true, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("режим=%v protected=%v", tt.confirmDeletes, tt.protected), func(t *testing.T) {
+			taskStorage := storage.NewInMemoryStorage()
+			mux := handlers.SetupHandlersWithConfig(taskStorage, handlers.Config{ConfirmDeletes: tt.confirmDeletes})
+
+			w := postTask(t, mux, map[string]interface{}{"title": "Бэклог", "description": "Описание", "protected": tt.protected})
+			if w.Code != http.StatusCreated {
+				t.Fatalf("Ожидался код %d, получен %d", http.StatusCreated, w.Code)
+			}
+
+			del := func(confirm string) *httptest.ResponseRecorder {
+				req, err := http.NewRequest("DELETE", "/tasks/1", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if confirm != "" {
+					req.Header.Set(handlers.ConfirmDeleteHeader, confirm)
+				}
+				w := httptest.NewRecorder()
+				mux.ServeHTTP(w, req)
+				return w
+			}
+
+			if !tt.needsConfirm {
+				if w := del(""); w.Code != http.StatusNoContent {
+					t.Errorf("Ожидался код %d, получен %d", http.StatusNoContent, w.Code)
+				}
+				return
+			}
+
+			for _, confirm := range []string{"", "2"} {
+				w := del(confirm)
+				if w.Code != http.StatusPreconditionRequired {
+					t.Fatalf("Подтверждение %q: ожидался код %d, получен %d", confirm, http.StatusPreconditionRequired, w.Code)
+				}
+				var body handlers.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+					t.Fatal(err)
+				}
+				if body.Code != handlers.CodeDeleteConfirmationRequired || body.Error == "" || body.Status != http.StatusPreconditionRequired {
+					t.Errorf("Неверное тело ошибки: %v", body)
+				}
+			}
+			if _, err := taskStorage.GetTask(t.Context(), 1); err != nil {
+				t.Fatalf("Задача удалена без подтверждения")
+			}
+
+			if w := del("1"); w.Code != http.StatusNoContent {
+				t.Errorf("Ожидался код %d, получен %d", http.StatusNoContent, w.Code)
+			}
+			if _, err := taskStorage.GetTask(t.Context(), 1); err == nil {
+				t.Errorf("Задача не удалена после подтверждения")
+			}
+		})
+	}
+}
+
+// TestDeleteConfirmationMissingTask проверяет, что для несуществующей задачи возвращается 404, а не 428
+func TestDeleteConfirmationMissingTask(t *testing.T) {
+	mux := handlers.SetupHandlersWithConfig(storage.NewInMemoryStorage(), handlers.Config{ConfirmDeletes: true})
+
+	w := doJSON(t, mux, "DELETE", "/tasks/99", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestProtectedFlagUpdate проверяет установку и снятие защиты через PUT
+func TestProtectedFlagUpdate(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+	mux := handlers.SetupHandlers(taskStorage)
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Задача", Description: "Описание"})
+
+	doJSON(t, mux, "PUT", "/tasks/1", map[string]interface{}{"title": "Задача", "description": "Описание", "protected": true})
+	if err := taskStorage.DeleteTask(t.Context(), 1); err != storage.ErrTaskProtected {
+		t.Fatalf("Ожидалась ошибка ErrTaskProtected, получена %v", err)
+	}
+
+	// PUT без поля protected защиту не снимает
+	doJSON(t, mux, "PUT", "/tasks/1", map[string]interface{}{"title": "Новое", "description": "Описание"})
+	if task, _ := taskStorage.GetTask(t.Context(), 1); !task.Protected {
+		t.Fatalf("Защита снята PUT без поля protected")
+	}
+
+	doJSON(t, mux, "PUT", "/tasks/1", map[string]interface{}{"title": "Новое", "description": "Описание", "protected": false})
+	if err := taskStorage.DeleteTask(t.Context(), 1); err != nil {
+		t.Errorf("Ожидалось удаление после снятия защиты, получена ошибка: %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"test/handlers"
+	"test/storage"
+	"testing"
+)
+
+// getDuplicates запрашивает path и разбирает группы дубликатов
+func getDuplicates(t *testing.T, mux http.Handler, path string) ([]handlers.DuplicateGroup, *httptest.ResponseRecorder) {
+	t.Helper()
+	w := doJSON(t, mux, "GET", path, nil)
+	expectCode(t, w, http.StatusOK)
+
+	var groups []handlers.DuplicateGroup
+	if err := json.Unmarshal(w.Body.Bytes(), &groups); err != nil {
+		t.Fatalf("Неверное тело ответа %q: %v", w.Body.String(), err)
+	}
+	return groups, w
+}
+
+// TestDuplicates проверяет отчет о дубликатах GET /tasks/duplicates
+//
+// Проверяет:
+// - Без threshold группируются названия, совпадающие после нормализации регистра, знаков и пробелов
+// - С threshold=0.9 к группам добавляются названия с опечаткой, а менее похожие остаются отдельно
+// - Сходство группы - наименьшее сходство объединивших ее пар
+// - Группы делятся на страницы с X-Total-Count, удаленные задачи не учитываются
+func TestDuplicates(t *testing.T) {
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage())
+	for _, title := range []string{
+		"Купить молоко",   // 1
+		"купить молоко!",  // 2
+		"Позвонить маме",  // 3
+		"Купить  молоко",  // 4
+		"Позвонить маме.", // 5
+		"Купить молока",   // 6: сходство 12/13 с "купить молоко"
+		"Позвонит маме",   // 7: сходство 13/14 с "позвонить маме"
+		"Починить кран",   // 8: сходство 12/14 с "почистить кран"
+		"Почистить кран",  // 9
+		"Закрыть спринт",  // 10
+		"Закрыть спринт",  // 11: удаляется
+	} {
+		expectCode(t, postTask(t, mux, map[string]string{"title": title, "description": "Описание"}), http.StatusCreated)
+	}
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/11", nil), http.StatusNoContent)
+
+	expect := func(groups []handlers.DuplicateGroup, ids [][]int, titles []string) {
+		t.Helper()
+		if len(groups) != len(ids) {
+			t.Fatalf("Ожидалось %d групп, получено %d: %+v", len(ids), len(groups), groups)
+		}
+		for i, group := range groups {
+			if !slices.Equal(group.IDs, ids[i]) || group.NormalizedTitle != titles[i] {
+				t.Errorf("Группа %d: ожидались %v %q, получены %v %q", i, ids[i], titles[i], group.IDs, group.NormalizedTitle)
+			}
+		}
+	}
+
+	groups, _ := getDuplicates(t, mux, "/tasks/duplicates")
+	expect(groups, [][]int{{1, 2, 4}, {3, 5}}, []string{"купить молоко", "позвонить маме"})
+	for _, group := range groups {
+		if group.Similarity != 1 {
+			t.Errorf("Точное совпадение должно иметь сходство 1, получено %v", group.Similarity)
+		}
+	}
+
+	groups, _ = getDuplicates(t, mux, "/tasks/duplicates?threshold=0.9")
+	expect(groups, [][]int{{1, 2, 4, 6}, {3, 5, 7}}, []string{"купить молоко", "позвонить маме"})
+	if len(groups) == 2 {
+		for i, expected := range []float64{12.0 / 13, 13.0 / 14} {
+			if diff := groups[i].Similarity - expected; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("Группа %d: ожидалось сходство %v, получено %v", i, expected, groups[i].Similarity)
+			}
+		}
+	}
+
+	groups, w := getDuplicates(t, mux, "/tasks/duplicates?threshold=0.9&limit=1&page=2")
+	expect(groups, [][]int{{3, 5, 7}}, []string{"позвонить маме"})
+	if total := w.Header().Get("X-Total-Count"); total != "2" {
+		t.Errorf("Ожидался X-Total-Count 2, получен %q", total)
+	}
+
+	for _, threshold := range []string{"0", "1.5", "abc"} {
+		expectCode(t, doJSON(t, mux, "GET", "/tasks/duplicates?threshold="+threshold, nil), http.StatusBadRequest)
+	}
+}
+
+// TestDuplicatesFuzzyLimit проверяет ограничение нечеткого поиска дубликатов
+//
+// Проверяет:
+// - При числе задач больше MaxFuzzyDuplicateTasks нечеткий поиск отвечает 503 с кодом duplicates_too_expensive
+// - Точный поиск на тех же данных работает
+func TestDuplicatesFuzzyLimit(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+	for i := 0; i <= handlers.MaxFuzzyDuplicateTasks; i++ {
+		taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: fmt.Sprintf("Задача %d", i%10), Description: "Описание"})
+	}
+	mux := handlers.SetupHandlers(taskStorage)
+
+	w := doJSON(t, mux, "GET", "/tasks/duplicates?threshold=0.8", nil)
+	expectCode(t, w, http.StatusServiceUnavailable)
+	var body handlers.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != handlers.CodeDuplicatesTooExpensive {
+		t.Errorf("Неверное тело ответа: %s", w.Body.String())
+	}
+
+	groups, _ := getDuplicates(t, mux, "/tasks/duplicates")
+	if len(groups) != 10 {
+		t.Errorf("Ожидалось 10 групп, получено %d", len(groups))
+	}
+}
@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"test/handlers"
+	"test/schema"
+	"test/storage"
+	"testing"
+)
+
+// enumErrorResponse - тело ответа 400 на недопустимое значение перечисления
+type enumErrorResponse struct {
+	Error  string              `json:"error"`
+	Code   string              `json:"code"`
+	Errors []schema.FieldError `json:"errors"`
+}
+
+// TestEnumValidationErrors проверяет единый формат ошибок для параметров с перечислимыми значениями
+//
+// Проверяет:
+// - Код 400 и code invalid_value для sort, collate, expand и has_link
+// - Имя поля и список допустимых значений в errors[0]
+// - Сообщение об ошибке со списком допустимых значений
+func TestEnumValidationErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		field   string
+		allowed []string
+	}{
+		{"sort", "/tasks?sort=size", "sort", []string{"title", "priority", "due_date", "created_at", "id"}},
+		{"collate", "/tasks?sort=title&collate=xx", "collate", handlers.CollationNames()},
+		{"expand", "/tasks/1?expand=links", "expand", []string{"metadata"}},
+		{"has_link", "/tasks?has_link=maybe", "has_link", []string{"true", "false"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := handlers.SetupHandlers(storage.NewInMemoryStorage())
+			postTask(t, mux, map[string]interface{}{"title": "Задача", "description": "Описание"})
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("Ожидался код %d, получен %d", http.StatusBadRequest, w.Code)
+			}
+
+			var resp enumErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Code != schema.CodeInvalidValue {
+				t.Errorf("Ожидался code %q, получен %q", schema.CodeInvalidValue, resp.Code)
+			}
+			if len(resp.Errors) != 1 {
+				t.Fatalf("Ожидалась одна ошибка поля, получено %d", len(resp.Errors))
+			}
+
+			fe := resp.Errors[0]
+			if fe.Field != tt.field || fe.Rule != "oneof" || fe.Code != schema.CodeInvalidValue {
+				t.Errorf("Несовпадение ошибки поля: %+v", fe)
+			}
+			if !reflect.DeepEqual(fe.Allowed, tt.allowed) {
+				t.Errorf("Ожидались допустимые значения %v, получены %v", tt.allowed, fe.Allowed)
+			}
+			if !strings.Contains(resp.Error, strings.Join(tt.allowed, ", ")) {
+				t.Errorf("Сообщение %q не содержит допустимые значения", resp.Error)
+			}
+		})
+	}
+}
+
+// TestEnumValidationFormCompleted проверяет ошибку недопустимого значения поля completed формы
+func TestEnumValidationFormCompleted(t *testing.T) {
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage())
+
+	form := url.Values{"title": {"Задача"}, "description": {"Описание"}, "completed": {"maybe"}}
+	req := newFormRequest(t, "POST", "/tasks", form)
+	req.Header.Set("Accept", "application/json")
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Ожидался код %d, получен %d", http.StatusBadRequest, w.Code)
+	}
+
+	var resp handlers.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(resp.Error, "completed") || !strings.Contains(resp.Error, "on, true, false") {
+		t.Errorf("Сообщение %q не содержит поле и допустимые значения", resp.Error)
+	}
+}
+
+// TestSchemaOneOfTag проверяет правило oneof тега validate
+//
+// Проверяет:
+// - Ошибку invalid_value со списком допустимых значений
+// - Пустое необязательное значение не проверяется
+// - Перечисление enum в сгенерированной схеме
+func TestSchemaOneOfTag(t *testing.T) {
+	type input struct {
+		Level string `json:"level" validate:"oneof=low high"`
+	}
+
+	var errs schema.Errors
+	if err := schema.Validate(input{Level: "medium"}); !errors.As(err, &errs) {
+		t.Fatalf("Ожидалась ошибка schema.Errors, получена %v", err)
+	}
+	if len(errs) != 1 || errs[0].Field != "level" || errs[0].Code != schema.CodeInvalidValue {
+		t.Fatalf("Несовпадение ошибок: %+v", errs)
+	}
+	if !reflect.DeepEqual(errs[0].Allowed, []string{"low", "high"}) {
+		t.Errorf("Ожидались допустимые значения [low high], получены %v", errs[0].Allowed)
+	}
+
+	if err := schema.Validate(input{}); err != nil {
+		t.Errorf("Пустое значение не должно проверяться: %v", err)
+	}
+	if err := schema.Validate(input{Level: "high"}); err != nil {
+		t.Errorf("Неожиданная ошибка: %v", err)
+	}
+
+	s := schema.Generate("/schema/input", "Input", input{})
+	level := s["properties"].(map[string]interface{})["level"].(map[string]interface{})
+	if !reflect.DeepEqual(level["enum"], []string{"low", "high"}) {
+		t.Errorf("Ожидалось enum [low high], получено %v", level["enum"])
+	}
+}
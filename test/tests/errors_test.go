@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"test/handlers"
+	"test/models"
+	"test/storage"
+	"testing"
+)
+
+// TestErrorResponses проверяет единую JSON-форму ответов с ошибками
+//
+// Проверяет:
+// - Ошибки, прежде отправлявшиеся текстом, возвращаются как application/json
+// - Поле error содержит текст ошибки, status совпадает с кодом ответа
+// - Машинный код есть только у ошибок, которые его предусматривают
+func TestErrorResponses(t *testing.T) {
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage())
+	expectCode(t, postTask(t, mux, map[string]string{"title": "Задача", "description": "Описание"}), http.StatusCreated)
+
+	tests := []struct {
+		method string
+		path   string
+		body   interface{}
+		status int
+		error  string
+		code   string
+	}{
+		{"GET", "/tasks/99", nil, http.StatusNotFound, "задача не найдена: ID 99", ""},
+		{"GET", "/tasks/abc", nil, http.StatusBadRequest, "", ""},
+		{"PUT", "/tasks", nil, http.StatusMethodNotAllowed, "Метод не поддерживается", ""},
+		{"POST", "/tasks", "не объект", http.StatusBadRequest, "", ""},
+		{"GET", "/tasks?sort=size", nil, http.StatusBadRequest, "", "invalid_value"},
+		{"GET", "/tasks?tz=Mars/Olympus", nil, http.StatusBadRequest, "", handlers.CodeInvalidTimezone},
+	}
+	for _, tt := range tests {
+		w := doJSON(t, mux, tt.method, tt.path, tt.body)
+		if w.Code != tt.status {
+			t.Errorf("%s %s: ожидался код %d, получен %d", tt.method, tt.path, tt.status, w.Code)
+			continue
+		}
+		if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+			t.Errorf("%s %s: неверный Content-Type %q", tt.method, tt.path, contentType)
+		}
+
+		var body handlers.ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Errorf("%s %s: тело не JSON: %q", tt.method, tt.path, w.Body.String())
+			continue
+		}
+		if body.Error == "" || (tt.error != "" && body.Error != tt.error) {
+			t.Errorf("%s %s: неверное поле error %q", tt.method, tt.path, body.Error)
+		}
+		if body.Status != tt.status || body.Code != tt.code {
+			t.Errorf("%s %s: ожидались status %d и code %q, получено %+v", tt.method, tt.path, tt.status, tt.code, body)
+		}
+	}
+}
+
+// failingStorage - хранилище, операции которого с существующей задачей возвращают err
+type failingStorage struct {
+	*stubStorage
+	err error
+}
+
+func (s *failingStorage) GetTask(ctx context.Context, id int) (*models.Task, error) {
+	return nil, s.err
+}
+
+func (s *failingStorage) UpdateTask(ctx context.Context, id int, input storage.UpdateTaskInput) (*models.Task, error) {
+	return nil, s.err
+}
+
+func (s *failingStorage) DeleteTask(ctx context.Context, id int) error {
+	return s.err
+}
+
+// TestUnexpectedStorageError проверяет, что код 404 отвечает только на отсутствие задачи
+//
+// Проверяет:
+// - Непредвиденная ошибка хранилища при чтении, обновлении и удалении задачи дает 500, а не 404
+// - Ошибка, оборачивающая storage.ErrTaskNotFound, дает 404
+func TestUnexpectedStorageError(t *testing.T) {
+	failures := map[error]int{
+		errors.New("ошибка чтения диска"):               http.StatusInternalServerError,
+		fmt.Errorf("%w: ID 1", storage.ErrTaskNotFound): http.StatusNotFound,
+	}
+	for failure, status := range failures {
+		mux := handlers.SetupHandlers(&failingStorage{stubStorage: newStubStorage(), err: failure})
+		for _, req := range []struct {
+			method string
+			body   interface{}
+		}{
+			{"GET", nil},
+			{"PUT", map[string]string{"title": "Задача", "description": "Описание"}},
+			{"DELETE", nil},
+		} {
+			w := doJSON(t, mux, req.method, "/tasks/1", req.body)
+			if w.Code != status {
+				t.Errorf("%s /tasks/1 с ошибкой %q: ожидался код %d, получен %d", req.method, failure, status, w.Code)
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"test/handlers"
+	"test/storage"
+	"testing"
+	"time"
+)
+
+// storedTasks возвращает число задач в снимке хранилища, включая истекшие и удаленные
+func storedTasks(t *testing.T, s *storage.InMemoryStorage) int {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := s.SaveSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var snap struct {
+		Tasks []json.RawMessage `json:"tasks"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &snap); err != nil {
+		t.Fatal(err)
+	}
+	return len(snap.Tasks)
+}
+
+// TestTaskExpiry проверяет задачи с ограниченным временем жизни
+//
+// Проверяет:
+// - expires_in_seconds задает expires_at от момента создания по часам хранилища
+// - expires_at и expires_in_seconds вместе, expires_in_seconds=0 и expires_at в прошлом отклоняются с кодом 400
+// - Истекшая задача отвечает 404 и пропадает из списка, фильтра priority и include_deleted
+// - PurgeExpired удаляет только истекшие задачи
+// - Хранилище без поддержки истечения отвечает 501, возможность task_expiry отмечена в GET /capabilities
+func TestTaskExpiry(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	taskStorage := storage.NewInMemoryStorage(storage.WithClock(clock.Now))
+	mux := handlers.SetupHandlers(taskStorage)
+
+	w := postTask(t, mux, map[string]interface{}{"title": "Напоминание", "description": "Описание", "priority": "high", "expires_in_seconds": 60})
+	expectCode(t, w, http.StatusCreated)
+	if task := decodeTask(t, w); task.ExpiresAt == nil || !task.ExpiresAt.Equal(clock.Now().Add(time.Minute)) {
+		t.Errorf("Ожидался expires_at %v, получен %v", clock.Now().Add(time.Minute), task.ExpiresAt)
+	}
+	w = postTask(t, mux, map[string]interface{}{"title": "Надолго", "description": "Описание", "priority": "high", "expires_at": "2100-01-01T00:00:00Z"})
+	expectCode(t, w, http.StatusCreated)
+	expectCode(t, postTask(t, mux, map[string]string{"title": "Бессрочная", "description": "Описание"}), http.StatusCreated)
+
+	for _, body := range []map[string]interface{}{
+		{"title": "Задача", "description": "Описание", "expires_in_seconds": 60, "expires_at": "2100-01-01T00:00:00Z"},
+		{"title": "Задача", "description": "Описание", "expires_in_seconds": 0},
+		{"title": "Задача", "description": "Описание", "expires_at": "2020-01-01T00:00:00Z"},
+	} {
+		expectCode(t, postTask(t, mux, body), http.StatusBadRequest)
+	}
+
+	clock.Advance(time.Minute)
+	expectCode(t, doJSON(t, mux, "GET", "/tasks/1", nil), http.StatusNotFound)
+	expectCode(t, doJSON(t, mux, "PUT", "/tasks/1", map[string]string{"title": "Задача", "description": "Описание"}), http.StatusNotFound)
+	expectCode(t, doJSON(t, mux, "GET", "/tasks/2", nil), http.StatusOK)
+	for _, path := range []string{"/tasks", "/tasks?page=1", "/tasks?priority=high", "/tasks?include_deleted=true"} {
+		if ids, _ := getPage(t, mux, path); slices.Contains(ids, 1) {
+			t.Errorf("%s: истекшая задача в списке: %v", path, ids)
+		}
+	}
+
+	if n, err := taskStorage.PurgeExpired(); err != nil || n != 1 {
+		t.Errorf("Ожидалось удаление 1 задачи, удалено %d: %v", n, err)
+	}
+	if n := storedTasks(t, taskStorage); n != 2 {
+		t.Errorf("Ожидалось 2 задачи в хранилище, осталось %d", n)
+	}
+	expectCode(t, doJSON(t, mux, "GET", "/tasks/1", nil), http.StatusNotFound)
+
+	var capabilities map[string]interface{}
+	json.Unmarshal(doJSON(t, mux, "GET", "/capabilities", nil).Body.Bytes(), &capabilities)
+	if capabilities["task_expiry"] != true {
+		t.Errorf("Возможность task_expiry недоступна")
+	}
+	sharded := handlers.SetupHandlers(storage.NewShardedStorage(4))
+	expectCode(t, postTask(t, sharded, map[string]interface{}{"title": "Задача", "description": "Описание", "expires_in_seconds": 60}), http.StatusNotImplemented)
+}
+
+// TestExpiryStartStop проверяет фоновое удаление истекших задач
+//
+// Проверяет:
+// - После Start истекшие задачи удаляются без обращений к хранилищу
+// - После Stop фоновое удаление не выполняется, повторный Stop ничего не делает
+func TestExpiryStartStop(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage(storage.WithExpiryInterval(5 * time.Millisecond))
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Задача", Description: "Описание", ExpiresIn: time.Millisecond})
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Бессрочная", Description: "Описание"})
+
+	taskStorage.Start(context.Background())
+	deadline := time.Now().Add(time.Second)
+	for storedTasks(t, taskStorage) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("Истекшая задача не удалена фоновым удалением")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	taskStorage.Stop()
+	taskStorage.Stop()
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Задача", Description: "Описание", ExpiresIn: time.Millisecond})
+	time.Sleep(50 * time.Millisecond)
+	if n := storedTasks(t, taskStorage); n != 2 {
+		t.Errorf("После Stop ожидалось 2 задачи в хранилище, осталось %d", n)
+	}
+}
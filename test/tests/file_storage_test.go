@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"test/handlers"
+	"test/storage"
+	"testing"
+)
+
+// newFileStorage создает файловое хранилище во временном каталоге теста
+func newFileStorage(t *testing.T, opts ...storage.Option) storage.Storage {
+	return openFileStorage(t, filepath.Join(t.TempDir(), "tasks.json"), opts...)
+}
+
+// openFileStorage загружает файловое хранилище из path
+func openFileStorage(t *testing.T, path string, opts ...storage.Option) *storage.FileStorage {
+	t.Helper()
+	taskStorage, err := storage.NewFileStorage(path, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return taskStorage
+}
+
+// TestFileStorage проверяет файловое хранилище общим набором проверок хранилищ
+func TestFileStorage(t *testing.T) {
+	testStorageBackend(t, newFileStorage)
+}
+
+// TestFileStorageReopen проверяет сохранение данных между загрузками файла
+//
+// Проверяет:
+// - Задачи, их метаданные и клиентские токены доступны после повторной загрузки
+// - Удаленная задача по-прежнему возвращает 410
+// - Нумерация продолжается с сохраненного последнего ID, даже если последняя задача удалена
+func TestFileStorageReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+
+	mux := handlers.SetupHandlers(openFileStorage(t, path))
+	expectCode(t, postTask(t, mux, map[string]string{"title": "Первая", "description": "Описание", "client_token": "abc"}), http.StatusCreated)
+	expectCode(t, postTask(t, mux, map[string]string{"title": "Вторая", "description": "Описание"}), http.StatusCreated)
+	expectCode(t, putRaw(t, mux, "/tasks/1/metadata/crm", []byte(`{"deal_id":42}`)), http.StatusNoContent)
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/2", nil), http.StatusNoContent)
+
+	mux = handlers.SetupHandlers(openFileStorage(t, path))
+	w := doJSON(t, mux, "GET", "/tasks/1", nil)
+	expectCode(t, w, http.StatusOK)
+	if task := decodeTask(t, w); task.Title != "Первая" {
+		t.Errorf("Неверная задача после загрузки: %+v", task)
+	}
+	if w := doJSON(t, mux, "GET", "/tasks/1/metadata/crm", nil); w.Body.String() != `{"deal_id":42}` {
+		t.Errorf("Метаданные потеряны: %d %s", w.Code, w.Body.String())
+	}
+	expectCode(t, doJSON(t, mux, "GET", "/tasks/2", nil), http.StatusGone)
+
+	// Повтор с тем же токеном возвращает исходную задачу
+	w = postTask(t, mux, map[string]string{"title": "Первая", "description": "Описание", "client_token": "abc"})
+	expectCode(t, w, http.StatusOK)
+	if task := decodeTask(t, w); task.ID != 1 {
+		t.Errorf("Ожидалась задача 1 по токену, получена %d", task.ID)
+	}
+
+	w = postTask(t, mux, map[string]string{"title": "Третья", "description": "Описание"})
+	expectCode(t, w, http.StatusCreated)
+	if task := decodeTask(t, w); task.ID != 3 {
+		t.Errorf("Ожидался ID 3, получен %d", task.ID)
+	}
+}
+
+// TestFileStorageDamaged проверяет загрузку пустого и поврежденного файла
+//
+// Проверяет:
+// - Хранилище создается пустым, нумерация начинается с 1
+// - Поврежденный файл сохраняется под другим именем и не перезаписывается
+func TestFileStorageDamaged(t *testing.T) {
+	for _, content := range []string{"", `{"last_id": 3, "tasks": {"1": `} {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "tasks.json")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		mux := handlers.SetupHandlers(openFileStorage(t, path))
+		w := postTask(t, mux, map[string]string{"title": "Задача", "description": "Описание"})
+		expectCode(t, w, http.StatusCreated)
+		if task := decodeTask(t, w); task.ID != 1 {
+			t.Errorf("%q: ожидался ID 1, получен %d", content, task.ID)
+		}
+
+		backups, err := filepath.Glob(filepath.Join(dir, "tasks.json.corrupt-*"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if content == "" {
+			if len(backups) != 0 {
+				t.Errorf("Пустой файл не должен сохраняться: %v", backups)
+			}
+			continue
+		}
+		if len(backups) != 1 {
+			t.Fatalf("Ожидалась одна копия поврежденного файла, найдены %v", backups)
+		}
+		if data, err := os.ReadFile(backups[0]); err != nil || string(data) != content {
+			t.Errorf("Копия поврежденного файла изменена: %q (%v)", data, err)
+		}
+	}
+}
+
+// TestFileStorageConcurrentWrites проверяет одновременные изменения
+//
+// Проверяет:
+// - Все задачи, созданные параллельно, получают разные ID и сохраняются в файл
+// - После записи в каталоге не остается временных файлов
+func TestFileStorageConcurrentWrites(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tasks.json")
+	taskStorage := openFileStorage(t, path)
+
+	const count = 20
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: fmt.Sprintf("Задача %d", i), Description: "Описание"}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	tasks, err := openFileStorage(t, path).GetAllTasks(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]int, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	sort.Ints(ids)
+	for i, id := range ids {
+		if id != i+1 {
+			t.Fatalf("Ожидались ID 1..%d, получены %v", count, ids)
+		}
+	}
+	if len(ids) != count {
+		t.Errorf("Ожидалось %d задач, загружено %d", count, len(ids))
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("В каталоге остались лишние файлы: %v", entries)
+	}
+}
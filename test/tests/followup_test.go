@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"test/handlers"
+	"test/models"
+	"test/storage"
+	"testing"
+)
+
+// TestCompleteWithFollowUp проверяет атомарное завершение задачи с созданием продолжения
+//
+// Проверяет:
+// - Исходная задача отмечается выполненной, продолжение ссылается на нее через follows_id
+// - Повтор для уже выполненной задачи отклоняется с кодом 409 без второго продолжения
+func TestCompleteWithFollowUp(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+	mux := handlers.SetupHandlers(taskStorage)
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Ревью X", Description: "Первый раунд"})
+
+	body := map[string]string{"title": "Ревью X - раунд 2", "description": "Проверить исправления"}
+	w := doJSON(t, mux, "POST", "/tasks/1/complete-with-followup", body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Ожидался код %d, получен %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if location := w.Header().Get("Location"); location != "/tasks/2" {
+		t.Errorf("Ожидался Location %q, получен %q", "/tasks/2", location)
+	}
+
+	var response handlers.FollowUpResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if !response.Completed.Completed || response.Completed.ID != 1 {
+		t.Errorf("Исходная задача не выполнена: %+v", response.Completed)
+	}
+	if response.FollowUp.ID != 2 || response.FollowUp.FollowsID != 1 || response.FollowUp.Completed {
+		t.Errorf("Неверное продолжение: %+v", response.FollowUp)
+	}
+
+	w = doJSON(t, mux, "POST", "/tasks/1/complete-with-followup", body)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusConflict, w.Code)
+	}
+	if tasks, _ := taskStorage.GetAllTasks(t.Context()); len(tasks) != 2 {
+		t.Errorf("Ожидалось 2 задачи, получено %d", len(tasks))
+	}
+}
+
+// TestCompleteWithFollowUpRollback проверяет, что при ошибке не меняется ни одна из задач
+//
+// Проверяет:
+// - Неверное тело продолжения не отмечает исходную задачу выполненной
+// - Несуществующая исходная задача не приводит к созданию продолжения
+func TestCompleteWithFollowUpRollback(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+	mux := handlers.SetupHandlers(taskStorage)
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Ревью X", Description: "Первый раунд"})
+
+	invalid := []interface{}{
+		map[string]string{"title": "Без описания"},
+		map[string]interface{}{"title": "Задача", "description": "Описание", "links": []models.Link{{URL: "javascript:alert(1)"}}},
+	}
+	for _, body := range invalid {
+		w := doJSON(t, mux, "POST", "/tasks/1/complete-with-followup", body)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Ожидался код %d, получен %d", http.StatusBadRequest, w.Code)
+		}
+	}
+
+	w := doJSON(t, mux, "POST", "/tasks/99/complete-with-followup", map[string]string{"title": "Задача", "description": "Описание"})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusNotFound, w.Code)
+	}
+
+	task, _ := taskStorage.GetTask(t.Context(), 1)
+	tasks, _ := taskStorage.GetAllTasks(t.Context())
+	if task.Completed || len(tasks) != 1 {
+		t.Errorf("Состояние изменилось после ошибки: completed=%v, задач %d", task.Completed, len(tasks))
+	}
+}
@@ -0,0 +1,275 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"test/handlers"
+	"test/models"
+	"test/storage"
+	"testing"
+)
+
+// newFormRequest создает запрос с телом application/x-www-form-urlencoded
+func newFormRequest(t *testing.T, method, path string, form url.Values) *http.Request {
+	req, err := http.NewRequest(method, path, strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+// TestCreateTaskFormRedirect проверяет создание задачи HTML-формой без JavaScript
+//
+// Проверяет:
+// - Перенаправление 303 на адрес созданной задачи
+// - Перенаправление 303 на адрес из поля return_to
+// - Игнорирование небезопасного адреса возврата
+func TestCreateTaskFormRedirect(t *testing.T) {
+	tests := []struct {
+		name     string
+		returnTo string
+		location string
+	}{
+		{"адрес задачи", "", "/tasks/1"},
+		{"адрес возврата", "/ui/tasks?view=all", "/ui/tasks?view=all"},
+		{"сторонний сайт", "//evil.example/phish", "/tasks/1"},
+		{"абсолютный адрес", "https://evil.example/", "/tasks/1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskStorage := storage.NewInMemoryStorage()
+			mux := handlers.SetupHandlers(taskStorage)
+
+			form := url.Values{"title": {"Купить продукты"}, "description": {"Молоко, хлеб"}}
+			if tt.returnTo != "" {
+				form.Set("return_to", tt.returnTo)
+			}
+
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, newFormRequest(t, "POST", "/tasks", form))
+
+			if w.Code != http.StatusSeeOther {
+				t.Fatalf("Ожидался код %d, получен %d", http.StatusSeeOther, w.Code)
+			}
+			if location := w.Header().Get("Location"); location != tt.location {
+				t.Errorf("Ожидался Location %q, получен %q", tt.location, location)
+			}
+
+			task, err := taskStorage.GetTask(t.Context(), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if task.Title != "Купить продукты" || task.Description != "Молоко, хлеб" {
+				t.Errorf("Несовпадение данных задачи")
+			}
+		})
+	}
+}
+
+// TestCreateTaskFormJSON проверяет ответ JSON на форму при Accept: application/json
+func TestCreateTaskFormJSON(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+	mux := handlers.SetupHandlers(taskStorage)
+
+	form := url.Values{"title": {"Тестовая задача"}, "description": {"Описание"}}
+	req := newFormRequest(t, "POST", "/tasks", form)
+	req.Header.Set("Accept", "application/json")
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Ожидался код %d, получен %d", http.StatusCreated, w.Code)
+	}
+	if location := w.Header().Get("Location"); location != "/tasks/1" {
+		t.Errorf("Ожидался Location %q, получен %q", "/tasks/1", location)
+	}
+
+	var task models.Task
+	if err := json.Unmarshal(w.Body.Bytes(), &task); err != nil {
+		t.Fatal(err)
+	}
+	if task.ID != 1 || task.Title != "Тестовая задача" || task.Completed {
+		t.Errorf("Несовпадение данных задачи: %+v", task)
+	}
+}
+
+// TestCreateTaskMultipartForm проверяет создание задачи формой multipart/form-data
+func TestCreateTaskMultipartForm(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+	mux := handlers.SetupHandlers(taskStorage)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("title", "Задача из multipart")
+	writer.WriteField("description", "Описание")
+	writer.Close()
+
+	req, err := http.NewRequest("POST", "/tasks", &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Accept", "application/json")
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Ожидался код %d, получен %d", http.StatusCreated, w.Code)
+	}
+
+	task, err := taskStorage.GetTask(t.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Title != "Задача из multipart" {
+		t.Errorf("Несовпадение заголовка: %q", task.Title)
+	}
+}
+
+// TestCreateTaskFormValidation проверяет передачу ошибок валидации формы
+//
+// Проверяет:
+// - JSON-ошибку с кодом 400 при Accept: application/json
+// - Перенаправление на адрес возврата с параметром error для браузера
+// - Отсутствие созданных задач
+func TestCreateTaskFormValidation(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+	mux := handlers.SetupHandlers(taskStorage)
+
+	form := url.Values{"title": {"Без описания"}, "return_to": {"/ui/new?draft=1"}}
+
+	// Клиент, ожидающий JSON
+	req := newFormRequest(t, "POST", "/tasks", form)
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusBadRequest, w.Code)
+	}
+	var errBody handlers.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &errBody); err != nil {
+		t.Fatal(err)
+	}
+	if errBody.Error == "" || errBody.Status != http.StatusBadRequest {
+		t.Errorf("Ожидалось сообщение об ошибке в поле error")
+	}
+
+	// Браузер
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, newFormRequest(t, "POST", "/tasks", form))
+
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("Ожидался код %d, получен %d", http.StatusSeeOther, w.Code)
+	}
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if location.Path != "/ui/new" || location.Query().Get("draft") != "1" {
+		t.Errorf("Неверный адрес возврата: %s", location)
+	}
+	if location.Query().Get("error") == "" {
+		t.Errorf("Ожидался параметр error в адресе возврата")
+	}
+
+	tasks, _ := taskStorage.GetAllTasks(t.Context())
+	if len(tasks) != 0 {
+		t.Errorf("Ожидалось 0 задач, получено %d", len(tasks))
+	}
+}
+
+// TestUpdateTaskFormCheckbox проверяет семантику checkbox для поля completed
+//
+// Проверяет:
+// - Значение "on" отмечает задачу выполненной
+// - Отсутствие поля снимает отметку
+func TestUpdateTaskFormCheckbox(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+	mux := handlers.SetupHandlers(taskStorage)
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Тестовая задача", Description: "Описание"})
+
+	steps := []struct {
+		completed string
+		expected  bool
+	}{
+		{"on", true},
+		{"", false},
+		{"true", true},
+	}
+
+	for _, step := range steps {
+		form := url.Values{"title": {"Тестовая задача"}, "description": {"Описание"}}
+		if step.completed != "" {
+			form.Set("completed", step.completed)
+		}
+
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, newFormRequest(t, "PUT", "/tasks/1", form))
+
+		if w.Code != http.StatusSeeOther {
+			t.Fatalf("Ожидался код %d, получен %d", http.StatusSeeOther, w.Code)
+		}
+		if location := w.Header().Get("Location"); location != "/tasks/1" {
+			t.Errorf("Ожидался Location %q, получен %q", "/tasks/1", location)
+		}
+
+		task, err := taskStorage.GetTask(t.Context(), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if task.Completed != step.expected {
+			t.Errorf("completed=%q: ожидалось %v, получено %v", step.completed, step.expected, task.Completed)
+		}
+	}
+
+	// Некорректное значение флажка
+	form := url.Values{"title": {"Тестовая задача"}, "description": {"Описание"}, "completed": {"maybe"}}
+	req := newFormRequest(t, "PUT", "/tasks/1", form)
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestUpdateTaskFormStorageErrors проверяет коды ответа на ошибки хранилища при изменении задачи формой
+//
+// Проверяет:
+// - Отсутствующая задача дает 404
+// - Закрытое хранилище дает 503, как и для JSON-запроса
+func TestUpdateTaskFormStorageErrors(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+	mux := handlers.SetupHandlers(taskStorage)
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Тестовая задача", Description: "Описание"})
+
+	update := func(path string) int {
+		t.Helper()
+		req := newFormRequest(t, "PUT", path, url.Values{"title": {"Тестовая задача"}, "description": {"Описание"}})
+		req.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := update("/tasks/2"); code != http.StatusNotFound {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusNotFound, code)
+	}
+	if err := taskStorage.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if code := update("/tasks/1"); code != http.StatusServiceUnavailable {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusServiceUnavailable, code)
+	}
+}
@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"test/handlers"
+	"test/storage"
+	"testing"
+)
+
+// getHealth запрашивает проверку состояния path и разбирает ответ
+func getHealth(t *testing.T, mux http.Handler, path string, status int) handlers.HealthResponse {
+	t.Helper()
+	w := doJSON(t, mux, "GET", path, nil)
+	expectCode(t, w, status)
+
+	var body handlers.HealthResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Неверное тело ответа %q: %v", w.Body.String(), err)
+	}
+	return body
+}
+
+// TestHealth проверяет проверки состояния GET /healthz и GET /readyz
+//
+// Проверяет:
+// - /healthz отвечает 200 со status ok и uptime_seconds
+// - /readyz отвечает 200 для хранилища в памяти и доступной базы SQLite
+// - /readyz отвечает 503 со status unavailable, когда база SQLite закрыта
+// - Проверки отвечают в обход middleware, отклоняющего все остальные запросы
+// - Методы, кроме GET, отклоняются с кодом 405
+func TestHealth(t *testing.T) {
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage())
+	if body := getHealth(t, mux, "/healthz", http.StatusOK); body.Status != "ok" || body.UptimeSeconds < 0 {
+		t.Errorf("Неверный ответ /healthz: %+v", body)
+	}
+	if body := getHealth(t, mux, "/readyz", http.StatusOK); body.Status != "ok" {
+		t.Errorf("Неверный ответ /readyz: %+v", body)
+	}
+	expectCode(t, doJSON(t, mux, "POST", "/healthz", nil), http.StatusMethodNotAllowed)
+
+	sqlite, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux = handlers.SetupHandlers(sqlite)
+	getHealth(t, mux, "/readyz", http.StatusOK)
+	sqlite.Close()
+	if body := getHealth(t, mux, "/readyz", http.StatusServiceUnavailable); body.Status != "unavailable" || body.Error == "" {
+		t.Errorf("Неверный ответ /readyz: %+v", body)
+	}
+	getHealth(t, mux, "/healthz", http.StatusOK)
+
+	deny := func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+	}
+	mux = handlers.SetupHandlers(storage.NewInMemoryStorage(), deny)
+	expectCode(t, doJSON(t, mux, "GET", "/tasks", nil), http.StatusTooManyRequests)
+	getHealth(t, mux, "/healthz", http.StatusOK)
+	getHealth(t, mux, "/readyz", http.StatusOK)
+}
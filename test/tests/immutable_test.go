@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"test/handlers"
+	"test/models"
+	"test/storage"
+	"testing"
+)
+
+// TestCompletedTaskImmutable проверяет запрет изменения выполненных задач
+//
+// Проверяет:
+// - Правка выполненной задачи отклоняется с кодом 409 и кодом task_completed_immutable
+// - Добавление и удаление ссылок выполненной задачи отклоняются
+// - Возобновление с заменой тегов отклоняется
+// - Возобновление (completed=false без других изменений) разрешено
+// - После возобновления задачу снова можно править
+func TestCompletedTaskImmutable(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage(storage.WithCompletedImmutable())
+	mux := handlers.SetupHandlers(taskStorage)
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{
+		Title:       "Отчет",
+		Description: "Квартальный",
+		Links:       []models.Link{{URL: "https://example.com/"}},
+	})
+
+	// Отметка о выполнении разрешена
+	w := doJSON(t, mux, "PUT", "/tasks/1", map[string]interface{}{"title": "Отчет", "description": "Квартальный", "completed": true})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался код %d, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	rejected := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+	}{
+		{"правка названия", "PUT", "/tasks/1", map[string]interface{}{"title": "Другой", "description": "Квартальный", "completed": true}},
+		{"возобновление с правкой", "PUT", "/tasks/1", map[string]interface{}{"title": "Другой", "description": "Квартальный", "completed": false}},
+		{"возобновление с заменой тегов", "PUT", "/tasks/1", map[string]interface{}{"title": "Отчет", "description": "Квартальный", "completed": false, "tags": []string{"y"}}},
+		{"замена ссылок", "PUT", "/tasks/1", map[string]interface{}{"title": "Отчет", "description": "Квартальный", "links": []models.Link{}}},
+		{"повтор без изменений", "PUT", "/tasks/1", map[string]interface{}{"title": "Отчет", "description": "Квартальный", "completed": true}},
+		{"добавление ссылки", "POST", "/tasks/1/links", models.Link{URL: "https://example.com/new"}},
+		{"удаление ссылки", "DELETE", "/tasks/1/links/0", nil},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doJSON(t, mux, tt.method, tt.path, tt.body)
+			if w.Code != http.StatusConflict {
+				t.Fatalf("Ожидался код %d, получен %d", http.StatusConflict, w.Code)
+			}
+			var body handlers.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Code != handlers.CodeTaskCompletedImmutable || body.Error == "" || body.Status != http.StatusConflict {
+				t.Errorf("Неверное тело ошибки: %v", body)
+			}
+		})
+	}
+
+	task, _ := taskStorage.GetTask(t.Context(), 1)
+	if task.Title != "Отчет" || !task.Completed || len(task.Links) != 1 || len(task.Tags) != 0 {
+		t.Fatalf("Выполненная задача изменена: %+v", task)
+	}
+
+	// Возобновление и правка после него
+	w = doJSON(t, mux, "PUT", "/tasks/1", map[string]interface{}{"title": "Отчет", "description": "Квартальный", "completed": false})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался код %d, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	w = doJSON(t, mux, "PUT", "/tasks/1", map[string]interface{}{"title": "Годовой отчет", "description": "Квартальный"})
+	if w.Code != http.StatusOK {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusOK, w.Code)
+	}
+}
+
+// TestCompletedTaskMutableByDefault проверяет, что без опции выполненные задачи можно править
+func TestCompletedTaskMutableByDefault(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Отчет", Description: "Квартальный"})
+	taskStorage.UpdateTask(t.Context(), 1, storage.UpdateTaskInput{Title: "Отчет", Description: "Квартальный", Completed: true})
+
+	if _, err := taskStorage.UpdateTask(t.Context(), 1, storage.UpdateTaskInput{Title: "Другой", Description: "Квартальный", Completed: true}); err != nil {
+		t.Errorf("Ожидалась успешная правка, получена ошибка: %v", err)
+	}
+}
+
+// TestCompletedTaskImmutableRace проверяет гонку отметки о выполнении и правки
+//
+// Правка, начатая до отметки о выполнении, не должна перезаписать выполненную задачу:
+// либо правка применяется раньше отметки, либо отклоняется. В обоих случаях итоговая
+// задача выполнена и имеет название из запроса на выполнение.
+func TestCompletedTaskImmutableRace(t *testing.T) {
+	for round := 0; round < 200; round++ {
+		taskStorage := storage.NewInMemoryStorage(storage.WithCompletedImmutable())
+		taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Черновик", Description: "Описание"})
+
+		var wg sync.WaitGroup
+		var editErr error
+		start := make(chan struct{})
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			<-start
+			taskStorage.UpdateTask(t.Context(), 1, storage.UpdateTaskInput{Title: "Выполнено", Description: "Описание", Completed: true})
+		}()
+		go func() {
+			defer wg.Done()
+			<-start
+			_, editErr = taskStorage.UpdateTask(t.Context(), 1, storage.UpdateTaskInput{Title: "Правка", Description: "Описание"})
+		}()
+		close(start)
+		wg.Wait()
+
+		task, _ := taskStorage.GetTask(t.Context(), 1)
+		if task.Title != "Выполнено" || !task.Completed {
+			t.Fatalf("Раунд %d: правка перезаписала выполненную задачу: %+v (ошибка правки: %v)", round, task, editErr)
+		}
+	}
+}
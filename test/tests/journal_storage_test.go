@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"test/handlers"
+	"test/storage"
+	"testing"
+)
+
+// newJournaledStorage создает хранилище с журналом во временном каталоге теста
+func newJournaledStorage(t *testing.T, opts ...storage.Option) storage.Storage {
+	return openJournaledStorage(t, filepath.Join(t.TempDir(), "tasks.journal"), opts...)
+}
+
+// openJournaledStorage восстанавливает хранилище из журнала path и закрывает его по окончании теста
+func openJournaledStorage(t *testing.T, path string, opts ...storage.Option) *storage.JournaledStorage {
+	t.Helper()
+	taskStorage, err := storage.NewJournaledStorage(path, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { taskStorage.Close() })
+	return taskStorage
+}
+
+// TestJournaledStorage проверяет хранилище с журналом общим набором проверок хранилищ
+func TestJournaledStorage(t *testing.T) {
+	testStorageBackend(t, newJournaledStorage)
+}
+
+// TestJournaledStorageCompact проверяет восстановление после повторного открытия и Compact
+//
+// Проверяет:
+// - Задачи, метаданные и клиентские токены восстанавливаются из журнала
+// - После Compact в журнале одна строка, а состояние не меняется
+// - Нумерация продолжается с последнего выданного ID, даже если последняя задача удалена
+// - Изменения после Compact дописываются в новый журнал
+func TestJournaledStorageCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.journal")
+
+	taskStorage := openJournaledStorage(t, path)
+	mux := handlers.SetupHandlers(taskStorage)
+	expectCode(t, postTask(t, mux, map[string]string{"title": "Первая", "description": "Описание", "client_token": "abc"}), http.StatusCreated)
+	expectCode(t, postTask(t, mux, map[string]string{"title": "Вторая", "description": "Описание"}), http.StatusCreated)
+	expectCode(t, patchTask(t, mux, "/tasks/1", `{"title":"Первая задача"}`), http.StatusOK)
+	expectCode(t, putRaw(t, mux, "/tasks/1/metadata/crm", []byte(`{"deal_id":42}`)), http.StatusNoContent)
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/2", nil), http.StatusNoContent)
+	before := doJSON(t, mux, "GET", "/tasks?include_deleted=true", nil).Body.String()
+
+	if err := taskStorage.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := bytes.Count(data, []byte("\n")); lines != 1 {
+		t.Errorf("После Compact ожидалась одна строка журнала, найдено %d", lines)
+	}
+
+	taskStorage = openJournaledStorage(t, path)
+	mux = handlers.SetupHandlers(taskStorage)
+	if after := doJSON(t, mux, "GET", "/tasks?include_deleted=true", nil).Body.String(); after != before {
+		t.Errorf("Состояние изменилось после Compact:\nдо:    %s\nпосле: %s", before, after)
+	}
+
+	// Запись после Compact должна попасть в новый журнал
+	expectCode(t, postTask(t, mux, map[string]string{"title": "Третья", "description": "Описание"}), http.StatusCreated)
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/3", nil), http.StatusNoContent)
+
+	mux = handlers.SetupHandlers(openJournaledStorage(t, path))
+	expectCode(t, doJSON(t, mux, "GET", "/tasks/3", nil), http.StatusGone)
+	if w := doJSON(t, mux, "GET", "/tasks/1/metadata/crm", nil); w.Body.String() != `{"deal_id":42}` {
+		t.Errorf("Метаданные потеряны: %d %s", w.Code, w.Body.String())
+	}
+
+	w := postTask(t, mux, map[string]string{"title": "Первая", "description": "Описание", "client_token": "abc"})
+	expectCode(t, w, http.StatusOK)
+	if task := decodeTask(t, w); task.ID != 1 {
+		t.Errorf("Ожидалась задача 1 по токену, получена %d", task.ID)
+	}
+
+	w = postTask(t, mux, map[string]string{"title": "Четвертая", "description": "Описание"})
+	expectCode(t, w, http.StatusCreated)
+	if task := decodeTask(t, w); task.ID != 4 {
+		t.Errorf("Ожидался ID 4, получен %d", task.ID)
+	}
+}
+
+// TestJournaledStorageCrash проверяет восстановление после сбоя посреди записи
+//
+// Сбой моделируется копией журнала, оборванной посреди очередной строки.
+//
+// Проверяет:
+// - Восстанавливается состояние после последней целой строки
+// - Следующая задача получает ID, следующий за последним записанным
+// - Оборванная строка удаляется и не мешает дальнейшей дозаписи
+func TestJournaledStorageCrash(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tasks.journal")
+	mux := handlers.SetupHandlers(openJournaledStorage(t, path))
+
+	steps := []func(){
+		func() {
+			expectCode(t, postTask(t, mux, map[string]string{"title": "Первая", "description": "Описание"}), http.StatusCreated)
+		},
+		func() {
+			expectCode(t, postTask(t, mux, map[string]string{"title": "Вторая", "description": "Описание", "client_token": "abc"}), http.StatusCreated)
+		},
+		func() { expectCode(t, patchTask(t, mux, "/tasks/1", `{"completed":true}`), http.StatusOK) },
+		func() { expectCode(t, doJSON(t, mux, "DELETE", "/tasks/2", nil), http.StatusNoContent) },
+		func() {
+			expectCode(t, postTask(t, mux, map[string]string{"title": "Третья", "description": "Описание"}), http.StatusCreated)
+		},
+		func() { expectCode(t, doJSON(t, mux, "DELETE", "/tasks/3", nil), http.StatusNoContent) },
+	}
+	nextIDs := []int{1, 2, 3, 3, 3, 4}
+
+	// Размер журнала и состояние перед каждым шагом и после последнего
+	sizes := []int64{0}
+	states := []string{doJSON(t, mux, "GET", "/tasks?include_deleted=true", nil).Body.String()}
+	for _, step := range steps {
+		step()
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizes = append(sizes, info.Size())
+		states = append(states, doJSON(t, mux, "GET", "/tasks?include_deleted=true", nil).Body.String())
+	}
+	journal, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range steps {
+		// Обрыв посреди строки шага i+1: на диске только строки шагов до i включительно
+		cut := sizes[i] + (sizes[i+1]-sizes[i])/2
+		crashed := filepath.Join(dir, "crashed.journal")
+		if err := os.WriteFile(crashed, journal[:cut], 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		recovered := handlers.SetupHandlers(openJournaledStorage(t, crashed))
+		if state := doJSON(t, recovered, "GET", "/tasks?include_deleted=true", nil).Body.String(); state != states[i] {
+			t.Errorf("Шаг %d: ожидалось состояние %s, восстановлено %s", i, states[i], state)
+		}
+		w := postTask(t, recovered, map[string]string{"title": "После сбоя", "description": "Описание"})
+		expectCode(t, w, http.StatusCreated)
+		if task := decodeTask(t, w); task.ID != nextIDs[i] {
+			t.Errorf("Шаг %d: ожидался ID %d, получен %d", i, nextIDs[i], task.ID)
+		}
+
+		// Новая строка записана вместо оборванной и читается при следующем открытии
+		reopened := handlers.SetupHandlers(openJournaledStorage(t, crashed))
+		expectCode(t, doJSON(t, reopened, "GET", fmt.Sprintf("/tasks/%d", nextIDs[i]), nil), http.StatusOK)
+	}
+}
+
+// TestJournaledStorageCorrupt проверяет отказ загружать журнал с поврежденной строкой в середине
+func TestJournaledStorageCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.journal")
+	content := "{\"last_id\":1,\"tasks\":{\"1\":{}}}\nне JSON\n{\"last_id\":2}\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := storage.NewJournaledStorage(path); err == nil {
+		t.Fatal("Ожидалась ошибка загрузки поврежденного журнала")
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != content {
+		t.Errorf("Поврежденный журнал изменен: %q (%v)", data, err)
+	}
+}
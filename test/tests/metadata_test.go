@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test/handlers"
+	"test/storage"
+	"testing"
+)
+
+// putRaw отправляет PUT с телом как есть и возвращает ответ
+func putRaw(t *testing.T, mux http.Handler, path string, body []byte) *httptest.ResponseRecorder {
+	req, err := http.NewRequest("PUT", path, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+// TestTaskMetadata проверяет метаданные интеграций задачи
+//
+// Проверяет:
+// - Сохранение значения с base64-данными без изменений
+// - Изоляцию пространств имен
+// - Отсутствие метаданных в обычных ответах и их выдачу по ?expand=metadata
+// - Удаление пространства и отклонение некорректного JSON
+func TestTaskMetadata(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+	mux := handlers.SetupHandlers(taskStorage)
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Сделка", Description: "Описание"})
+
+	blob := base64.StdEncoding.EncodeToString([]byte{0x00, 0xff, 0x10, 0x80, 0xfe, '"', '\\'})
+	crm := []byte(`{"deal_id":42,"blob":"` + blob + `"}`)
+	if w := putRaw(t, mux, "/tasks/1/metadata/crm", crm); w.Code != http.StatusNoContent {
+		t.Fatalf("Ожидался код %d, получен %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+	}
+	if w := putRaw(t, mux, "/tasks/1/metadata/slack", []byte(`"C01/1700000000.000100"`)); w.Code != http.StatusNoContent {
+		t.Fatalf("Ожидался код %d, получен %d", http.StatusNoContent, w.Code)
+	}
+
+	w := doJSON(t, mux, "GET", "/tasks/1/metadata/crm", nil)
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), crm) {
+		t.Errorf("Значение изменилось: %d %s", w.Code, w.Body.String())
+	}
+
+	// Замена одного пространства не трогает другое
+	putRaw(t, mux, "/tasks/1/metadata/crm", []byte(`{"deal_id":43}`))
+	w = doJSON(t, mux, "GET", "/tasks/1/metadata/slack", nil)
+	if w.Body.String() != `"C01/1700000000.000100"` {
+		t.Errorf("Пространство slack изменилось: %s", w.Body.String())
+	}
+
+	// Обычные ответы не содержат метаданных
+	for _, path := range []string{"/tasks", "/tasks/1"} {
+		if w := doJSON(t, mux, "GET", path, nil); strings.Contains(w.Body.String(), "metadata") {
+			t.Errorf("%s: метаданные в обычном ответе: %s", path, w.Body.String())
+		}
+	}
+
+	w = doJSON(t, mux, "GET", "/tasks/1?expand=metadata", nil)
+	var expanded struct {
+		ID       int                        `json:"id"`
+		Metadata map[string]json.RawMessage `json:"metadata"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &expanded); err != nil {
+		t.Fatal(err)
+	}
+	if expanded.ID != 1 || string(expanded.Metadata["crm"]) != `{"deal_id":43}` || len(expanded.Metadata) != 2 {
+		t.Errorf("Неверный ответ с метаданными: %s", w.Body.String())
+	}
+	if w := doJSON(t, mux, "GET", "/tasks/1?expand=owner", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusBadRequest, w.Code)
+	}
+
+	if w := putRaw(t, mux, "/tasks/1/metadata/crm", []byte(`{"deal_id":`)); w.Code != http.StatusBadRequest {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusBadRequest, w.Code)
+	}
+
+	if w := doJSON(t, mux, "DELETE", "/tasks/1/metadata/slack", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("Ожидался код %d, получен %d", http.StatusNoContent, w.Code)
+	}
+	if w := doJSON(t, mux, "GET", "/tasks/1/metadata/slack", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusNotFound, w.Code)
+	}
+	if w := doJSON(t, mux, "DELETE", "/tasks/1/metadata/slack", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestTaskMetadataLimit проверяет ограничение размера пространства метаданных на границе
+func TestTaskMetadataLimit(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+	mux := handlers.SetupHandlers(taskStorage)
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Задача", Description: "Описание"})
+
+	// JSON-строка ровно на лимите: две кавычки и содержимое
+	atLimit := []byte(`"` + strings.Repeat("a", storage.DefaultMetadataLimit-2) + `"`)
+	if w := putRaw(t, mux, "/tasks/1/metadata/crm", atLimit); w.Code != http.StatusNoContent {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusNoContent, w.Code)
+	}
+
+	overLimit := []byte(`"` + strings.Repeat("a", storage.DefaultMetadataLimit-1) + `"`)
+	if w := putRaw(t, mux, "/tasks/1/metadata/crm", overLimit); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+
+	small := handlers.SetupHandlers(storage.NewInMemoryStorage(storage.WithMetadataLimit(8)))
+	if w := doJSON(t, small, "GET", "/capabilities", nil); !strings.Contains(w.Body.String(), `"max_metadata_bytes":8`) {
+		t.Errorf("Лимит не отражен в /capabilities: %s", w.Body.String())
+	}
+}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"test/handlers"
+	"test/handlers/middleware"
+	"test/storage"
+	"testing"
+)
+
+// TestLoggingMiddleware проверяет журнал запросов middleware.Logging
+//
+// Проверяет:
+// - Каждый запрос записывается одной JSON-строкой с методом, путем, кодом и временем обработки
+// - Код ответа берется у обработчика, в том числе ошибки параметра ?tz
+// - Параметры запроса не попадают в журнал
+func TestLoggingMiddleware(t *testing.T) {
+	var out bytes.Buffer
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage(), middleware.Logging(&out))
+
+	expectCode(t, postTask(t, mux, map[string]string{"title": "Задача", "description": "Описание"}), http.StatusCreated)
+	expectCode(t, doJSON(t, mux, "GET", "/tasks/1?tz=UTC", nil), http.StatusOK)
+	expectCode(t, doJSON(t, mux, "GET", "/tasks/99", nil), http.StatusNotFound)
+	expectCode(t, doJSON(t, mux, "GET", "/tasks?tz=Mars/Olympus", nil), http.StatusBadRequest)
+
+	expected := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{"POST", "/tasks", http.StatusCreated},
+		{"GET", "/tasks/1", http.StatusOK},
+		{"GET", "/tasks/99", http.StatusNotFound},
+		{"GET", "/tasks", http.StatusBadRequest},
+	}
+
+	scanner := bufio.NewScanner(&out)
+	for i, want := range expected {
+		if !scanner.Scan() {
+			t.Fatalf("Ожидалось %d строк журнала, получено %d", len(expected), i)
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Строка %d не JSON: %q", i, scanner.Text())
+		}
+		for _, field := range []string{"time", "method", "path", "status", "latency_ms"} {
+			if _, ok := entry[field]; !ok {
+				t.Errorf("Строка %d: нет поля %s: %s", i, field, scanner.Text())
+			}
+		}
+		if entry["method"] != want.method || entry["path"] != want.path || entry["status"] != float64(want.status) {
+			t.Errorf("Строка %d: ожидались %s %s %d, получено %s", i, want.method, want.path, want.status, scanner.Text())
+		}
+		if latency, _ := entry["latency_ms"].(float64); latency < 0 {
+			t.Errorf("Строка %d: отрицательное время обработки %v", i, latency)
+		}
+		if strings.Contains(scanner.Text(), "tz=") {
+			t.Errorf("Строка %d содержит параметры запроса: %s", i, scanner.Text())
+		}
+	}
+	if scanner.Scan() {
+		t.Errorf("Лишняя строка журнала: %s", scanner.Text())
+	}
+}
+
+// TestMiddlewareOrder проверяет порядок промежуточных обработчиков SetupHandlers
+//
+// Проверяет:
+// - Первый промежуточный обработчик получает запрос первым и завершается последним
+func TestMiddlewareOrder(t *testing.T) {
+	var calls []string
+	trace := func(name string) handlers.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name+" до")
+				next.ServeHTTP(w, r)
+				calls = append(calls, name+" после")
+			})
+		}
+	}
+
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage(), trace("первый"), trace("второй"))
+	expectCode(t, doJSON(t, mux, "GET", "/tasks", nil), http.StatusOK)
+
+	if got, want := strings.Join(calls, ", "), "первый до, второй до, второй после, первый после"; got != want {
+		t.Errorf("Неверный порядок вызовов: %s", got)
+	}
+}
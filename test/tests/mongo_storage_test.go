@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"test/storage"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// mongoTestURI - переменная окружения с адресом тестового набора реплик MongoDB;
+// без нее тесты MongoDB пропускаются
+const mongoTestURI = "TEST_MONGO_URI"
+
+// newMongoStorage создает хранилище MongoDB в отдельной базе данных, удаляемой после теста
+func newMongoStorage(t *testing.T, opts ...storage.Option) storage.Storage {
+	t.Helper()
+	uri := os.Getenv(mongoTestURI)
+	if uri == "" {
+		t.Skipf("%s не задана", mongoTestURI)
+	}
+
+	db := fmt.Sprintf("tasks_test_%d", time.Now().UnixNano())
+	taskStorage, err := storage.NewMongoStorage(uri, db, "tasks", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { taskStorage.Close() })
+
+	admin, err := mongo.Connect(options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		ctx := context.Background()
+		admin.Database(db).Drop(ctx)
+		admin.Disconnect(ctx)
+	})
+	return taskStorage
+}
+
+// TestMongoStorage проверяет хранилище MongoDB общим набором проверок хранилищ
+//
+// Требует TEST_MONGO_URI, например mongodb://localhost:27017/?replicaSet=rs0.
+func TestMongoStorage(t *testing.T) {
+	if os.Getenv(mongoTestURI) == "" {
+		t.Skipf("%s не задана", mongoTestURI)
+	}
+	testStorageBackend(t, newMongoStorage)
+}
@@ -6,10 +6,12 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"test/handlers"
 	"test/models"
 	"test/storage"
 	"testing"
+	"time"
 )
 
 // TestCreateTaskHandler проверяет создание новой задачи через POST /tasks
@@ -19,8 +21,13 @@ import (
 // - Правильность установки ID
 // - Соответствие полей созданной задачи
 func TestCreateTaskHandler(t *testing.T) {
+	testCreateTaskHandler(t, newMemoryStorage)
+}
+
+// testCreateTaskHandler проверяет создание задачи на хранилищах, созданных newStorage
+func testCreateTaskHandler(t *testing.T, newStorage storageFactory) {
 	// Инициализация хранилища и обработчиков
-	taskStorage := storage.NewInMemoryStorage()
+	taskStorage := newStorage(t)
 	mux := handlers.SetupHandlers(taskStorage)
 
 	// Эталонная задача для тестирования
@@ -80,8 +87,13 @@ func TestCreateTaskHandler(t *testing.T) {
 // - Соответствие количества задач
 // - Правильность данных возвращаемых задач
 func TestGetAllTasksHandler(t *testing.T) {
+	testGetAllTasksHandler(t, newMemoryStorage)
+}
+
+// testGetAllTasksHandler проверяет получение списка задач на хранилищах, созданных newStorage
+func testGetAllTasksHandler(t *testing.T, newStorage storageFactory) {
 	// Инициализация хранилища и обработчиков
-	taskStorage := storage.NewInMemoryStorage()
+	taskStorage := newStorage(t)
 	mux := handlers.SetupHandlers(taskStorage)
 
 	// Создание тестовой задачи
@@ -89,7 +101,7 @@ func TestGetAllTasksHandler(t *testing.T) {
 		Title:       "Тестовая задача",
 		Description: "Описание тестовой задачи",
 	}
-	taskStorage.CreateTask(testTask.Title, testTask.Description)
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: testTask.Title, Description: testTask.Description})
 
 	// Создание GET запроса
 	req, err := http.NewRequest("GET", "/tasks", nil)
@@ -134,8 +146,13 @@ func TestGetAllTasksHandler(t *testing.T) {
 // - Корректность получения задачи по ID
 // - Соответствие всех полей задачи
 func TestGetTaskHandler(t *testing.T) {
+	testGetTaskHandler(t, newMemoryStorage)
+}
+
+// testGetTaskHandler проверяет получение задачи по ID на хранилищах, созданных newStorage
+func testGetTaskHandler(t *testing.T, newStorage storageFactory) {
 	// Инициализация хранилища и обработчиков
-	taskStorage := storage.NewInMemoryStorage()
+	taskStorage := newStorage(t)
 	mux := handlers.SetupHandlers(taskStorage)
 
 	// Создание тестовой задачи
@@ -143,7 +160,7 @@ func TestGetTaskHandler(t *testing.T) {
 		Title:       "Тестовая задача",
 		Description: "Описание тестовой задачи",
 	}
-	taskStorage.CreateTask(testTask.Title, testTask.Description)
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: testTask.Title, Description: testTask.Description})
 
 	// Создание GET запроса
 	req, err := http.NewRequest("GET", "/tasks/1", nil)
@@ -183,8 +200,13 @@ func TestGetTaskHandler(t *testing.T) {
 // - Корректность обновления всех полей задачи
 // - Соответствие обновленных данных
 func TestUpdateTaskHandler(t *testing.T) {
+	testUpdateTaskHandler(t, newMemoryStorage)
+}
+
+// testUpdateTaskHandler проверяет обновление задачи на хранилищах, созданных newStorage
+func testUpdateTaskHandler(t *testing.T, newStorage storageFactory) {
 	// Инициализация хранилища и обработчиков
-	taskStorage := storage.NewInMemoryStorage()
+	taskStorage := newStorage(t)
 	mux := handlers.SetupHandlers(taskStorage)
 
 	// Создание тестовой задачи
@@ -192,7 +214,7 @@ func TestUpdateTaskHandler(t *testing.T) {
 		Title:       "Тестовая задача",
 		Description: "Описание тестовой задачи",
 	}
-	taskStorage.CreateTask(testTask.Title, testTask.Description)
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: testTask.Title, Description: testTask.Description})
 
 	// Обновление задачи
 	updatedTask := models.Task{
@@ -245,8 +267,13 @@ func TestUpdateTaskHandler(t *testing.T) {
 // - Корректность удаления задачи
 // - Отсутствие задачи после удаления
 func TestDeleteTaskHandler(t *testing.T) {
+	testDeleteTaskHandler(t, newMemoryStorage)
+}
+
+// testDeleteTaskHandler проверяет удаление задачи на хранилищах, созданных newStorage
+func testDeleteTaskHandler(t *testing.T, newStorage storageFactory) {
 	// Инициализация хранилища и обработчиков
-	taskStorage := storage.NewInMemoryStorage()
+	taskStorage := newStorage(t)
 	mux := handlers.SetupHandlers(taskStorage)
 
 	// Создание тестовой задачи
@@ -254,7 +281,7 @@ func TestDeleteTaskHandler(t *testing.T) {
 		Title:       "Тестовая задача",
 		Description: "Описание тестовой задачи",
 	}
-	taskStorage.CreateTask(testTask.Title, testTask.Description)
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: testTask.Title, Description: testTask.Description})
 
 	// Создание DELETE запроса
 	req, err := http.NewRequest("DELETE", "/tasks/1", nil)
@@ -279,7 +306,73 @@ func TestDeleteTaskHandler(t *testing.T) {
 	w = httptest.NewRecorder()
 	mux.ServeHTTP(w, req)
 
-	if w.Code != http.StatusNotFound {
-		t.Errorf("Ожидался код %d, получен %d", http.StatusNotFound, w.Code)
+	// Недавно удаленная задача отвечает 410, а не 404
+	if w.Code != http.StatusGone {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusGone, w.Code)
+	}
+}
+
+// TestTaskTimestamps проверяет поля created_at и updated_at задачи
+//
+// Проверяет:
+// - created_at и updated_at задаются при создании и не равны нулю
+// - PUT и PATCH обновляют updated_at, не меняя created_at
+// - Значения created_at и updated_at из тела запроса игнорируются
+// - Оба поля присутствуют в ответах GET /tasks и GET /tasks/{id}
+func TestTaskTimestamps(t *testing.T) {
+	testTaskTimestamps(t, newMemoryStorage)
+}
+
+// testTaskTimestamps проверяет метки времени задачи на хранилищах, созданных newStorage
+func testTaskTimestamps(t *testing.T, newStorage storageFactory) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	mux := handlers.SetupHandlers(newStorage(t, storage.WithClock(clock.Now)))
+	forged := "2000-01-01T00:00:00Z"
+	created := clock.Now()
+
+	w := postTask(t, mux, map[string]string{
+		"title":       "Задача",
+		"description": "Описание",
+		"created_at":  forged,
+		"updated_at":  forged,
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Ожидался код %d, получен %d", http.StatusCreated, w.Code)
+	}
+	task := decodeTask(t, w)
+	if task.CreatedAt.IsZero() || !task.CreatedAt.Equal(created) || !task.UpdatedAt.Equal(created) {
+		t.Errorf("Неверные моменты создания: created_at=%v updated_at=%v", task.CreatedAt, task.UpdatedAt)
+	}
+
+	clock.Advance(time.Hour)
+	w = doJSON(t, mux, "PUT", "/tasks/1", map[string]interface{}{
+		"title":       "Задача",
+		"description": "Новое описание",
+		"created_at":  forged,
+		"updated_at":  forged,
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался код %d, получен %d", http.StatusOK, w.Code)
+	}
+	task = decodeTask(t, w)
+	if !task.CreatedAt.Equal(created) || !task.UpdatedAt.Equal(clock.Now()) {
+		t.Errorf("После PUT: created_at=%v updated_at=%v", task.CreatedAt, task.UpdatedAt)
+	}
+
+	clock.Advance(time.Hour)
+	w = patchTask(t, mux, "/tasks/1", `{"completed": true, "updated_at": "2000-01-01T00:00:00Z"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался код %d, получен %d", http.StatusOK, w.Code)
+	}
+	task = decodeTask(t, w)
+	if !task.CreatedAt.Equal(created) || !task.UpdatedAt.Equal(clock.Now()) {
+		t.Errorf("После PATCH: created_at=%v updated_at=%v", task.CreatedAt, task.UpdatedAt)
+	}
+
+	for _, path := range []string{"/tasks", "/tasks/1"} {
+		body := doJSON(t, mux, "GET", path, nil).Body.String()
+		if !strings.Contains(body, `"created_at":"2024-05-01T12:00:00Z"`) || !strings.Contains(body, `"updated_at":"2024-05-01T14:00:00Z"`) {
+			t.Errorf("%s: нет моментов создания и изменения: %s", path, body)
+		}
 	}
 }
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"test/handlers"
+	"test/storage"
+	"testing"
+	"time"
+)
+
+// TestOverdueTasks проверяет срок выполнения задач и GET /tasks/overdue
+//
+// Проверяет:
+// - Просрочены только невыполненные задачи со сроком раньше текущего момента
+// - Задача без срока, выполненная и удаленная задача не просрочены
+// - Срок с другим смещением хранится в UTC
+// - Срок сравнивается с часами хранилища, PATCH меняет срок и снимает его через null
+// - Код 400 на срок не в RFC3339 и 405 на запись в /tasks/overdue
+func TestOverdueTasks(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage(storage.WithClock(clock.Now)))
+
+	dueDates := []string{
+		"2024-01-01T10:00:00Z",      // 1: просрочена
+		"2024-01-02T12:00:00Z",      // 2: срок завтра
+		"",                          // 3: без срока
+		"2024-01-01T09:00:00Z",      // 4: выполнена ниже
+		"2024-01-01T15:00:00+07:00", // 5: просрочена, 08:00 UTC
+		"2023-12-31T00:00:00Z",      // 6: удалена ниже
+	}
+	for i, due := range dueDates {
+		body := map[string]string{"title": fmt.Sprintf("Задача %d", i+1), "description": "Описание"}
+		if due != "" {
+			body["due_date"] = due
+		}
+		expectCode(t, postTask(t, mux, body), http.StatusCreated)
+	}
+	expectCode(t, doJSON(t, mux, "PUT", "/tasks/4", map[string]interface{}{"title": "Задача 4", "description": "Описание", "completed": true}), http.StatusOK)
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/6", nil), http.StatusNoContent)
+
+	if task := decodeTask(t, doJSON(t, mux, "GET", "/tasks/5", nil)); task.DueDate == nil || task.DueDate.Location() != time.UTC || task.DueDate.Hour() != 8 {
+		t.Errorf("Срок должен храниться в UTC: %v", task.DueDate)
+	}
+
+	steps := []struct {
+		name     string
+		apply    func()
+		expected string
+	}{
+		{"начальное состояние", func() {}, "[1 5]"},
+		{"срок задачи 2 прошел", func() { clock.Advance(48 * time.Hour) }, "[1 2 5]"},
+		{"срок снят", func() { expectCode(t, patchTask(t, mux, "/tasks/2", `{"due_date":null}`), http.StatusOK) }, "[1 5]"},
+		{"срок перенесен", func() {
+			expectCode(t, patchTask(t, mux, "/tasks/1", `{"due_date":"2024-02-01T00:00:00Z"}`), http.StatusOK)
+		}, "[5]"},
+	}
+	for _, step := range steps {
+		step.apply()
+		if ids, _ := getPage(t, mux, "/tasks/overdue"); fmt.Sprint(ids) != step.expected {
+			t.Errorf("%s: ожидались задачи %s, получены %v", step.name, step.expected, ids)
+		}
+	}
+
+	expectCode(t, postTask(t, mux, map[string]string{"title": "Задача", "description": "Описание", "due_date": "завтра"}), http.StatusBadRequest)
+	expectCode(t, patchTask(t, mux, "/tasks/1", `{"due_date":"2024-02-01"}`), http.StatusBadRequest)
+	expectCode(t, doJSON(t, mux, "POST", "/tasks/overdue", nil), http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,174 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test/handlers"
+	"test/models"
+	"test/storage"
+	"testing"
+)
+
+// newPaginationMux создает маршрутизатор с count задачами "Задача 1" ... "Задача count"
+func newPaginationMux(t *testing.T, count int) http.Handler {
+	t.Helper()
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage())
+	for i := 1; i <= count; i++ {
+		w := postTask(t, mux, map[string]string{"title": fmt.Sprintf("Задача %d", i), "description": "Описание"})
+		expectCode(t, w, http.StatusCreated)
+	}
+	return mux
+}
+
+// getPage запрашивает страницу списка и возвращает ID ее задач
+func getPage(t *testing.T, mux http.Handler, path string) ([]int, *httptest.ResponseRecorder) {
+	t.Helper()
+	w := doJSON(t, mux, "GET", path, nil)
+	expectCode(t, w, http.StatusOK)
+
+	var tasks []models.Task
+	if err := json.Unmarshal(w.Body.Bytes(), &tasks); err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]int, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	return ids, w
+}
+
+// TestPagination проверяет разбиение списка задач на страницы
+//
+// Проверяет:
+// - Страницу в порядке ID и заголовок X-Total-Count
+// - Размер страницы по умолчанию DefaultPageLimit
+// - Пустую страницу за пределами списка
+// - Список целиком без параметров page и limit
+func TestPagination(t *testing.T) {
+	mux := newPaginationMux(t, 45)
+
+	ids, w := getPage(t, mux, "/tasks?page=2&limit=10")
+	if len(ids) != 10 || ids[0] != 11 || ids[9] != 20 {
+		t.Errorf("Ожидались задачи 11-20, получены %v", ids)
+	}
+	if total := w.Header().Get("X-Total-Count"); total != "45" {
+		t.Errorf("Ожидался X-Total-Count 45, получен %q", total)
+	}
+
+	ids, _ = getPage(t, mux, "/tasks?page=3")
+	if len(ids) != 45-2*handlers.DefaultPageLimit || ids[0] != 2*handlers.DefaultPageLimit+1 {
+		t.Errorf("Неверная последняя страница по умолчанию: %v", ids)
+	}
+
+	ids, w = getPage(t, mux, "/tasks?page=10&limit=10")
+	if len(ids) != 0 {
+		t.Errorf("Ожидалась пустая страница, получены %v", ids)
+	}
+	if total := w.Header().Get("X-Total-Count"); total != "45" {
+		t.Errorf("Ожидался X-Total-Count 45, получен %q", total)
+	}
+
+	ids, w = getPage(t, mux, "/tasks")
+	if len(ids) != 45 {
+		t.Errorf("Ожидались все 45 задач, получено %d", len(ids))
+	}
+	if w.Header().Get("X-Total-Count") != "" || w.Header().Get("Link") != "" {
+		t.Errorf("Заголовки страницы в ответе без пагинации")
+	}
+}
+
+// TestPaginationLinks проверяет заголовок Link со ссылками на соседние страницы
+func TestPaginationLinks(t *testing.T) {
+	mux := newPaginationMux(t, 45)
+
+	tests := []struct {
+		name  string
+		path  string
+		links []string
+	}{
+		{"первая", "/tasks?limit=20", []string{
+			`</tasks?limit=20&page=1>; rel="first"`,
+			`</tasks?limit=20&page=2>; rel="next"`,
+			`</tasks?limit=20&page=3>; rel="last"`,
+		}},
+		{"средняя", "/tasks?page=2&limit=20", []string{
+			`</tasks?limit=20&page=1>; rel="first"`,
+			`</tasks?limit=20&page=1>; rel="prev"`,
+			`</tasks?limit=20&page=3>; rel="next"`,
+			`</tasks?limit=20&page=3>; rel="last"`,
+		}},
+		{"за пределами списка", "/tasks?page=9&limit=20", []string{
+			`</tasks?limit=20&page=1>; rel="first"`,
+			`</tasks?limit=20&page=3>; rel="prev"`,
+			`</tasks?limit=20&page=3>; rel="last"`,
+		}},
+		{"с фильтром", "/tasks?page=1&limit=50&has_link=false", []string{
+			`</tasks?has_link=false&limit=50&page=1>; rel="first"`,
+			`</tasks?has_link=false&limit=50&page=1>; rel="last"`,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, w := getPage(t, mux, tt.path)
+			if link := w.Header().Get("Link"); link != strings.Join(tt.links, ", ") {
+				t.Errorf("Неверный заголовок Link:\n%s\nожидался:\n%s", link, strings.Join(tt.links, ", "))
+			}
+		})
+	}
+}
+
+// TestPaginationSortedAndFiltered проверяет страницы отсортированного и отфильтрованного списка
+//
+// Проверяет:
+// - Страница берется из списка, отсортированного по названию
+// - X-Total-Count учитывает фильтр has_link
+func TestPaginationSortedAndFiltered(t *testing.T) {
+	mux := newPaginationMux(t, 12)
+	for _, id := range []int{3, 7} {
+		w := doJSON(t, mux, "POST", fmt.Sprintf("/tasks/%d/links", id), map[string]string{"url": "https://example.com/", "title": "Ссылка"})
+		expectCode(t, w, http.StatusCreated)
+	}
+
+	// По названию с учетом чисел: Задача 1, Задача 2, ..., Задача 12
+	ids, _ := getPage(t, mux, "/tasks?sort=title&page=2&limit=5")
+	if fmt.Sprint(ids) != "[6 7 8 9 10]" {
+		t.Errorf("Ожидались задачи [6 7 8 9 10], получены %v", ids)
+	}
+
+	ids, w := getPage(t, mux, "/tasks?has_link=true&page=1&limit=1")
+	if fmt.Sprint(ids) != "[3]" {
+		t.Errorf("Ожидалась задача [3], получены %v", ids)
+	}
+	if total := w.Header().Get("X-Total-Count"); total != "2" {
+		t.Errorf("Ожидался X-Total-Count 2, получен %q", total)
+	}
+}
+
+// TestPaginationInvalidParams проверяет код 400 на неверные page и limit
+func TestPaginationInvalidParams(t *testing.T) {
+	mux := newPaginationMux(t, 1)
+
+	paths := []string{
+		"/tasks?page=0",
+		"/tasks?page=-1",
+		"/tasks?page=first",
+		"/tasks?limit=0",
+		"/tasks?limit=ten",
+		fmt.Sprintf("/tasks?limit=%d", handlers.MaxPageLimit+1),
+	}
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			expectCode(t, doJSON(t, mux, "GET", path, nil), http.StatusBadRequest)
+		})
+	}
+
+	// Граница допустима, а огромный номер страницы дает пустую страницу
+	getPage(t, mux, fmt.Sprintf("/tasks?limit=%d", handlers.MaxPageLimit))
+	if ids, _ := getPage(t, mux, "/tasks?page=9223372036854775807&limit=100"); len(ids) != 0 {
+		t.Errorf("Ожидалась пустая страница, получены %v", ids)
+	}
+}
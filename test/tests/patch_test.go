@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test/handlers"
+	"test/models"
+	"test/schema"
+	"test/storage"
+	"testing"
+)
+
+// patchTask отправляет PATCH /tasks/{id} с телом как есть
+func patchTask(t *testing.T, mux http.Handler, path, body string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest(http.MethodPatch, path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+// TestPatchTask проверяет частичное обновление задачи
+//
+// Проверяет:
+// - Отсутствующие поля не меняются, в том числе completed
+// - Явно переданное completed=false возобновляет задачу
+// - Пустое тело ничего не меняет
+// - Ссылки и защита задачи не затрагиваются
+func TestPatchTask(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+	mux := handlers.SetupHandlers(taskStorage)
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{
+		Title:       "Задача",
+		Description: "Описание",
+		Links:       []models.Link{{URL: "https://example.com/"}},
+		Protected:   true,
+	})
+
+	steps := []struct {
+		body     string
+		expected models.Task
+	}{
+		{`{"completed": true}`, models.Task{Title: "Задача", Description: "Описание", Completed: true}},
+		{`{"title": "Новое название"}`, models.Task{Title: "Новое название", Description: "Описание", Completed: true}},
+		{`{}`, models.Task{Title: "Новое название", Description: "Описание", Completed: true}},
+		{`{"completed": false, "description": ""}`, models.Task{Title: "Новое название", Description: "", Completed: false}},
+	}
+	for _, step := range steps {
+		w := patchTask(t, mux, "/tasks/1", step.body)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: ожидался код %d, получен %d: %s", step.body, http.StatusOK, w.Code, w.Body.String())
+		}
+		var task models.Task
+		if err := json.Unmarshal(w.Body.Bytes(), &task); err != nil {
+			t.Fatal(err)
+		}
+		if task.Title != step.expected.Title || task.Description != step.expected.Description || task.Completed != step.expected.Completed {
+			t.Errorf("%s: неверная задача %+v", step.body, task)
+		}
+		if len(task.Links) != 1 || !task.Protected {
+			t.Errorf("%s: изменились ссылки или защита: %+v", step.body, task)
+		}
+	}
+}
+
+// TestPatchTaskInvalid проверяет отклонение некорректных тел PATCH
+//
+// Проверяет:
+// - Неверный тип значения, null и тело не-объект дают 400
+// - Переданные поля проверяются правилами схемы: пустой title дает 400 со списком ошибок полей
+// - Неизвестное значение priority дает 400 с кодом invalid_value
+// - Неизвестные поля игнорируются, в строгом режиме - отклоняются
+func TestPatchTaskInvalid(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+	mux := handlers.SetupHandlers(taskStorage)
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Задача", Description: "Описание"})
+
+	for _, body := range []string{`{"completed": "yes"}`, `{"title": null}`, `[]`, `null`, `{"title": 1}`} {
+		if w := patchTask(t, mux, "/tasks/1", body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: ожидался код %d, получен %d", body, http.StatusBadRequest, w.Code)
+		}
+	}
+
+	w := patchTask(t, mux, "/tasks/1", `{"title": "", "completed": true}`)
+	var response struct {
+		Errors []schema.FieldError `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusBadRequest || len(response.Errors) != 1 || response.Errors[0].Field != "title" {
+		t.Errorf("Пустой title: ожидался код %d с ошибкой поля title, получен %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+
+	w = patchTask(t, mux, "/tasks/1", `{"priority": "urgent"}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"code":"invalid_value"`) {
+		t.Errorf("Неизвестный priority: ожидался код %d с invalid_value, получен %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+
+	task, _ := taskStorage.GetTask(t.Context(), 1)
+	if task.Title != "Задача" || task.Completed {
+		t.Errorf("Задача изменилась: %+v", task)
+	}
+
+	// Неизвестные поля игнорируются, в строгом режиме - отклоняются
+	if w := patchTask(t, mux, "/tasks/1", `{"color": 1}`); w.Code != http.StatusOK {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusOK, w.Code)
+	}
+	strict := handlers.SetupHandlersWithConfig(taskStorage, handlers.Config{StrictSchema: true})
+	if w := patchTask(t, strict, "/tasks/1", `{"color": 1}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusBadRequest, w.Code)
+	}
+
+	// Хранилище проверяет типы значений само
+	if _, err := taskStorage.PatchTask(1, map[string]interface{}{"completed": "true"}); !errors.Is(err, storage.ErrInvalidPatch) {
+		t.Errorf("Ожидалась ошибка ErrInvalidPatch, получено %v", err)
+	}
+	if _, err := taskStorage.PatchTask(1, map[string]interface{}{"id": 5}); !errors.Is(err, storage.ErrInvalidPatch) {
+		t.Errorf("Ожидалась ошибка ErrInvalidPatch, получено %v", err)
+	}
+}
+
+// TestPatchCompletedImmutable проверяет политику выполненных задач для PATCH
+func TestPatchCompletedImmutable(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage(storage.WithCompletedImmutable())
+	mux := handlers.SetupHandlers(taskStorage)
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Задача", Description: "Описание"})
+
+	if w := patchTask(t, mux, "/tasks/1", `{"completed": true}`); w.Code != http.StatusOK {
+		t.Fatalf("Ожидался код %d, получен %d", http.StatusOK, w.Code)
+	}
+	if w := patchTask(t, mux, "/tasks/1", `{"title": "Другое"}`); w.Code != http.StatusConflict {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusConflict, w.Code)
+	}
+	if w := patchTask(t, mux, "/tasks/1", `{"completed": false}`); w.Code != http.StatusOK {
+		t.Errorf("Возобновление: ожидался код %d, получен %d", http.StatusOK, w.Code)
+	}
+}
+
+// TestPatchTaskLinksAndTags проверяет замену ссылок и тегов через PATCH
+//
+// Проверяет:
+// - links и tags заменяются целиком, остальные поля не меняются
+// - Ссылки и теги проверяются так же, как в PUT: недопустимая схема, дубликат и неверный тег дают 400
+// - Пустые списки удаляют ссылки и теги
+func TestPatchTaskLinksAndTags(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+	mux := handlers.SetupHandlers(taskStorage)
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{
+		Title:       "Задача",
+		Description: "Описание",
+		Links:       []models.Link{{URL: "https://example.com/old"}},
+		Tags:        []string{"old"},
+	})
+
+	w := patchTask(t, mux, "/tasks/1", `{"links": [{"url": "HTTPS://Example.com/new"}], "tags": ["backend", "ui"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался код %d, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	task, _ := taskStorage.GetTask(t.Context(), 1)
+	if len(task.Links) != 1 || task.Links[0].URL != "https://example.com/new" || strings.Join(task.Tags, ",") != "backend,ui" || task.Title != "Задача" {
+		t.Errorf("Неверная задача после PATCH: %+v", task)
+	}
+
+	for _, body := range []string{
+		`{"links": [{"url": "javascript:alert(1)"}]}`,
+		`{"links": [{"url": "https://example.com/a"}, {"url": "https://example.com/a"}]}`,
+		`{"tags": ["не тег!"]}`,
+		`{"links": null}`,
+	} {
+		if w := patchTask(t, mux, "/tasks/1", body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: ожидался код %d, получен %d", body, http.StatusBadRequest, w.Code)
+		}
+	}
+
+	if w := patchTask(t, mux, "/tasks/1", `{"links": [], "tags": []}`); w.Code != http.StatusOK {
+		t.Fatalf("Ожидался код %d, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	task, _ = taskStorage.GetTask(t.Context(), 1)
+	if len(task.Links) != 0 || len(task.Tags) != 0 {
+		t.Errorf("Ожидалось удаление ссылок и тегов: %+v", task)
+	}
+}
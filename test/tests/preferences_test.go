@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"test/handlers"
+	"test/models"
+	"test/storage"
+	"testing"
+)
+
+// getPreferences возвращает настройки GET /preferences
+func getPreferences(t *testing.T, mux http.Handler) models.Preferences {
+	t.Helper()
+	w := doJSON(t, mux, "GET", "/preferences", nil)
+	expectCode(t, w, http.StatusOK)
+	var preferences models.Preferences
+	if err := json.Unmarshal(w.Body.Bytes(), &preferences); err != nil {
+		t.Fatal(err)
+	}
+	return preferences
+}
+
+// TestPreferences проверяет настройки по умолчанию GET и PUT /preferences
+//
+// Проверяет:
+// - Сохраненные настройки возвращаются GET /preferences, теги нормализуются, пустое тело сбрасывает их
+// - Задача без priority и tags получает значения по умолчанию в POST /tasks, /tasks/bulk, /tasks/quick и из формы
+// - Явные priority и tags, в том числе пустой список тегов, не заменяются
+// - Часовой пояс по умолчанию применяется к ответам, ?tz его переопределяет
+func TestPreferences(t *testing.T) {
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage())
+	if preferences := getPreferences(t, mux); fmt.Sprint(preferences) != "{ [] }" {
+		t.Errorf("Ожидались пустые настройки, получено %+v", preferences)
+	}
+
+	expectCode(t, putRaw(t, mux, "/preferences", []byte(`{"default_priority": "high", "default_tags": ["работа", " работа", "отчеты"], "timezone": "Asia/Bangkok"}`)), http.StatusOK)
+	if preferences := getPreferences(t, mux); fmt.Sprint(preferences) != "{high [работа отчеты] Asia/Bangkok}" {
+		t.Errorf("Неверные настройки: %+v", preferences)
+	}
+
+	expectCode(t, postTask(t, mux, map[string]string{"title": "По умолчанию", "description": "Описание"}), http.StatusCreated)
+	expectCode(t, postTask(t, mux, map[string]interface{}{"title": "Явная", "description": "Описание", "priority": "low", "tags": []string{}}), http.StatusCreated)
+	expectCode(t, postTask(t, mux, map[string]interface{}{"title": "Свои теги", "description": "Описание", "tags": []string{"дом"}}), http.StatusCreated)
+	expectCode(t, doJSON(t, mux, "POST", "/tasks/bulk", []map[string]string{{"title": "Пакет", "description": "Описание"}}), http.StatusCreated)
+	expectCode(t, postQuick(t, mux, "text/plain", "Быстрая #срочно"), http.StatusCreated)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, newFormRequest(t, "POST", "/tasks", url.Values{"title": {"Форма"}, "description": {"Описание"}}))
+	expectCode(t, w, http.StatusSeeOther)
+
+	expected := []string{
+		"high [работа отчеты]",
+		"low []",
+		"high [дом]",
+		"high [работа отчеты]",
+		"high [срочно]",
+		"high [работа отчеты]",
+	}
+	for i, want := range expected {
+		task := decodeTask(t, doJSON(t, mux, "GET", fmt.Sprintf("/tasks/%d", i+1), nil))
+		if got := fmt.Sprintf("%s %v", task.Priority, task.Tags); got != want {
+			t.Errorf("Задача %d: ожидалось %s, получено %s", i+1, want, got)
+		}
+	}
+
+	w = doJSON(t, mux, "GET", "/tasks/1", nil)
+	if !strings.Contains(w.Body.String(), "+07:00") {
+		t.Errorf("Ожидались метки времени в Asia/Bangkok: %s", w.Body.String())
+	}
+	if w := doJSON(t, mux, "GET", "/tasks/1?tz=UTC", nil); strings.Contains(w.Body.String(), "+07:00") {
+		t.Errorf("Параметр tz должен переопределять настройку: %s", w.Body.String())
+	}
+
+	expectCode(t, putRaw(t, mux, "/preferences", []byte(`{}`)), http.StatusOK)
+	if preferences := getPreferences(t, mux); fmt.Sprint(preferences) != "{ [] }" {
+		t.Errorf("Ожидались сброшенные настройки, получено %+v", preferences)
+	}
+}
+
+// TestPreferencesRejected проверяет отклонение неверных настроек
+//
+// Проверяет:
+// - Код 400 на неизвестный приоритет, неверный тег, неизвестный часовой пояс и неизвестное поле
+// - Отклоненный запрос не меняет сохраненные настройки
+// - Код 405 на POST /preferences
+// - Код 501 для хранилища без настроек
+func TestPreferencesRejected(t *testing.T) {
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage())
+	expectCode(t, putRaw(t, mux, "/preferences", []byte(`{"default_priority": "low"}`)), http.StatusOK)
+
+	for _, body := range []string{
+		`{"default_priority": "urgent"}`,
+		`{"default_tags": ["два слова"]}`,
+		`{"timezone": "Mars/Olympus"}`,
+		`{"timezone": "Local"}`,
+		`{"default_project_id": 1}`,
+		`[]`,
+	} {
+		if w := putRaw(t, mux, "/preferences", []byte(body)); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: ожидался код %d, получен %d", body, http.StatusBadRequest, w.Code)
+		}
+	}
+	if preferences := getPreferences(t, mux); preferences.DefaultPriority != "low" {
+		t.Errorf("Отклоненный запрос изменил настройки: %+v", preferences)
+	}
+	expectCode(t, doJSON(t, mux, "POST", "/preferences", nil), http.StatusMethodNotAllowed)
+
+	expectCode(t, doJSON(t, handlers.SetupHandlers(newSQLiteStorage(t)), "GET", "/preferences", nil), http.StatusNotImplemented)
+}
@@ -0,0 +1,183 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"test/handlers"
+	"test/models"
+	"test/schema"
+	"test/storage"
+	"testing"
+)
+
+// expectInvalidPriority проверяет ответ 400 с кодом invalid_value для поля priority
+func expectInvalidPriority(t *testing.T, body []byte) {
+	t.Helper()
+	var resp enumErrorResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code != schema.CodeInvalidValue || len(resp.Errors) != 1 || resp.Errors[0].Field != "priority" {
+		t.Fatalf("Неверный ответ: %+v", resp)
+	}
+	if !reflect.DeepEqual(resp.Errors[0].Allowed, models.Priorities) {
+		t.Errorf("Ожидались допустимые значения %v, получены %v", models.Priorities, resp.Errors[0].Allowed)
+	}
+}
+
+// unindexedStorage - хранилище в памяти без индекса по приоритету
+//
+// Поле TasksByPriority скрывает одноименный метод встроенного хранилища,
+// поэтому оно не реализует storage.PriorityStorage.
+type unindexedStorage struct {
+	*storage.InMemoryStorage
+	TasksByPriority struct{}
+}
+
+// priorityIDs возвращает ID задач из списка GET path
+func priorityIDs(t *testing.T, mux http.Handler, path string) string {
+	t.Helper()
+	ids, _ := getPage(t, mux, path)
+	return fmt.Sprint(ids)
+}
+
+// TestTaskPriority проверяет задание и изменение приоритета задачи
+//
+// Проверяет:
+// - Создание с приоритетом и без него
+// - Код 400 и invalid_value на неизвестный приоритет при создании, PUT и PATCH
+// - PUT без priority сохраняет приоритет, пустая строка снимает его
+// - PATCH меняет приоритет
+func TestTaskPriority(t *testing.T) {
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage())
+
+	w := postTask(t, mux, map[string]string{"title": "Срочная", "description": "Описание", "priority": "critical"})
+	expectCode(t, w, http.StatusCreated)
+	if task := decodeTask(t, w); task.Priority != models.PriorityCritical {
+		t.Errorf("Ожидался приоритет critical, получен %q", task.Priority)
+	}
+
+	w = postTask(t, mux, map[string]string{"title": "Обычная", "description": "Описание"})
+	expectCode(t, w, http.StatusCreated)
+	if strings.Contains(w.Body.String(), "priority") {
+		t.Errorf("Пустой приоритет в ответе: %s", w.Body.String())
+	}
+
+	w = postTask(t, mux, map[string]string{"title": "Задача", "description": "Описание", "priority": "urgent"})
+	expectCode(t, w, http.StatusBadRequest)
+	expectInvalidPriority(t, w.Body.Bytes())
+
+	w = doJSON(t, mux, "PUT", "/tasks/1", map[string]string{"title": "Срочная", "description": "Описание"})
+	expectCode(t, w, http.StatusOK)
+	if task := decodeTask(t, w); task.Priority != models.PriorityCritical {
+		t.Errorf("PUT без priority изменил приоритет: %q", task.Priority)
+	}
+
+	w = doJSON(t, mux, "PUT", "/tasks/1", map[string]string{"title": "Срочная", "description": "Описание", "priority": "HIGH"})
+	expectCode(t, w, http.StatusBadRequest)
+	expectInvalidPriority(t, w.Body.Bytes())
+
+	w = patchTask(t, mux, "/tasks/1", `{"priority": "low"}`)
+	expectCode(t, w, http.StatusOK)
+	if task := decodeTask(t, w); task.Priority != models.PriorityLow {
+		t.Errorf("Ожидался приоритет low, получен %q", task.Priority)
+	}
+
+	w = patchTask(t, mux, "/tasks/1", `{"priority": "urgent"}`)
+	expectCode(t, w, http.StatusBadRequest)
+	expectInvalidPriority(t, w.Body.Bytes())
+
+	w = doJSON(t, mux, "PUT", "/tasks/1", map[string]string{"title": "Срочная", "description": "Описание", "priority": ""})
+	expectCode(t, w, http.StatusOK)
+	if task := decodeTask(t, w); task.Priority != "" {
+		t.Errorf("Пустая строка не сняла приоритет: %q", task.Priority)
+	}
+}
+
+// TestTaskPriorityFilter проверяет фильтр ?priority= списка задач
+//
+// Проверяет:
+// - Фильтр по индексу хранилища и обходом задач хранилища без индекса
+// - Изменение приоритета и удаление задачи обновляют индекс
+// - Сочетание с has_link и страницами
+// - Код 400 и invalid_value на неизвестный приоритет
+func TestTaskPriorityFilter(t *testing.T) {
+	backends := map[string]storage.Storage{
+		"с индексом":  storage.NewInMemoryStorage(),
+		"без индекса": &unindexedStorage{InMemoryStorage: storage.NewInMemoryStorage()},
+	}
+
+	for name, taskStorage := range backends {
+		t.Run(name, func(t *testing.T) {
+			mux := handlers.SetupHandlers(taskStorage)
+			for i, priority := range []string{"high", "low", "high", "", "high"} {
+				w := postTask(t, mux, map[string]string{"title": fmt.Sprintf("Задача %d", i+1), "description": "Описание", "priority": priority})
+				expectCode(t, w, http.StatusCreated)
+			}
+
+			if got := priorityIDs(t, mux, "/tasks?priority=high&sort=title"); got != "[1 3 5]" {
+				t.Errorf("Ожидались задачи [1 3 5], получены %s", got)
+			}
+
+			expectCode(t, patchTask(t, mux, "/tasks/3", `{"priority": "medium"}`), http.StatusOK)
+			expectCode(t, doJSON(t, mux, "DELETE", "/tasks/5", nil), http.StatusNoContent)
+			expectCode(t, patchTask(t, mux, "/tasks/4", `{"priority": "high"}`), http.StatusOK)
+			if got := priorityIDs(t, mux, "/tasks?priority=high&sort=title"); got != "[1 4]" {
+				t.Errorf("Ожидались задачи [1 4], получены %s", got)
+			}
+			if got := priorityIDs(t, mux, "/tasks?priority=medium"); got != "[3]" {
+				t.Errorf("Ожидалась задача [3], получены %s", got)
+			}
+
+			ids, w := getPage(t, mux, "/tasks?priority=high&page=2&limit=1")
+			if fmt.Sprint(ids) != "[4]" || w.Header().Get("X-Total-Count") != "2" {
+				t.Errorf("Неверная страница: %v, X-Total-Count %q", ids, w.Header().Get("X-Total-Count"))
+			}
+
+			expectCode(t, doJSON(t, mux, "POST", "/tasks/4/links", map[string]string{"url": "https://example.com/", "title": "Ссылка"}), http.StatusCreated)
+			if got := priorityIDs(t, mux, "/tasks?priority=high&has_link=true"); got != "[4]" {
+				t.Errorf("Ожидалась задача [4], получены %s", got)
+			}
+
+			w = doJSON(t, mux, "GET", "/tasks?priority=urgent", nil)
+			expectCode(t, w, http.StatusBadRequest)
+			expectInvalidPriority(t, w.Body.Bytes())
+		})
+	}
+}
+
+// TestTaskPrioritySchema проверяет, что схемы запросов перечисляют models.Priorities
+//
+// Проверяет:
+// - enum поля priority в схемах запросов и default_priority в настройках совпадает с models.Priorities
+// - Новый приоритет в models.Priorities сразу принимается проверкой запросов
+func TestTaskPrioritySchema(t *testing.T) {
+	requests := map[string]interface{}{
+		"create": handlers.CreateTaskRequest{},
+		"update": handlers.UpdateTaskRequest{},
+		"patch":  handlers.PatchTaskRequest{},
+	}
+	for name, request := range requests {
+		s := schema.Generate("/schemas/"+name, name, request)
+		priority := s["properties"].(map[string]interface{})["priority"].(map[string]interface{})
+		if !reflect.DeepEqual(priority["enum"], models.Priorities) {
+			t.Errorf("%s: ожидалось enum %v, получено %v", name, models.Priorities, priority["enum"])
+		}
+	}
+
+	s := schema.Generate("/schemas/preferences", "preferences", handlers.PreferencesRequest{})
+	if priority := s["properties"].(map[string]interface{})["default_priority"].(map[string]interface{}); !reflect.DeepEqual(priority["enum"], models.Priorities) {
+		t.Errorf("preferences: ожидалось enum %v, получено %v", models.Priorities, priority["enum"])
+	}
+
+	priorities := models.Priorities
+	defer func() { models.Priorities = priorities }()
+	models.Priorities = append(slices.Clone(priorities), "urgent")
+	if err := schema.Validate(handlers.CreateTaskRequest{Title: "Задача", Description: "Описание", Priority: "urgent"}); err != nil {
+		t.Errorf("Новый приоритет отклонен: %v", err)
+	}
+}
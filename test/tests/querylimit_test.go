@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test/handlers"
+	"test/handlers/middleware"
+	"test/models"
+	"testing"
+)
+
+// TestQueryLimit проверяет ограничения строки запроса middleware.NewQueryLimit
+//
+// Проверяет:
+// - Строка запроса ровно на пределе длины проходит, на байт длиннее - 414 с кодом query_too_long
+// - Ровно максимальное число параметров проходит, на один больше - 400 с кодом too_many_parameters
+// - Ровно максимальное число значений параметра проходит, на одно больше - 400 с too_many_values
+// - Тело ошибки называет превышенное ограничение и его значение, обработчик не вызывается
+func TestQueryLimit(t *testing.T) {
+	var called bool
+	limited := middleware.NewQueryLimit(
+		middleware.WithMaxQueryBytes(64),
+		middleware.WithMaxQueryParams(3),
+		middleware.WithMaxParamValues(4),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	get := func(query string) *httptest.ResponseRecorder {
+		called = false
+		w := httptest.NewRecorder()
+		limited.ServeHTTP(w, httptest.NewRequest("GET", "/tasks?"+query, nil))
+		return w
+	}
+	expectLimit := func(query string, status int, code, limit string, max int, param string) {
+		t.Helper()
+		w := get(query)
+		expectCode(t, w, status)
+		var body middleware.QueryLimitError
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Неверное тело ответа: %s", w.Body.String())
+		}
+		if body.Code != code || body.Status != status || body.Limit != limit || body.Max != max || body.Param != param {
+			t.Errorf("Неверное тело ответа: %s", w.Body.String())
+		}
+		if called {
+			t.Errorf("Обработчик вызван для строки запроса %q", query)
+		}
+	}
+	expectPass := func(query string) {
+		t.Helper()
+		expectCode(t, get(query), http.StatusOK)
+		if !called {
+			t.Errorf("Обработчик не вызван для строки запроса %q", query)
+		}
+	}
+
+	long := "q=" + strings.Repeat("a", 62)
+	expectPass(long)
+	expectLimit(long+"a", http.StatusRequestURITooLong, middleware.CodeQueryTooLong, "max_query_bytes", 64, "")
+
+	expectPass("a=1&b=2&c=3")
+	expectLimit("a=1&b=2&c=3&d=4", http.StatusBadRequest, middleware.CodeTooManyParameters, "max_query_params", 3, "")
+
+	expectPass("tag=a&tag=b&tag=c&tag=d")
+	expectLimit("tag=a&tag=b&tag=c&tag=d&tag=e", http.StatusBadRequest, middleware.CodeTooManyValues, "max_param_values", 4, "tag")
+
+	// Экранированное имя считается тем же параметром
+	expectLimit("tag=a&tag=b&t%61g=c&tag=d&%74ag=e", http.StatusBadRequest, middleware.CodeTooManyValues, "max_param_values", 4, "tag")
+}
+
+// TestQueryLimitDefaults проверяет ограничения NewQueryLimit по умолчанию
+//
+// Проверяет:
+// - Строка запроса длиной DefaultMaxQueryBytes проходит, на байт длиннее - 414
+// - DefaultMaxQueryParams параметров проходят, на один больше - 400
+func TestQueryLimitDefaults(t *testing.T) {
+	limited := middleware.NewQueryLimit()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		limited.ServeHTTP(w, httptest.NewRequest("GET", "/tasks?"+query, nil))
+		return w
+	}
+
+	long := "q=" + strings.Repeat("a", middleware.DefaultMaxQueryBytes-2)
+	expectCode(t, get(long), http.StatusOK)
+	expectCode(t, get(long+"a"), http.StatusRequestURITooLong)
+
+	params := make([]string, middleware.DefaultMaxQueryParams+1)
+	for i := range params {
+		params[i] = fmt.Sprintf("p%d=1", i)
+	}
+	expectCode(t, get(strings.Join(params[:middleware.DefaultMaxQueryParams], "&")), http.StatusOK)
+	expectCode(t, get(strings.Join(params, "&")), http.StatusBadRequest)
+}
+
+// TestTagFilterLimit проверяет ограничение числа значений фильтра tag в GET /tasks
+//
+// Проверяет:
+// - models.MaxTags значений tag принимаются
+// - models.MaxTags+1 значений tag отклоняются с 400
+func TestTagFilterLimit(t *testing.T) {
+	mux := handlers.SetupHandlers(newMemoryStorage(t))
+	tags := make([]string, models.MaxTags+1)
+	for i := range tags {
+		tags[i] = fmt.Sprintf("tag=t%d", i)
+	}
+
+	expectCode(t, doJSON(t, mux, "GET", "/tasks?"+strings.Join(tags[:models.MaxTags], "&"), nil), http.StatusOK)
+	expectCode(t, doJSON(t, mux, "GET", "/tasks?"+strings.Join(tags, "&"), nil), http.StatusBadRequest)
+}
@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"test/handlers"
+	"test/models"
+	"test/storage"
+	"testing"
+)
+
+// postQuick отправляет строки задач в POST /tasks/quick
+func postQuick(t *testing.T, mux http.Handler, contentType, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/tasks/quick", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+// quickTask - задача из ответа POST /tasks/quick
+type quickTask struct {
+	models.Task
+	Warnings []struct {
+		Field string `json:"field"`
+		Rule  string `json:"rule"`
+	} `json:"warnings"`
+}
+
+// quickLineErrors - тело ответа на строки, не прошедшие валидацию
+type quickLineErrors struct {
+	Error  string `json:"error"`
+	Code   string `json:"code"`
+	Errors []struct {
+		Line  int    `json:"line"`
+		Field string `json:"field"`
+		Rule  string `json:"rule"`
+	} `json:"errors"`
+}
+
+// TestParseQuickLine проверяет разбор строки быстрого добавления
+func TestParseQuickLine(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected models.QuickLine
+	}{
+		{"Купить молоко", models.QuickLine{Title: "Купить молоко"}},
+		{"  Купить   молоко  ", models.QuickLine{Title: "Купить   молоко"}},
+		{"Купить молоко #дом #магазин", models.QuickLine{Title: "Купить молоко", Tags: []string{"дом", "магазин"}}},
+		{"! Позвонить в банк", models.QuickLine{Title: "Позвонить в банк", HighPriority: true}},
+		{"!Позвонить #срочно", models.QuickLine{Title: "Позвонить", Tags: []string{"срочно"}, HighPriority: true}},
+		{"Исправить #12 в отчете", models.QuickLine{Title: "Исправить #12 в отчете"}},
+		{"Отчет #work #work #q-3_x", models.QuickLine{Title: "Отчет", Tags: []string{"work", "q-3_x"}}},
+		{"Цена # и #!", models.QuickLine{Title: "Цена # и #!"}},
+		{"Отчет\t#work", models.QuickLine{Title: "Отчет", Tags: []string{"work"}}},
+		{"#только #теги", models.QuickLine{Tags: []string{"только", "теги"}}},
+		{"!", models.QuickLine{HighPriority: true}},
+		{"Восклицание!", models.QuickLine{Title: "Восклицание!"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			if parsed := models.ParseQuickLine(tt.line); !reflect.DeepEqual(parsed, tt.expected) {
+				t.Errorf("Ожидалось %+v, получено %+v", tt.expected, parsed)
+			}
+		})
+	}
+}
+
+// TestQuickAdd проверяет создание задач из строк текста
+//
+// Проверяет:
+// - Код 201 и задачи в порядке строк с пустым описанием
+// - Пропуск пустых строк и строк из пробелов
+// - Метки #тег сохраняются тегами задачи без предупреждений
+// - Приоритет high у строки с "!"
+// - Задачи сохранены в хранилище
+func TestQuickAdd(t *testing.T) {
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage())
+
+	body := "Купить молоко #дом\r\n\n   \n! Позвонить в банк\nНаписать отчет"
+	w := postQuick(t, mux, "text/plain; charset=utf-8", body)
+	expectCode(t, w, http.StatusCreated)
+
+	var tasks []quickTask
+	if err := json.Unmarshal(w.Body.Bytes(), &tasks); err != nil {
+		t.Fatal(err)
+	}
+	titles := []string{"Купить молоко", "Позвонить в банк", "Написать отчет"}
+	if len(tasks) != len(titles) {
+		t.Fatalf("Ожидалось %d задач, получено %d", len(titles), len(tasks))
+	}
+	for i, task := range tasks {
+		if task.ID != i+1 || task.Title != titles[i] || task.Description != "" {
+			t.Errorf("Неверная задача %d: %+v", i, task.Task)
+		}
+	}
+
+	if !slices.Equal(tasks[0].Tags, []string{"дом"}) || len(tasks[1].Tags) != 0 {
+		t.Errorf("Ожидался тег дом только у задачи 1: %v, %v", tasks[0].Tags, tasks[1].Tags)
+	}
+	if tasks[1].Priority != models.PriorityHigh || tasks[0].Priority != "" || tasks[2].Priority != "" {
+		t.Errorf("Ожидался приоритет high только у задачи 2: %q, %q, %q", tasks[0].Priority, tasks[1].Priority, tasks[2].Priority)
+	}
+	for _, task := range tasks {
+		if len(task.Warnings) != 0 {
+			t.Errorf("Неожиданные предупреждения задачи %d: %+v", task.ID, task.Warnings)
+		}
+	}
+
+	if got := listTitles(t, mux, "/tasks?sort=title", ""); len(got) != 3 {
+		t.Errorf("Ожидалось 3 задачи в хранилище, получено %v", got)
+	}
+}
+
+// TestQuickAddInvalidLine проверяет отклонение запроса со строкой, не прошедшей валидацию
+//
+// Проверяет:
+// - Код 400 и code invalid_lines с номерами строк без меток
+// - Ни одна задача не создана
+// - Код 422 при нарушении строгого правила с номером строки
+func TestQuickAddInvalidLine(t *testing.T) {
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage())
+
+	w := postQuick(t, mux, "text/plain", "Купить молоко\n\n#только #теги\n!")
+	expectCode(t, w, http.StatusBadRequest)
+
+	var resp quickLineErrors
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code != handlers.CodeInvalidLines || len(resp.Errors) != 2 {
+		t.Fatalf("Неверный ответ: %+v", resp)
+	}
+	if resp.Errors[0].Line != 3 || resp.Errors[1].Line != 4 || resp.Errors[0].Field != "title" || resp.Errors[0].Rule != "required" {
+		t.Errorf("Неверные ошибки строк: %+v", resp.Errors)
+	}
+	if !strings.HasPrefix(resp.Error, "строка 3: ") {
+		t.Errorf("Сообщение %q не содержит номер строки", resp.Error)
+	}
+
+	if got := listTitles(t, mux, "/tasks", ""); len(got) != 0 {
+		t.Errorf("Задачи созданы несмотря на ошибку: %v", got)
+	}
+
+	strict := handlers.SetupHandlersWithConfig(storage.NewInMemoryStorage(), handlers.Config{HardRules: []string{handlers.RuleTitleLength}})
+	w = postQuick(t, strict, "text/plain", "Короткая\n"+strings.Repeat("я", handlers.SoftTitleLength+1))
+	expectCode(t, w, http.StatusUnprocessableEntity)
+	resp = quickLineErrors{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code != handlers.CodeValidationFailed || len(resp.Errors) != 1 || resp.Errors[0].Line != 2 {
+		t.Errorf("Неверный ответ: %+v", resp)
+	}
+}
+
+// TestQuickAddLimits проверяет ограничения запроса быстрого добавления
+//
+// Проверяет:
+// - Код 400 сверх QuickAddMaxLines задач; пустые строки не учитываются
+// - Код 400 на тело без задач
+// - Код 415 на тело не text/plain
+func TestQuickAddLimits(t *testing.T) {
+	mux := handlers.SetupHandlersWithConfig(storage.NewInMemoryStorage(), handlers.Config{QuickAddMaxLines: 2})
+
+	expectCode(t, postQuick(t, mux, "text/plain", "Первая\n\n\nВторая\n"), http.StatusCreated)
+	expectCode(t, postQuick(t, mux, "text/plain", "Первая\nВторая\nТретья"), http.StatusBadRequest)
+	expectCode(t, postQuick(t, mux, "text/plain", "\n  \n"), http.StatusBadRequest)
+	expectCode(t, postQuick(t, mux, "application/json", `["Первая"]`), http.StatusUnsupportedMediaType)
+	expectCode(t, doJSON(t, mux, "GET", "/tasks/quick", nil), http.StatusMethodNotAllowed)
+}
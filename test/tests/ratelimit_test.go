@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"runtime/metrics"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"test/handlers"
+	"test/handlers/middleware"
+	"test/storage"
+	"testing"
+	"time"
+)
+
+// requestFrom выполняет GET path с адреса remoteAddr
+func requestFrom(mux http.Handler, path, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	req.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+// TestRateLimiter проверяет ограничение частоты запросов middleware.NewRateLimiter
+//
+// Проверяет:
+// - Адрес может отправить burst запросов подряд, следующий получает 429 с Retry-After и кодом rate_limited
+// - Лимит считается по IP без порта: другой порт того же адреса ограничен, другой адрес - нет
+// - Адрес без запросов дольше WithIdleTTL забывается и снова получает полный запас
+func TestRateLimiter(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage(),
+		middleware.NewRateLimiter(0.1, 2, middleware.WithIdleTTL(50*time.Millisecond), middleware.WithStop(stop)))
+
+	const client, other = "192.0.2.1:1000", "192.0.2.2:1000"
+	expectCode(t, requestFrom(mux, "/tasks", client), http.StatusOK)
+	expectCode(t, requestFrom(mux, "/tasks", client), http.StatusOK)
+
+	w := requestFrom(mux, "/tasks", "192.0.2.1:2000")
+	expectCode(t, w, http.StatusTooManyRequests)
+	if seconds, err := strconv.Atoi(w.Header().Get("Retry-After")); err != nil || seconds < 1 || seconds > 10 {
+		t.Errorf("Неверный Retry-After: %q", w.Header().Get("Retry-After"))
+	}
+	var body handlers.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != middleware.CodeRateLimited {
+		t.Errorf("Неверное тело ответа: %s", w.Body.String())
+	}
+
+	expectCode(t, requestFrom(mux, "/tasks", other), http.StatusOK)
+
+	time.Sleep(200 * time.Millisecond)
+	expectCode(t, requestFrom(mux, "/tasks", client), http.StatusOK)
+	expectCode(t, requestFrom(mux, "/tasks", client), http.StatusOK)
+}
+
+// TestRateLimiterConcurrent проверяет лимит при одновременных запросах с одного адреса
+//
+// Проверяет:
+// - Из 1000 одновременных запросов проходят ровно burst, остальные получают 429
+func TestRateLimiterConcurrent(t *testing.T) {
+	const requests, burst = 1000, 100
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage(), middleware.NewRateLimiter(0.001, burst))
+
+	var passed, limited atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			switch requestFrom(mux, "/capabilities", "192.0.2.1:1000").Code {
+			case http.StatusOK:
+				passed.Add(1)
+			case http.StatusTooManyRequests:
+				limited.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if passed.Load() != burst || limited.Load() != requests-burst {
+		t.Errorf("Ожидалось %d успешных и %d отклоненных запросов, получено %d и %d",
+			burst, requests-burst, passed.Load(), limited.Load())
+	}
+}
+
+// BenchmarkRateLimiter измеряет NewRateLimiter при 1000 одновременных горутин
+//
+// Кроме времени на запрос, сообщается время ожидания мьютексов процесса на
+// запрос (mutex-wait-ns/op): на уже известных адресах таблица лимитов берет
+// блокировку только на чтение, поэтому при 1000 адресах ожидание должно быть
+// близко к нулю. С одного адреса горутины ждут мьютекс его rate.Limiter - это
+// цена общего лимита, а не таблицы. Запросы идут прямо в лимит без обработчиков API.
+func BenchmarkRateLimiter(b *testing.B) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	for _, clients := range []int{1, 1000} {
+		b.Run(fmt.Sprintf("%d адресов", clients), func(b *testing.B) {
+			stop := make(chan struct{})
+			defer close(stop)
+			limited := middleware.NewRateLimiter(1e9, 1e9, middleware.WithStop(stop))(ok)
+			addrs := make([]string, clients)
+			for i := range addrs {
+				addrs[i] = fmt.Sprintf("10.0.%d.%d:1000", i/256, i%256)
+				requestFrom(limited, "/", addrs[i])
+			}
+			var workers atomic.Int32
+
+			b.SetParallelism(max(1000/runtime.GOMAXPROCS(0), 1))
+			b.ReportAllocs()
+			waitBefore := mutexWait()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				addr := addrs[int(workers.Add(1))%clients]
+				req := httptest.NewRequest("GET", "/", nil)
+				req.RemoteAddr = addr
+				w := httptest.NewRecorder()
+				for pb.Next() {
+					limited.ServeHTTP(w, req)
+				}
+			})
+			b.StopTimer()
+			b.ReportMetric(float64(mutexWait()-waitBefore)/float64(b.N), "mutex-wait-ns/op")
+		})
+	}
+}
+
+// mutexWait возвращает суммарное время ожидания мьютексов процесса в наносекундах
+func mutexWait() int64 {
+	sample := []metrics.Sample{{Name: "/sync/mutex/wait/total:seconds"}}
+	metrics.Read(sample)
+	return int64(sample[0].Value.Float64() * 1e9)
+}
@@ -0,0 +1,194 @@
+package tests
+
+import (
+	"encoding/json"
+	"sync"
+	"test/storage"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// newRedisStorage создает хранилище на отдельном встроенном сервере Redis
+func newRedisStorage(t *testing.T, opts ...storage.Option) storage.Storage {
+	return openRedisStorage(t, miniredis.RunT(t), opts...)
+}
+
+// openRedisStorage подключает хранилище к серверу server, закрываемое по завершении теста
+func openRedisStorage(t *testing.T, server *miniredis.Miniredis, opts ...storage.Option) *storage.RedisStorage {
+	t.Helper()
+	taskStorage, err := storage.NewRedisStorage("redis://"+server.Addr(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { taskStorage.Close() })
+	return taskStorage
+}
+
+// TestRedisStorage проверяет хранилище Redis общим набором проверок хранилищ
+func TestRedisStorage(t *testing.T) {
+	testStorageBackend(t, newRedisStorage)
+}
+
+// TestRedisStorageLayout проверяет раскладку задач по ключам Redis
+//
+// Проверяет:
+// - Задача хранится хешем task:{id} с полями в JSON
+// - ID задачи входит в множество tasks, счетчик tasks:next_id увеличивается
+// - Мягкое удаление оставляет хеш с полем deleted_at и ID в множестве
+// - Несуществующая задача возвращает ошибку
+func TestRedisStorageLayout(t *testing.T) {
+	server := miniredis.RunT(t)
+	taskStorage := openRedisStorage(t, server)
+
+	task, err := taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Купить продукты", Description: "Молоко, хлеб"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.ID != 1 {
+		t.Fatalf("Ожидался ID 1, получен %d", task.ID)
+	}
+
+	var title string
+	if err := json.Unmarshal([]byte(server.HGet("task:1", "title")), &title); err != nil {
+		t.Fatal(err)
+	}
+	if title != "Купить продукты" {
+		t.Errorf("Ожидалось название %q в хеше, получено %q", "Купить продукты", title)
+	}
+	if ok, _ := server.SIsMember("tasks", "1"); !ok {
+		t.Errorf("ID 1 отсутствует в множестве tasks")
+	}
+	if next, _ := server.Get("tasks:next_id"); next != "1" {
+		t.Errorf("Ожидался счетчик 1, получен %q", next)
+	}
+
+	if err := taskStorage.DeleteTask(t.Context(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if server.HGet("task:1", "deleted_at") == "" {
+		t.Errorf("Хеш удаленной задачи не содержит deleted_at")
+	}
+	if ok, _ := server.SIsMember("tasks", "1"); !ok {
+		t.Errorf("ID удаленной задачи отсутствует в множестве tasks")
+	}
+
+	if _, err := taskStorage.GetTask(t.Context(), 42); err == nil {
+		t.Errorf("Ожидалась ошибка для несуществующей задачи")
+	}
+}
+
+// TestRedisStorageConcurrentInstances проверяет два экземпляра хранилища с общим Redis
+//
+// Проверяет:
+// - Одновременные создания задач не выдают одинаковых ID
+// - Все созданные задачи видны обоим экземплярам
+// - Одновременные создания с одним клиентским токеном создают одну задачу
+func TestRedisStorageConcurrentInstances(t *testing.T) {
+	server := miniredis.RunT(t)
+	instances := []*storage.RedisStorage{openRedisStorage(t, server), openRedisStorage(t, server)}
+
+	const perInstance = 50
+	var mu sync.Mutex
+	ids := make(map[int]bool)
+	var wg sync.WaitGroup
+	for _, instance := range instances {
+		for i := 0; i < perInstance; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				task, err := instance.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Задача", Description: "Описание"})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				if ids[task.ID] {
+					t.Errorf("ID %d выдан повторно", task.ID)
+				}
+				ids[task.ID] = true
+			}()
+		}
+	}
+	wg.Wait()
+
+	for _, instance := range instances {
+		tasks, err := instance.GetAllTasks(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tasks) != 2*perInstance {
+			t.Errorf("Ожидалось %d задач, получено %d", 2*perInstance, len(tasks))
+		}
+	}
+
+	created := make(chan int, 2*perInstance)
+	for _, instance := range instances {
+		for i := 0; i < perInstance; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				task, _, err := instance.CreateTaskWithToken("token-1", storage.CreateTaskInput{Title: "С токеном", Description: "Описание"})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				created <- task.ID
+			}()
+		}
+	}
+	wg.Wait()
+	close(created)
+
+	first := <-created
+	for id := range created {
+		if id != first {
+			t.Fatalf("Повторы с одним токеном создали задачи %d и %d", first, id)
+		}
+	}
+}
+
+// TestRedisStorageCapacity проверяет вытеснение токенов создания и записей об удалении
+//
+// Проверяет:
+// - Сверх емкости удаляются самые давние токены вместе с ID их задач
+// - Сверх емкости удаляются самые давние записи об удалении
+func TestRedisStorageCapacity(t *testing.T) {
+	server := miniredis.RunT(t)
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	taskStorage := openRedisStorage(t, server,
+		storage.WithClock(clock.Now), storage.WithClientTokenCapacity(2), storage.WithTombstoneCapacity(2))
+
+	for _, token := range []string{"a", "b", "c"} {
+		clock.Advance(time.Second)
+		if _, _, err := taskStorage.CreateTaskWithToken(token, storage.CreateTaskInput{Title: token, Description: "Описание"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	members, err := server.ZMembers("client_tokens:created")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 2 || members[0] != "b" || members[1] != "c" {
+		t.Errorf("Ожидались токены [b c], получены %v", members)
+	}
+	if keys, _ := server.HKeys("client_tokens"); len(keys) != 2 {
+		t.Errorf("Ожидалось 2 ID задач токенов, получено %v", keys)
+	}
+
+	for id := 1; id <= 3; id++ {
+		clock.Advance(time.Second)
+		if err := taskStorage.DeleteTask(t.Context(), id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tombstones, err := server.ZMembers("tombstones")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tombstones) != 2 || tombstones[0] != "2" || tombstones[1] != "3" {
+		t.Errorf("Ожидались записи об удалении [2 3], получены %v", tombstones)
+	}
+}
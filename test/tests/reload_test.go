@@ -0,0 +1,205 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"test/handlers"
+	"test/handlers/middleware"
+	"testing"
+)
+
+// newReloadable создает middleware.Reloadable над обработчиком next с журналом в out
+func newReloadable(t *testing.T, out *bytes.Buffer, config middleware.RuntimeConfig, next http.Handler) (*middleware.Reloadable, http.Handler) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	runtime, err := middleware.NewReloadable(ctx, out, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return runtime, runtime.Middleware(next)
+}
+
+// serve выполняет GET /tasks и возвращает код ответа
+func serve(handler http.Handler) int {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/tasks", nil))
+	return w.Code
+}
+
+// TestReloadLogRequests проверяет включение журнала запросов на ходу
+//
+// Проверяет:
+// - Без журнала запрос не записывается
+// - После Reload с LogRequests следующий запрос записывается
+// - Reload возвращает описание изменений
+func TestReloadLogRequests(t *testing.T) {
+	var out bytes.Buffer
+	runtime, handler := newReloadable(t, &out, middleware.RuntimeConfig{}, http.NotFoundHandler())
+
+	serve(handler)
+	if out.Len() != 0 {
+		t.Errorf("Журнал выключен, но записано: %s", out.String())
+	}
+
+	changes, err := runtime.Reload(middleware.RuntimeConfig{LogRequests: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(changes) != "[log-requests: false -> true]" {
+		t.Errorf("Неверные изменения: %v", changes)
+	}
+	serve(handler)
+	if lines := strings.Count(out.String(), "\n"); lines != 1 {
+		t.Errorf("Ожидалась 1 строка журнала, получено %d: %s", lines, out.String())
+	}
+}
+
+// TestReloadRateLimit проверяет изменение лимита частоты на ходу
+//
+// Проверяет:
+// - Без лимита запросы не ограничиваются
+// - После включения лимита запрос сверх burst получает 429
+// - Снятие лимита снова пропускает запросы
+func TestReloadRateLimit(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	runtime, handler := newReloadable(t, &bytes.Buffer{}, middleware.RuntimeConfig{}, ok)
+
+	for i := 0; i < 5; i++ {
+		if code := serve(handler); code != http.StatusOK {
+			t.Fatalf("Ожидался код %d без лимита, получен %d", http.StatusOK, code)
+		}
+	}
+
+	if _, err := runtime.Reload(middleware.RuntimeConfig{RateLimit: 0.001, RateBurst: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if code := serve(handler); code != http.StatusOK {
+		t.Errorf("Ожидался код %d в пределах burst, получен %d", http.StatusOK, code)
+	}
+	if code := serve(handler); code != http.StatusTooManyRequests {
+		t.Errorf("Ожидался код %d сверх лимита, получен %d", http.StatusTooManyRequests, code)
+	}
+
+	if _, err := runtime.Reload(middleware.RuntimeConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if code := serve(handler); code != http.StatusOK {
+		t.Errorf("Ожидался код %d после снятия лимита, получен %d", http.StatusOK, code)
+	}
+}
+
+// TestReloadInvalid проверяет отказ в применении неверных настроек
+//
+// Проверяет:
+// - Reload с неверным полем возвращает ошибку со всеми найденными ошибками
+// - Действующие настройки и поведение не меняются, даже если часть полей верна
+func TestReloadInvalid(t *testing.T) {
+	var out bytes.Buffer
+	initial := middleware.RuntimeConfig{LogRequests: true}
+	runtime, handler := newReloadable(t, &out, initial, http.NotFoundHandler())
+
+	_, err := runtime.Reload(middleware.RuntimeConfig{RateLimit: -1, CORSMaxAge: -5})
+	if err == nil || !strings.Contains(err.Error(), "rate-limit") || !strings.Contains(err.Error(), "cors-max-age") {
+		t.Errorf("Ожидались ошибки rate-limit и cors-max-age, получено %v", err)
+	}
+	if _, err := runtime.Reload(middleware.RuntimeConfig{RateLimit: 5}); err == nil {
+		t.Error("Ожидалась ошибка rate-burst при rate-limit")
+	}
+	if got := runtime.Config(); fmt.Sprint(got) != fmt.Sprint(initial) {
+		t.Errorf("Настройки изменились после отказа: %+v", got)
+	}
+	serve(handler)
+	if out.Len() == 0 {
+		t.Error("Журнал выключился после отказа")
+	}
+}
+
+// TestReloadInFlight проверяет, что замена настроек не прерывает выполняющиеся запросы
+//
+// Проверяет:
+// - Запрос, начатый до Reload, завершается с кодом 200 и записывается по старым настройкам
+// - Запрос после Reload обрабатывается уже по новым настройкам
+func TestReloadInFlight(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			close(entered)
+			<-release
+		})
+	})
+	var out bytes.Buffer
+	runtime, handler := newReloadable(t, &out, middleware.RuntimeConfig{LogRequests: true}, slow)
+
+	var wg sync.WaitGroup
+	var code int
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		code = serve(handler)
+	}()
+	<-entered
+
+	if _, err := runtime.Reload(middleware.RuntimeConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	wg.Wait()
+
+	if code != http.StatusOK {
+		t.Errorf("Ожидался код %d выполнявшегося запроса, получен %d", http.StatusOK, code)
+	}
+	if lines := strings.Count(out.String(), "\n"); lines != 1 {
+		t.Fatalf("Ожидалась 1 строка журнала выполнявшегося запроса, получено %d", lines)
+	}
+	serve(handler)
+	if lines := strings.Count(out.String(), "\n"); lines != 1 {
+		t.Errorf("Запрос после выключения журнала записан: %s", out.String())
+	}
+}
+
+// TestReloadConfigHandler проверяет POST /admin/config/reload
+//
+// Проверяет:
+// - Успешная перезагрузка отвечает 200 с изменениями и предупреждениями
+// - Ошибка перезагрузки отвечает 422 с кодом invalid_config
+// - Без Config.ReloadConfig маршрут отвечает 501, а возможность config_reload выключена
+// - Метод кроме POST отклоняется кодом 405
+func TestReloadConfigHandler(t *testing.T) {
+	result := handlers.ConfigReload{Changes: []string{"rate-limit: 0 -> 5"}, Warnings: []string{"storage: изменение применится после перезапуска"}}
+	var reloadErr error
+	mux := handlers.SetupHandlersWithConfig(newMemoryStorage(t), handlers.Config{
+		ReloadConfig: func() (handlers.ConfigReload, error) { return result, reloadErr },
+	})
+
+	w := doJSON(t, mux, "POST", "/admin/config/reload", nil)
+	expectCode(t, w, http.StatusOK)
+	var body handlers.ConfigReload
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || fmt.Sprint(body) != fmt.Sprint(result) {
+		t.Errorf("Неверное тело ответа: %s", w.Body.String())
+	}
+
+	reloadErr = errors.New("неверное значение rate-limit")
+	w = doJSON(t, mux, "POST", "/admin/config/reload", nil)
+	expectCode(t, w, http.StatusUnprocessableEntity)
+	var errBody handlers.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &errBody); err != nil || errBody.Code != handlers.CodeInvalidConfig {
+		t.Errorf("Неверное тело ответа: %s", w.Body.String())
+	}
+	expectCode(t, doJSON(t, mux, "GET", "/admin/config/reload", nil), http.StatusMethodNotAllowed)
+
+	mux = handlers.SetupHandlers(newMemoryStorage(t))
+	expectCode(t, doJSON(t, mux, "POST", "/admin/config/reload", nil), http.StatusNotImplemented)
+	var capabilities map[string]interface{}
+	if err := json.Unmarshal(doJSON(t, mux, "GET", "/capabilities", nil).Body.Bytes(), &capabilities); err != nil || capabilities["config_reload"] != false {
+		t.Errorf("Возможность config_reload: ожидалось false, получено %v", capabilities["config_reload"])
+	}
+}
@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"test/handlers"
+	"test/models"
+	"test/storage"
+	"testing"
+	"time"
+)
+
+// TestSoftDeleteLifecycle проверяет мягкое удаление и восстановление задачи
+//
+// Проверяет:
+// - Создание -> удаление -> GET 410, после истечения записи об удалении 404
+// - Восстановление после этого -> GET 200 с прежними полями и без deleted_at
+// - Удаленная задача не видна в списке и в фильтре priority
+// - Код 409 на восстановление неудаленной задачи и 404 - несуществующей
+func TestSoftDeleteLifecycle(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage(storage.WithClock(clock.Now), storage.WithTombstoneTTL(time.Hour)))
+
+	for _, title := range []string{"Первая", "Вторая"} {
+		w := postTask(t, mux, map[string]string{"title": title, "description": "Описание", "priority": "high"})
+		expectCode(t, w, http.StatusCreated)
+	}
+
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/1", nil), http.StatusNoContent)
+	expectCode(t, doJSON(t, mux, "GET", "/tasks/1", nil), http.StatusGone)
+	if ids, _ := getPage(t, mux, "/tasks?priority=high"); fmt.Sprint(ids) != "[2]" {
+		t.Errorf("Удаленная задача в списке: %v", ids)
+	}
+
+	clock.Advance(2 * time.Hour)
+	expectCode(t, doJSON(t, mux, "GET", "/tasks/1", nil), http.StatusNotFound)
+
+	w := doJSON(t, mux, "POST", "/tasks/1/restore", nil)
+	expectCode(t, w, http.StatusOK)
+	restored := decodeTask(t, w)
+	if restored.ID != 1 || restored.Title != "Первая" || restored.Priority != models.PriorityHigh || restored.DeletedAt != nil {
+		t.Errorf("Неверная восстановленная задача: %+v", restored)
+	}
+	if !restored.UpdatedAt.Equal(clock.Now()) {
+		t.Errorf("Ожидался updated_at %v, получен %v", clock.Now(), restored.UpdatedAt)
+	}
+
+	expectCode(t, doJSON(t, mux, "GET", "/tasks/1", nil), http.StatusOK)
+	if ids, _ := getPage(t, mux, "/tasks?priority=high"); fmt.Sprint(ids) != "[1 2]" {
+		t.Errorf("Ожидались задачи [1 2], получены %v", ids)
+	}
+
+	expectCode(t, doJSON(t, mux, "POST", "/tasks/1/restore", nil), http.StatusConflict)
+	expectCode(t, doJSON(t, mux, "POST", "/tasks/99/restore", nil), http.StatusNotFound)
+	expectCode(t, doJSON(t, mux, "GET", "/tasks/1/restore", nil), http.StatusMethodNotAllowed)
+}
+
+// TestIncludeDeleted проверяет параметр include_deleted списка задач
+//
+// Проверяет:
+// - include_deleted=true добавляет удаленные задачи с полем deleted_at
+// - include_deleted=false и отсутствие параметра их не показывают
+// - Страницы и фильтры применяются к полному списку
+// - Код 400 и invalid_value на значение не true/false
+func TestIncludeDeleted(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage(storage.WithClock(clock.Now)))
+	for i := 1; i <= 3; i++ {
+		w := postTask(t, mux, map[string]string{"title": fmt.Sprintf("Задача %d", i), "description": "Описание", "priority": "low"})
+		expectCode(t, w, http.StatusCreated)
+	}
+	clock.Advance(time.Minute)
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/2", nil), http.StatusNoContent)
+
+	w := doJSON(t, mux, "GET", "/tasks?include_deleted=true", nil)
+	expectCode(t, w, http.StatusOK)
+	var tasks []models.Task
+	if err := json.Unmarshal(w.Body.Bytes(), &tasks); err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 3 {
+		t.Fatalf("Ожидалось 3 задачи, получено %d", len(tasks))
+	}
+	for _, task := range tasks {
+		deleted := task.DeletedAt != nil
+		if deleted != (task.ID == 2) {
+			t.Errorf("Задача %d: неверный deleted_at %v", task.ID, task.DeletedAt)
+		}
+		if deleted && !task.DeletedAt.Equal(clock.Now()) {
+			t.Errorf("Ожидался deleted_at %v, получен %v", clock.Now(), task.DeletedAt)
+		}
+	}
+
+	for path, expected := range map[string]string{
+		"/tasks?include_deleted=false&sort=title":               "[1 3]",
+		"/tasks?include_deleted=true&priority=low":              "[1 2 3]",
+		"/tasks?include_deleted=true&sort=title&page=2&limit=1": "[2]",
+	} {
+		if ids, _ := getPage(t, mux, path); fmt.Sprint(ids) != expected {
+			t.Errorf("%s: ожидались задачи %s, получены %v", path, expected, ids)
+		}
+	}
+
+	w = doJSON(t, mux, "GET", "/tasks?include_deleted=all", nil)
+	expectCode(t, w, http.StatusBadRequest)
+	var resp enumErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0].Field != "include_deleted" {
+		t.Errorf("Неверная ошибка: %+v", resp)
+	}
+}
+
+// TestSoftDeletedClientToken проверяет повтор создания с токеном удаленной задачи
+func TestSoftDeletedClientToken(t *testing.T) {
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage())
+	body := map[string]string{"title": "Задача", "description": "Описание", "client_token": "abc"}
+	expectCode(t, postTask(t, mux, body), http.StatusCreated)
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/1", nil), http.StatusNoContent)
+
+	// Удаленная задача не возвращается повтором, создается новая
+	w := postTask(t, mux, body)
+	expectCode(t, w, http.StatusCreated)
+	if task := decodeTask(t, w); task.ID != 2 {
+		t.Errorf("Ожидался ID 2, получен %d", task.ID)
+	}
+}
+
+// TestPurgeTask проверяет окончательное удаление задачи
+//
+// Проверяет:
+// - PurgeTask отклоняет неудаленную задачу с ErrTaskNotDeleted и стирает мягко удаленную
+// - Повторный DELETE стирает задачу, после чего ее нельзя восстановить и нет в include_deleted
+// - DELETE ?permanent=true стирает задачу сразу, но защищенная задача требует подтверждения
+// - Неверное значение permanent отклоняется с кодом 400, несуществующая задача - 404
+// - ID стертой задачи повторно не выдается
+func TestPurgeTask(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	taskStorage := storage.NewInMemoryStorage(storage.WithClock(clock.Now), storage.WithTombstoneTTL(time.Hour))
+	mux := handlers.SetupHandlers(taskStorage)
+	for _, title := range []string{"Первая", "Вторая", "Третья"} {
+		expectCode(t, postTask(t, mux, map[string]string{"title": title, "description": "Описание"}), http.StatusCreated)
+	}
+	w := postTask(t, mux, map[string]interface{}{"title": "Защищенная", "description": "Описание", "protected": true})
+	expectCode(t, w, http.StatusCreated)
+
+	if err := taskStorage.PurgeTask(1); !errors.Is(err, storage.ErrTaskNotDeleted) {
+		t.Errorf("Ожидалась ошибка ErrTaskNotDeleted, получена %v", err)
+	}
+	taskStorage.DeleteTask(t.Context(), 1)
+	if err := taskStorage.PurgeTask(1); err != nil {
+		t.Fatal(err)
+	}
+	if tasks, _ := taskStorage.GetAllTasksWithDeleted(); len(tasks) != 3 {
+		t.Errorf("Ожидалось 3 задачи, получено %d", len(tasks))
+	}
+
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/2", nil), http.StatusNoContent)
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/2", nil), http.StatusNoContent)
+	expectCode(t, doJSON(t, mux, "POST", "/tasks/2/restore", nil), http.StatusGone)
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/3?permanent=true", nil), http.StatusNoContent)
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/4?permanent=true", nil), http.StatusPreconditionRequired)
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/4?permanent=abc", nil), http.StatusBadRequest)
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/99?permanent=true", nil), http.StatusNotFound)
+	if ids, _ := getPage(t, mux, "/tasks?include_deleted=true"); fmt.Sprint(ids) != "[4]" {
+		t.Errorf("Ожидалась задача [4], получены %v", ids)
+	}
+
+	clock.Advance(2 * time.Hour)
+	expectCode(t, doJSON(t, mux, "POST", "/tasks/3/restore", nil), http.StatusNotFound)
+	task, _ := taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Новая", Description: "Описание"})
+	if task.ID != 5 {
+		t.Errorf("Ожидался ID 5, получен %d", task.ID)
+	}
+}
@@ -0,0 +1,201 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test/handlers"
+	"test/models"
+	"test/storage"
+	"testing"
+)
+
+// routingMethods - методы, которыми проверяется каждая форма пути
+var routingMethods = []string{
+	http.MethodGet,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodDelete,
+	http.MethodPatch,
+}
+
+// routingBodies - корректные тела запросов для маршрутов, принимающих тело,
+// чтобы ответ определялся только путем, методом и существованием задачи
+var routingBodies = map[string]interface{}{
+	http.MethodPost:  map[string]interface{}{"url": "https://example.com/new", "title": "Задача", "description": "Описание"},
+	http.MethodPut:   map[string]interface{}{"title": "Задача", "description": "Описание"},
+	http.MethodPatch: map[string]interface{}{"completed": true},
+}
+
+// TestTaskRoutingMatrix фиксирует коды ответа семейства маршрутов /tasks/{id}
+//
+// Каждая форма пути проверяется всеми методами из routingMethods на новом хранилище,
+// где существует только задача 1 с одной ссылкой. Таблица должна совпадать
+// с описанием в handlers/routing.go.
+//
+// Проверяет:
+// - 400 для пустого, нечислового, неканонического и переполняющего ID
+// - Отдельный маршрут /tasks/quick
+// - 404 для неизвестных вложенных ресурсов и лишних сегментов пути
+// - 400 для неверного индекса ссылки
+// - 405 для неподдерживаемых маршрутом методов независимо от существования задачи
+// - 404 для несуществующей задачи или ссылки
+func TestTaskRoutingMatrix(t *testing.T) {
+	testTaskRoutingMatrix(t, newMemoryStorage)
+}
+
+// testTaskRoutingMatrix проверяет таблицу маршрутов на хранилищах, созданных newStorage
+func testTaskRoutingMatrix(t *testing.T, newStorage storageFactory) {
+	const (
+		methodNotAllowed = http.StatusMethodNotAllowed
+		badRequest       = http.StatusBadRequest
+		notFound         = http.StatusNotFound
+	)
+
+	// all возвращает одинаковый код для всех методов
+	all := func(code int) map[string]int {
+		codes := make(map[string]int, len(routingMethods))
+		for _, method := range routingMethods {
+			codes[method] = code
+		}
+		return codes
+	}
+	// only возвращает 405 для всех методов, кроме перечисленных
+	only := func(allowed map[string]int) map[string]int {
+		codes := all(methodNotAllowed)
+		for method, code := range allowed {
+			codes[method] = code
+		}
+		return codes
+	}
+
+	tests := []struct {
+		path  string
+		codes map[string]int
+	}{
+		// Неверный ID
+		{"/tasks/", all(badRequest)},
+		{"/tasks/abc", all(badRequest)},
+		{"/tasks/1a", all(badRequest)},
+		{"/tasks/1.5", all(badRequest)},
+		{"/tasks/0", all(badRequest)},
+		{"/tasks/-1", all(badRequest)},
+		{"/tasks/+1", all(badRequest)},
+		{"/tasks/01", all(badRequest)},
+		{"/tasks/99999999999999999999", all(badRequest)},
+		{"/tasks/abc/links", all(badRequest)},
+		{"/tasks/abc/unknown", all(badRequest)},
+
+		// Быстрое добавление - отдельный маршрут, тело JSON вместо text/plain отклоняется
+		{"/tasks/quick", only(map[string]int{http.MethodPost: http.StatusUnsupportedMediaType})},
+		{"/tasks/quick/", all(badRequest)},
+
+		// Сама задача
+		{"/tasks/1", only(map[string]int{
+			http.MethodGet:    http.StatusOK,
+			http.MethodPut:    http.StatusOK,
+			http.MethodPatch:  http.StatusOK,
+			http.MethodDelete: http.StatusNoContent,
+		})},
+		{"/tasks/2", only(map[string]int{
+			http.MethodGet:    notFound,
+			http.MethodPut:    notFound,
+			http.MethodPatch:  notFound,
+			http.MethodDelete: notFound,
+		})},
+
+		// Неизвестные вложенные ресурсы и лишние сегменты
+		{"/tasks/1/", all(notFound)},
+		{"/tasks/2/", all(notFound)},
+		{"/tasks/1/unknown", all(notFound)},
+		{"/tasks/1/unknown/0", all(notFound)},
+		{"/tasks/1/links/0/extra", all(notFound)},
+
+		// Ссылки задачи
+		{"/tasks/1/links", only(map[string]int{http.MethodPost: http.StatusCreated})},
+		{"/tasks/2/links", only(map[string]int{http.MethodPost: notFound})},
+		{"/tasks/1/links/0", only(map[string]int{http.MethodDelete: http.StatusNoContent})},
+		{"/tasks/1/links/5", only(map[string]int{http.MethodDelete: notFound})},
+		{"/tasks/2/links/0", only(map[string]int{http.MethodDelete: notFound})},
+		{"/tasks/1/links/", all(badRequest)},
+		{"/tasks/1/links/abc", all(badRequest)},
+		{"/tasks/1/links/-1", all(badRequest)},
+		{"/tasks/1/links/00", all(badRequest)},
+
+		// Завершение с продолжением
+		{"/tasks/1/complete-with-followup", only(map[string]int{http.MethodPost: http.StatusCreated})},
+		{"/tasks/2/complete-with-followup", only(map[string]int{http.MethodPost: notFound})},
+		{"/tasks/1/complete-with-followup/1", all(notFound)},
+
+		// Восстановление удаленной задачи, неудаленная задача - конфликт
+		{"/tasks/1/restore", only(map[string]int{http.MethodPost: http.StatusConflict})},
+		{"/tasks/2/restore", only(map[string]int{http.MethodPost: notFound})},
+		{"/tasks/1/restore/1", all(notFound)},
+
+		// Переключение выполнения
+		{"/tasks/1/toggle", only(map[string]int{http.MethodPut: http.StatusOK})},
+		{"/tasks/2/toggle", only(map[string]int{http.MethodPut: notFound})},
+		{"/tasks/1/toggle/1", all(notFound)},
+
+		// Метаданные интеграций
+		{"/tasks/1/metadata", only(map[string]int{http.MethodGet: http.StatusOK})},
+		{"/tasks/2/metadata", only(map[string]int{http.MethodGet: notFound})},
+		{"/tasks/1/metadata/crm", only(map[string]int{
+			http.MethodGet:    notFound,
+			http.MethodPut:    http.StatusNoContent,
+			http.MethodDelete: notFound,
+		})},
+		{"/tasks/2/metadata/crm", only(map[string]int{
+			http.MethodGet:    notFound,
+			http.MethodPut:    notFound,
+			http.MethodDelete: notFound,
+		})},
+		{"/tasks/1/metadata/CRM", all(badRequest)},
+		{"/tasks/1/metadata/", all(badRequest)},
+		{"/tasks/1/metadata/crm/x", all(notFound)},
+	}
+
+	for _, tt := range tests {
+		for _, method := range routingMethods {
+			expected, ok := tt.codes[method]
+			if !ok {
+				t.Fatalf("Для %s не задан код метода %s", tt.path, method)
+			}
+
+			t.Run(method+" "+strings.TrimPrefix(tt.path, "/"), func(t *testing.T) {
+				taskStorage := newStorage(t)
+				taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{
+					Title:       "Задача",
+					Description: "Описание",
+					Links:       []models.Link{{URL: "https://example.com/"}},
+				})
+				mux := handlers.SetupHandlers(taskStorage)
+
+				w := doJSON(t, mux, method, tt.path, routingBodies[method])
+				if w.Code != expected {
+					t.Errorf("Ожидался код %d, получен %d: %s", expected, w.Code, w.Body.String())
+				}
+			})
+		}
+	}
+}
+
+// TestTaskRoutingBodyBeforeExistence проверяет, что тело запроса проверяется до существования задачи
+func TestTaskRoutingBodyBeforeExistence(t *testing.T) {
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage())
+
+	for _, method := range []string{http.MethodPut, http.MethodPatch} {
+		for _, body := range []string{"{", `{"title":42}`} {
+			req, err := http.NewRequest(method, "/tasks/99", strings.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s, тело %s: ожидался код %d, получен %d", method, body, http.StatusBadRequest, w.Code)
+			}
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test/handlers"
+	"test/storage"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// fetchSchema получает опубликованную схему и компилирует ее сторонним валидатором
+func fetchSchema(t *testing.T, mux http.Handler, path string) *jsonschema.Schema {
+	req, err := http.NewRequest("GET", path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался код %d, получен %d", http.StatusOK, w.Code)
+	}
+	t.Logf("Схема %s: %s", path, w.Body.Bytes())
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(path, bytes.NewReader(w.Body.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	compiled, err := compiler.Compile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return compiled
+}
+
+// TestCreateTaskRequestSchema проверяет опубликованную схему тела POST /tasks
+//
+// Проверяет:
+// - Корректное тело проходит проверку сторонним валидатором
+// - Некорректные тела отклоняются и валидатором, и строгим режимом сервера
+func TestCreateTaskRequestSchema(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+	mux := handlers.SetupHandlersWithConfig(taskStorage, handlers.Config{StrictSchema: true})
+
+	compiled := fetchSchema(t, mux, "/schemas/create-task-request.json")
+
+	tests := []struct {
+		name  string
+		body  string
+		valid bool
+	}{
+		{"корректное тело", `{"title":"Купить продукты","description":"Молоко"}`, true},
+		{"с токеном", `{"title":"Задача","description":"Описание","client_token":"abc"}`, true},
+		{"без описания", `{"title":"Задача"}`, false},
+		{"пустое название", `{"title":"","description":"Описание"}`, false},
+		{"неизвестное поле", `{"title":"Задача","description":"Описание","owner":"bob"}`, false},
+		{"неверный тип", `{"title":42,"description":"Описание"}`, false},
+		{"длинный токен", `{"title":"Задача","description":"Описание","client_token":"` + strings.Repeat("x", 129) + `"}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var payload interface{}
+			if err := json.Unmarshal([]byte(tt.body), &payload); err != nil {
+				t.Fatal(err)
+			}
+
+			// Сторонний валидатор
+			err := compiled.Validate(payload)
+			if (err == nil) != tt.valid {
+				t.Errorf("Валидатор схемы: ожидалось valid=%v, ошибка: %v", tt.valid, err)
+			}
+
+			// Строгий режим сервера должен совпадать со схемой
+			req, err := http.NewRequest("POST", "/tasks", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			expected := http.StatusBadRequest
+			if tt.valid {
+				expected = http.StatusCreated
+			}
+			if w.Code != expected {
+				t.Errorf("Ожидался код %d, получен %d: %s", expected, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestTaskSchema проверяет, что ответ сервера соответствует опубликованной схеме задачи
+func TestTaskSchema(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+	mux := handlers.SetupHandlers(taskStorage)
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Тестовая задача", Description: "Описание"})
+
+	compiled := fetchSchema(t, mux, "/schemas/task.json")
+
+	req, err := http.NewRequest("GET", "/tasks/1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	var payload interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if err := compiled.Validate(payload); err != nil {
+		t.Errorf("Ответ не соответствует схеме: %v", err)
+	}
+
+	var bad interface{}
+	json.Unmarshal([]byte(`{"id":"1","title":"Задача"}`), &bad)
+	if err := compiled.Validate(bad); err == nil {
+		t.Errorf("Ожидалась ошибка проверки для некорректной задачи")
+	}
+}
+
+// TestSchemaNotFound проверяет ответ на запрос неизвестной схемы
+func TestSchemaNotFound(t *testing.T) {
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage())
+
+	req, err := http.NewRequest("GET", "/schemas/unknown.json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestLenientModeIgnoresUnknownFields проверяет, что без строгого режима неизвестные поля игнорируются
+func TestLenientModeIgnoresUnknownFields(t *testing.T) {
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage())
+
+	req, err := http.NewRequest("POST", "/tasks", strings.NewReader(`{"title":"Задача","description":"Описание","owner":"bob"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusCreated, w.Code)
+	}
+}
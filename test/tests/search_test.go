@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"test/handlers"
+	"test/storage"
+	"testing"
+)
+
+// TestSearchTasks проверяет GET /tasks/search
+//
+// Проверяет:
+// - Подстрока ищется в названии и описании без учета регистра, задачи идут по возрастанию ID
+// - Удаленные задачи не находятся, отсутствие совпадений дает пустой массив
+// - Код 400 на q короче 2 символов, в том числе из пробелов, и 405 на запись
+// - Путь /tasks/search не разбирается как ID задачи
+func TestSearchTasks(t *testing.T) {
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage())
+	tasks := []map[string]string{
+		{"title": "Квартальный отчет", "description": "Собрать цифры"},
+		{"title": "Позвонить клиенту", "description": "Обсудить ОТЧЕТ"},
+		{"title": "Купить молоко", "description": "В магазине"},
+		{"title": "Отчеты за год", "description": "Архив"},
+	}
+	for _, task := range tasks {
+		expectCode(t, postTask(t, mux, task), http.StatusCreated)
+	}
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/4", nil), http.StatusNoContent)
+
+	for query, expected := range map[string]string{
+		"отчет":    "[1 2]",
+		"ОтЧеТ":    "[1 2]",
+		"  цифры ": "[1]",
+		"молоко":   "[3]",
+		"ы":        "",
+		"поезд":    "[]",
+	} {
+		path := "/tasks/search?q=" + url.QueryEscape(query)
+		if expected == "" {
+			expectCode(t, doJSON(t, mux, "GET", path, nil), http.StatusBadRequest)
+			continue
+		}
+		if ids, _ := getPage(t, mux, path); fmt.Sprint(ids) != expected {
+			t.Errorf("q=%q: ожидались задачи %s, получены %v", query, expected, ids)
+		}
+	}
+
+	expectCode(t, doJSON(t, mux, "GET", "/tasks/search", nil), http.StatusBadRequest)
+	expectCode(t, doJSON(t, mux, "GET", "/tasks/search?q=+++", nil), http.StatusBadRequest)
+	expectCode(t, doJSON(t, mux, "POST", "/tasks/search?q=отчет", nil), http.StatusMethodNotAllowed)
+}
+
+// BenchmarkSearchTasks измеряет поиск подстрокой перебором всех задач
+//
+// Время на операцию растет линейно с числом задач: каждая задача проверяется
+// strings.Contains, поэтому 100000 задач ищутся примерно в 10 раз дольше 10000.
+func BenchmarkSearchTasks(b *testing.B) {
+	for _, count := range []int{1000, 10000, 100000} {
+		b.Run(fmt.Sprintf("%d задач", count), func(b *testing.B) {
+			taskStorage := storage.NewInMemoryStorage()
+			for i := 0; i < count; i++ {
+				taskStorage.CreateTask(b.Context(), storage.CreateTaskInput{Title: fmt.Sprintf("Задача %d", i), Description: "Описание задачи"})
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := taskStorage.SearchTasks("99"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
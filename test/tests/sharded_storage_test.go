@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"test/handlers"
+	"test/storage"
+	"testing"
+)
+
+// newShardedStorage создает хранилище в памяти из четырех сегментов
+func newShardedStorage(t *testing.T, opts ...storage.Option) storage.Storage {
+	return storage.NewShardedStorage(4, opts...)
+}
+
+// TestShardedStorageHandlers проверяет основные обработчики на ShardedStorage
+func TestShardedStorageHandlers(t *testing.T) {
+	t.Run("Create", func(t *testing.T) { testCreateTaskHandler(t, newShardedStorage) })
+	t.Run("GetAll", func(t *testing.T) { testGetAllTasksHandler(t, newShardedStorage) })
+	t.Run("Get", func(t *testing.T) { testGetTaskHandler(t, newShardedStorage) })
+	t.Run("Update", func(t *testing.T) { testUpdateTaskHandler(t, newShardedStorage) })
+}
+
+// TestShardedStorage проверяет разделенное на сегменты хранилище
+//
+// Проверяет:
+// - GetAllTasks собирает задачи всех сегментов в порядке ID
+// - Удаленная задача отвечает 404, а не 410, защищенная удаляется только с подтверждением
+// - Неподдерживаемые возможности отмечены в GET /capabilities и отвечают 501
+// - Конкурентно созданные задачи получают разные ID без пропусков
+func TestShardedStorage(t *testing.T) {
+	t.Run("GetAllTasks", func(t *testing.T) {
+		taskStorage := storage.NewShardedStorage(3)
+		for i := 1; i <= 10; i++ {
+			taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: fmt.Sprintf("Задача %d", i), Description: "Описание"})
+		}
+		if err := taskStorage.DeleteTask(t.Context(), 5); err != nil {
+			t.Fatal(err)
+		}
+
+		tasks, err := taskStorage.GetAllTasks(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []int{1, 2, 3, 4, 6, 7, 8, 9, 10}
+		if len(tasks) != len(expected) {
+			t.Fatalf("Ожидалось %d задач, получено %d", len(expected), len(tasks))
+		}
+		for i, task := range tasks {
+			if task.ID != expected[i] {
+				t.Errorf("Позиция %d: ожидался ID %d, получен %d", i, expected[i], task.ID)
+			}
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		mux := handlers.SetupHandlers(storage.NewShardedStorage(2))
+		expectCode(t, postTask(t, mux, map[string]string{"title": "Обычная", "description": "Описание"}), http.StatusCreated)
+		expectCode(t, postTask(t, mux, map[string]interface{}{"title": "Защищенная", "description": "Описание", "protected": true}), http.StatusCreated)
+
+		expectCode(t, doJSON(t, mux, "DELETE", "/tasks/1", nil), http.StatusNoContent)
+		expectCode(t, doJSON(t, mux, "GET", "/tasks/1", nil), http.StatusNotFound)
+		expectCode(t, doJSON(t, mux, "DELETE", "/tasks/1", nil), http.StatusNotFound)
+
+		expectCode(t, doJSON(t, mux, "DELETE", "/tasks/2", nil), http.StatusPreconditionRequired)
+		req := httptest.NewRequest("DELETE", "/tasks/2", nil)
+		req.Header.Set(handlers.ConfirmDeleteHeader, "2")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		expectCode(t, w, http.StatusNoContent)
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		mux := handlers.SetupHandlers(storage.NewShardedStorage(2))
+		var capabilities map[string]interface{}
+		if err := json.Unmarshal(doJSON(t, mux, "GET", "/capabilities", nil).Body.Bytes(), &capabilities); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"patch", "quick_add", "bulk_create", "bulk_delete", "metadata", "tombstones"} {
+			if capabilities[name] != false {
+				t.Errorf("Возможность %s не должна быть доступна", name)
+			}
+		}
+
+		expectCode(t, postTask(t, mux, map[string]string{"title": "Задача", "description": "Описание"}), http.StatusCreated)
+		expectCode(t, patchTask(t, mux, "/tasks/1", `{"completed":true}`), http.StatusNotImplemented)
+	})
+
+	t.Run("ConcurrentCreate", func(t *testing.T) {
+		taskStorage := storage.NewShardedStorage(8)
+		const workers, perWorker = 8, 100
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < perWorker; i++ {
+					taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Задача", Description: "Описание"})
+				}
+			}()
+		}
+		wg.Wait()
+
+		tasks, err := taskStorage.GetAllTasks(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tasks) != workers*perWorker {
+			t.Fatalf("Ожидалось %d задач, получено %d", workers*perWorker, len(tasks))
+		}
+		for i, task := range tasks {
+			if task.ID != i+1 {
+				t.Fatalf("Позиция %d: ожидался ID %d, получен %d", i, i+1, task.ID)
+			}
+		}
+		if next, err := taskStorage.NextID(); err != nil || next != workers*perWorker+1 {
+			t.Errorf("Ожидался следующий ID %d, получен %d (%v)", workers*perWorker+1, next, err)
+		}
+		if err := taskStorage.SetLastID(1); !errors.Is(err, storage.ErrLastIDDecrease) {
+			t.Errorf("Ожидалась ErrLastIDDecrease, получена %v", err)
+		}
+	})
+}
+
+// BenchmarkMixedWorkload сравнивает InMemoryStorage и ShardedStorage при
+// параллельных чтениях и записях
+//
+// Каждая итерация - одна операция: чтение случайной задачи или, с заданной долей,
+// обновление задачи либо создание новой. Полный список задач в нагрузку не входит:
+// его стоимость для обоих хранилищ определяется копированием, а не блокировками.
+func BenchmarkMixedWorkload(b *testing.B) {
+	backends := []struct {
+		name string
+		new  func() storage.Storage
+	}{
+		{"InMemory", func() storage.Storage { return storage.NewInMemoryStorage() }},
+		{"Sharded16", func() storage.Storage { return storage.NewShardedStorage(16) }},
+	}
+	const preloaded = 10000
+
+	for _, writePercent := range []int{10, 50} {
+		for _, backend := range backends {
+			b.Run(fmt.Sprintf("%s/%d%%записей", backend.name, writePercent), func(b *testing.B) {
+				taskStorage := backend.new()
+				for i := 0; i < preloaded; i++ {
+					taskStorage.CreateTask(b.Context(), storage.CreateTaskInput{Title: fmt.Sprintf("Задача %d", i), Description: "Описание задачи"})
+				}
+				update := storage.UpdateTaskInput{Title: "Обновленная задача", Description: "Описание задачи"}
+
+				var workers atomic.Uint32
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					// Линейный конгруэнтный генератор со своим началом у каждой горутины
+					seed := workers.Add(1)
+					for pb.Next() {
+						seed = seed*1664525 + 1013904223
+						id := int(seed%preloaded) + 1
+						switch op := int(seed>>16) % 100; {
+						case op >= writePercent:
+							taskStorage.GetTask(b.Context(), id)
+						case op%10 == 0:
+							taskStorage.CreateTask(b.Context(), storage.CreateTaskInput{Title: "Новая задача", Description: "Описание задачи"})
+						default:
+							taskStorage.UpdateTask(b.Context(), id, update)
+						}
+					}
+				})
+			})
+		}
+	}
+}
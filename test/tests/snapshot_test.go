@@ -0,0 +1,186 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"test/handlers"
+	"test/storage"
+	"testing"
+)
+
+// TestSnapshotRoundTrip проверяет сохранение и загрузку снимка хранилища в памяти
+//
+// Проверяет:
+// - Задачи восстанавливаются с теми же ID, статусом выполнения и метаданными
+// - Удаленная задача восстанавливается удаленной и по-прежнему возвращает 410
+// - Нумерация продолжается с сохраненного последнего ID, даже если последняя задача удалена
+// - Повторное сохранение загруженного хранилища дает тот же снимок
+func TestSnapshotRoundTrip(t *testing.T) {
+	source := storage.NewInMemoryStorage()
+	mux := handlers.SetupHandlers(source)
+	for _, title := range []string{"Первая", "Вторая", "Третья", "Четвертая"} {
+		expectCode(t, postTask(t, mux, map[string]string{"title": title, "description": "Описание"}), http.StatusCreated)
+	}
+	expectCode(t, patchTask(t, mux, "/tasks/2", `{"completed":true,"priority":"high"}`), http.StatusOK)
+	expectCode(t, putRaw(t, mux, "/tasks/1/metadata/crm", []byte(`{"deal_id":42}`)), http.StatusNoContent)
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/3", nil), http.StatusNoContent)
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/4", nil), http.StatusNoContent)
+
+	var saved bytes.Buffer
+	if err := source.SaveSnapshot(&saved); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := storage.NewInMemoryStorage()
+	if err := loaded.LoadSnapshot(bytes.NewReader(saved.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	restored := handlers.SetupHandlers(loaded)
+
+	const list = "/tasks?include_deleted=true"
+	if expected, actual := doJSON(t, mux, "GET", list, nil).Body.String(), doJSON(t, restored, "GET", list, nil).Body.String(); actual != expected {
+		t.Errorf("Задачи после загрузки отличаются:\nожидалось: %s\nполучено:  %s", expected, actual)
+	}
+	if task := decodeTask(t, doJSON(t, restored, "GET", "/tasks/2", nil)); !task.Completed || task.Priority != "high" {
+		t.Errorf("Статус или приоритет задачи 2 потерян: %+v", task)
+	}
+	if ids, _ := getPage(t, restored, "/tasks?priority=high"); len(ids) != 1 || ids[0] != 2 {
+		t.Errorf("Индекс приоритета не восстановлен: %v", ids)
+	}
+	if w := doJSON(t, restored, "GET", "/tasks/1/metadata/crm", nil); w.Body.String() != `{"deal_id":42}` {
+		t.Errorf("Метаданные потеряны: %d %s", w.Code, w.Body.String())
+	}
+	expectCode(t, doJSON(t, restored, "GET", "/tasks/4", nil), http.StatusGone)
+
+	var again bytes.Buffer
+	if err := loaded.SaveSnapshot(&again); err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != saved.String() {
+		t.Errorf("Снимок изменился после загрузки:\nбыло:  %s\nстало: %s", saved.String(), again.String())
+	}
+
+	w := postTask(t, restored, map[string]string{"title": "Пятая", "description": "Описание"})
+	expectCode(t, w, http.StatusCreated)
+	if task := decodeTask(t, w); task.ID != 5 {
+		t.Errorf("Ожидался ID 5, получен %d", task.ID)
+	}
+}
+
+// TestSnapshotVersions проверяет чтение снимков разных версий
+//
+// Проверяет:
+// - Снимок текущей версии с неизвестными полями загружается, поля игнорируются
+// - Снимок без версии, будущей версии или с повторяющимся ID отклоняется
+// - Отклоненный снимок не меняет содержимое хранилища
+func TestSnapshotVersions(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+	future := `{"version":1,"last_id":7,"checksum":"abc","tasks":[{"id":7,"title":"Задача","description":"Описание","completed":true,"color":"red"}]}`
+	if err := taskStorage.LoadSnapshot(strings.NewReader(future)); err != nil {
+		t.Fatalf("Снимок с неизвестными полями не загружен: %v", err)
+	}
+
+	for _, content := range []string{
+		`{"last_id":1,"tasks":[]}`,
+		`{"version":99,"last_id":1,"tasks":[]}`,
+		`{"version":1,"last_id":2,"tasks":[{"id":2,"title":"А"},{"id":2,"title":"Б"}]}`,
+		`{"version":1,`,
+	} {
+		if err := taskStorage.LoadSnapshot(strings.NewReader(content)); err == nil {
+			t.Errorf("%s: ожидалась ошибка загрузки", content)
+		}
+	}
+
+	tasks, err := taskStorage.GetAllTasks(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := json.Marshal(tasks); len(tasks) != 1 || tasks[0].ID != 7 || !tasks[0].Completed {
+		t.Errorf("Содержимое хранилища изменилось: %s", data)
+	}
+}
+
+// TestSnapshotCounter проверяет, что после загрузки снимка ID задач снимка не выдаются повторно
+//
+// Проверяет:
+// - После снимка с задачами 1-5 без last_id следующая задача получает ID 6, а не 1
+// - NextID сообщает тот же ID до создания задачи
+func TestSnapshotCounter(t *testing.T) {
+	var tasks []string
+	for id := 1; id <= 5; id++ {
+		tasks = append(tasks, fmt.Sprintf(`{"id":%d,"title":"Задача %d","description":"Описание"}`, id, id))
+	}
+	taskStorage := storage.NewInMemoryStorage()
+	if err := taskStorage.LoadSnapshot(strings.NewReader(`{"version":1,"tasks":[` + strings.Join(tasks, ",") + `]}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if next, err := taskStorage.NextID(); err != nil || next != 6 {
+		t.Errorf("Ожидался следующий ID 6, получен %d (%v)", next, err)
+	}
+	w := postTask(t, handlers.SetupHandlers(taskStorage), map[string]string{"title": "Новая", "description": "Описание"})
+	expectCode(t, w, http.StatusCreated)
+	if task := decodeTask(t, w); task.ID != 6 {
+		t.Errorf("Ожидался ID 6, получен %d", task.ID)
+	}
+}
+
+// TestSnapshotConcurrentCreate проверяет, что задачи, созданные параллельно после
+// загрузки снимка с отставшим last_id, не занимают ID задач снимка
+//
+// Проверяет:
+// - Все созданные задачи получают разные ID больше наибольшего ID снимка
+// - Задачи снимка остаются без изменений
+// - Параллельный подъем счетчика SetLastID не приводит к повторной выдаче ID
+func TestSnapshotConcurrentCreate(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+	content := `{"version":1,"last_id":3,"tasks":[` +
+		`{"id":2,"title":"Снимок 2","description":"Описание"},` +
+		`{"id":5,"title":"Снимок 5","description":"Описание"},` +
+		`{"id":9,"title":"Снимок 9","description":"Описание"}]}`
+	if err := taskStorage.LoadSnapshot(strings.NewReader(content)); err != nil {
+		t.Fatal(err)
+	}
+
+	const workers, perWorker = 8, 50
+	created := make(chan int, workers*perWorker)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				if i == perWorker/2 {
+					// Ошибка при уменьшении ожидаема: другие горутины уже могли поднять счетчик выше
+					taskStorage.SetLastID(100 + w)
+				}
+				task, err := taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Новая", Description: "Описание"})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				created <- task.ID
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(created)
+
+	seen := map[int]bool{2: true, 5: true, 9: true}
+	for id := range created {
+		if id <= 9 || seen[id] {
+			t.Errorf("ID %d выдан повторно или занят снимком", id)
+		}
+		seen[id] = true
+	}
+	for _, id := range []int{2, 5, 9} {
+		task, err := taskStorage.GetTask(t.Context(), id)
+		if err != nil || task.Title != fmt.Sprintf("Снимок %d", id) {
+			t.Errorf("Задача снимка %d изменена: %+v (%v)", id, task, err)
+		}
+	}
+}
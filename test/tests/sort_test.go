@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"test/handlers"
+	"test/models"
+	"test/storage"
+	"testing"
+	"time"
+)
+
+// sortTitles - смешанный набор названий на кириллице и латинице с числами
+var sortTitles = []string{"яблоко", "Apple", "Ёжик", "ежевика", "banana", "10 дел", "2 дела", "Яблоко", "zebra", "Жук", "apple"}
+
+// listTitles запрашивает список задач и возвращает их названия по порядку
+func listTitles(t *testing.T, mux http.Handler, path, acceptLanguage string) []string {
+	req, err := http.NewRequest("GET", path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acceptLanguage != "" {
+		req.Header.Set("Accept-Language", acceptLanguage)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался код %d, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var tasks []*models.Task
+	if err := json.Unmarshal(w.Body.Bytes(), &tasks); err != nil {
+		t.Fatal(err)
+	}
+	titles := make([]string, len(tasks))
+	for i, task := range tasks {
+		titles[i] = task.Title
+	}
+	return titles
+}
+
+// TestSortByTitleCollation проверяет сортировку ?sort=title с учетом локали
+//
+// Проверяет:
+// - Порядок без учета регистра, с равными названиями в порядке ID
+// - "ё" сортируется рядом с "е", а не после "я"
+// - Числа сравниваются по значению
+// - Выбор локали через ?collate и Accept-Language
+// - Отклонение неподдерживаемой локали и неизвестного параметра sort
+func TestSortByTitleCollation(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+	mux := handlers.SetupHandlersWithConfig(taskStorage, handlers.Config{Collation: "ru"})
+	for _, title := range sortTitles {
+		taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: title, Description: "Описание"})
+	}
+
+	expected := []string{"2 дела", "10 дел", "Apple", "apple", "banana", "zebra", "ежевика", "Ёжик", "Жук", "яблоко", "Яблоко"}
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptLanguage string
+	}{
+		{"локаль по умолчанию", "/tasks?sort=title", ""},
+		{"параметр ru", "/tasks?sort=title&collate=ru", ""},
+		{"параметр en", "/tasks?sort=title&collate=en", ""},
+		{"Accept-Language ru", "/tasks?sort=title", "ru-RU,ru;q=0.9"},
+		{"Accept-Language en", "/tasks?sort=title", "en-US,en;q=0.8"},
+		{"неподходящий Accept-Language", "/tasks?sort=title", "ja"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if titles := listTitles(t, mux, tt.path, tt.acceptLanguage); !reflect.DeepEqual(titles, expected) {
+				t.Errorf("Ожидался порядок %q, получен %q", expected, titles)
+			}
+		})
+	}
+
+	// Фильтр применяется вместе с сортировкой
+	if titles := listTitles(t, mux, "/tasks?sort=title&has_link=true", ""); len(titles) != 0 {
+		t.Errorf("Ожидался пустой список, получено %q", titles)
+	}
+
+	for _, path := range []string{"/tasks?sort=title&collate=xx-invalid-", "/tasks?sort=title&collate=ja", "/tasks?sort=size"} {
+		if w := doJSON(t, mux, "GET", path, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: ожидался код %d, получен %d", path, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestSortMultipleKeys проверяет сортировку по нескольким ключам ?sort=-priority,due_date,id
+//
+// Проверяет:
+// - Полный порядок для сочетаний ключей и направлений
+// - Задачи без приоритета и без срока последние при любом направлении
+// - Задачи, равные по всем ключам, идут по возрастанию ID, в том числе на страницах
+// - Код 400 на больше MaxSortKeys ключей, повтор ключа и неизвестный ключ
+func TestSortMultipleKeys(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)}
+	taskStorage := storage.NewInMemoryStorage(storage.WithClock(clock.Now))
+	mux := handlers.SetupHandlers(taskStorage)
+
+	date := func(day int) *time.Time {
+		due := time.Date(2024, 5, day, 0, 0, 0, 0, time.UTC)
+		return &due
+	}
+	for i, input := range []storage.CreateTaskInput{
+		{Title: "B", Priority: models.PriorityHigh, DueDate: date(3)},
+		{Title: "A", DueDate: date(1)},
+		{Title: "C", Priority: models.PriorityCritical},
+		{Title: "A", Priority: models.PriorityHigh},
+		{Title: "B", Priority: models.PriorityLow, DueDate: date(1)},
+		{Title: "C"},
+	} {
+		// Задачи 3 и 4 созданы в один момент
+		if i != 3 {
+			clock.Advance(time.Hour)
+		}
+		input.Description = "Описание"
+		if _, err := taskStorage.CreateTask(t.Context(), input); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for path, expected := range map[string]string{
+		"/tasks?sort=-priority,due_date,id":                "[3 1 4 5 2 6]",
+		"/tasks?sort=priority":                             "[5 1 4 3 2 6]",
+		"/tasks?sort=due_date":                             "[2 5 1 3 4 6]",
+		"/tasks?sort=-due_date":                            "[1 2 5 3 4 6]",
+		"/tasks?sort=-due_date,-id":                        "[1 5 2 6 4 3]",
+		"/tasks?sort=title,-priority":                      "[4 2 1 5 3 6]",
+		"/tasks?sort=-created_at":                          "[6 5 3 4 2 1]",
+		"/tasks?sort=-id":                                  "[6 5 4 3 2 1]",
+		"/tasks?sort=-priority,due_date,id&limit=2&page=2": "[4 5]",
+		"/tasks?sort=-priority,due_date&limit=2&page=3":    "[2 6]",
+	} {
+		if ids := priorityIDs(t, mux, path); ids != expected {
+			t.Errorf("%s: ожидались задачи %s, получены %s", path, expected, ids)
+		}
+	}
+
+	for _, sort := range []string{"title,priority,due_date,id", "title,-title", "-size", "title,"} {
+		expectCode(t, doJSON(t, mux, "GET", "/tasks?sort="+sort, nil), http.StatusBadRequest)
+	}
+}
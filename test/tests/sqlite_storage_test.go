@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"net/http"
+	"path/filepath"
+	"test/handlers"
+	"test/storage"
+	"testing"
+)
+
+// newSQLiteStorage создает хранилище SQLite во временном файле теста
+func newSQLiteStorage(t *testing.T, opts ...storage.Option) storage.Storage {
+	return openSQLiteStorage(t, filepath.Join(t.TempDir(), "tasks.db"), opts...)
+}
+
+// openSQLiteStorage открывает хранилище SQLite, закрываемое по завершении теста
+func openSQLiteStorage(t *testing.T, path string, opts ...storage.Option) *storage.SQLiteStorage {
+	t.Helper()
+	taskStorage, err := storage.NewSQLiteStorage(path, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { taskStorage.Close() })
+	return taskStorage
+}
+
+// TestSQLiteStorage проверяет хранилище SQLite общим набором проверок хранилищ
+func TestSQLiteStorage(t *testing.T) {
+	testStorageBackend(t, newSQLiteStorage)
+}
+
+// TestSQLiteStorageReopen проверяет сохранение данных между открытиями базы данных
+//
+// Проверяет:
+// - Задачи и их метаданные доступны после повторного открытия
+// - Удаленная задача по-прежнему возвращает 410
+// - ID удаленной задачи не выдается повторно после повторного открытия
+func TestSQLiteStorageReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.db")
+
+	first := openSQLiteStorage(t, path)
+	mux := handlers.SetupHandlers(first)
+	for _, title := range []string{"Первая", "Вторая"} {
+		expectCode(t, postTask(t, mux, map[string]string{"title": title, "description": "Описание"}), http.StatusCreated)
+	}
+	expectCode(t, putRaw(t, mux, "/tasks/1/metadata/crm", []byte(`{"deal_id":42}`)), http.StatusNoContent)
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/2", nil), http.StatusNoContent)
+	if err := first.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mux = handlers.SetupHandlers(openSQLiteStorage(t, path))
+	w := doJSON(t, mux, "GET", "/tasks/1", nil)
+	expectCode(t, w, http.StatusOK)
+	if task := decodeTask(t, w); task.Title != "Первая" {
+		t.Errorf("Неверная задача после открытия: %+v", task)
+	}
+	if w := doJSON(t, mux, "GET", "/tasks/1/metadata/crm", nil); w.Body.String() != `{"deal_id":42}` {
+		t.Errorf("Метаданные потеряны: %d %s", w.Code, w.Body.String())
+	}
+	expectCode(t, doJSON(t, mux, "GET", "/tasks/2", nil), http.StatusGone)
+
+	w = postTask(t, mux, map[string]string{"title": "Третья", "description": "Описание"})
+	expectCode(t, w, http.StatusCreated)
+	if task := decodeTask(t, w); task.ID != 3 {
+		t.Errorf("Ожидался ID 3, получен %d", task.ID)
+	}
+}
+
+// TestSQLiteStorageHandlers проверяет основные обработчики задач из name_test.go на хранилище SQLite
+func TestSQLiteStorageHandlers(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(*testing.T, storageFactory)
+	}{
+		{"CreateTask", testCreateTaskHandler},
+		{"GetAllTasks", testGetAllTasksHandler},
+		{"GetTask", testGetTaskHandler},
+		{"UpdateTask", testUpdateTaskHandler},
+		{"DeleteTask", testDeleteTaskHandler},
+		{"Timestamps", testTaskTimestamps},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) { tt.run(t, newSQLiteStorage) })
+	}
+}
+
+// TestSQLiteMigrateUp проверяет повторное применение схемы
+//
+// Проверяет:
+// - Повторный вызов MigrateUp не возвращает ошибку и не затрагивает данные
+// - Хранилище работает после повторного вызова
+func TestSQLiteMigrateUp(t *testing.T) {
+	taskStorage := openSQLiteStorage(t, filepath.Join(t.TempDir(), "tasks.db"))
+	mux := handlers.SetupHandlers(taskStorage)
+	expectCode(t, postTask(t, mux, map[string]string{"title": "Первая", "description": "Описание"}), http.StatusCreated)
+
+	if err := storage.MigrateUp(taskStorage); err != nil {
+		t.Fatal(err)
+	}
+	expectCode(t, doJSON(t, mux, "GET", "/tasks/1", nil), http.StatusOK)
+	w := postTask(t, mux, map[string]string{"title": "Вторая", "description": "Описание"})
+	expectCode(t, w, http.StatusCreated)
+	if task := decodeTask(t, w); task.ID != 2 {
+		t.Errorf("Ожидался ID 2, получен %d", task.ID)
+	}
+}
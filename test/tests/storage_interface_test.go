@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"test/handlers"
+	"test/models"
+	"test/storage"
+	"testing"
+)
+
+// stubStorage - минимальное хранилище, реализующее только storage.Storage
+type stubStorage struct {
+	tasks  map[int]*models.Task
+	lastID int
+}
+
+var _ storage.Storage = (*stubStorage)(nil)
+
+func newStubStorage() *stubStorage {
+	return &stubStorage{tasks: make(map[int]*models.Task)}
+}
+
+func (s *stubStorage) CreateTask(ctx context.Context, input storage.CreateTaskInput) (*models.Task, error) {
+	s.lastID++
+	task := &models.Task{ID: s.lastID, Title: input.Title, Description: input.Description, Links: input.Links}
+	s.tasks[task.ID] = task
+	return task, nil
+}
+
+func (s *stubStorage) GetAllTasks(ctx context.Context) ([]*models.Task, error) {
+	tasks := make([]*models.Task, 0, len(s.tasks))
+	for _, task := range s.tasks {
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks, nil
+}
+
+func (s *stubStorage) GetTask(ctx context.Context, id int) (*models.Task, error) {
+	task, exists := s.tasks[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: ID %d", storage.ErrTaskNotFound, id)
+	}
+	return task, nil
+}
+
+func (s *stubStorage) UpdateTask(ctx context.Context, id int, input storage.UpdateTaskInput) (*models.Task, error) {
+	task, err := s.GetTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	task.Title, task.Description, task.Completed = input.Title, input.Description, input.Completed
+	return task, nil
+}
+
+func (s *stubStorage) DeleteTask(ctx context.Context, id int) error {
+	if _, err := s.GetTask(ctx, id); err != nil {
+		return err
+	}
+	delete(s.tasks, id)
+	return nil
+}
+
+func (s *stubStorage) Close() error {
+	return nil
+}
+
+// TestAlternativeStorage проверяет работу обработчиков с хранилищем, отличным от InMemoryStorage
+//
+// Проверяет:
+// - Создание, список, получение, обновление и удаление задачи
+// - Возможности, которых нет у хранилища, отмечены в /capabilities как недоступные
+// - Маршруты недоступных возможностей отвечают кодом 501
+func TestAlternativeStorage(t *testing.T) {
+	stub := newStubStorage()
+	mux := handlers.SetupHandlers(stub)
+
+	if w := postTask(t, mux, map[string]string{"title": "Задача", "description": "Описание"}); w.Code != http.StatusCreated {
+		t.Fatalf("Ожидался код %d, получен %d", http.StatusCreated, w.Code)
+	}
+	postTask(t, mux, map[string]string{"title": "Вторая", "description": "Описание"})
+
+	w := doJSON(t, mux, "GET", "/tasks", nil)
+	var tasks []models.Task
+	if err := json.Unmarshal(w.Body.Bytes(), &tasks); err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 || tasks[0].Title != "Задача" || tasks[1].Title != "Вторая" {
+		t.Errorf("Неверный список: %s", w.Body.String())
+	}
+
+	w = doJSON(t, mux, "PUT", "/tasks/1", map[string]interface{}{"title": "Задача", "description": "Описание", "completed": true})
+	if w.Code != http.StatusOK || !stub.tasks[1].Completed {
+		t.Errorf("Задача не обновлена: %d %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(t, mux, "GET", "/tasks/1", nil); w.Code != http.StatusOK {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusOK, w.Code)
+	}
+
+	// Подтвержденное удаление выполняется обычным DeleteTask
+	req, _ := http.NewRequest("DELETE", "/tasks/2", nil)
+	req.Header.Set(handlers.ConfirmDeleteHeader, strconv.Itoa(2))
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent || len(stub.tasks) != 1 {
+		t.Errorf("Задача не удалена: %d", w.Code)
+	}
+	if w := doJSON(t, mux, "DELETE", "/tasks/1", nil); w.Code != http.StatusNoContent {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusNoContent, w.Code)
+	}
+	if w := doJSON(t, mux, "GET", "/tasks/1", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusNotFound, w.Code)
+	}
+
+	w = doJSON(t, mux, "GET", "/capabilities", nil)
+	var caps map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &caps); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"client_token", "streaming_list", "tombstones", "links", "patch", "toggle", "complete_with_followup", "metadata", "admin_caches", "quick_add", "bulk_create", "bulk_delete", "soft_delete", "overdue", "search", "preferences", "optimistic_concurrency", "task_expiry"} {
+		if caps[name] != false {
+			t.Errorf("Возможность %s: ожидалось false, получено %v", name, caps[name])
+		}
+	}
+
+	stub.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Задача", Description: "Описание"})
+	unsupported := []struct {
+		method string
+		path   string
+		body   interface{}
+	}{
+		{"POST", "/tasks", map[string]string{"title": "Задача", "description": "Описание", "client_token": "t-1"}},
+		{"PATCH", "/tasks/3", map[string]bool{"completed": true}},
+		{"PUT", "/tasks/3/toggle", nil},
+		{"POST", "/tasks/3/links", models.Link{URL: "https://example.com/"}},
+		{"DELETE", "/tasks/3/links/0", nil},
+		{"POST", "/tasks/3/complete-with-followup", map[string]string{"title": "Дальше", "description": "Описание"}},
+		{"GET", "/tasks/3/metadata", nil},
+		{"GET", "/tasks/3?expand=metadata", nil},
+		{"GET", "/admin/caches", nil},
+		{"POST", "/tasks/quick", nil},
+		{"POST", "/tasks/bulk", nil},
+		{"DELETE", "/tasks/bulk", map[string][]int{"ids": {3}}},
+		{"POST", "/tasks/3/restore", nil},
+		{"GET", "/tasks?include_deleted=true", nil},
+		{"GET", "/tasks/overdue", nil},
+		{"GET", "/tasks/search?q=задача", nil},
+		{"GET", "/preferences", nil},
+		{"PUT", "/tasks/3", map[string]interface{}{"title": "Задача", "description": "Описание", "version": 1}},
+		{"POST", "/tasks", map[string]interface{}{"title": "Задача", "description": "Описание", "expires_in_seconds": 60}},
+	}
+	for _, req := range unsupported {
+		w := doJSON(t, mux, req.method, req.path, req.body)
+		if w.Code != http.StatusNotImplemented {
+			t.Errorf("%s %s: ожидался код %d, получен %d", req.method, req.path, http.StatusNotImplemented, w.Code)
+			continue
+		}
+		var body map[string]string
+		json.Unmarshal(w.Body.Bytes(), &body)
+		if body["code"] != handlers.CodeNotSupported {
+			t.Errorf("%s %s: неверное тело ошибки: %s", req.method, req.path, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"test/handlers"
+	"test/models"
+	"test/storage"
+	"testing"
+)
+
+// TestGetAllTasksStream проверяет потоковую выдачу списка задач
+//
+// Проверяет:
+// - Ответ является корректным JSON-массивом со всеми задачами в порядке возрастания ID
+// - Пустое хранилище и пустой результат фильтра дают []
+// - Отмена запроса до первого элемента возвращается кодом 499
+func TestGetAllTasksStream(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+	mux := handlers.SetupHandlers(taskStorage)
+
+	w := doJSON(t, mux, "GET", "/tasks", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "[]\n" {
+		t.Fatalf("Ожидался пустой массив, получен код %d: %q", w.Code, w.Body.String())
+	}
+
+	const count = 1000
+	for i := 1; i <= count; i++ {
+		taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: fmt.Sprintf("Задача %d", i), Description: "Описание"})
+	}
+
+	w = doJSON(t, mux, "GET", "/tasks", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался код %d, получен %d", http.StatusOK, w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Ожидался Content-Type application/json, получен %q", contentType)
+	}
+
+	var tasks []*models.Task
+	if err := json.Unmarshal(w.Body.Bytes(), &tasks); err != nil {
+		t.Fatalf("Ответ не является JSON-массивом: %v", err)
+	}
+	seen := make(map[int]bool, len(tasks))
+	for i, task := range tasks {
+		seen[task.ID] = true
+		if i > 0 && task.ID <= tasks[i-1].ID {
+			t.Fatalf("Нарушен порядок возрастания ID: %d после %d", task.ID, tasks[i-1].ID)
+		}
+	}
+	if len(tasks) != count || len(seen) != count {
+		t.Errorf("Ожидалось %d разных задач, получено %d (%d разных)", count, len(tasks), len(seen))
+	}
+
+	w = doJSON(t, mux, "GET", "/tasks?has_link=true", nil)
+	if w.Body.String() != "[]\n" {
+		t.Errorf("Ожидался пустой массив для фильтра, получено %q", w.Body.String())
+	}
+
+	// Отмененный запрос прерывает обход до первого элемента
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", "/tasks", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != handlers.StatusClientClosedRequest {
+		t.Errorf("Ожидался код %d, получен %d", handlers.StatusClientClosedRequest, w.Code)
+	}
+}
+
+// BenchmarkGetAllTasksStream измеряет выдачу большого списка задач
+//
+// В памяти одновременно находятся только снимок указателей и буфер одного элемента,
+// а не весь JSON-ответ: число байт на задачу не должно расти с размером списка.
+func BenchmarkGetAllTasksStream(b *testing.B) {
+	for _, count := range []int{1000, 100000} {
+		b.Run(fmt.Sprintf("%d задач", count), func(b *testing.B) {
+			taskStorage := storage.NewInMemoryStorage()
+			for i := 0; i < count; i++ {
+				taskStorage.CreateTask(b.Context(), storage.CreateTaskInput{Title: fmt.Sprintf("Задача %d", i), Description: "Описание задачи"})
+			}
+			mux := handlers.SetupHandlers(taskStorage)
+			req, err := http.NewRequest("GET", "/tasks", nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				mux.ServeHTTP(discardWriter{header: http.Header{}}, req)
+			}
+		})
+	}
+}
+
+// discardWriter - ResponseWriter, отбрасывающий тело ответа
+type discardWriter struct {
+	header http.Header
+}
+
+func (d discardWriter) Header() http.Header         { return d.header }
+func (d discardWriter) Write(p []byte) (int, error) { return io.Discard.Write(p) }
+func (d discardWriter) WriteHeader(int)             {}
@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"test/handlers"
+	"test/storage"
+	"testing"
+)
+
+// untaggedStorage - хранилище в памяти без индекса по тегам
+//
+// Поле TasksByTags скрывает одноименный метод встроенного хранилища,
+// поэтому оно не реализует storage.TagStorage.
+type untaggedStorage struct {
+	*storage.InMemoryStorage
+	TasksByTags struct{}
+}
+
+// TestTaskTags проверяет задание и изменение тегов задачи
+//
+// Проверяет:
+// - Повторы тегов отбрасываются при создании и обновлении, порядок сохраняется
+// - PUT без tags сохраняет теги, пустой список снимает их
+// - Код 400 на неверный тег и на больше MaxTags тегов
+func TestTaskTags(t *testing.T) {
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage())
+
+	w := postTask(t, mux, map[string]interface{}{"title": "Задача", "description": "Описание", "tags": []string{"дом", " работа ", "дом", "работа"}})
+	expectCode(t, w, http.StatusCreated)
+	if task := decodeTask(t, w); !slices.Equal(task.Tags, []string{"дом", "работа"}) {
+		t.Errorf("Ожидались теги [дом работа], получены %v", task.Tags)
+	}
+
+	w = doJSON(t, mux, "PUT", "/tasks/1", map[string]string{"title": "Задача", "description": "Описание"})
+	expectCode(t, w, http.StatusOK)
+	if task := decodeTask(t, w); !slices.Equal(task.Tags, []string{"дом", "работа"}) {
+		t.Errorf("PUT без tags изменил теги: %v", task.Tags)
+	}
+
+	w = doJSON(t, mux, "PUT", "/tasks/1", map[string]interface{}{"title": "Задача", "description": "Описание", "tags": []string{"срочно", "срочно", "дом"}})
+	expectCode(t, w, http.StatusOK)
+	if task := decodeTask(t, w); !slices.Equal(task.Tags, []string{"срочно", "дом"}) {
+		t.Errorf("Ожидались теги [срочно дом], получены %v", task.Tags)
+	}
+
+	w = doJSON(t, mux, "PUT", "/tasks/1", map[string]interface{}{"title": "Задача", "description": "Описание", "tags": []string{}})
+	expectCode(t, w, http.StatusOK)
+	if task := decodeTask(t, w); len(task.Tags) != 0 {
+		t.Errorf("Пустой список не снял теги: %v", task.Tags)
+	}
+
+	tooMany := make([]string, 21)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("тег%d", i)
+	}
+	for _, tags := range [][]string{{"два слова"}, {""}, {"#дом"}, {strings.Repeat("т", 51)}, tooMany} {
+		expectCode(t, postTask(t, mux, map[string]interface{}{"title": "Задача", "description": "Описание", "tags": tags}), http.StatusBadRequest)
+		expectCode(t, doJSON(t, mux, "PUT", "/tasks/1", map[string]interface{}{"title": "Задача", "description": "Описание", "tags": tags}), http.StatusBadRequest)
+	}
+}
+
+// TestTaskTagFilter проверяет фильтр ?tag= списка задач
+//
+// Проверяет:
+// - Один tag отбирает задачи с этим тегом, несколько - задачи со всеми тегами
+// - Фильтр по индексу хранилища и обходом задач хранилища без индекса
+// - Изменение тегов и удаление задачи обновляют индекс
+// - Сочетание с priority, сортировкой и страницами
+// - Код 400 на неверный тег в параметре
+func TestTaskTagFilter(t *testing.T) {
+	backends := map[string]storage.Storage{
+		"с индексом":  storage.NewInMemoryStorage(),
+		"без индекса": &untaggedStorage{InMemoryStorage: storage.NewInMemoryStorage()},
+		"SQLite":      newSQLiteStorage(t),
+	}
+
+	for name, taskStorage := range backends {
+		t.Run(name, func(t *testing.T) {
+			mux := handlers.SetupHandlers(taskStorage)
+			for i, tags := range [][]string{{"дом"}, {"дом", "срочно"}, {"работа", "срочно"}, nil, {"срочно", "дом", "магазин"}} {
+				body := map[string]interface{}{"title": fmt.Sprintf("Задача %d", i+1), "description": "Описание", "tags": tags}
+				if i == 4 {
+					body["priority"] = "high"
+				}
+				expectCode(t, postTask(t, mux, body), http.StatusCreated)
+			}
+
+			for path, expected := range map[string]string{
+				"/tasks?tag=дом&sort=title":                 "[1 2 5]",
+				"/tasks?tag=срочно&sort=title":              "[2 3 5]",
+				"/tasks?tag=дом&tag=срочно&sort=title":      "[2 5]",
+				"/tasks?tag=срочно&tag=дом&tag=магазин":     "[5]",
+				"/tasks?tag=дом&tag=работа":                 "[]",
+				"/tasks?tag=нет":                            "[]",
+				"/tasks?tag=срочно&priority=high":           "[5]",
+				"/tasks?tag=срочно&limit=2&page=2":          "[5]",
+				"/tasks?tag=дом&tag=дом&sort=title&limit=2": "[1 2]",
+			} {
+				if ids := priorityIDs(t, mux, path); ids != expected {
+					t.Errorf("%s: ожидались задачи %s, получены %s", path, expected, ids)
+				}
+			}
+
+			expectCode(t, doJSON(t, mux, "PUT", "/tasks/1", map[string]interface{}{"title": "Задача 1", "description": "Описание", "tags": []string{"срочно"}}), http.StatusOK)
+			expectCode(t, doJSON(t, mux, "DELETE", "/tasks/2", nil), http.StatusNoContent)
+			if ids := priorityIDs(t, mux, "/tasks?tag=дом"); ids != "[5]" {
+				t.Errorf("После изменения и удаления ожидались задачи [5] с тегом дом, получены %s", ids)
+			}
+			if ids := priorityIDs(t, mux, "/tasks?tag=срочно&sort=title"); ids != "[1 3 5]" {
+				t.Errorf("После изменения ожидались задачи [1 3 5] с тегом срочно, получены %s", ids)
+			}
+
+			for _, tag := range []string{"", "два%20слова", "%23дом"} {
+				expectCode(t, doJSON(t, mux, "GET", "/tasks?tag="+tag, nil), http.StatusBadRequest)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"test/handlers"
+	"test/models"
+	"test/storage"
+	"testing"
+	"time"
+)
+
+// update перезаписывает эталонные файлы testdata текущим выводом
+var update = flag.Bool("update", false, "перезаписать эталонные файлы testdata")
+
+// getText выполняет GET-запрос с заголовком Accept: text/plain
+func getText(t *testing.T, mux http.Handler, path string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest("GET", path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "text/plain")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+// checkGolden сравнивает вывод с эталонным файлом testdata/name
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(expected) {
+		t.Errorf("Вывод не совпадает с %s:\n%s\nожидалось:\n%s", path, got, expected)
+	}
+}
+
+// newTextStorage создает хранилище с задачами для проверки текстового вывода,
+// созданными 2024-01-01 в 12:00 UTC
+func newTextStorage(t *testing.T) *storage.InMemoryStorage {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	due := time.Date(2024, 1, 5, 18, 0, 0, 0, time.UTC)
+	taskStorage := storage.NewInMemoryStorage(storage.WithClock(clock.Now))
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Buy milk", Description: "2 литра"})
+	done, _ := taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Ship release", Description: "Собрать"})
+	completed := true
+	if _, err := taskStorage.UpdateTask(t.Context(), done.ID, storage.UpdateTaskInput{Title: done.Title, Description: done.Description, Completed: completed}); err != nil {
+		t.Fatal(err)
+	}
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{
+		Title:       "Ревью\tрелиза\nv2",
+		Description: "@alice глянь https://git.example/pr/7\r\nи отпишись",
+		Links:       []models.Link{{Title: "PR", URL: "https://git.example/pr/7"}, {URL: "https://tracker.example/T-1"}},
+		Tags:        []string{"release", "backend"},
+		Protected:   true,
+		Priority:    "high",
+		DueDate:     &due,
+		ExpiresIn:   30 * 24 * time.Hour,
+	})
+	if err := taskStorage.SetMetadata(3, "crm", []byte(`{ "deal_id": 42 }`)); err != nil {
+		t.Fatal(err)
+	}
+	return taskStorage
+}
+
+// TestTextPlainOutput проверяет вывод text/plain для скриптов
+//
+// Проверяет:
+// - Список задач по одной на строку с фильтрами и сортировкой
+// - Блок одной задачи со всеми заполненными полями, как в JSON-ответе
+// - Метки времени блока выводятся в часовом поясе ?tz
+// - Управляющие символы в названии не разрывают строку
+// - Ошибки выводятся одной строкой с кодом
+// - Без предпочтения text/plain ответ остается JSON
+func TestTextPlainOutput(t *testing.T) {
+	mux := handlers.SetupHandlers(newTextStorage(t))
+
+	tests := []struct {
+		path   string
+		status int
+		golden string
+	}{
+		// Порядок обхода хранилища не определен, поэтому списки сравниваются отсортированными
+		{"/tasks?sort=title", http.StatusOK, "text_list.golden"},
+		{"/tasks?has_link=false&sort=title", http.StatusOK, "text_list_without_links.golden"},
+		{"/tasks?has_link=true", http.StatusOK, "text_list_has_link.golden"},
+		{"/tasks/1", http.StatusOK, "text_task_minimal.golden"},
+		{"/tasks/3?expand=metadata", http.StatusOK, "text_task_full.golden"},
+		{"/tasks/3?tz=Asia/Bangkok", http.StatusOK, "text_task_timezone.golden"},
+		{"/tasks/99", http.StatusNotFound, "text_error_not_found.golden"},
+		{"/tasks?sort=size", http.StatusBadRequest, "text_error_bad_request.golden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := getText(t, mux, tt.path)
+			if w.Code != tt.status {
+				t.Fatalf("Ожидался код %d, получен %d", tt.status, w.Code)
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != "text/plain; charset=utf-8" {
+				t.Errorf("Неверный Content-Type: %q", contentType)
+			}
+			checkGolden(t, tt.golden, w.Body.Bytes())
+		})
+	}
+}
+
+// TestTextPlainNegotiation проверяет выбор text/plain по весам заголовка Accept
+func TestTextPlainNegotiation(t *testing.T) {
+	mux := handlers.SetupHandlers(newTextStorage(t))
+
+	tests := []struct {
+		accept   string
+		expected string
+	}{
+		{"", "application/json"},
+		{"*/*", "application/json"},
+		{"application/json", "application/json"},
+		{"text/plain", "text/plain; charset=utf-8"},
+		{"text/*", "text/plain; charset=utf-8"},
+		{"application/json;q=0.5, text/plain", "text/plain; charset=utf-8"},
+		{"text/plain;q=0.5, application/json", "application/json"},
+		{"text/html,application/xhtml+xml,*/*;q=0.8", "application/json"},
+		{"text/plain;q=0", "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/tasks/1", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+			if contentType := w.Header().Get("Content-Type"); contentType != tt.expected {
+				t.Errorf("Ожидался Content-Type %q, получен %q", tt.expected, contentType)
+			}
+		})
+	}
+
+	// Запросы изменения не затрагиваются
+	req, _ := http.NewRequest("DELETE", "/tasks/99", nil)
+	req.Header.Set("Accept", "text/plain")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	var body handlers.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Code != http.StatusNotFound || body.Error != "задача не найдена: ID 99" {
+		t.Errorf("Ответ DELETE изменился: %d %q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,208 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test/handlers"
+	"test/storage"
+	"testing"
+	"time"
+)
+
+// timestamps - метки времени задачи в исходном виде ответа
+type timestamps struct {
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// newTimezoneMux создает маршрутизатор с задачей 1, созданной 2024-01-01 в 12:00 UTC
+func newTimezoneMux(t *testing.T) http.Handler {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage(storage.WithClock(clock.Now)))
+	expectCode(t, postTask(t, mux, map[string]string{"title": "Отчет", "description": `"created_at":"2024-01-01T12:00:00Z"`}), http.StatusCreated)
+	return mux
+}
+
+// getTimestamps выполняет GET с заголовками и возвращает метки времени ответа
+func getTimestamps(t *testing.T, mux http.Handler, path string, header map[string]string) timestamps {
+	t.Helper()
+	r := httptest.NewRequest("GET", path, nil)
+	for name, value := range header {
+		r.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	expectCode(t, w, http.StatusOK)
+
+	var ts timestamps
+	if err := json.Unmarshal(w.Body.Bytes(), &ts); err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
+
+// TestResponseTimezone проверяет вывод меток времени в часовом поясе клиента
+//
+// Проверяет:
+// - По умолчанию и для tz=UTC метки времени выводятся в UTC
+// - Одна задача в трех поясах: смещение меняется, момент времени тот же
+// - Заголовок X-Timezone действует без ?tz, а ?tz важнее заголовка
+// - Метки времени в списке задач и в ответе 410 тоже переводятся
+func TestResponseTimezone(t *testing.T) {
+	mux := newTimezoneMux(t)
+
+	zones := []struct {
+		tz       string
+		expected string
+	}{
+		{"", "2024-01-01T12:00:00Z"},
+		{"UTC", "2024-01-01T12:00:00Z"},
+		{"Asia/Bangkok", "2024-01-01T19:00:00+07:00"},
+		{"America/New_York", "2024-01-01T07:00:00-05:00"},
+		{"Asia/Kolkata", "2024-01-01T17:30:00+05:30"},
+	}
+	for _, zone := range zones {
+		ts := getTimestamps(t, mux, "/tasks/1?tz="+zone.tz, nil)
+		if ts.CreatedAt != zone.expected || ts.UpdatedAt != zone.expected {
+			t.Errorf("tz=%s: ожидалось %s, получено %+v", zone.tz, zone.expected, ts)
+		}
+	}
+
+	if ts := getTimestamps(t, mux, "/tasks/1", map[string]string{handlers.TimezoneHeader: "Asia/Bangkok"}); ts.CreatedAt != "2024-01-01T19:00:00+07:00" {
+		t.Errorf("Заголовок %s не учтен: %+v", handlers.TimezoneHeader, ts)
+	}
+	if ts := getTimestamps(t, mux, "/tasks/1?tz=Asia/Kolkata", map[string]string{handlers.TimezoneHeader: "Asia/Bangkok"}); ts.CreatedAt != "2024-01-01T17:30:00+05:30" {
+		t.Errorf("Параметр tz должен быть важнее заголовка: %+v", ts)
+	}
+
+	// Описание с текстом метки времени не меняется
+	w := doJSON(t, mux, "GET", "/tasks?tz=Asia/Bangkok", nil)
+	expectCode(t, w, http.StatusOK)
+	var list []struct {
+		timestamps
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].CreatedAt != "2024-01-01T19:00:00+07:00" || list[0].Description != `"created_at":"2024-01-01T12:00:00Z"` {
+		t.Errorf("Неверный список: %+v", list)
+	}
+
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/1", nil), http.StatusNoContent)
+	w = doJSON(t, mux, "GET", "/tasks/1?tz=Asia/Bangkok", nil)
+	expectCode(t, w, http.StatusGone)
+	if !strings.Contains(w.Body.String(), `"deleted_at":"2024-01-01T19:00:00+07:00"`) {
+		t.Errorf("Момент удаления не переведен: %s", w.Body.String())
+	}
+}
+
+// TestResponseTimezoneWrites проверяет, что часовой пояс не влияет на запись
+//
+// Проверяет:
+// - Неизвестный пояс в GET отклоняется кодом 400 и invalid_timezone, как и Local
+// - POST и PUT не учитывают ?tz и X-Timezone, в том числе неизвестные
+// - Хранилище по-прежнему отдает метки времени в UTC
+func TestResponseTimezoneWrites(t *testing.T) {
+	mux := newTimezoneMux(t)
+
+	for _, path := range []string{"/tasks/1?tz=Mars/Olympus", "/tasks?tz=Local", "/tasks/1?tz=+07:00"} {
+		w := doJSON(t, mux, "GET", path, nil)
+		expectCode(t, w, http.StatusBadRequest)
+		var resp struct {
+			Code string `json:"code"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Code != handlers.CodeInvalidTimezone {
+			t.Errorf("%s: ожидался код %s, получено %s", path, handlers.CodeInvalidTimezone, w.Body.String())
+		}
+	}
+
+	w := doJSON(t, mux, "POST", "/tasks?tz=Asia/Bangkok", map[string]string{"title": "Вторая", "description": "Описание"})
+	expectCode(t, w, http.StatusCreated)
+	if task := decodeTask(t, w); task.CreatedAt.Location() != time.UTC {
+		t.Errorf("Ответ на запись переведен в другой пояс: %v", task.CreatedAt)
+	}
+
+	r := httptest.NewRequest("PUT", "/tasks/1?tz=Mars/Olympus", strings.NewReader(`{"title":"Отчет","description":"Описание","completed":true}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(handlers.TimezoneHeader, "Asia/Bangkok")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	expectCode(t, w, http.StatusOK)
+	if !strings.Contains(w.Body.String(), `"updated_at":"2024-01-01T12:00:00Z"`) {
+		t.Errorf("Ответ на запись переведен в другой пояс: %s", w.Body.String())
+	}
+
+	if ts := getTimestamps(t, mux, "/tasks/1", nil); ts.CreatedAt != "2024-01-01T12:00:00Z" || ts.UpdatedAt != "2024-01-01T12:00:00Z" {
+		t.Errorf("Ожидались метки времени в UTC: %+v", ts)
+	}
+}
+
+// TestResponseTimezoneMetadata проверяет, что часовой пояс не меняет метаданные интеграций
+//
+// Проверяет:
+// - В ответе ?expand=metadata переводятся метки времени задачи, но не ключи метаданных
+// - GET /tasks/{id}/metadata/{ns} отдает значение как есть
+func TestResponseTimezoneMetadata(t *testing.T) {
+	mux := newTimezoneMux(t)
+	value := `{"created_at":"2024-01-01T12:00:00Z","due_date":"2024-02-01T00:00:00Z"}`
+	expectCode(t, putRaw(t, mux, "/tasks/1/metadata/crm", []byte(value)), http.StatusNoContent)
+
+	w := doJSON(t, mux, "GET", "/tasks/1?expand=metadata&tz=Asia/Bangkok", nil)
+	expectCode(t, w, http.StatusOK)
+	var task struct {
+		timestamps
+		Metadata map[string]json.RawMessage `json:"metadata"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &task); err != nil {
+		t.Fatal(err)
+	}
+	if task.CreatedAt != "2024-01-01T19:00:00+07:00" || string(task.Metadata["crm"]) != value {
+		t.Errorf("Неверный ответ ?expand=metadata: %s", w.Body.String())
+	}
+
+	w = doJSON(t, mux, "GET", "/tasks/1/metadata/crm?tz=Asia/Bangkok", nil)
+	expectCode(t, w, http.StatusOK)
+	if body := strings.TrimSpace(w.Body.String()); body != value {
+		t.Errorf("Метаданные изменены: ожидалось %s, получено %s", value, body)
+	}
+}
+
+// TestResponseTimezoneExpiresAt проверяет перевод срока и момента истечения задачи
+//
+// Проверяет:
+// - due_date и expires_at переводятся в пояс ?tz в задаче и в списке задач
+func TestResponseTimezoneExpiresAt(t *testing.T) {
+	mux := newTimezoneMux(t)
+	expectCode(t, postTask(t, mux, map[string]interface{}{
+		"title": "Напоминание", "description": "Описание", "due_date": "2024-01-02T00:00:00Z", "expires_in_seconds": 3600,
+	}), http.StatusCreated)
+
+	type deadlines struct {
+		DueDate   string `json:"due_date"`
+		ExpiresAt string `json:"expires_at"`
+	}
+	expected := deadlines{DueDate: "2024-01-02T07:00:00+07:00", ExpiresAt: "2024-01-01T20:00:00+07:00"}
+
+	w := doJSON(t, mux, "GET", "/tasks/2?tz=Asia/Bangkok", nil)
+	expectCode(t, w, http.StatusOK)
+	var task deadlines
+	if err := json.Unmarshal(w.Body.Bytes(), &task); err != nil {
+		t.Fatal(err)
+	}
+	if task != expected {
+		t.Errorf("Ожидалось %+v, получено %+v", expected, task)
+	}
+
+	w = doJSON(t, mux, "GET", "/tasks?tz=Asia/Bangkok", nil)
+	expectCode(t, w, http.StatusOK)
+	var list []deadlines
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[1] != expected {
+		t.Errorf("Неверный список: %+v", list)
+	}
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"net/http"
+	"sync"
+	"test/handlers"
+	"test/storage"
+	"testing"
+)
+
+// TestToggleTask проверяет PUT /tasks/{id}/toggle
+//
+// Проверяет:
+// - Каждый запрос инвертирует completed и возвращает обновленную задачу с новой версией
+// - Переключение разрешено при неизменяемых выполненных задачах
+// - Код 404 для несуществующей задачи и 405 на другие методы
+func TestToggleTask(t *testing.T) {
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage(storage.WithCompletedImmutable()))
+	expectCode(t, postTask(t, mux, map[string]string{"title": "Задача", "description": "Описание"}), http.StatusCreated)
+
+	for i, completed := range []bool{true, false, true} {
+		w := doJSON(t, mux, "PUT", "/tasks/1/toggle", nil)
+		expectCode(t, w, http.StatusOK)
+		if task := decodeTask(t, w); task.Completed != completed || task.Version != i+2 {
+			t.Errorf("Переключение %d: ожидалось completed=%v и версия %d, получено %v и %d", i+1, completed, i+2, task.Completed, task.Version)
+		}
+	}
+	if task := decodeTask(t, doJSON(t, mux, "GET", "/tasks/1", nil)); !task.Completed {
+		t.Error("Переключение не сохранено")
+	}
+
+	expectCode(t, doJSON(t, mux, "PUT", "/tasks/2/toggle", nil), http.StatusNotFound)
+	expectCode(t, doJSON(t, mux, "POST", "/tasks/1/toggle", nil), http.StatusMethodNotAllowed)
+}
+
+// TestToggleTaskConcurrent проверяет атомарность ToggleTask
+//
+// Запускать с -race. Если бы чтение completed и запись нового значения шли
+// отдельными операциями, часть переключений терялась бы и итог зависел бы от
+// порядка горутин.
+//
+// Проверяет:
+// - После 100 параллельных переключений задача снова не выполнена
+// - Версия выросла ровно на 100, ни одно переключение не потеряно
+func TestToggleTaskConcurrent(t *testing.T) {
+	for name, newStorage := range map[string]storageFactory{
+		"InMemory": newMemoryStorage,
+		"Sharded":  newShardedStorage,
+		"SQLite":   newSQLiteStorage,
+		"Bolt":     newBoltStorage,
+	} {
+		t.Run(name, func(t *testing.T) {
+			taskStorage := newStorage(t)
+			created, err := taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Задача", Description: "Описание"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			toggle := taskStorage.(storage.ToggleStorage)
+
+			const workers = 100
+			var wg sync.WaitGroup
+			for i := 0; i < workers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := toggle.ToggleTask(created.ID); err != nil {
+						t.Errorf("Ошибка переключения: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			task, err := taskStorage.GetTask(t.Context(), created.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if task.Completed || task.Version != created.Version+workers {
+				t.Errorf("Ожидалось completed=false и версия %d, получено %v и %d", created.Version+workers, task.Completed, task.Version)
+			}
+		})
+	}
+}
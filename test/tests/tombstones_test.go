@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"test/handlers"
+	"test/models"
+	"test/storage"
+	"testing"
+	"time"
+)
+
+// TestDeletedTaskGone проверяет ответ 410 на обращение к недавно удаленной задаче
+//
+// Проверяет:
+// - GET, PUT и операции со ссылками удаленной задачи возвращают 410 с моментом удаления
+// - Повторный DELETE окончательно удаляет задачу, но запись об удалении остается
+// - Никогда не существовавшая задача по-прежнему возвращает 404
+// - Новая задача не получает ID удаленной
+// - После истечения TTL удаленная задача возвращает 404
+func TestDeletedTaskGone(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	taskStorage := storage.NewInMemoryStorage(storage.WithClock(clock.Now), storage.WithTombstoneTTL(time.Hour))
+	mux := handlers.SetupHandlers(taskStorage)
+
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Задача", Description: "Описание"})
+	if w := doJSON(t, mux, "DELETE", "/tasks/1", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("Ожидался код %d, получен %d", http.StatusNoContent, w.Code)
+	}
+	clock.Advance(10 * time.Minute)
+
+	requests := []struct {
+		method string
+		path   string
+		body   interface{}
+	}{
+		{"GET", "/tasks/1", nil},
+		{"PUT", "/tasks/1", map[string]string{"title": "Задача", "description": "Описание"}},
+		{"POST", "/tasks/1/links", models.Link{URL: "https://example.com/"}},
+		{"DELETE", "/tasks/1/links/0", nil},
+	}
+	for _, req := range requests {
+		w := doJSON(t, mux, req.method, req.path, req.body)
+		if w.Code != http.StatusGone {
+			t.Errorf("%s %s: ожидался код %d, получен %d", req.method, req.path, http.StatusGone, w.Code)
+			continue
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if body["code"] != handlers.CodeTaskDeleted || body["deleted_at"] != "2024-05-01T12:00:00Z" {
+			t.Errorf("%s %s: неверное тело ошибки: %v", req.method, req.path, body)
+		}
+	}
+
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/1", nil), http.StatusNoContent)
+	expectCode(t, doJSON(t, mux, "DELETE", "/tasks/1", nil), http.StatusGone)
+
+	if w := doJSON(t, mux, "GET", "/tasks/99", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusNotFound, w.Code)
+	}
+
+	// ID удаленной задачи повторно не выдается
+	task, _ := taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Новая", Description: "Описание"})
+	if task.ID == 1 {
+		t.Fatalf("Новой задаче выдан ID удаленной")
+	}
+	if w := doJSON(t, mux, "GET", "/tasks/1", nil); w.Code != http.StatusGone {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusGone, w.Code)
+	}
+
+	clock.Advance(time.Hour)
+	if w := doJSON(t, mux, "GET", "/tasks/1", nil); w.Code != http.StatusNotFound {
+		t.Errorf("После истечения TTL ожидался код %d, получен %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestTombstoneEviction проверяет вытеснение самых давних удалений при переполнении
+func TestTombstoneEviction(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage(storage.WithTombstoneCapacity(2))
+	for i := 0; i < 3; i++ {
+		taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Задача", Description: "Описание"})
+	}
+	for id := 1; id <= 3; id++ {
+		taskStorage.DeleteTask(t.Context(), id)
+	}
+
+	for id, gone := range map[int]bool{1: false, 2: true, 3: true} {
+		_, err := taskStorage.GetTask(t.Context(), id)
+		_, deleted := err.(*storage.TaskDeletedError)
+		if deleted != gone {
+			t.Errorf("Задача %d: ожидалось deleted=%v, ошибка: %v", id, gone, err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"test/storage"
+	"testing"
+)
+
+// TestTransactionConcurrent проверяет, что транзакции не перемежаются
+//
+// Проверяет:
+// - Параллельные транзакции чтение-изменение одной задачи не теряют обновлений
+// - Чтение во время транзакций видит только целые пары созданных задач
+func TestTransactionConcurrent(t *testing.T) {
+	for name, newStorage := range map[string]storageFactory{
+		"InMemory": newMemoryStorage,
+		"SQLite":   newSQLiteStorage,
+	} {
+		t.Run(name, func(t *testing.T) {
+			taskStorage := newStorage(t)
+			transactions := taskStorage.(storage.TransactionalStorage)
+			if _, err := taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "0", Description: "Счетчик"}); err != nil {
+				t.Fatal(err)
+			}
+
+			const workers, iterations = 4, 25
+			var wg sync.WaitGroup
+			for w := 0; w < workers; w++ {
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < iterations; i++ {
+						err := transactions.RunInTransaction(func(tx storage.TaskTx) error {
+							counter, err := tx.GetTask(1)
+							if err != nil {
+								return err
+							}
+							n, _ := strconv.Atoi(counter.Title)
+							for _, title := range []string{"Пара", "Пара"} {
+								if _, err := tx.CreateTask(storage.CreateTaskInput{Title: title, Description: "Описание"}); err != nil {
+									return err
+								}
+							}
+							_, err = tx.UpdateTask(1, storage.UpdateTaskInput{Title: strconv.Itoa(n + 1), Description: counter.Description})
+							return err
+						})
+						if err != nil {
+							t.Errorf("Ошибка транзакции: %v", err)
+						}
+					}
+				}()
+				go func() {
+					defer wg.Done()
+					for i := 0; i < iterations; i++ {
+						tasks, err := taskStorage.GetAllTasks(t.Context())
+						if err != nil {
+							t.Errorf("Ошибка чтения: %v", err)
+							return
+						}
+						if len(tasks)%2 != 1 {
+							t.Errorf("Прочитано промежуточное состояние транзакции: %d задач", len(tasks))
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			counter, err := taskStorage.GetTask(t.Context(), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected := fmt.Sprint(workers * iterations); counter.Title != expected {
+				t.Errorf("Ожидался счетчик %s, получен %s", expected, counter.Title)
+			}
+			if tasks, _ := taskStorage.GetAllTasks(t.Context()); len(tasks) != 1+2*workers*iterations {
+				t.Errorf("Ожидалось %d задач, получено %d", 1+2*workers*iterations, len(tasks))
+			}
+		})
+	}
+}
+
+// TestTransactionStorageLimit проверяет транзакцию в хранилище с лимитом задач
+//
+// Проверяет:
+// - Транзакция, которой не хватает места, не применяется и возвращает ErrStorageFull
+// - Задача, выполненная в самой транзакции, не вытесняется ради ее же новых задач
+func TestTransactionStorageLimit(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorageWithLimit(2, storage.EvictOldestCompleted)
+	createTasks(t, taskStorage, 2)
+
+	err := taskStorage.RunInTransaction(func(tx storage.TaskTx) error {
+		if _, err := tx.UpdateTask(1, storage.UpdateTaskInput{Title: "Задача 1", Description: "Описание", Completed: true}); err != nil {
+			return err
+		}
+		_, err := tx.CreateTask(storage.CreateTaskInput{Title: "Задача 3", Description: "Описание"})
+		return err
+	})
+	if !errors.Is(err, storage.ErrStorageFull) {
+		t.Errorf("Ожидалась ошибка ErrStorageFull, получено %v", err)
+	}
+	if task, err := taskStorage.GetTask(t.Context(), 1); err != nil || task.Completed {
+		t.Errorf("Транзакция применилась частично: %+v, %v", task, err)
+	}
+	if n := storedTasks(t, taskStorage); n != 2 {
+		t.Errorf("Ожидалось 2 задачи, получено %d", n)
+	}
+}
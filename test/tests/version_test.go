@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"test/handlers"
+	"testing"
+)
+
+// TestOptimisticConcurrency проверяет условное обновление задачи по полю version
+//
+// Проверяет:
+// - Новая задача имеет версию 1, PUT с текущей версией увеличивает ее до 2
+// - PUT с устаревшей версией отвечает 409 с кодом version_conflict и текущей версией, задача не меняется
+// - PUT без version обновляет задачу безусловно
+// - PUT с version для несуществующей задачи отвечает 404, а не 409
+func TestOptimisticConcurrency(t *testing.T) {
+	for name, newStorage := range map[string]storageFactory{
+		"InMemory": newMemoryStorage,
+		"Sharded":  newShardedStorage,
+		"SQLite":   newSQLiteStorage,
+	} {
+		t.Run(name, func(t *testing.T) {
+			mux := handlers.SetupHandlers(newStorage(t))
+			w := postTask(t, mux, map[string]string{"title": "Задача", "description": "Описание"})
+			expectCode(t, w, http.StatusCreated)
+			if task := decodeTask(t, w); task.Version != 1 {
+				t.Fatalf("Ожидалась версия 1, получена %d", task.Version)
+			}
+
+			w = doJSON(t, mux, "PUT", "/tasks/1", map[string]interface{}{"title": "Первый клиент", "description": "Описание", "version": 1})
+			expectCode(t, w, http.StatusOK)
+			if task := decodeTask(t, w); task.Version != 2 || task.Title != "Первый клиент" {
+				t.Errorf("Неверная обновленная задача: %+v", task)
+			}
+
+			w = doJSON(t, mux, "PUT", "/tasks/1", map[string]interface{}{"title": "Второй клиент", "description": "Описание", "version": 1})
+			expectCode(t, w, http.StatusConflict)
+			var body struct {
+				handlers.ErrorResponse
+				CurrentVersion int `json:"current_version"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != handlers.CodeVersionConflict || body.CurrentVersion != 2 {
+				t.Errorf("Неверное тело ответа: %s", w.Body.String())
+			}
+			w = doJSON(t, mux, "GET", "/tasks/1", nil)
+			expectCode(t, w, http.StatusOK)
+			if task := decodeTask(t, w); task.Version != 2 || task.Title != "Первый клиент" {
+				t.Errorf("Задача изменена устаревшим обновлением: %+v", task)
+			}
+
+			w = doJSON(t, mux, "PUT", "/tasks/1", map[string]interface{}{"title": "Без версии", "description": "Описание"})
+			expectCode(t, w, http.StatusOK)
+			if task := decodeTask(t, w); task.Version != 3 || task.Title != "Без версии" {
+				t.Errorf("Неверная обновленная задача: %+v", task)
+			}
+
+			expectCode(t, doJSON(t, mux, "PUT", "/tasks/99", map[string]interface{}{"title": "Задача", "description": "Описание", "version": 1}), http.StatusNotFound)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"test/handlers"
+	"test/storage"
+	"testing"
+)
+
+// writeResult - поля ответа записи задачи, относящиеся к мягкой валидации
+type writeResult struct {
+	ID       int    `json:"id"`
+	Title    string `json:"title"`
+	Code     string `json:"code"`
+	Warnings []struct {
+		Field string `json:"field"`
+		Rule  string `json:"rule"`
+	} `json:"warnings"`
+	Errors []struct {
+		Field string `json:"field"`
+		Rule  string `json:"rule"`
+	} `json:"errors"`
+}
+
+// TestSoftValidationWarnings проверяет предупреждения мягких правил
+//
+// Проверяет:
+// - Длинное название сохраняется, а ответ содержит предупреждение title_length
+// - Название на границе мягкого лимита предупреждений не вызывает
+// - Обновление с длинным названием также выполняется с предупреждением
+// - Обычная ошибка валидации по-прежнему отклоняет запрос
+func TestSoftValidationWarnings(t *testing.T) {
+	mux := handlers.SetupHandlers(storage.NewInMemoryStorage())
+	long := strings.Repeat("я", handlers.SoftTitleLength+1)
+
+	w := postTask(t, mux, map[string]string{"title": long, "description": "Описание"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Ожидался код %d, получен %d", http.StatusCreated, w.Code)
+	}
+	var created writeResult
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.Title != long || len(created.Warnings) != 1 || created.Warnings[0].Rule != handlers.RuleTitleLength || created.Warnings[0].Field != "title" {
+		t.Errorf("Неверный ответ: %s", w.Body.String())
+	}
+
+	w = postTask(t, mux, map[string]string{"title": strings.Repeat("я", handlers.SoftTitleLength), "description": "Описание"})
+	if strings.Contains(w.Body.String(), "warnings") {
+		t.Errorf("Предупреждение на границе лимита: %s", w.Body.String())
+	}
+
+	w = doJSON(t, mux, "PUT", "/tasks/2", map[string]string{"title": long, "description": "Описание"})
+	var updated writeResult
+	json.Unmarshal(w.Body.Bytes(), &updated)
+	if w.Code != http.StatusOK || len(updated.Warnings) != 1 {
+		t.Errorf("Ожидалось обновление с предупреждением: %d %s", w.Code, w.Body.String())
+	}
+
+	if w := postTask(t, mux, map[string]string{"title": long}); w.Code != http.StatusBadRequest {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestHardRulesConfig проверяет перевод мягкого правила в строгое настройкой развертывания
+func TestHardRulesConfig(t *testing.T) {
+	taskStorage := storage.NewInMemoryStorage()
+	mux := handlers.SetupHandlersWithConfig(taskStorage, handlers.Config{HardRules: []string{handlers.RuleTitleLength}})
+	long := strings.Repeat("a", handlers.SoftTitleLength+1)
+
+	w := postTask(t, mux, map[string]string{"title": long, "description": "Описание"})
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Ожидался код %d, получен %d", http.StatusUnprocessableEntity, w.Code)
+	}
+	var failed writeResult
+	if err := json.Unmarshal(w.Body.Bytes(), &failed); err != nil {
+		t.Fatal(err)
+	}
+	if failed.Code != handlers.CodeValidationFailed || len(failed.Errors) != 1 || failed.Errors[0].Rule != handlers.RuleTitleLength {
+		t.Errorf("Неверное тело ошибки: %s", w.Body.String())
+	}
+	if tasks, _ := taskStorage.GetAllTasks(t.Context()); len(tasks) != 0 {
+		t.Errorf("Задача сохранена несмотря на ошибку")
+	}
+
+	taskStorage.CreateTask(t.Context(), storage.CreateTaskInput{Title: "Задача", Description: "Описание"})
+	if w := doJSON(t, mux, "PUT", "/tasks/1", map[string]string{"title": long, "description": "Описание"}); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Ожидался код %d, получен %d", http.StatusUnprocessableEntity, w.Code)
+	}
+
+	w = doJSON(t, mux, "GET", "/capabilities", nil)
+	if !strings.Contains(w.Body.String(), `"validation_rules":{"title_length":"error"}`) {
+		t.Errorf("Режим правила не отражен в /capabilities: %s", w.Body.String())
+	}
+}